@@ -0,0 +1,53 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pulumi/crd2pulumi/gen"
+)
+
+// listCRDs prints a table of every CRD from the given loader to w, without generating any code.
+func listCRDs(w io.Writer, loader gen.SchemaLoader) error {
+	crds, err := gen.LoadCRDs(loader)
+	if err != nil {
+		return err
+	}
+	crgs, err := gen.NewCustomResourceGenerators(crds)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tKIND\tVERSIONS\tSCOPE\tSTRUCTURAL")
+	for _, crg := range crgs {
+		versions := "-"
+		if len(crg.Versions) > 0 {
+			versions = strings.Join(crg.Versions, ",")
+		}
+		structural := "no"
+		if !crg.HasSchemas() || len(crg.SchemalessVersions) == len(crg.Versions) {
+			structural = "no schema"
+		} else if crg.IsStructural() {
+			structural = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", crg.Group, crg.Kind, versions, crg.Scope, structural)
+	}
+	return tw.Flush()
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pulumi/crd2pulumi/gen"
 	"github.com/spf13/cobra"
@@ -46,6 +47,158 @@ const (
 	PythonName string = "pythonName"
 )
 
+const NodeJSScope string = "nodejs-scope"
+
+const NodeJSBarrel string = "nodejs-barrel"
+
+const (
+	PythonDistributionName string = "python-distribution-name"
+	DotNetAssemblyName     string = "dotnet-assembly-name"
+	GoPackageName          string = "go-package-name"
+)
+
+const DotNetNullable string = "dotnet-nullable"
+
+const GoClientHelpers string = "go-client-helpers"
+
+const GoUtilityHelpers string = "go-utility-helpers"
+
+const DryRunCompile string = "dry-run-compile"
+
+const PythonImportCheck string = "python-import-check"
+
+const ListCRDs string = "list-crds"
+
+const OCI string = "oci"
+
+const InputGlob string = "input-glob"
+
+const (
+	Git     string = "git"
+	GitPath string = "path"
+)
+
+const EmbeddedCRDs string = "embedded-crds"
+
+const (
+	NoCache  string = "no-cache"
+	CacheTTL string = "cache-ttl"
+)
+
+const (
+	CACert          string = "ca-cert"
+	ClientCert      string = "client-cert"
+	ClientKey       string = "client-key"
+	BearerToken     string = "bearer-token"
+	BearerTokenFile string = "bearer-token-file"
+)
+
+const Format string = "format"
+
+const ExampleManifest string = "example-manifest"
+
+const EmitJSONSchema string = "emit-jsonschema"
+
+const EmitProto string = "emit-proto"
+
+const EmitTypeDeclarations string = "emit-type-declarations"
+
+const EmitYAMLReference string = "emit-yaml-reference"
+
+const EmitImportFile string = "emit-import-file"
+
+const ImportFrom string = "import-from"
+
+const MetricsFile string = "metrics-file"
+
+const AnyTypesReport string = "any-types-report"
+
+const CompactNames string = "compact-names"
+
+const EmitChangelog string = "emit-changelog"
+
+const ChangelogBase string = "changelog-base"
+
+const MergeSchema string = "merge-schema"
+
+const SchemaVersion string = "schema-version"
+
+const PrettyJSON string = "pretty-json"
+
+const KeepPlaceholderMeta string = "keep-temp-placeholder-meta"
+
+const ObjectMetaRequired string = "object-meta-required"
+
+const MergeObjectMetaFrom string = "merge-object-meta-from"
+
+const LanguageOption string = "language-option"
+
+const OverlayTemplate string = "overlay-template"
+
+const PackageVersion string = "package-version"
+
+const EmitSDKVersionFile string = "emit-sdk-version-file"
+
+const RootPath string = "root-path"
+
+const TopLevelModule string = "top-level-module"
+
+const GroupPrefixStrip string = "group-prefix-strip"
+
+const ExcludeStatus string = "exclude-status"
+
+const ExcludeDescriptions string = "exclude-descriptions"
+
+const (
+	ImmutablePath   string = "immutable-path"
+	DetectImmutable string = "detect-immutable"
+)
+
+const PrinterColumns string = "printer-columns"
+
+const ResourceDocLinks string = "resource-doc-links"
+
+const StripKubebuilderMarkers string = "strip-kubebuilder-markers"
+
+const AwaitAnnotations string = "await-annotations"
+
+const OwnerReferenceHelpers string = "owner-reference-helpers"
+
+const CRDResources string = "crd-resources"
+
+const UnknownTypes string = "unknown-types"
+
+const NormalizeEnumsCase string = "normalize-enums-case"
+
+const MaxDepth string = "max-depth"
+
+const Versions string = "versions"
+
+const Rename string = "rename"
+
+const Method string = "method"
+
+const PartialSchema string = "partial-schema"
+
+const AnyTypeRef string = "any-type-ref"
+
+const Strict string = "strict"
+
+const FailOnEmpty string = "fail-on-empty"
+
+const (
+	SortProperties        string = "sort-properties"
+	PreservePropertyOrder string = "preserve-property-order"
+)
+
+const MapScalarDefaults string = "map-scalar-defaults"
+
+const AnnotateSource string = "annotate-source"
+
+const OnlyLanguagesChanged string = "only-languages-changed"
+
+const EmitTestStubs string = "emit-test-stubs"
+
 const defaultOutputPath = "crds/"
 
 const long = `crd2pulumi is a CLI tool that generates typed Kubernetes 
@@ -56,6 +209,15 @@ const example = `crd2pulumi --nodejs crontabs.yaml
 crd2pulumi -dgnp crd-certificates.yaml crd-issuers.yaml crd-challenges.yaml
 crd2pulumi --pythonPath=crds/python/istio --nodejsPath=crds/nodejs/istio crd-all.gen.yaml crd-mixer.yaml crd-operator.yaml
 crd2pulumi --pythonPath=crds/python/gke https://raw.githubusercontent.com/GoogleCloudPlatform/gke-managed-certs/master/deploy/managedcertificates-crd.yaml
+crd2pulumi --list-crds crd-all.gen.yaml
+crd2pulumi --nodejs --oci oci://ghcr.io/myorg/crds:v1.0.0
+crd2pulumi --go --git https://github.com/myorg/operator@v1.2.3 --path config/crd/bases
+crd2pulumi --go 'manifests/**/*.yaml'
+crd2pulumi --example-manifest=crontabs-example.yaml crontabs.yaml
+crd2pulumi --emit-jsonschema=crontabs-schemas crontabs.yaml
+kubectl get crontabs --all-namespaces -o yaml > existing.yaml && crd2pulumi --emit-import-file=import.json --import-from=existing.yaml crontabs.yaml
+crd2pulumi --emit-type-declarations=types.d.ts --partial-schema stable.example.com/v1:CronTabSpec=crontab-spec.yaml
+helm template my-release ./chart --include-crds | crd2pulumi --nodejs -
 
 Notice that by just setting a language-specific output path (--pythonPath, --nodejsPath, etc) the code will
 still get generated, so setting -p, -n, etc becomes unnecessary.
@@ -79,19 +241,118 @@ func NewLanguageSettings(flags *pflag.FlagSet) (gen.LanguageSettings, []string)
 	dotNetName, _ := flags.GetString(DotNetName)
 	goName, _ := flags.GetString(GoName)
 
+	nodejsScope, _ := flags.GetString(NodeJSScope)
+	nodejsBarrel, _ := flags.GetBool(NodeJSBarrel)
+	pythonDistributionName, _ := flags.GetString(PythonDistributionName)
+	dotNetAssemblyName, _ := flags.GetString(DotNetAssemblyName)
+	dotNetNullable, _ := flags.GetBool(DotNetNullable)
+	goPackageName, _ := flags.GetString(GoPackageName)
+	goClientHelpers, _ := flags.GetBool(GoClientHelpers)
+	goUtilityHelpers, _ := flags.GetBool(GoUtilityHelpers)
+	dryRunCompile, _ := flags.GetBool(DryRunCompile)
+	pythonImportCheck, _ := flags.GetBool(PythonImportCheck)
+	format, _ := flags.GetBool(Format)
+	exampleManifest, _ := flags.GetString(ExampleManifest)
+	emitJSONSchema, _ := flags.GetString(EmitJSONSchema)
+	emitProto, _ := flags.GetString(EmitProto)
+	emitTypeDeclarations, _ := flags.GetString(EmitTypeDeclarations)
+	emitYAMLReference, _ := flags.GetString(EmitYAMLReference)
+	emitImportFile, _ := flags.GetString(EmitImportFile)
+	metricsFile, _ := flags.GetString(MetricsFile)
+	anyTypesReport, _ := flags.GetString(AnyTypesReport)
+	compactNames, _ := flags.GetString(CompactNames)
+	emitChangelog, _ := flags.GetString(EmitChangelog)
+	changelogBase, _ := flags.GetString(ChangelogBase)
+	mergeSchema, _ := flags.GetString(MergeSchema)
+	schemaVersion, _ := flags.GetString(SchemaVersion)
+	prettyJSON, _ := flags.GetBool(PrettyJSON)
+	keepPlaceholderMeta, _ := flags.GetBool(KeepPlaceholderMeta)
+	objectMetaRequired, _ := flags.GetBool(ObjectMetaRequired)
+	packageVersion, _ := flags.GetString(PackageVersion)
+	emitSDKVersionFile, _ := flags.GetBool(EmitSDKVersionFile)
+	rootPath, _ := flags.GetString(RootPath)
+	topLevelModule, _ := flags.GetString(TopLevelModule)
+	groupPrefixStrip, _ := flags.GetString(GroupPrefixStrip)
+	excludeStatus, _ := flags.GetBool(ExcludeStatus)
+	excludeDescriptions, _ := flags.GetBool(ExcludeDescriptions)
+	unknownTypes, _ := flags.GetString(UnknownTypes)
+	normalizeEnumsCase, _ := flags.GetString(NormalizeEnumsCase)
+	maxDepth, _ := flags.GetInt(MaxDepth)
+	versions, _ := flags.GetString(Versions)
+	anyTypeRef, _ := flags.GetString(AnyTypeRef)
+	immutablePaths, _ := flags.GetStringSlice(ImmutablePath)
+	importManifests, _ := flags.GetStringArray(ImportFrom)
+	detectImmutable, _ := flags.GetBool(DetectImmutable)
+	printerColumns, _ := flags.GetBool(PrinterColumns)
+	resourceDocLinks, _ := flags.GetString(ResourceDocLinks)
+	stripKubebuilderMarkers, _ := flags.GetBool(StripKubebuilderMarkers)
+	awaitAnnotations, _ := flags.GetBool(AwaitAnnotations)
+	ownerReferenceHelpers, _ := flags.GetBool(OwnerReferenceHelpers)
+	crdResources, _ := flags.GetBool(CRDResources)
+	sortProperties, _ := flags.GetBool(SortProperties)
+	preservePropertyOrder, _ := flags.GetBool(PreservePropertyOrder)
+	mapScalarDefaults, _ := flags.GetBool(MapScalarDefaults)
+	annotateSource, _ := flags.GetBool(AnnotateSource)
+	onlyLanguagesChanged, _ := flags.GetBool(OnlyLanguagesChanged)
+	emitTestStubs, _ := flags.GetBool(EmitTestStubs)
+
 	var notices []string
 	ls := gen.LanguageSettings{
-		NodeJSName: nodejsName,
-		PythonName: pythonName,
-		DotNetName: dotNetName,
-		GoName:     goName,
+		NodeJSName:   nodejsName,
+		PythonName:   pythonName,
+		DotNetName:   dotNetName,
+		GoName:       goName,
+		NodeJSScope:  nodejsScope,
+		NodeJSBarrel: nodejsBarrel,
+
+		PythonDistributionName: pythonDistributionName,
+		DotNetAssemblyName:     dotNetAssemblyName,
+		DotNetNullable:         dotNetNullable,
+		GoPackageName:          goPackageName,
+
+		GoClientHelpers:   goClientHelpers,
+		GoUtilityHelpers:  goUtilityHelpers,
+		GoDryRunCompile:   dryRunCompile,
+		PythonImportCheck: pythonImportCheck,
+		Format:            format,
+
+		KeepPlaceholderMeta:     keepPlaceholderMeta,
+		ObjectMetaRequired:      objectMetaRequired,
+		PackageVersion:          packageVersion,
+		SchemaVersion:           schemaVersion,
+		SDKVersionFile:          emitSDKVersionFile,
+		RootPath:                rootPath,
+		TopLevelModule:          topLevelModule,
+		GroupPrefixStrip:        groupPrefixStrip,
+		ExcludeStatus:           excludeStatus,
+		ExcludeDescriptions:     excludeDescriptions,
+		UnknownTypes:            gen.UnknownTypePolicy(unknownTypes),
+		EnumCollisions:          gen.EnumCollisionPolicy(normalizeEnumsCase),
+		MaxDepth:                maxDepth,
+		VersionSelection:        gen.VersionSelection(versions),
+		AnyTypeRef:              anyTypeRef,
+		ImmutablePaths:          immutablePaths,
+		ImportManifests:         importManifests,
+		DetectImmutable:         detectImmutable,
+		PrinterColumns:          printerColumns,
+		ResourceDocLinks:        resourceDocLinks,
+		StripKubebuilderMarkers: stripKubebuilderMarkers,
+		AwaitAnnotations:        awaitAnnotations,
+		OwnerReferenceHelpers:   ownerReferenceHelpers,
+		CRDResources:            crdResources,
+		SchemaPropertyOrder:     !sortProperties || preservePropertyOrder,
+		OmitDefaults:            !mapScalarDefaults,
+		AnnotateSource:          annotateSource,
+		OnlyLanguagesChanged:    onlyLanguagesChanged,
+		TestStubs:               emitTestStubs,
+		CompactJSON:             !prettyJSON,
 	}
 	if nodejsPath != "" {
 		ls.NodeJSPath = &nodejsPath
 		if nodejs {
 			notices = append(notices, "-n is not necessary if --nodejsPath is already set")
 		}
-	} else if nodejs || nodejsName != gen.DefaultName {
+	} else if nodejs || nodejsName != gen.DefaultName || nodejsScope != "" || nodejsBarrel {
 		path := filepath.Join(defaultOutputPath, NodeJS)
 		ls.NodeJSPath = &path
 	}
@@ -100,7 +361,7 @@ func NewLanguageSettings(flags *pflag.FlagSet) (gen.LanguageSettings, []string)
 		if python {
 			notices = append(notices, "-p is not necessary if --pythonPath is already set")
 		}
-	} else if python || pythonName != gen.DefaultName {
+	} else if python || pythonName != gen.DefaultName || pythonDistributionName != "" || pythonImportCheck {
 		path := filepath.Join(defaultOutputPath, Python)
 		ls.PythonPath = &path
 	}
@@ -109,26 +370,64 @@ func NewLanguageSettings(flags *pflag.FlagSet) (gen.LanguageSettings, []string)
 		if dotnet {
 			notices = append(notices, "-d is not necessary if --dotnetPath is already set")
 		}
-	} else if dotnet || dotNetName != gen.DefaultName {
+	} else if dotnet || dotNetName != gen.DefaultName || dotNetAssemblyName != "" || dotNetNullable {
 		path := filepath.Join(defaultOutputPath, DotNet)
 		ls.DotNetPath = &path
 	}
+	if exampleManifest != "" {
+		ls.ExampleManifestPath = &exampleManifest
+	}
+	if emitJSONSchema != "" {
+		ls.JSONSchemaPath = &emitJSONSchema
+	}
+	if emitProto != "" {
+		ls.ProtoPath = &emitProto
+	}
+	if emitTypeDeclarations != "" {
+		ls.TypeDeclarationsPath = &emitTypeDeclarations
+	}
+	if emitYAMLReference != "" {
+		ls.YAMLReferencePath = &emitYAMLReference
+	}
+	if emitImportFile != "" {
+		ls.ImportFilePath = &emitImportFile
+	}
+	if metricsFile != "" {
+		ls.MetricsPath = &metricsFile
+	}
+	if anyTypesReport != "" {
+		ls.AnyTypesReportPath = &anyTypesReport
+	}
+	if compactNames != "" {
+		ls.CompactNamesPath = &compactNames
+	}
+	if emitChangelog != "" {
+		ls.ChangelogPath = &emitChangelog
+		ls.ChangelogBase = changelogBase
+	}
+	if mergeSchema != "" {
+		ls.MergeSchemaPath = &mergeSchema
+	}
 	if goPath != "" {
 		ls.GoPath = &goPath
 		if golang {
 			notices = append(notices, "-g is not necessary if --goPath is already set")
 		}
-	} else if golang || goName != gen.DefaultName {
+	} else if golang || goName != gen.DefaultName || goPackageName != "" || goClientHelpers || goUtilityHelpers || dryRunCompile {
 		path := filepath.Join(defaultOutputPath, Go)
 		ls.GoPath = &path
 	}
 	return ls, notices
 }
 
-var forceValue bool
+var forceValue, listCRDsValue, formatValue, goClientHelpersValue, goUtilityHelpersValue, dryRunCompileValue, pythonImportCheckValue, keepPlaceholderMetaValue, objectMetaRequiredValue, detectImmutableValue, printerColumnsValue, stripKubebuilderMarkersValue, awaitAnnotationsValue, ownerReferenceHelpersValue, crdResourcesValue, excludeStatusValue, excludeDescriptionsValue, strictValue, sortPropertiesValue, preservePropertyOrderValue, mapScalarDefaultsValue, nodeJSBarrelValue, annotateSourceValue, onlyLanguagesChangedValue, emitTestStubsValue, prettyJSONValue, dotNetNullableValue, noCacheValue, embeddedCRDsValue, emitSDKVersionFileValue, failOnEmptyValue bool
 var nodeJSValue, pythonValue, dotNetValue, goValue bool
 var nodeJSPathValue, pythonPathValue, dotNetPathValue, goPathValue string
 var nodeJSNameValue, pythonNameValue, dotNetNameValue, goNameValue string
+var nodeJSScopeValue, pythonDistributionNameValue, dotNetAssemblyNameValue, goPackageNameValue, exampleManifestValue, emitJSONSchemaValue, emitProtoValue, emitTypeDeclarationsValue, emitYAMLReferenceValue, emitImportFileValue, metricsFileValue, anyTypesReportValue, compactNamesValue, emitChangelogValue, changelogBaseValue, mergeSchemaValue, schemaVersionValue, packageVersionValue, rootPathValue, topLevelModuleValue, groupPrefixStripValue, unknownTypesValue, normalizeEnumsCaseValue, versionsValue, anyTypeRefValue, resourceDocLinksValue, gitPathValue, caCertValue, clientCertValue, clientKeyValue, bearerTokenValue, bearerTokenFileValue string
+var cacheTTLValue time.Duration
+var maxDepthValue int
+var immutablePathsValue, mergeObjectMetaFromValue, renamesValue, methodsValue, partialSchemasValue, importFromValue, ociValue, gitValue, inputGlobsValue, languageOptionsValue, overlayTemplatesValue []string
 
 func Execute() error {
 	rootCmd := &cobra.Command{
@@ -137,25 +436,117 @@ func Execute() error {
 		Long:    long,
 		Example: example,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if ls, _ := NewLanguageSettings(cmd.Flags()); !ls.GeneratesAtLeastOneLanguage() {
+			list, _ := cmd.Flags().GetBool(ListCRDs)
+			if ls, _ := NewLanguageSettings(cmd.Flags()); !list && !ls.GeneratesAtLeastOneLanguage() && ls.ExampleManifestPath == nil && ls.JSONSchemaPath == nil && ls.ProtoPath == nil && ls.TypeDeclarationsPath == nil && ls.YAMLReferencePath == nil && ls.ImportFilePath == nil && ls.ChangelogPath == nil {
 				return errors.New("must specify at least one language")
 			}
 
-			err := cobra.MinimumNArgs(1)(cmd, args)
+			ociReferences, _ := cmd.Flags().GetStringSlice(OCI)
+			inputGlobs, _ := cmd.Flags().GetStringArray(InputGlob)
+			gitReferences, _ := cmd.Flags().GetStringSlice(Git)
+			partialSchemas, _ := cmd.Flags().GetStringArray(PartialSchema)
+			err := cobra.MinimumNArgs(1)(cmd, append(append(append(append(args, ociReferences...), inputGlobs...), gitReferences...), partialSchemas...))
 			if err != nil {
-				return errors.New("must specify at least one CRD YAML file")
+				return errors.New("must specify at least one CRD YAML file, glob pattern, OCI artifact, Git repository or partial schema")
+			}
+			if gitPath, _ := cmd.Flags().GetString(GitPath); gitPath != "" && len(gitReferences) == 0 {
+				return fmt.Errorf("--%s requires --%s", GitPath, Git)
+			}
+			if importFrom, _ := cmd.Flags().GetStringArray(ImportFrom); len(importFrom) > 0 {
+				if emitImportFile, _ := cmd.Flags().GetString(EmitImportFile); emitImportFile == "" {
+					return fmt.Errorf("--%s requires --%s", ImportFrom, EmitImportFile)
+				}
 			}
 
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			loader, err := gen.NewSchemaLoaders(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(-1)
+			}
+			ociReferences, _ := cmd.Flags().GetStringSlice(OCI)
+			for _, ociReference := range ociReferences {
+				reference, err := gen.ParseOCIReference(ociReference)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(-1)
+				}
+				loader = append(loader, gen.OCILoader{Reference: reference})
+			}
+			inputGlobs, _ := cmd.Flags().GetStringArray(InputGlob)
+			for _, inputGlob := range inputGlobs {
+				loader = append(loader, gen.GlobLoader{Pattern: inputGlob})
+			}
+			gitReferences, _ := cmd.Flags().GetStringSlice(Git)
+			gitPath, _ := cmd.Flags().GetString(GitPath)
+			for _, gitReference := range gitReferences {
+				reference, err := gen.ParseGitReference(gitReference)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(-1)
+				}
+				loader = append(loader, gen.GitLoader{Reference: reference, Path: gitPath})
+			}
+			if embeddedCRDs, _ := cmd.Flags().GetBool(EmbeddedCRDs); embeddedCRDs {
+				loader = loader.WithEmbeddedCRDs()
+			}
+			caCert, _ := cmd.Flags().GetString(CACert)
+			clientCert, _ := cmd.Flags().GetString(ClientCert)
+			clientKey, _ := cmd.Flags().GetString(ClientKey)
+			bearerToken, _ := cmd.Flags().GetString(BearerToken)
+			bearerTokenFile, _ := cmd.Flags().GetString(BearerTokenFile)
+			urlAuth := gen.URLAuth{CACertPath: caCert, ClientCertPath: clientCert, ClientKeyPath: clientKey, BearerToken: bearerToken, BearerTokenPath: bearerTokenFile}
+			if !urlAuth.IsZero() {
+				if loader, err = loader.WithURLAuth(urlAuth); err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(-1)
+				}
+			}
+			// The cache is skipped if there's no cache directory, e.g. without
+			// a home directory
+			if noCache, _ := cmd.Flags().GetBool(NoCache); !noCache {
+				if cacheDir, err := gen.DefaultCacheDir(); err == nil {
+					cacheTTL, _ := cmd.Flags().GetDuration(CacheTTL)
+					loader = loader.WithCache(&gen.Cache{Dir: cacheDir, TTL: cacheTTL})
+				}
+			}
+
+			if list, _ := cmd.Flags().GetBool(ListCRDs); list {
+				if err := listCRDs(os.Stdout, loader); err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(-1)
+				}
+				return
+			}
+
 			force, _ := cmd.Flags().GetBool("force")
+			strict, _ := cmd.Flags().GetBool(Strict)
+			failOnEmpty, _ := cmd.Flags().GetBool(FailOnEmpty)
+			mergeObjectMetaFrom, _ := cmd.Flags().GetStringSlice(MergeObjectMetaFrom)
+			languageOptions, _ := cmd.Flags().GetStringArray(LanguageOption)
+			renames, _ := cmd.Flags().GetStringSlice(Rename)
+			methods, _ := cmd.Flags().GetStringSlice(Method)
+			partialSchemas, _ := cmd.Flags().GetStringArray(PartialSchema)
+			overlayTemplates, _ := cmd.Flags().GetStringArray(OverlayTemplate)
 			ls, notices := NewLanguageSettings(cmd.Flags())
 			for _, notice := range notices {
 				fmt.Println("notice: " + notice)
 			}
 
-			err := gen.Generate(ls, args, force)
+			err = gen.GenerateWithOptions(loader,
+				gen.WithLanguageSettings(ls),
+				gen.WithForce(force),
+				gen.WithStrict(strict),
+				gen.WithFailOnEmpty(failOnEmpty),
+				gen.WithObjectMetaFrom(mergeObjectMetaFrom...),
+				gen.WithLanguageOptions(languageOptions...),
+				gen.WithRenames(renames...),
+				gen.WithMethods(methods...),
+				gen.WithPartialSchemas(partialSchemas...),
+				gen.WithOverlayTemplates(overlayTemplates...),
+			)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				os.Exit(-1)
@@ -165,6 +556,25 @@ func Execute() error {
 		},
 	}
 	rootCmd.PersistentFlags().BoolVarP(&forceValue, "force", "f", false, "overwrite existing files")
+	rootCmd.PersistentFlags().BoolVar(&strictValue, Strict, false, "fail instead of warning about unformattable code, CRDs without a structural schema, and CRD versions whose schemas differ across the inputs")
+	rootCmd.PersistentFlags().BoolVar(&failOnEmptyValue, FailOnEmpty, true, "fail instead of generating an empty SDK if the inputs produce no resources")
+	rootCmd.PersistentFlags().BoolVar(&emitTestStubsValue, EmitTestStubs, false, "generate a test for each resource that constructs it with placeholders for its required properties (NodeJS, Python and Go only)")
+	rootCmd.PersistentFlags().BoolVar(&annotateSourceValue, AnnotateSource, false, "comment each generated file with the CRDs it was generated from and the crd2pulumi version")
+	rootCmd.PersistentFlags().BoolVar(&onlyLanguagesChangedValue, OnlyLanguagesChanged, false, "skip the languages whose output a previous run with this flag generated from the same CRDs and settings, regenerating only the new or stale languages; use --force to regenerate every language")
+	rootCmd.PersistentFlags().BoolVar(&formatValue, Format, false, "format the generated Go (gofmt) and TypeScript (prettier, if installed) code")
+	rootCmd.PersistentFlags().StringSliceVar(&ociValue, OCI, nil, "OCI artifact to load the CRDs from, e.g. oci://ghcr.io/myorg/crds:v1.0.0, with the Docker credentials of its registry")
+	rootCmd.PersistentFlags().StringArrayVar(&inputGlobsValue, InputGlob, nil, "glob pattern of the files to load the CRDs from, e.g. 'manifests/**/*.yaml', where ** matches any number of directories")
+	rootCmd.PersistentFlags().StringSliceVar(&gitValue, Git, nil, "Git repository to load the CRDs from, at a branch, tag or commit, e.g. https://github.com/myorg/operator@v1.2.3, shallowly cloned with the git CLI and its credentials")
+	rootCmd.PersistentFlags().StringVar(&gitPathValue, GitPath, "", "file or directory in the --git repositories to load the CRDs from, e.g. config/crd/bases, instead of every YAML and JSON file in them")
+	rootCmd.PersistentFlags().BoolVar(&embeddedCRDsValue, EmbeddedCRDs, false, "also load the CRDs embedded in the .yaml and .yml data keys of the ConfigMaps of the inputs, as some operators ship them")
+	rootCmd.PersistentFlags().BoolVar(&noCacheValue, NoCache, false, "fetch the CRDs of URLs and OCI artifacts again instead of using the ones cached in the user's cache directory")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTLValue, CacheTTL, gen.DefaultCacheTTL, "how long to use cached CRDs without revalidating them, unless their HTTP caching headers say otherwise")
+	rootCmd.PersistentFlags().StringVar(&caCertValue, CACert, "", "optional path of a PEM bundle of the CAs to verify the servers of the CRD URLs with, instead of the system's CAs")
+	rootCmd.PersistentFlags().StringVar(&clientCertValue, ClientCert, "", "optional path of a PEM client certificate to authenticate to the servers of the CRD URLs with, along with --client-key")
+	rootCmd.PersistentFlags().StringVar(&clientKeyValue, ClientKey, "", "optional path of the PEM key of --client-cert")
+	rootCmd.PersistentFlags().StringVar(&bearerTokenValue, BearerToken, "", "optional bearer token to send in the Authorization header of the requests for the CRD URLs, which must be https")
+	rootCmd.PersistentFlags().StringVar(&bearerTokenFileValue, BearerTokenFile, "", "optional path of a file with the bearer token, instead of --bearer-token, to keep it out of the process list and the shell history")
+	rootCmd.PersistentFlags().BoolVar(&listCRDsValue, ListCRDs, false, "list the CRDs found in the input files without generating code")
 	rootCmd.PersistentFlags().BoolVarP(&nodeJSValue, NodeJS, "n", false, "generate NodeJS")
 	rootCmd.PersistentFlags().BoolVarP(&pythonValue, Python, "p", false, "generate Python")
 	rootCmd.PersistentFlags().BoolVarP(&dotNetValue, DotNet, "d", false, "generate .NET")
@@ -177,6 +587,62 @@ func Execute() error {
 	rootCmd.PersistentFlags().StringVar(&pythonNameValue, PythonName, gen.DefaultName, "name of Python package")
 	rootCmd.PersistentFlags().StringVar(&dotNetNameValue, DotNetName, gen.DefaultName, "name of .NET package")
 	rootCmd.PersistentFlags().StringVar(&goNameValue, GoName, gen.DefaultName, "name of Go package")
+	rootCmd.PersistentFlags().StringVar(&nodeJSScopeValue, NodeJSScope, "", "npm scope of NodeJS package (default \"pulumi\")")
+	rootCmd.PersistentFlags().StringVar(&pythonDistributionNameValue, PythonDistributionName, "", "name to publish the Python package under (default \"pulumi_<pythonName>\")")
+	rootCmd.PersistentFlags().StringVar(&dotNetAssemblyNameValue, DotNetAssemblyName, "", "name of the .NET assembly and NuGet package (default \"Pulumi.<DotnetName>\")")
+	rootCmd.PersistentFlags().BoolVar(&dotNetNullableValue, DotNetNullable, false, "add a #nullable enable directive to each generated C# file, so that its nullable reference type annotations also apply in projects that don't enable them")
+	rootCmd.PersistentFlags().StringVar(&goPackageNameValue, GoPackageName, "", "name of the root Go package with the shared utilities (default \"kubernetes\")")
+	rootCmd.PersistentFlags().BoolVar(&nodeJSBarrelValue, NodeJSBarrel, false, "re-export the resources and type modules from the root of the NodeJS package, e.g. import { CronTab } from \"@pulumi/crds\"")
+	rootCmd.PersistentFlags().BoolVar(&sortPropertiesValue, SortProperties, true, "list properties alphabetically instead of in schema order, e.g. in the example manifest and the test stubs")
+	rootCmd.PersistentFlags().BoolVar(&preservePropertyOrderValue, PreservePropertyOrder, false, "list properties in the order that the CRD declares them in, e.g. in the example manifest and the test stubs; same as --sort-properties=false")
+	rootCmd.PersistentFlags().BoolVar(&mapScalarDefaultsValue, MapScalarDefaults, true, "set the scalar property defaults of the schemas in the generated SDKs, instead of leaving them to the API server")
+	rootCmd.PersistentFlags().StringVar(&exampleManifestValue, ExampleManifest, "", "optional path to write an example Kubernetes YAML manifest to")
+	rootCmd.PersistentFlags().StringVar(&emitJSONSchemaValue, EmitJSONSchema, "", "optional dir to write a JSON Schema of each CRD version to, converted from the generated types")
+	rootCmd.PersistentFlags().StringVar(&emitProtoValue, EmitProto, "", "optional dir to write the generated types to as proto3 messages, a .proto file per CRD group version (experimental)")
+	rootCmd.PersistentFlags().StringVar(&emitTypeDeclarationsValue, EmitTypeDeclarations, "", "optional path to write a standalone TypeScript declaration file (.d.ts) of the generated types to, without the resource classes")
+	rootCmd.PersistentFlags().StringVar(&emitYAMLReferenceValue, EmitYAMLReference, "", "optional path to write a YAML reference of the resources to, with the required and optional inputs of each resource token and their types, for Pulumi YAML programs")
+	rootCmd.PersistentFlags().StringVar(&emitImportFileValue, EmitImportFile, "", "optional path to write a bulk import file to, for pulumi import --file, with the resource type token and the ID of each existing resource of the --import-from manifests")
+	rootCmd.PersistentFlags().StringArrayVar(&importFromValue, ImportFrom, nil, "YAML or JSON manifests of the existing resources to write to the --emit-import-file, e.g. the output of kubectl get crontabs --all-namespaces -o yaml")
+	rootCmd.PersistentFlags().StringVar(&metricsFileValue, MetricsFile, "", "optional path to write the statistics of the run to as Prometheus metrics, e.g. for the node exporter's textfile collector")
+	rootCmd.PersistentFlags().StringVar(&anyTypesReportValue, AnyTypesReport, "", "optional path to write the JSON paths of the fields typed as any to, sorted for diffing, or - for stderr")
+	rootCmd.PersistentFlags().StringVar(&compactNamesValue, CompactNames, "", fmt.Sprintf("optional path to compact the names of the types longer than %d characters to stable hashed names, e.g. T_0a1b2c3d4e5f, and to write the JSON mapping to their original names and paths to, or - for stderr", gen.MaxTypeNameLength))
+	rootCmd.PersistentFlags().StringVar(&emitChangelogValue, EmitChangelog, "", "optional path to write the added, removed and changed resources, types and properties since --changelog-base to, as JSON if it ends in .json and as Markdown otherwise, or - for stderr")
+	rootCmd.PersistentFlags().StringVar(&changelogBaseValue, ChangelogBase, "", "path of the previous version to diff against for --emit-changelog: a Pulumi schema if it ends in .json, e.g. one written with --merge-schema, or otherwise the previous CRDs")
+	rootCmd.PersistentFlags().StringVar(&mergeSchemaValue, MergeSchema, "", "optional path of a Pulumi schema to merge into the generated package if it exists, and to write the merged schema back to, to grow an SDK across runs")
+	rootCmd.PersistentFlags().StringVar(&schemaVersionValue, SchemaVersion, gen.MaxSchemaVersion, "the Pulumi version that the schema written to --merge-schema targets, from "+gen.MinSchemaVersion+"; the schema features that it doesn't support are omitted, with a warning")
+	rootCmd.PersistentFlags().BoolVar(&prettyJSONValue, PrettyJSON, true, "indent the JSON Schemas and the merged schema for readability and diffs, instead of writing them compactly")
+	rootCmd.PersistentFlags().StringVar(&packageVersionValue, PackageVersion, "", "semver version of the generated packages, e.g. 1.2.0 (default is the crd2pulumi version)")
+	rootCmd.PersistentFlags().BoolVar(&emitSDKVersionFileValue, EmitSDKVersionFile, false, "generate a file in each language that exposes the package version at runtime, e.g. version.go with a Version constant")
+	rootCmd.PersistentFlags().StringVar(&rootPathValue, RootPath, "", "only generate the types reachable from this dot-separated property path, e.g. spec.forProvider")
+	rootCmd.PersistentFlags().StringVar(&topLevelModuleValue, TopLevelModule, "", "nest the modules of every CRD group under this module, e.g. crds for crds/stable/v1 instead of stable/v1")
+	rootCmd.PersistentFlags().StringVar(&groupPrefixStripValue, GroupPrefixStrip, "", "remove this from the start or the end of the CRD groups when deriving their modules, e.g. acme- for compute/v1 instead of acmecompute/v1 for acme-compute.example.com")
+	rootCmd.PersistentFlags().BoolVar(&excludeStatusValue, ExcludeStatus, false, "remove the status of every CRD, so that no status types are generated")
+	rootCmd.PersistentFlags().BoolVar(&excludeDescriptionsValue, ExcludeDescriptions, false, "remove the description of every type, property, enum value and method, for the smallest SDKs, without inline docs")
+	rootCmd.PersistentFlags().StringVar(&unknownTypesValue, UnknownTypes, string(gen.UnknownTypeAny), "how to convert schemas whose type isn't an OpenAPI type: \"any\", \"object\" for arbitrary JSON, or \"error\" to fail")
+	rootCmd.PersistentFlags().StringVar(&normalizeEnumsCaseValue, NormalizeEnumsCase, string(gen.EnumCollisionSuffix), "how to name the members of enum values that collide once sanitized, e.g. Active and active: \"suffix\" to number the later ones, with a warning (an error with --strict), or \"error\" to fail")
+	rootCmd.PersistentFlags().IntVar(&maxDepthValue, MaxDepth, gen.DefaultMaxDepth, "how deeply schemas can be nested before they're typed as any, with a warning (an error with --strict), to bound pathological schemas")
+	rootCmd.PersistentFlags().StringVar(&versionsValue, Versions, string(gen.ConversionVersions), "which versions of each CRD to generate: \"all\", \"storage\" for only the storage version, or \"conversion\" for only the storage version of CRDs whose conversion strategy is None")
+	rootCmd.PersistentFlags().StringSliceVar(&renamesValue, Rename, nil, "generate the resource type of a CRD with another name than its kind, as <group>/<Kind>=<NewName>, e.g. stable.example.com/CronTab=ScheduledJob")
+	rootCmd.PersistentFlags().StringSliceVar(&methodsValue, Method, nil, "add a placeholder method, which the Kubernetes provider doesn't implement, to the resources of a CRD, as <group>/<Kind>=<method>, e.g. stable.example.com/CronTab=trigger")
+	rootCmd.PersistentFlags().StringArrayVar(&partialSchemasValue, PartialSchema, nil, "bare OpenAPI schema, e.g. a subtree of a CRD's schema, to generate types from without a resource, as <group>/<version>:<Name>=<path>, e.g. stable.example.com/v1:CronTabSpec=crontab-spec.yaml; its types are only in the type declarations, JSON schemas, Protobuf files and YAML reference")
+	rootCmd.PersistentFlags().StringVar(&anyTypeRefValue, AnyTypeRef, "", "ref of the type that properties whose schemas don't describe them fall back to, e.g. pulumi.json#/Json (default \"pulumi.json#/Any\")")
+	rootCmd.PersistentFlags().StringSliceVar(&immutablePathsValue, ImmutablePath, nil, "dot-separated path of a property that forces the resource to be replaced when changed, e.g. spec.bucketName")
+	rootCmd.PersistentFlags().BoolVar(&detectImmutableValue, DetectImmutable, false, "force the resource to be replaced when properties with a \"self == oldSelf\" validation rule change")
+	rootCmd.PersistentFlags().BoolVar(&printerColumnsValue, PrinterColumns, false, "document the additionalPrinterColumns of each CRD version in the resource descriptions")
+	rootCmd.PersistentFlags().StringVar(&resourceDocLinksValue, ResourceDocLinks, "", "URL template of the documentation of each CRD version, e.g. 'https://docs.example.com/{group}/{kind}', to link to from the resource descriptions, with the {group}, {version}, {kind} and {plural} of the resource")
+	rootCmd.PersistentFlags().BoolVar(&stripKubebuilderMarkersValue, StripKubebuilderMarkers, false, "remove the lines of the descriptions that are Kubebuilder markers, e.g. +kubebuilder:validation:Optional, +optional or +required")
+	rootCmd.PersistentFlags().BoolVar(&awaitAnnotationsValue, AwaitAnnotations, false, "document the pulumi.com/skipAwait and pulumi.com/timeoutSeconds annotations on the metadata of each resource, and show them in the example manifest")
+	rootCmd.PersistentFlags().BoolVar(&objectMetaRequiredValue, ObjectMetaRequired, false, "make the metadata of every resource a required input, instead of letting Pulumi auto-name the resources without it")
+	rootCmd.PersistentFlags().BoolVar(&keepPlaceholderMetaValue, KeepPlaceholderMeta, false, "generate the ObjectMeta type instead of importing it from the Kubernetes SDK (NodeJS and Python only)")
+	rootCmd.PersistentFlags().StringSliceVar(&mergeObjectMetaFromValue, MergeObjectMetaFrom, nil, "import the ObjectMeta type from an existing Kubernetes SDK, as <language>=<name>@<version>, e.g. nodejs=@myorg/kubernetes@^3.0.0 (NodeJS and Python only)")
+	rootCmd.PersistentFlags().StringArrayVar(&languageOptionsValue, LanguageOption, nil, "set an option of a language's Pulumi code generator, as <language>:<key>=<value>, e.g. nodejs:typescriptVersion=4.9 (objects, arrays and booleans are JSON)")
+	rootCmd.PersistentFlags().StringArrayVar(&overlayTemplatesValue, OverlayTemplate, nil, "replace the text/template of a file that crd2pulumi adds to a language's SDK, as <language>:<overlay>=<path>, where the overlays are nodejs:meta, python:meta and python:utilities")
+	rootCmd.PersistentFlags().BoolVar(&ownerReferenceHelpersValue, OwnerReferenceHelpers, false, "generate a helper that constructs the owner reference to a resource, to set the ownerReferences of the resources it owns")
+	rootCmd.PersistentFlags().BoolVar(&crdResourcesValue, CRDResources, false, "generate a helper that registers the input CRDs as apiextensions.k8s.io CustomResourceDefinition resources, to install them in the same program as their CustomResources")
+	rootCmd.PersistentFlags().BoolVar(&goClientHelpersValue, GoClientHelpers, false, "generate a typed list/watch client for each Go resource (requires k8s.io/client-go)")
+	rootCmd.PersistentFlags().BoolVar(&goUtilityHelpersValue, GoUtilityHelpers, false, "generate Go helpers that set the inputs of each resource from the plain types, e.g. CronTabSpecFrom, and pointer helpers for their optional fields, e.g. StringRef")
+	rootCmd.PersistentFlags().BoolVar(&dryRunCompileValue, DryRunCompile, false, "verify that the generated Go code compiles with \"go build\" (requires the Go toolchain)")
+	rootCmd.PersistentFlags().BoolVar(&pythonImportCheckValue, PythonImportCheck, false, "verify that the generated Python code compiles and its packages can be imported (requires Python with the pulumi and Kubernetes SDK packages)")
 
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
@@ -0,0 +1,123 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// AnyTypePath is a field of a resource that's typed as `any`, because its
+// schema doesn't describe it.
+type AnyTypePath struct {
+	// Resource is the token of the resource, e.g.
+	// `kubernetes:stable.example.com/v1:CronTab`.
+	Resource string `json:"resource"`
+	// Path is the path of the field in the resource, e.g.
+	// `spec.containers[*].config`, where `[*]` stands for the items of an
+	// array and `*` for the values of a map.
+	Path string `json:"path"`
+}
+
+// AnyTypePaths returns the path of every field, array item and map value of
+// the resources that's typed as `any`, i.e. that falls back to the any type
+// ref. They're sorted by resource and path, so that the reports of different
+// runs, e.g. of two versions of a CRD, can be diffed.
+func (pg *PackageGenerator) AnyTypePaths() []AnyTypePath {
+	resourceTokens := append([]string(nil), pg.ResourceTokens...)
+	sort.Strings(resourceTokens)
+
+	var anyTypePaths []AnyTypePath
+	for _, resourceToken := range resourceTokens {
+		var paths []string
+		pg.collectAnyTypePaths(pschema.TypeSpec{Ref: "#/types/" + resourceToken}, "", map[string]bool{}, &paths)
+		sort.Strings(paths)
+		for _, path := range paths {
+			anyTypePaths = append(anyTypePaths, AnyTypePath{Resource: resourceToken, Path: path})
+		}
+	}
+	return anyTypePaths
+}
+
+// collectAnyTypePaths appends the paths of the `any` types in the given type
+// at the given path, following the refs to the object types of the package.
+// The visiting types are skipped, in case a merged schema is recursive.
+func (pg *PackageGenerator) collectAnyTypePaths(typeSpec pschema.TypeSpec, path string, visiting map[string]bool, paths *[]string) {
+	if typeSpec.Ref == pg.anyTypeRefOrDefault() {
+		*paths = append(*paths, path)
+		return
+	}
+	if token := strings.TrimPrefix(typeSpec.Ref, "#/types/"); token != typeSpec.Ref && !visiting[token] {
+		if complexTypeSpec, ok := pg.Types[token]; ok && complexTypeSpec.Type == Object {
+			visiting[token] = true
+			for propertyName, propertySpec := range complexTypeSpec.Properties {
+				pg.collectAnyTypePaths(propertySpec.TypeSpec, joinFieldPath(path, propertyName), visiting, paths)
+			}
+			delete(visiting, token)
+		}
+	}
+	if typeSpec.Items != nil {
+		pg.collectAnyTypePaths(*typeSpec.Items, path+"[*]", visiting, paths)
+	}
+	if typeSpec.AdditionalProperties != nil {
+		pg.collectAnyTypePaths(*typeSpec.AdditionalProperties, joinFieldPath(path, "*"), visiting, paths)
+	}
+	for _, oneOf := range typeSpec.OneOf {
+		pg.collectAnyTypePaths(oneOf, path, visiting, paths)
+	}
+}
+
+// joinFieldPath appends the given field to the path. Fields that contain
+// dots or brackets are quoted in brackets, e.g. `metadata["example.com/key"]`.
+func joinFieldPath(path, field string) string {
+	if strings.ContainsAny(field, ".[]") {
+		return path + "[" + strconv.Quote(field) + "]"
+	}
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// writeAnyTypePaths writes the AnyTypePaths as JSON to the given path, or to
+// stderr if the path is `-`.
+func (pg *PackageGenerator) writeAnyTypePaths(outputPath string) error {
+	anyTypePaths := pg.AnyTypePaths()
+	if anyTypePaths == nil {
+		anyTypePaths = []AnyTypePath{}
+	}
+	data, err := marshalJSON(anyTypePaths, pg.compactJSON)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the any type paths")
+	}
+	if outputPath == "-" {
+		_, err := os.Stderr.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
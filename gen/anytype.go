@@ -0,0 +1,90 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// jsonTypeRef is Pulumi's built-in type of arbitrary JSON values, which the
+// code generators render as e.g. `any` in NodeJS and `pulumi.Any` in Go, but
+// serialize as JSON.
+const jsonTypeRef = "pulumi.json#/Json"
+
+// SetAnyTypeRef points the properties, items and map values that fall back to
+// the `pulumi.json#/Any` type, because their schema doesn't describe them, at
+// the type with the given ref instead. The ref is either `pulumi.json#/Json`
+// or `pulumi.json#/Any`, or `#/types/<token>` of a type of the package, e.g. a
+// `JSONValue` type that was added to the Types.
+//
+// Returns an error if the ref doesn't point to one of these types.
+func (pg *PackageGenerator) SetAnyTypeRef(ref string) error {
+	switch {
+	case ref == anyTypeRef, ref == jsonTypeRef:
+	case strings.HasPrefix(ref, "#/types/"):
+		if _, ok := pg.Types[strings.TrimPrefix(ref, "#/types/")]; !ok {
+			return errors.Errorf("could not find the type of the any type ref %q", ref)
+		}
+	default:
+		return errors.Errorf("invalid any type ref %q, expected %s, %s or #/types/<token>", ref, jsonTypeRef, anyTypeRef)
+	}
+
+	previous := pg.anyTypeRefOrDefault()
+	for token, complexTypeSpec := range pg.Types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			propertySpec.TypeSpec = replaceRef(propertySpec.TypeSpec, previous, ref)
+			complexTypeSpec.Properties[propertyName] = propertySpec
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+	pg.anyTypeRef = ref
+	return nil
+}
+
+// anyTypeRefOrDefault returns the ref of the type that the untyped properties
+// fall back to.
+func (pg *PackageGenerator) anyTypeRefOrDefault() string {
+	if pg.anyTypeRef == "" {
+		return anyTypeRef
+	}
+	return pg.anyTypeRef
+}
+
+// replaceRef returns a copy of the given type with the given ref replaced,
+// including in its array items, map values and union members.
+func replaceRef(typeSpec pschema.TypeSpec, ref, replacement string) pschema.TypeSpec {
+	if typeSpec.Ref == ref {
+		typeSpec.Ref = replacement
+	}
+	if typeSpec.Items != nil {
+		items := replaceRef(*typeSpec.Items, ref, replacement)
+		typeSpec.Items = &items
+	}
+	if typeSpec.AdditionalProperties != nil {
+		additionalProperties := replaceRef(*typeSpec.AdditionalProperties, ref, replacement)
+		typeSpec.AdditionalProperties = &additionalProperties
+	}
+	if len(typeSpec.OneOf) > 0 {
+		oneOf := make([]pschema.TypeSpec, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			oneOf = append(oneOf, replaceRef(oneOfTypeSpec, ref, replacement))
+		}
+		typeSpec.OneOf = oneOf
+	}
+	return typeSpec
+}
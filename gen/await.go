@@ -0,0 +1,62 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// The annotations that the Kubernetes provider reads from the metadata of a
+// resource to customize how it waits for the resource to become ready.
+const (
+	SkipAwaitAnnotation      string = "pulumi.com/skipAwait"
+	TimeoutSecondsAnnotation string = "pulumi.com/timeoutSeconds"
+)
+
+// awaitAnnotationsDescription documents the await annotations on the
+// `metadata` of each resource
+var awaitAnnotationsDescription = fmt.Sprintf("Standard object's metadata. The Kubernetes provider reads these annotations from it:\n"+
+	"- `%s`: set to `\"true\"` to not wait for the resource to become ready, e.g. if its controller doesn't report readiness\n"+
+	"- `%s`: the number of seconds to wait for the resource to become ready before failing",
+	SkipAwaitAnnotation, TimeoutSecondsAnnotation)
+
+// DocumentAwaitAnnotations documents the annotations that customize how the
+// Kubernetes provider waits for a resource on the `metadata` of each
+// resource, since CRD controllers often don't signal readiness the way that
+// the controllers of the built-in resources do.
+func (pg *PackageGenerator) DocumentAwaitAnnotations() {
+	for _, resourceToken := range pg.ResourceTokens {
+		resourceType, ok := pg.Types[resourceToken]
+		if !ok {
+			continue
+		}
+		metadata, ok := resourceType.Properties["metadata"]
+		if !ok {
+			continue
+		}
+		metadata.Description = awaitAnnotationsDescription
+		resourceType.Properties["metadata"] = metadata
+	}
+}
+
+// writeAwaitAnnotations writes the await annotations to the metadata of an
+// example manifest, commented out since they change how the resource is
+// deployed.
+func writeAwaitAnnotations(buffer *bytes.Buffer) {
+	buffer.WriteString("  # annotations:\n")
+	fmt.Fprintf(buffer, "  #   %s: \"true\" # don't wait for the resource to become ready\n", SkipAwaitAnnotation)
+	fmt.Fprintf(buffer, "  #   %s: \"600\" # how long to wait for the resource to become ready\n", TimeoutSecondsAnnotation)
+}
@@ -0,0 +1,197 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCacheTTL is how long fetched files are used without revalidating
+// them, unless their HTTP caching headers say otherwise.
+const DefaultCacheTTL = time.Hour
+
+// Cache is an on-disk cache of the files that the URLLoader and OCILoader
+// fetch, keyed on their URL. Fresh entries are used without a request; stale
+// entries are revalidated with their `ETag` or `Last-Modified` date, so that
+// an unchanged file isn't downloaded again. Entries are fresh for the TTL,
+// unless the `Cache-Control` or `Expires` headers of the response set another
+// lifetime, and responses with `Cache-Control: no-store` aren't cached.
+//
+// The cache is best-effort: entries that can't be read or written are
+// fetched again.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// DefaultCacheDir returns the directory of the cache under the user's cache
+// directory, e.g. `~/.cache/crd2pulumi` on Linux.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tool), nil
+}
+
+// cacheEntry is a cached file, and the headers to revalidate it with.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	Expires      time.Time `json:"expires"`
+	Data         []byte    `json:"data"`
+}
+
+// get returns the content, and its content type, at the given URL. It's
+// taken from the cache if it's fresh there, and fetched otherwise: fetch
+// sends the request with the given conditional headers, and returns the
+// response if its status is OK or Not Modified, or an error. A nil Cache
+// always fetches the content.
+func (c *Cache) get(u string, fetch func(header http.Header) (*http.Response, error)) ([]byte, string, error) {
+	entry, found := c.lookup(u)
+	if found && time.Now().Before(entry.Expires) {
+		return entry.Data, entry.ContentType, nil
+	}
+
+	header := http.Header{}
+	if found && entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	if found && entry.LastModified != "" {
+		header.Set("If-Modified-Since", entry.LastModified)
+	}
+	resp, err := fetch(header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && found {
+		if expires, ok := c.expires(resp.Header); ok {
+			entry.Expires = expires
+			c.store(entry)
+		}
+		return entry.Data, entry.ContentType, nil
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "could not read %s", u)
+	}
+	if expires, ok := c.expires(resp.Header); ok {
+		c.store(cacheEntry{
+			URL:          u,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			Expires:      expires,
+			Data:         data,
+		})
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// lookup returns the cached entry of the URL, if there is one.
+func (c *Cache) lookup(u string) (cacheEntry, bool) {
+	var entry cacheEntry
+	if c == nil {
+		return entry, false
+	}
+	data, err := ioutil.ReadFile(c.path(u))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != u {
+		return entry, false
+	}
+	return entry, true
+}
+
+// store writes the entry to the cache, ignoring errors.
+func (c *Cache) store(entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// The entries can be the responses of authenticated requests, e.g. with a
+	// bearer token or from a private registry, so only the user can read them
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(c.path(entry.URL), data, 0600)
+}
+
+// path returns the path of the cached entry of the URL.
+func (c *Cache) path(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// expires returns when a response with the given headers becomes stale, or
+// false if it must not be cached.
+func (c *Cache) expires(header http.Header) (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return time.Time{}, false
+		case directive == "no-cache":
+			return now, true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second), true
+			}
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires, true
+	}
+	return now.Add(c.TTL), true
+}
+
+// WithCache returns a copy of the loaders, with the given cache set on the
+// loaders that fetch files, i.e. the URLLoaders and OCILoaders.
+func (l MultiLoader) WithCache(cache *Cache) MultiLoader {
+	loaders := make(MultiLoader, 0, len(l))
+	for _, loader := range l {
+		switch loader := loader.(type) {
+		case URLLoader:
+			loader.Cache = cache
+			loaders = append(loaders, loader)
+		case OCILoader:
+			loader.Cache = cache
+			loaders = append(loaders, loader)
+		default:
+			loaders = append(loaders, loader)
+		}
+	}
+	return loaders
+}
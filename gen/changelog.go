@@ -0,0 +1,291 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// ChangelogEntry is a change of a resource, a type, a property or an enum
+// value between two generations of a package.
+type ChangelogEntry struct {
+	// Change is `added`, `removed` or `changed`
+	Change string `json:"change"`
+	// Kind is `resource`, `type`, `property` or `value`
+	Kind string `json:"kind"`
+	// Token is the token of the resource or type, or of the resource or type
+	// of the property or enum value
+	Token string `json:"token"`
+	// Name is the name of the property, or the JSON of the enum value, or ""
+	// for resources and types
+	Name string `json:"name,omitempty"`
+	// Detail describes how a property changed, e.g.
+	// "type changed from `string` to `integer`"
+	Detail string `json:"detail,omitempty"`
+	// Breaking is true if code written against the old SDK may not compile
+	// or work with the new one
+	Breaking bool `json:"breaking"`
+}
+
+// String describes the change, e.g.
+// "Removed the property `image` of `kubernetes:stable.example.com/v1:CronTabSpec`".
+func (entry ChangelogEntry) String() string {
+	description := strings.ToUpper(entry.Change[:1]) + entry.Change[1:] + " the " + entry.Kind
+	if entry.Name != "" {
+		description += fmt.Sprintf(" `%s` of", entry.Name)
+	}
+	description += fmt.Sprintf(" `%s`", entry.Token)
+	if entry.Detail != "" {
+		description += ": " + entry.Detail
+	}
+	return description
+}
+
+// Changelog returns the changes from the old package schema to the new one,
+// sorted by token, to help users of the generated SDKs understand what an
+// upgrade of the CRDs changes. Removals, new required properties, and
+// changed property types are breaking. Descriptions aren't compared.
+func Changelog(old, new pschema.PackageSpec) []ChangelogEntry {
+	var entries []ChangelogEntry
+	for _, token := range unionKeys(old.Resources, new.Resources) {
+		oldResource, inOld := old.Resources[token]
+		newResource, inNew := new.Resources[token]
+		switch {
+		case !inNew:
+			entries = append(entries, ChangelogEntry{Change: "removed", Kind: "resource", Token: token, Breaking: true})
+		case !inOld:
+			entries = append(entries, ChangelogEntry{Change: "added", Kind: "resource", Token: token})
+		default:
+			entries = append(entries, changedProperties(token,
+				oldResource.InputProperties, newResource.InputProperties,
+				oldResource.RequiredInputs, newResource.RequiredInputs)...)
+		}
+	}
+
+	// The types of the resources are compared as resources
+	oldTypes, newTypes := nonResourceTypes(old), nonResourceTypes(new)
+	for _, token := range unionKeys(oldTypes, newTypes) {
+		if token == objectMetaToken {
+			continue
+		}
+		oldType, inOld := oldTypes[token]
+		newType, inNew := newTypes[token]
+		switch {
+		case !inNew:
+			entries = append(entries, ChangelogEntry{Change: "removed", Kind: "type", Token: token, Breaking: true})
+		case !inOld:
+			entries = append(entries, ChangelogEntry{Change: "added", Kind: "type", Token: token})
+		case (len(oldType.Enum) > 0) != (len(newType.Enum) > 0):
+			entries = append(entries, ChangelogEntry{Change: "changed", Kind: "type", Token: token,
+				Detail: "changed between an enum and an object type", Breaking: true})
+		case len(newType.Enum) > 0:
+			entries = append(entries, changedEnumValues(token, oldType.Enum, newType.Enum)...)
+		default:
+			entries = append(entries, changedProperties(token,
+				oldType.Properties, newType.Properties, oldType.Required, newType.Required)...)
+		}
+	}
+	return entries
+}
+
+// changedProperties returns the changes of the properties of a resource or type.
+func changedProperties(token string, old, new map[string]pschema.PropertySpec, oldRequired, newRequired []string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	for _, name := range unionKeys(old, new) {
+		oldProperty, inOld := old[name]
+		newProperty, inNew := new[name]
+		entry := ChangelogEntry{Kind: "property", Token: token, Name: name}
+		switch {
+		case !inNew:
+			entry.Change, entry.Breaking = "removed", true
+		case !inOld:
+			entry.Change = "added"
+			if contains(newRequired, name) {
+				entry.Detail, entry.Breaking = "it's required", true
+			}
+		case !sameSpec(oldProperty.TypeSpec, newProperty.TypeSpec):
+			entry.Change, entry.Breaking = "changed", true
+			entry.Detail = fmt.Sprintf("type changed from `%s` to `%s`",
+				describeTypeSpec(oldProperty.TypeSpec), describeTypeSpec(newProperty.TypeSpec))
+		case !contains(oldRequired, name) && contains(newRequired, name):
+			entry.Change, entry.Detail, entry.Breaking = "changed", "it's now required", true
+		case contains(oldRequired, name) && !contains(newRequired, name):
+			entry.Change, entry.Detail = "changed", "it's now optional"
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// changedEnumValues returns the changes of the values of an enum type.
+func changedEnumValues(token string, old, new []pschema.EnumValueSpec) []ChangelogEntry {
+	values := func(enum []pschema.EnumValueSpec) map[string]bool {
+		set := map[string]bool{}
+		for _, enumValue := range enum {
+			set[skewValue(enumValue.Value)] = true
+		}
+		return set
+	}
+	oldValues, newValues := values(old), values(new)
+	var entries []ChangelogEntry
+	for _, value := range unionKeys(oldValues, newValues) {
+		if !newValues[value] {
+			entries = append(entries, ChangelogEntry{Change: "removed", Kind: "value", Token: token, Name: value, Breaking: true})
+		} else if !oldValues[value] {
+			entries = append(entries, ChangelogEntry{Change: "added", Kind: "value", Token: token, Name: value})
+		}
+	}
+	return entries
+}
+
+// describeTypeSpec returns a short description of a type, e.g.
+// `array of string`, for the changelog.
+func describeTypeSpec(typeSpec pschema.TypeSpec) string {
+	switch {
+	case len(typeSpec.OneOf) > 0:
+		oneOf := make([]string, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			oneOf = append(oneOf, describeTypeSpec(oneOfTypeSpec))
+		}
+		return strings.Join(oneOf, " | ")
+	case typeSpec.Ref != "":
+		return strings.TrimPrefix(typeSpec.Ref, "#/types/")
+	case typeSpec.Type == Array && typeSpec.Items != nil:
+		return "array of " + describeTypeSpec(*typeSpec.Items)
+	case typeSpec.Type == Object && typeSpec.AdditionalProperties != nil:
+		return "map of " + describeTypeSpec(*typeSpec.AdditionalProperties)
+	default:
+		return typeSpec.Type
+	}
+}
+
+// nonResourceTypes returns the types of the schema that aren't resources,
+// since generated schemas also list the resources as types.
+func nonResourceTypes(spec pschema.PackageSpec) map[string]pschema.ComplexTypeSpec {
+	types := map[string]pschema.ComplexTypeSpec{}
+	for token, complexTypeSpec := range spec.Types {
+		if _, ok := spec.Resources[token]; !ok {
+			types[token] = complexTypeSpec
+		}
+	}
+	return types
+}
+
+// unionKeys returns the sorted keys of both maps, which must have string
+// keys.
+func unionKeys(a, b interface{}) []string {
+	set := map[string]bool{}
+	for _, m := range []interface{}{a, b} {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			set[key.String()] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readChangelogBase returns the package schema to diff the generated one
+// against: the Pulumi package schema at the path if it ends in `.json`, e.g.
+// one written with LanguageSettings.MergeSchemaPath, or otherwise the schema
+// generated from the CRDs at the path, with the default options.
+func readChangelogBase(path string) (pschema.PackageSpec, error) {
+	if strings.HasSuffix(path, ".json") {
+		return ReadSchema(path)
+	}
+	pg, err := NewPackageGenerator([]string{path})
+	if err != nil {
+		return pschema.PackageSpec{}, errors.Wrapf(err, "could not load the CRDs of the changelog base %s", path)
+	}
+	return genPackageSpec(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, pg.objectMetaRequired), nil
+}
+
+// writeChangelog writes the changes from the schema of the base to the
+// generated one to the given path, as JSON if it ends in `.json` and as
+// Markdown otherwise, or as Markdown to stderr if the path is `-`.
+func (pg *PackageGenerator) writeChangelog(outputPath, basePath string) error {
+	base, err := readChangelogBase(basePath)
+	if err != nil {
+		return err
+	}
+	entries := Changelog(base, genPackageSpec(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, pg.objectMetaRequired))
+
+	var data []byte
+	if strings.HasSuffix(outputPath, ".json") {
+		if entries == nil {
+			entries = []ChangelogEntry{}
+		}
+		if data, err = marshalJSON(entries, pg.compactJSON); err != nil {
+			return errors.Wrap(err, "could not marshal the changelog")
+		}
+	} else {
+		data = formatChangelog(entries)
+	}
+	if outputPath == "-" {
+		_, err := os.Stderr.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
+
+// formatChangelog formats the changes as Markdown, with the breaking changes
+// first.
+func formatChangelog(entries []ChangelogEntry) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("# Changelog\n")
+	if len(entries) == 0 {
+		buffer.WriteString("\nNo changes.\n")
+		return buffer.Bytes()
+	}
+	for _, breaking := range []bool{true, false} {
+		heading := "Breaking changes"
+		if !breaking {
+			heading = "Other changes"
+		}
+		written := false
+		for _, entry := range entries {
+			if entry.Breaking != breaking {
+				continue
+			}
+			if !written {
+				fmt.Fprintf(&buffer, "\n## %s\n\n", heading)
+				written = true
+			}
+			fmt.Fprintf(&buffer, "- %s\n", entry)
+		}
+	}
+	return buffer.Bytes()
+}
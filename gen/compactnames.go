@@ -0,0 +1,213 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// MaxTypeNameLength is the length of the longest type name that
+// CompactTypeNames keeps. The names of nested types concatenate the names of
+// the properties they're nested in, so the types of deep schemas can exceed
+// the limits of file names and identifiers.
+const MaxTypeNameLength = 64
+
+// compactHashLength is the number of hex digits of a compact type name's
+// hash, unless more are needed to tell the names apart
+const compactHashLength = 12
+
+// CompactTypeName is a type whose name was replaced by a short hashed name.
+type CompactTypeName struct {
+	// Token is the token of the type, e.g.
+	// `kubernetes:stable.example.com/v1:T_0a1b2c3d4e5f`.
+	Token string `json:"token"`
+	// Original is the token that the type would have had otherwise.
+	Original string `json:"original"`
+	// Resource is the token of the first resource, by token, that has a
+	// field of the type, and Path is the path of the field, e.g.
+	// `spec.template.spec.containers[*]`. They're empty if no resource has
+	// a field of the type.
+	Resource string `json:"resource,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// CompactTypeNames replaces the name of each type, other than the resource
+// types, that's longer than MaxTypeNameLength with `T_` and the first hex
+// digits of the SHA-256 hash of its token, e.g. `T_0a1b2c3d4e5f`, so that
+// the same type always gets the same name. More digits are used if the names
+// of two types would collide. The original name is documented on each type.
+// Returns the renamed types, sorted by token.
+func (pg *PackageGenerator) CompactTypeNames() []CompactTypeName {
+	var originals []string
+	for token := range pg.Types {
+		if len(tokens.ModuleMember(token).Name()) > MaxTypeNameLength && !contains(pg.ResourceTokens, token) {
+			originals = append(originals, token)
+		}
+	}
+	if len(originals) == 0 {
+		return nil
+	}
+	sort.Strings(originals)
+
+	hashes := make(map[string]string, len(originals))
+	for _, original := range originals {
+		hash := sha256.Sum256([]byte(original))
+		hashes[original] = hex.EncodeToString(hash[:])
+	}
+	paths := pg.typeFieldPaths()
+	var compactTypeNames []CompactTypeName
+	for _, original := range originals {
+		member := tokens.ModuleMember(original)
+		token := string(member.Module()) + ":T_" + compactHash(original, hashes)
+		path := paths[original]
+		compactTypeNames = append(compactTypeNames, CompactTypeName{
+			Token:    token,
+			Original: original,
+			Resource: path.Resource,
+			Path:     path.Path,
+		})
+	}
+	sort.Slice(compactTypeNames, func(i, j int) bool {
+		return compactTypeNames[i].Token < compactTypeNames[j].Token
+	})
+
+	for _, compactTypeName := range compactTypeNames {
+		complexTypeSpec := pg.Types[compactTypeName.Original]
+		complexTypeSpec.Description = appendParagraph(complexTypeSpec.Description,
+			"Compacted from the type `"+string(tokens.ModuleMember(compactTypeName.Original).Name())+"`.")
+		delete(pg.Types, compactTypeName.Original)
+		pg.Types[compactTypeName.Token] = complexTypeSpec
+	}
+	for token, complexTypeSpec := range pg.Types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			for _, compactTypeName := range compactTypeNames {
+				propertySpec.TypeSpec = replaceRef(propertySpec.TypeSpec,
+					"#/types/"+compactTypeName.Original, "#/types/"+compactTypeName.Token)
+			}
+			complexTypeSpec.Properties[propertyName] = propertySpec
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+	for _, compactTypeName := range compactTypeNames {
+		if pg.anyTypeRef == "#/types/"+compactTypeName.Original {
+			pg.anyTypeRef = "#/types/" + compactTypeName.Token
+		}
+	}
+	return compactTypeNames
+}
+
+// compactHash returns the shortest prefix of the hash of the given token,
+// of at least compactHashLength digits, that no other hash starts with.
+func compactHash(token string, hashes map[string]string) string {
+	hash := hashes[token]
+	for length := compactHashLength; length < len(hash); length++ {
+		unique := true
+		for other, otherHash := range hashes {
+			if other != token && strings.HasPrefix(otherHash, hash[:length]) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return hash[:length]
+		}
+	}
+	return hash
+}
+
+// typeFieldPaths returns the shortest path to a field of each object type,
+// in the first resource, by token, that has one. The properties are visited
+// in alphabetical order, so that the paths are stable.
+func (pg *PackageGenerator) typeFieldPaths() map[string]AnyTypePath {
+	resourceTokens := append([]string(nil), pg.ResourceTokens...)
+	sort.Strings(resourceTokens)
+
+	type field struct {
+		token string
+		path  AnyTypePath
+	}
+	paths := map[string]AnyTypePath{}
+	var queue []field
+	for _, resourceToken := range resourceTokens {
+		queue = append(queue, field{resourceToken, AnyTypePath{Resource: resourceToken}})
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		complexTypeSpec := pg.Types[current.token]
+		propertyNames := make([]string, 0, len(complexTypeSpec.Properties))
+		for propertyName := range complexTypeSpec.Properties {
+			propertyNames = append(propertyNames, propertyName)
+		}
+		sort.Strings(propertyNames)
+		for _, propertyName := range propertyNames {
+			var visit func(typeSpec pschema.TypeSpec, path string)
+			visit = func(typeSpec pschema.TypeSpec, path string) {
+				if token := strings.TrimPrefix(typeSpec.Ref, "#/types/"); token != typeSpec.Ref {
+					if _, ok := pg.Types[token]; ok {
+						if _, seen := paths[token]; !seen {
+							paths[token] = AnyTypePath{Resource: current.path.Resource, Path: path}
+							queue = append(queue, field{token, paths[token]})
+						}
+					}
+				}
+				if typeSpec.Items != nil {
+					visit(*typeSpec.Items, path+"[*]")
+				}
+				if typeSpec.AdditionalProperties != nil {
+					visit(*typeSpec.AdditionalProperties, joinFieldPath(path, "*"))
+				}
+				for _, oneOf := range typeSpec.OneOf {
+					visit(oneOf, path)
+				}
+			}
+			visit(complexTypeSpec.Properties[propertyName].TypeSpec, joinFieldPath(current.path.Path, propertyName))
+		}
+	}
+	return paths
+}
+
+// writeCompactTypeNames writes the given CompactTypeNames as JSON to the
+// given path, or to stderr if the path is `-`.
+func (pg *PackageGenerator) writeCompactTypeNames(outputPath string, compactTypeNames []CompactTypeName) error {
+	if compactTypeNames == nil {
+		compactTypeNames = []CompactTypeName{}
+	}
+	data, err := marshalJSON(compactTypeNames, pg.compactJSON)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the compact type names")
+	}
+	if outputPath == "-" {
+		_, err := os.Stderr.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
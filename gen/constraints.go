@@ -0,0 +1,229 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// describeConstraints returns a human-readable summary of the validation
+// keywords on a property's schema that the generated SDKs can't enforce, e.g.
+// "minimum 0, exclusive maximum 1" or "1-10 items, unique". Returns "" if the
+// schema has no such keywords.
+func describeConstraints(schema map[string]interface{}) string {
+	var constraints []string
+	// The allowed values of int-or-string schemas are documented if they
+	// can't be enums
+	if intOrString, _ := schema["x-kubernetes-int-or-string"].(bool); intOrString {
+		if _, _, _, ok := intOrStringEnumTypeSpecs(schema); !ok {
+			if values := formatEnumValues(schema["enum"]); values != "" {
+				constraints = append(constraints, "one of "+values)
+			}
+		}
+	}
+	switch schema["type"] {
+	case Integer, Number:
+		if minimum := formatBound(schema, "minimum", "exclusiveMinimum"); minimum != "" {
+			constraints = append(constraints, minimum)
+		}
+		if maximum := formatBound(schema, "maximum", "exclusiveMaximum"); maximum != "" {
+			constraints = append(constraints, maximum)
+		}
+	case Array:
+		if itemsRange := formatRange(schema["minItems"], schema["maxItems"], "item"); itemsRange != "" {
+			constraints = append(constraints, itemsRange)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			if len(constraints) == 0 {
+				constraints = append(constraints, "unique items")
+			} else {
+				constraints = append(constraints, "unique")
+			}
+		}
+	}
+	return strings.Join(constraints, ", ")
+}
+
+// formatBound formats a numeric bound, e.g. "minimum 0" or "exclusive minimum
+// 0". Handles both the draft 4 / OpenAPI v3 form, where the exclusive keyword
+// is a boolean modifying the inclusive keyword, and the draft 6+ form, where
+// the exclusive keyword is a number of its own. If the schema has both an
+// inclusive and a numeric exclusive bound, then the stricter one is used.
+// Returns "" if the schema has no such bound.
+func formatBound(schema map[string]interface{}, boundKey, exclusiveKey string) string {
+	bound, foundBound := toFloat64(schema[boundKey])
+	exclusive, _ := schema[exclusiveKey].(bool)
+	if exclusiveBound, ok := toFloat64(schema[exclusiveKey]); ok {
+		isMinimum := boundKey == "minimum"
+		if !foundBound || (isMinimum && exclusiveBound >= bound) || (!isMinimum && exclusiveBound <= bound) {
+			bound, foundBound, exclusive = exclusiveBound, true, true
+		}
+	}
+	if !foundBound {
+		return ""
+	}
+	if exclusive {
+		return "exclusive " + boundKey + " " + formatNumber(bound)
+	}
+	return boundKey + " " + formatNumber(bound)
+}
+
+// appendConstraints appends the schema's constraints to the description as
+// their own paragraph.
+func appendConstraints(description string, schema map[string]interface{}) string {
+	constraints := describeConstraints(schema)
+	if constraints == "" {
+		return description
+	}
+	return appendParagraph(description, "Constraints: "+constraints+".")
+}
+
+// formatEnumValues formats the values of an `enum` as JSON, e.g.
+// "`80`, `"http"`". Returns "" if there are no values.
+func formatEnumValues(enum interface{}) string {
+	values, _ := enum.([]interface{})
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		formatted = append(formatted, "`"+string(data)+"`")
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatRange formats an inclusive range of a countable unit, e.g.
+// "1-10 items", "at least 1 item", or "at most 5 items". Returns "" if
+// neither bound is a number.
+func formatRange(min, max interface{}, unit string) string {
+	minValue, foundMin := toFloat64(min)
+	maxValue, foundMax := toFloat64(max)
+	switch {
+	case foundMin && foundMax && minValue == maxValue:
+		return formatCount(maxValue, unit)
+	case foundMin && foundMax:
+		return formatNumber(minValue) + "-" + formatCount(maxValue, unit)
+	case foundMin:
+		return "at least " + formatCount(minValue, unit)
+	case foundMax:
+		return "at most " + formatCount(maxValue, unit)
+	default:
+		return ""
+	}
+}
+
+// formatCount formats a number of the given unit, pluralizing the unit unless
+// the number is 1.
+func formatCount(n float64, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return formatNumber(n) + " " + unit + "s"
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// describeDependencies returns a sentence for each conditional requirement of
+// an object schema, e.g. "If `tls` is set, `host` is required." The
+// requirements come from `dependentRequired`, and from the property and
+// schema forms of `dependencies` and `dependentSchemas`. Other keywords of
+// schema dependencies aren't described. The sentences are sorted by the
+// property they depend on.
+func describeDependencies(schema map[string]interface{}) []string {
+	dependents := map[string][]string{}
+	for _, key := range []string{"dependencies", "dependentRequired", "dependentSchemas"} {
+		dependencies, _ := schema[key].(map[string]interface{})
+		for propertyName, dependency := range dependencies {
+			var required []string
+			switch dependency := dependency.(type) {
+			case []interface{}:
+				required = toStrings(dependency)
+			case []string:
+				required = dependency
+			case map[string]interface{}:
+				switch dependencyRequired := dependency["required"].(type) {
+				case []interface{}:
+					required = toStrings(dependencyRequired)
+				case []string:
+					required = dependencyRequired
+				}
+			}
+			if len(required) > 0 {
+				dependents[propertyName] = appendMissing(dependents[propertyName], required...)
+			}
+		}
+	}
+
+	propertyNames := make([]string, 0, len(dependents))
+	for propertyName := range dependents {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+
+	sentences := make([]string, 0, len(propertyNames))
+	for _, propertyName := range propertyNames {
+		required := dependents[propertyName]
+		verb := "is"
+		if len(required) > 1 {
+			verb = "are"
+		}
+		sentences = append(sentences, fmt.Sprintf("If `%s` is set, %s %s required.", propertyName, formatList(required), verb))
+	}
+	return sentences
+}
+
+// appendDependencies appends the object schema's conditional requirements to
+// the description as their own paragraph.
+func appendDependencies(description string, schema map[string]interface{}) string {
+	dependencies := describeDependencies(schema)
+	if len(dependencies) == 0 {
+		return description
+	}
+	return appendParagraph(description, strings.Join(dependencies, " "))
+}
+
+// formatList formats property names as an English list of code spans, e.g.
+// "`a`", "`a` and `b`", or "`a`, `b`, and `c`".
+func formatList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	switch len(quoted) {
+	case 1:
+		return quoted[0]
+	case 2:
+		return quoted[0] + " and " + quoted[1]
+	default:
+		return strings.Join(quoted[:len(quoted)-1], ", ") + ", and " + quoted[len(quoted)-1]
+	}
+}
+
+func toStrings(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
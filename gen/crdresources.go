@@ -0,0 +1,246 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The CRD resource helpers register the CustomResourceDefinitions that the
+// package was generated from as `apiextensions.k8s.io` resources of the
+// Kubernetes SDK, so that a program can install the CRDs along with the
+// CustomResources that depend on them.
+
+const nodejsCRDResourcesPath = "customResourceDefinitions.ts"
+const nodejsCRDResourcesFile = `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+import * as pulumi from "@pulumi/pulumi";
+import * as k8s from "@pulumi/kubernetes";
+
+const manifests: any[] = [
+%s];
+
+/**
+ * Registers the CustomResourceDefinitions that this package was generated from. Add them to the ` + "`dependsOn`" + ` of
+ * the CustomResources, so that the CRDs are installed first.
+ */
+export function customResourceDefinitions(opts?: pulumi.CustomResourceOptions): pulumi.CustomResource[] {
+    return manifests.map(manifest => manifest.apiVersion === "apiextensions.k8s.io/v1beta1"
+        ? new k8s.apiextensions.v1beta1.CustomResourceDefinition(manifest.metadata.name, manifest, opts)
+        : new k8s.apiextensions.v1.CustomResourceDefinition(manifest.metadata.name, manifest, opts));
+}
+`
+
+const pythonCRDResourcesModule = "custom_resource_definitions"
+const pythonCRDResourcesFile = `# coding=utf-8
+# *** WARNING: this file was generated by crd2pulumi. ***
+# *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+import json
+from typing import List, Optional
+
+import pulumi
+from pulumi_kubernetes.apiextensions import v1, v1beta1
+
+__all__ = ['custom_resource_definitions']
+
+_MANIFESTS = [
+%s]
+
+
+def custom_resource_definitions(opts: Optional[pulumi.ResourceOptions] = None) -> List[pulumi.CustomResource]:
+    """
+    Registers the CustomResourceDefinitions that this package was generated from. Add them to the ` + "`depends_on`" + `
+    of the CustomResources, so that the CRDs are installed first.
+    """
+    crds = []
+    for data in _MANIFESTS:
+        manifest = json.loads(data)
+        module = v1beta1 if manifest['apiVersion'] == 'apiextensions.k8s.io/v1beta1' else v1
+        crds.append(module.CustomResourceDefinition(manifest['metadata']['name'], opts=opts,
+                                                    metadata=manifest['metadata'], spec=manifest['spec']))
+    return crds
+`
+
+const goCRDResourcesPath = "customResourceDefinitions.go"
+const goCRDResourcesFile = `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package kubernetes
+
+import (
+	"encoding/json"
+
+	k8s "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var customResourceDefinitionManifests = []string{
+%s}
+
+// NewCustomResourceDefinitions registers the CustomResourceDefinitions that this package was generated from. Add them
+// to the dependencies of the CustomResources, with pulumi.DependsOn, so that the CRDs are installed first.
+func NewCustomResourceDefinitions(ctx *pulumi.Context, opts ...pulumi.ResourceOption) ([]pulumi.Resource, error) {
+	var crds []pulumi.Resource
+	for _, data := range customResourceDefinitionManifests {
+		var manifest map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &manifest); err != nil {
+			return nil, err
+		}
+		name := manifest["metadata"].(map[string]interface{})["name"].(string)
+		token := "kubernetes:" + manifest["apiVersion"].(string) + ":CustomResourceDefinition"
+		var crd pulumi.CustomResourceState
+		if err := ctx.RegisterResource(token, name, k8s.UntypedArgs(manifest), &crd, opts...); err != nil {
+			return nil, err
+		}
+		crds = append(crds, &crd)
+	}
+	return crds, nil
+}
+`
+
+// crdManifestMetadata are the fields of the CRD metadata that are kept in the
+// manifests of the CRD resources. The others are set by the API server, if the
+// CRD was read from a cluster.
+var crdManifestMetadata = []string{"name", "labels", "annotations"}
+
+// crdManifests returns the JSON manifest of each CRD that the package was
+// generated from, without its status, the metadata that the API server sets,
+// or the annotations and schema extensions that crd2pulumi adds when loading
+// it.
+func (pg *PackageGenerator) crdManifests() ([]string, error) {
+	manifests := make([]string, 0, len(pg.CustomResourceGenerators))
+	for _, crg := range pg.CustomResourceGenerators {
+		crd := crg.CustomResourceDefinition.DeepCopy()
+		delete(crd.Object, "status")
+		metadata := map[string]interface{}{}
+		crdMetadata, _ := crd.Object["metadata"].(map[string]interface{})
+		for _, field := range crdManifestMetadata {
+			if value, ok := crdMetadata[field]; ok {
+				metadata[field] = value
+			}
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, SourceAnnotation)
+			delete(annotations, EmbeddedInAnnotation)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+		crd.Object["metadata"] = metadata
+		removeSchemaExtensions(crd.Object)
+
+		manifest, err := json.Marshal(crd.Object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not marshal the CRD %s", crd.GetName())
+		}
+		manifests = append(manifests, string(manifest))
+	}
+	return manifests, nil
+}
+
+// removeSchemaExtensions removes the schema extensions that crd2pulumi adds
+// to the schemas of the CRDs it loads, e.g. the PropertyOrderKey, from the
+// given value and the values nested in it, in place.
+func removeSchemaExtensions(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			if strings.HasPrefix(key, "x-crd2pulumi-") {
+				delete(value, key)
+			} else {
+				removeSchemaExtensions(nested)
+			}
+		}
+	case []interface{}:
+		for _, nested := range value {
+			removeSchemaExtensions(nested)
+		}
+	}
+}
+
+// validateCRDResources returns an error if the CRD resource helpers are
+// generated along with the placeholder ObjectMeta type, since they need the
+// Kubernetes SDK.
+func (ls LanguageSettings) validateCRDResources() error {
+	if ls.CRDResources && ls.KeepPlaceholderMeta {
+		return errors.New("the CRD resources can't be generated with the placeholder ObjectMeta type")
+	}
+	return nil
+}
+
+// addNodeJSCRDResources adds the CRD resource helper, with the given
+// Kubernetes SDK, to the generated NodeJS files, and exports it from the root
+// of the package.
+func (pg *PackageGenerator) addNodeJSCRDResources(files map[string][]byte, kubernetesPackage string) error {
+	index, ok := files[nodejsIndexPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", nodejsIndexPath)
+	}
+	manifests, err := pg.crdManifests()
+	if err != nil {
+		return err
+	}
+	var literals strings.Builder
+	for _, manifest := range manifests {
+		fmt.Fprintf(&literals, "    %s,\n", manifest)
+	}
+	code := fmt.Sprintf(nodejsCRDResourcesFile, literals.String())
+	files[nodejsCRDResourcesPath] = []byte(strings.Replace(code, `"@pulumi/kubernetes"`, strconv.Quote(kubernetesPackage), 1))
+	files[nodejsIndexPath] = bytes.Replace(index, []byte("// Export members:\n"), []byte("// Export members:\nexport * from \"./customResourceDefinitions\";\n"), 1)
+	return nil
+}
+
+// addPythonCRDResources adds the CRD resource helper to the generated Python
+// files, and exports it from the root of the package.
+func (pg *PackageGenerator) addPythonCRDResources(files map[string][]byte, pythonPackageDir string) error {
+	initPath := filepath.Join(pythonPackageDir, "__init__.py")
+	init, ok := files[initPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", initPath)
+	}
+	manifests, err := pg.crdManifests()
+	if err != nil {
+		return err
+	}
+	var literals strings.Builder
+	for _, manifest := range manifests {
+		fmt.Fprintf(&literals, "    %s,\n", strconv.Quote(manifest))
+	}
+	files[filepath.Join(pythonPackageDir, pythonCRDResourcesModule+".py")] = []byte(fmt.Sprintf(pythonCRDResourcesFile, literals.String()))
+	files[initPath] = bytes.Replace(init, []byte("# Export this package's modules as members:\n"), []byte("# Export this package's modules as members:\nfrom ."+pythonCRDResourcesModule+" import *\n"), 1)
+	return nil
+}
+
+// goCRDResources returns the CRD resource helper of the generated Go package.
+func (pg *PackageGenerator) goCRDResources() (*bytes.Buffer, error) {
+	manifests, err := pg.crdManifests()
+	if err != nil {
+		return nil, err
+	}
+	var literals strings.Builder
+	for _, manifest := range manifests {
+		fmt.Fprintf(&literals, "\t%s,\n", strconv.Quote(manifest))
+	}
+	return bytes.NewBufferString(fmt.Sprintf(goCRDResourcesFile, literals.String())), nil
+}
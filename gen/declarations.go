@@ -0,0 +1,247 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// tsIdentifierRe matches the property names that don't need to be quoted in
+// TypeScript
+var tsIdentifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func (pg *PackageGenerator) genTypeDeclarations(outputPath string) error {
+	declarations, err := pg.TypeDeclarations()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, declarations.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
+
+// TypeDeclarations returns a standalone TypeScript declaration file of the
+// generated types, for NodeJS consumers that only need the types, e.g. to
+// validate config, without the resource classes and the Pulumi runtime:
+//
+//   - Each resource and object type is an interface of the plain values of
+//     its manifests, rather than of `pulumi.Input`s, in a namespace of its
+//     module, e.g. `stable.v1.CronTab`.
+//   - Enums are unions of their values.
+//   - The placeholder ObjectMeta type is declared as `meta.v1.ObjectMeta`,
+//     so that the file has no imports.
+func (pg *PackageGenerator) TypeDeclarations() (*bytes.Buffer, error) {
+	w := declarationWriter{
+		types:      map[string]pschema.ComplexTypeSpec{objectMetaToken: objectMetaTypeSpec},
+		namespaces: map[string]string{objectMetaToken: "meta.v1"},
+		anyTypeRef: pg.anyTypeRefOrDefault(),
+	}
+	tokensByNamespace := map[string][]string{"meta.v1": {objectMetaToken}}
+	for token, complexTypeSpec := range pg.Types {
+		module := string(tokens.ModuleMember(token).Module().Name())
+		namespace := strings.ReplaceAll(pg.modulePath(module), "/", ".")
+		w.types[token] = complexTypeSpec
+		w.namespaces[token] = namespace
+		tokensByNamespace[namespace] = append(tokensByNamespace[namespace], token)
+	}
+	namespaces := make([]string, 0, len(tokensByNamespace))
+	for namespace := range tokensByNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var file bytes.Buffer
+	file.WriteString("// *** WARNING: this file was generated by crd2pulumi. ***\n")
+	file.WriteString("// *** Do not edit by hand unless you're certain you know what you are doing! ***\n")
+	for _, namespace := range namespaces {
+		declarationTokens := tokensByNamespace[namespace]
+		sort.Slice(declarationTokens, func(i, j int) bool {
+			return tokens.ModuleMember(declarationTokens[i]).Name() < tokens.ModuleMember(declarationTokens[j]).Name()
+		})
+		w.namespace = namespace
+		fmt.Fprintf(&file, "\nexport namespace %s {\n", namespace)
+		for i, token := range declarationTokens {
+			if i > 0 {
+				file.WriteString("\n")
+			}
+			if err := w.declaration(&file, token); err != nil {
+				return nil, errors.Wrapf(err, "could not declare %s", token)
+			}
+		}
+		file.WriteString("}\n")
+	}
+	return &file, nil
+}
+
+// declarationWriter converts Pulumi types to TypeScript declarations
+type declarationWriter struct {
+	types map[string]pschema.ComplexTypeSpec
+	// namespaces are the namespaces of the type tokens, and namespace the one
+	// being written
+	namespaces map[string]string
+	namespace  string
+	anyTypeRef string
+}
+
+// declaration writes the interface, or the enum union, of the type.
+func (w *declarationWriter) declaration(body *bytes.Buffer, token string) error {
+	complexTypeSpec := w.types[token]
+	name := string(tokens.ModuleMember(token).Name())
+	writeDocComment(body, "    ", complexTypeSpec.Description)
+	if len(complexTypeSpec.Enum) > 0 {
+		values := make([]string, 0, len(complexTypeSpec.Enum))
+		for _, enumValue := range complexTypeSpec.Enum {
+			value, err := json.Marshal(enumValue.Value)
+			if err != nil {
+				return err
+			}
+			values = append(values, string(value))
+		}
+		fmt.Fprintf(body, "    export type %s = %s;\n", name, strings.Join(values, " | "))
+		return nil
+	}
+
+	fmt.Fprintf(body, "    export interface %s {\n", name)
+	propertyNames := make([]string, 0, len(complexTypeSpec.Properties))
+	for propertyName := range complexTypeSpec.Properties {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	for _, propertyName := range propertyNames {
+		propertySpec := complexTypeSpec.Properties[propertyName]
+		typeExpr, err := w.typeExpr(propertySpec.TypeSpec)
+		if err != nil {
+			return errors.Wrapf(err, "in property %q", propertyName)
+		}
+		if isForbidden(propertySpec) {
+			typeExpr = "never"
+		} else if propertySpec.Const != nil {
+			value, err := json.Marshal(propertySpec.Const)
+			if err != nil {
+				return err
+			}
+			typeExpr = string(value)
+		}
+		optional := "?"
+		if contains(complexTypeSpec.Required, propertyName) {
+			optional = ""
+		}
+		key := propertyName
+		if !tsIdentifierRe.MatchString(key) {
+			key = fmt.Sprintf("%q", key)
+		}
+		writeDocComment(body, "        ", propertySpec.Description)
+		fmt.Fprintf(body, "        %s%s: %s;\n", key, optional, typeExpr)
+	}
+	body.WriteString("    }\n")
+	return nil
+}
+
+// typeExpr returns the TypeScript type of the given type.
+func (w *declarationWriter) typeExpr(typeSpec pschema.TypeSpec) (string, error) {
+	if len(typeSpec.OneOf) > 0 {
+		oneOf := make([]string, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			typeExpr, err := w.typeExpr(oneOfTypeSpec)
+			if err != nil {
+				return "", err
+			}
+			oneOf = append(oneOf, typeExpr)
+		}
+		return strings.Join(oneOf, " | "), nil
+	}
+	if typeSpec.Ref == anyTypeRef || typeSpec.Ref == jsonTypeRef || typeSpec.Ref == w.anyTypeRef {
+		return "any", nil
+	}
+	if strings.HasPrefix(typeSpec.Ref, "#/types/") {
+		token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+		namespace, ok := w.namespaces[token]
+		if !ok {
+			return "", errors.Errorf("could not find type %s", token)
+		}
+		name := string(tokens.ModuleMember(token).Name())
+		if namespace == w.namespace {
+			return name, nil
+		}
+		return namespace + "." + name, nil
+	}
+	if typeSpec.Ref != "" {
+		return "", errors.Errorf("unsupported type reference %q", typeSpec.Ref)
+	}
+
+	switch typeSpec.Type {
+	case String:
+		return "string", nil
+	case Integer, Number:
+		return "number", nil
+	case Boolean:
+		return "boolean", nil
+	case Array:
+		items := "any"
+		if typeSpec.Items != nil {
+			var err error
+			if items, err = w.typeExpr(*typeSpec.Items); err != nil {
+				return "", err
+			}
+		}
+		if strings.Contains(items, " | ") {
+			items = "(" + items + ")"
+		}
+		return items + "[]", nil
+	case Object:
+		values := "any"
+		if typeSpec.AdditionalProperties != nil {
+			var err error
+			if values, err = w.typeExpr(*typeSpec.AdditionalProperties); err != nil {
+				return "", err
+			}
+		}
+		return "{[key: string]: " + values + "}", nil
+	default:
+		return "any", nil
+	}
+}
+
+// writeDocComment writes the description as a JSDoc comment.
+func writeDocComment(body *bytes.Buffer, indent, description string) {
+	if description == "" {
+		return
+	}
+	fmt.Fprintf(body, "%s/**\n", indent)
+	for _, line := range strings.Split(strings.TrimSpace(strings.ReplaceAll(description, "*/", "*\\/")), "\n") {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			fmt.Fprintf(body, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(body, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(body, "%s */\n", indent)
+}
@@ -0,0 +1,63 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// docLinkPlaceholderRe matches the placeholders of a doc link template
+var docLinkPlaceholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+
+// docLinkPlaceholders are the placeholders that a doc link template can use
+var docLinkPlaceholders = []string{"{group}", "{version}", "{kind}", "{plural}"}
+
+// validateDocLinkTemplate returns an error if the template uses a placeholder
+// that isn't one of the docLinkPlaceholders.
+func validateDocLinkTemplate(template string) error {
+	for _, placeholder := range docLinkPlaceholderRe.FindAllString(template, -1) {
+		if !contains(docLinkPlaceholders, placeholder) {
+			return errors.Errorf("unknown placeholder %s in the doc link template %q, expected %s",
+				placeholder, template, strings.Join(docLinkPlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// AddResourceDocLinks appends a link to the documentation of each
+// CustomResource version to the description of its resource. The link is
+// the template expanded with the group, the version, the kind and the plural
+// of the resource, e.g. `https://docs.example.com/{group}/{kind}`.
+func (pg *PackageGenerator) AddResourceDocLinks(template string) error {
+	if err := validateDocLinkTemplate(template); err != nil {
+		return err
+	}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
+			resourceType, ok := pg.Types[resourceToken]
+			if !ok {
+				continue
+			}
+			link := strings.NewReplacer("{group}", crg.Group, "{version}", version, "{kind}", crg.Kind, "{plural}", crg.Plural).Replace(template)
+			resourceType.Description = appendParagraph(resourceType.Description, "Documentation: "+link)
+			pg.Types[resourceToken] = resourceType
+		}
+	}
+	return nil
+}
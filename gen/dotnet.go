@@ -33,7 +33,7 @@ var unneededDotNetFiles = []string{
 func (pg *PackageGenerator) genDotNet(outputDir, name string) error {
 	if files, err := pg.genDotNetFiles(name); err != nil {
 		return err
-	} else if err := writeFiles(files, outputDir); err != nil {
+	} else if err := pg.writeFiles(files, outputDir); err != nil {
 		return err
 	}
 	return nil
@@ -46,8 +46,10 @@ func (pg *PackageGenerator) genDotNetFiles(name string) (map[string]*bytes.Buffe
 	namespaces := map[string]string{}
 	for _, groupVersion := range pg.GroupVersions {
 		group, version := splitGroupVersion(groupVersion)
-		groupPrefix := groupPrefix(group)
-		namespaces[groupVersion] = strings.Title(groupPrefix) + "." + versionToUpper(version)
+		namespaces[groupVersion] = TitleCase(pg.groupModule(group)) + "." + versionToUpper(version)
+		if pg.topLevelModule != "" {
+			namespaces[groupVersion] = TitleCase(pg.topLevelModule) + "." + namespaces[groupVersion]
+		}
 	}
 	namespaces["meta/v1"] = "Meta.V1"
 
@@ -58,7 +60,7 @@ func (pg *PackageGenerator) genDotNetFiles(name string) (map[string]*bytes.Buffe
 	// only get generated properly if `compatibility` was `kubernetes20`.
 	oldName := pkg.Name
 	pkg.Name = name
-	pkg.Language["csharp"] = rawMessage(map[string]interface{}{
+	pkg.Language["csharp"] = pg.languageInfo(DotNet, map[string]interface{}{
 		"packageReferences": map[string]string{
 			"Pulumi.Kubernetes": "3.*",
 		},
@@ -75,14 +77,30 @@ func (pg *PackageGenerator) genDotNetFiles(name string) (map[string]*bytes.Buffe
 	pkg.Name = oldName
 	delete(pkg.Language, "csharp")
 
+	if err := pg.appendTypeTokens(files, DotNet); err != nil {
+		return nil, err
+	}
+
 	namespaceName := dotnet.Title(name)
 	files["KubernetesResource.cs"] = []byte(kubernetesResource(namespaceName))
 	files["Utilities.cs"] = []byte(dotNetUtilities(namespaceName))
+	if pg.ownerReferenceHelpers {
+		files[dotNetOwnerReferencePath] = []byte(dotNetOwnerReferenceFile(namespaceName))
+	}
+	if pg.sdkVersionFile {
+		files[dotNetVersionPath] = []byte(pg.PackageVersion())
+	}
+	if pg.dotNetAssemblyName != "" {
+		renameDotNetAssembly(files, "Pulumi."+namespaceName, pg.dotNetAssemblyName)
+	}
 
 	// Delete unneeded files
 	for _, unneededFile := range unneededDotNetFiles {
 		delete(files, unneededFile)
 	}
+	if pg.dotNetNullable {
+		enableDotNetNullable(files)
+	}
 
 	buffers := map[string]*bytes.Buffer{}
 	for name, code := range files {
@@ -92,6 +110,28 @@ func (pg *PackageGenerator) genDotNetFiles(name string) (map[string]*bytes.Buffe
 	return buffers, nil
 }
 
+// enableDotNetNullable adds a `#nullable enable` directive to each C# file,
+// after its header comments.
+func enableDotNetNullable(files map[string][]byte) {
+	for path, code := range files {
+		if !strings.HasSuffix(path, ".cs") {
+			continue
+		}
+		header := 0
+		for bytes.HasPrefix(code[header:], []byte("//")) {
+			end := bytes.IndexByte(code[header:], '\n')
+			if end < 0 {
+				header = len(code)
+				break
+			}
+			header += end + 1
+		}
+		nullable := append([]byte{}, code[:header]...)
+		nullable = append(nullable, "\n#nullable enable\n"...)
+		files[path] = append(nullable, code[header:]...)
+	}
+}
+
 func kubernetesResource(name string) string {
 	return `// Copyright 2016-2020, Pulumi Corporation
 namespace Pulumi.` + name + `{
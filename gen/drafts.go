@@ -0,0 +1,307 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The schema dialects that NormalizeSchema recognizes. CRD schemas are
+// OpenAPI v3, but schemas converted from other tools may declare a JSON Schema
+// draft via `$schema`.
+const (
+	DraftOpenAPIV3 string = "openapi-v3"
+	Draft4         string = "draft-04"
+	Draft6         string = "draft-06"
+	Draft7         string = "draft-07"
+	Draft201909    string = "2019-09"
+	Draft202012    string = "2020-12"
+)
+
+var knownDrafts = []string{Draft4, Draft6, Draft7, Draft201909, Draft202012}
+
+// DetectSchemaDraft returns the JSON Schema draft declared by the schema's
+// `$schema` keyword, or DraftOpenAPIV3 if it doesn't declare a known draft.
+func DetectSchemaDraft(schema map[string]interface{}) string {
+	uri, _ := schema["$schema"].(string)
+	for _, draft := range knownDrafts {
+		if strings.Contains(uri, draft) {
+			return draft
+		}
+	}
+	return DraftOpenAPIV3
+}
+
+// NormalizeSchema returns a copy of the given schema with JSON Schema draft
+// idioms rewritten into their OpenAPI v3 equivalents, so that the rest of the
+// conversion can assume OpenAPI v3:
+//   - `type: [string, "null"]` becomes `type: string` with `nullable: true`,
+//     and multiple types become a `oneOf`
+//   - numeric `exclusiveMinimum`/`exclusiveMaximum` (draft 6+) become
+//     `minimum`/`maximum` with a boolean `exclusiveMinimum`/`exclusiveMaximum`
+//   - `const` becomes a single-valued `enum`
+//   - tuple `items` lists become a single `items` schema if possible
+//   - local `$ref`s into `definitions`, `$defs`, or anywhere else in the
+//     document are inlined. Recursive references become arbitrary JSON,
+//     marked with the recursiveRefKey so that they can be converted to the
+//     type of the schema they refer to, e.g. for the `items` of a tree's
+//     `children`. The keywords next to a `$ref`, e.g. its `description`,
+//     override the ones of the schema it refers to
+//
+// Returns an error if a local `$ref` can't be resolved.
+func NormalizeSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	n := schemaNormalizer{
+		root:      schema,
+		resolving: map[string]bool{},
+		recursive: map[string]bool{},
+	}
+	return n.normalize(schema)
+}
+
+type schemaNormalizer struct {
+	// root is the schema document that local `$ref`s are resolved against
+	root map[string]interface{}
+	// resolving contains the `$ref`s currently being inlined, to detect cycles
+	resolving map[string]bool
+	// recursive contains the `$ref`s that were found in their own schemas
+	recursive map[string]bool
+}
+
+// refKey is the schema extension that records the local `$ref` that a
+// recursive schema was inlined from, and recursiveRefKey the one that
+// records the `$ref` of a recursive reference to it, e.g.
+// `#/definitions/node`.
+const (
+	refKey          = "x-crd2pulumi-ref"
+	recursiveRefKey = "x-crd2pulumi-recursive-ref"
+)
+
+func (n *schemaNormalizer) normalize(schema map[string]interface{}) (map[string]interface{}, error) {
+	if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		resolved, err := n.resolveRef(ref)
+		if err != nil || len(schema) == 1 {
+			return resolved, err
+		}
+		return n.mergeRefSiblings(resolved, schema)
+	}
+
+	normalized := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "$schema", "$id", "id", "definitions", "$defs":
+			// These only matter for resolving `$ref`s, which are inlined
+			continue
+		case "properties", "patternProperties":
+			properties, ok := value.(map[string]interface{})
+			if !ok {
+				normalized[key] = value
+				continue
+			}
+			normalizedProperties := make(map[string]interface{}, len(properties))
+			for propertyName, propertySchema := range properties {
+				normalizedPropertySchema, err := n.normalizeValue(propertySchema)
+				if err != nil {
+					return nil, err
+				}
+				normalizedProperties[propertyName] = normalizedPropertySchema
+			}
+			normalized[key] = normalizedProperties
+		case "items":
+			// Tuple validation can only be represented if every item has the same schema
+			if tuple, ok := value.([]interface{}); ok {
+				if len(tuple) != 1 {
+					continue
+				}
+				value = tuple[0]
+			}
+			normalizedItems, err := n.normalizeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			normalized[key] = normalizedItems
+		case "additionalProperties", "not":
+			normalizedValue, err := n.normalizeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			normalized[key] = normalizedValue
+		case "allOf", "anyOf", "oneOf":
+			subSchemas, ok := value.([]interface{})
+			if !ok {
+				normalized[key] = value
+				continue
+			}
+			normalizedSubSchemas := make([]interface{}, 0, len(subSchemas))
+			for _, subSchema := range subSchemas {
+				normalizedSubSchema, err := n.normalizeValue(subSchema)
+				if err != nil {
+					return nil, err
+				}
+				normalizedSubSchemas = append(normalizedSubSchemas, normalizedSubSchema)
+			}
+			normalized[key] = normalizedSubSchemas
+		default:
+			normalized[key] = value
+		}
+	}
+
+	normalizeTypeList(normalized)
+	normalizeExclusiveBound(normalized, "exclusiveMinimum", "minimum")
+	normalizeExclusiveBound(normalized, "exclusiveMaximum", "maximum")
+	if constValue, foundConst := normalized["const"]; foundConst {
+		if _, foundEnum := normalized["enum"]; !foundEnum {
+			normalized["enum"] = []interface{}{constValue}
+		}
+		delete(normalized, "const")
+	}
+	return normalized, nil
+}
+
+// normalizeValue normalizes the given value if it's a schema, and returns it
+// unchanged otherwise (e.g. the boolean form of `additionalProperties`).
+func (n *schemaNormalizer) normalizeValue(value interface{}) (interface{}, error) {
+	if schema, ok := value.(map[string]interface{}); ok {
+		return n.normalize(schema)
+	}
+	return value, nil
+}
+
+// resolveRef returns the normalized schema the given local `$ref` points to.
+func (n *schemaNormalizer) resolveRef(ref string) (map[string]interface{}, error) {
+	if n.resolving[ref] {
+		n.recursive[ref] = true
+		return map[string]interface{}{
+			"type":                                 Object,
+			"x-kubernetes-preserve-unknown-fields": true,
+			recursiveRefKey:                        ref,
+		}, nil
+	}
+
+	target, ok := resolvePointer(n.root, strings.TrimPrefix(ref, "#"))
+	if !ok {
+		return nil, errors.Errorf("could not resolve $ref %q", ref)
+	}
+	schema, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("$ref %q does not point to a schema", ref)
+	}
+
+	n.resolving[ref] = true
+	defer delete(n.resolving, ref)
+	normalized, err := n.normalize(schema)
+	if err != nil {
+		return nil, err
+	}
+	if n.recursive[ref] {
+		normalized[refKey] = ref
+	}
+	return normalized, nil
+}
+
+// mergeRefSiblings merges the keywords next to a `$ref`, e.g. its
+// `description` or `default`, onto the schema that it resolved to, as
+// OpenAPI 3.1 allows. The sibling keywords override the ones of the resolved
+// schema, which is a copy, so the other references to it are unaffected.
+func (n *schemaNormalizer) mergeRefSiblings(resolved, schema map[string]interface{}) (map[string]interface{}, error) {
+	siblings := make(map[string]interface{}, len(schema)-1)
+	for key, value := range schema {
+		if key != "$ref" {
+			siblings[key] = value
+		}
+	}
+	normalizedSiblings, err := n.normalize(siblings)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range normalizedSiblings {
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// resolvePointer returns the value that the given JSON pointer, e.g.
+// `/definitions/Foo`, points to in the given document.
+func resolvePointer(document interface{}, pointer string) (interface{}, bool) {
+	target := document
+	if pointer == "" {
+		return target, true
+	}
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if target, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+	return target, true
+}
+
+// normalizeTypeList rewrites a list of types into OpenAPI v3's single `type`.
+func normalizeTypeList(schema map[string]interface{}) {
+	typeList, ok := schema["type"].([]interface{})
+	if !ok {
+		return
+	}
+	delete(schema, "type")
+
+	var schemaTypes []interface{}
+	for _, schemaType := range typeList {
+		if schemaType == "null" {
+			schema["nullable"] = true
+		} else {
+			schemaTypes = append(schemaTypes, schemaType)
+		}
+	}
+	switch len(schemaTypes) {
+	case 0:
+		return
+	case 1:
+		schema["type"] = schemaTypes[0]
+	default:
+		if _, foundOneOf := schema["oneOf"]; foundOneOf {
+			return
+		}
+		oneOf := make([]interface{}, 0, len(schemaTypes))
+		for _, schemaType := range schemaTypes {
+			oneOf = append(oneOf, map[string]interface{}{"type": schemaType})
+		}
+		schema["oneOf"] = oneOf
+	}
+}
+
+// normalizeExclusiveBound rewrites the numeric form of `exclusiveMinimum` or
+// `exclusiveMaximum` (draft 6+) into OpenAPI v3's boolean form alongside
+// `minimum` or `maximum`. If the schema also has an inclusive bound that is
+// stricter, then the exclusive bound is dropped instead.
+func normalizeExclusiveBound(schema map[string]interface{}, exclusiveKey, boundKey string) {
+	exclusiveBound, ok := toFloat64(schema[exclusiveKey])
+	if !ok {
+		return
+	}
+	if bound, ok := toFloat64(schema[boundKey]); ok {
+		isMinimum := boundKey == "minimum"
+		if (isMinimum && bound > exclusiveBound) || (!isMinimum && bound < exclusiveBound) {
+			delete(schema, exclusiveKey)
+			return
+		}
+	}
+	schema[boundKey] = exclusiveBound
+	schema[exclusiveKey] = true
+}
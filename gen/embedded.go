@@ -0,0 +1,117 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EmbeddedInAnnotation is the annotation that records where a CRD that was
+// embedded in another manifest was found, e.g.
+// `ConfigMap operator/crds, key crontabs.yaml`.
+const EmbeddedInAnnotation = "crd2pulumi.pulumi.com/embedded-in"
+
+// embeddedCRDs returns the CRDs embedded in the given manifest, if it's a
+// ConfigMap: the CRDs of the YAML documents of its data keys that end in
+// `.yaml` or `.yml`, in the order of the keys. Other manifests embed none.
+func embeddedCRDs(manifest unstruct.Unstructured) ([]unstruct.Unstructured, error) {
+	if manifest.GetKind() != "ConfigMap" || manifest.GetAPIVersion() != "v1" {
+		return nil, nil
+	}
+	data, _, _ := unstruct.NestedStringMap(manifest.Object, "data")
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if strings.HasSuffix(key, ".yaml") || strings.HasSuffix(key, ".yml") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	name := manifest.GetName()
+	if namespace := manifest.GetNamespace(); namespace != "" {
+		name = namespace + "/" + name
+	}
+	var crds []unstruct.Unstructured
+	for _, key := range keys {
+		embedded, err := UnmarshalYamls([][]byte{[]byte(data[key])})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not unmarshal the key %s of the ConfigMap %s", key, name)
+		}
+		for _, crd := range embedded {
+			annotations := crd.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[EmbeddedInAnnotation] = fmt.Sprintf("ConfigMap %s, key %s", name, key)
+			crd.SetAnnotations(annotations)
+			crds = append(crds, crd)
+		}
+	}
+	return crds, nil
+}
+
+// embeddedNotices returns a notice for each of the CRDs that were embedded in
+// another manifest, with where they were found.
+func embeddedNotices(crds []unstruct.Unstructured) []string {
+	var notices []string
+	for _, crd := range crds {
+		embeddedIn, ok := crd.GetAnnotations()[EmbeddedInAnnotation]
+		if !ok {
+			continue
+		}
+		source, ok := crd.GetAnnotations()[SourceAnnotation]
+		if !ok {
+			source = "the inputs"
+		}
+		notices = append(notices, fmt.Sprintf("found the CRD %s in %s, embedded in the %s", crd.GetName(), source, embeddedIn))
+	}
+	return notices
+}
+
+// WithEmbeddedCRDs returns a copy of the loaders, that also load the CRDs
+// embedded in ConfigMaps, as some operators ship their CRDs.
+func (l MultiLoader) WithEmbeddedCRDs() MultiLoader {
+	loaders := make(MultiLoader, 0, len(l))
+	for _, loader := range l {
+		switch loader := loader.(type) {
+		case FileLoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		case URLLoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		case YAMLLoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		case GlobLoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		case OCILoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		case GitLoader:
+			loader.Embedded = true
+			loaders = append(loaders, loader)
+		default:
+			loaders = append(loaders, loader)
+		}
+	}
+	return loaders
+}
@@ -0,0 +1,302 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Vendor extensions that annotate each value of an `enum` with a member name
+// or a description. Each is a list parallel to the `enum` list.
+var (
+	enumNameExtensions        = []string{"x-enum-varnames", "x-enumNames"}
+	enumDescriptionExtensions = []string{"x-enum-descriptions", "x-enumDescriptions"}
+)
+
+var enumWordRegex = regexp.MustCompile("[a-zA-Z0-9]+")
+
+// EnumCollisionPolicy is how the members of enum values whose names collide
+// once sanitized, e.g. `Active` and `active`, are named.
+type EnumCollisionPolicy string
+
+const (
+	// EnumCollisionSuffix suffixes the names of the later values with a
+	// number, e.g. `Active2`, with a warning, which is the default
+	EnumCollisionSuffix EnumCollisionPolicy = "suffix"
+	// EnumCollisionError fails the conversion
+	EnumCollisionError EnumCollisionPolicy = "error"
+)
+
+// ParseEnumCollisionPolicy returns the policy of the given name.
+func ParseEnumCollisionPolicy(name string) (EnumCollisionPolicy, error) {
+	switch policy := EnumCollisionPolicy(name); policy {
+	case EnumCollisionSuffix, EnumCollisionError:
+		return policy, nil
+	default:
+		return "", errors.Errorf("invalid enum collision policy %q, expected suffix or error", name)
+	}
+}
+
+// enumCollision is an enum value whose member name was already taken by an
+// earlier value of the enum, and the name that it was given instead.
+type enumCollision struct {
+	value, previous interface{}
+	renamed         string
+}
+
+// SetEnumCollisionPolicy sets how the members of enum values whose names
+// collide are named, and regenerates the types with it. Returns an error if
+// the policy is EnumCollisionError and the names of an enum collide. The
+// renamed members are returned by RenamedEnumValues.
+func (pg *PackageGenerator) SetEnumCollisionPolicy(policy EnumCollisionPolicy) error {
+	pg.enumCollisions = policy
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
+
+// RenamedEnumValues returns a sorted sentence for each enum member that was
+// suffixed because its name collided with an earlier member of its enum,
+// when the types were last generated.
+func (pg *PackageGenerator) RenamedEnumValues() []string {
+	renamed := append([]string(nil), pg.renamedEnumValues...)
+	sort.Strings(renamed)
+	return renamed
+}
+
+// enumCollisions records the collisions of the enum type of the given name
+// according to the policy: the suffixed members are recorded in
+// renamedEnumValues, or the first collision is the error with
+// EnumCollisionError.
+func (c *typeConverter) enumCollisions(name string, collisions []enumCollision) {
+	for _, collision := range collisions {
+		description := fmt.Sprintf("the member names of the enum values %s and %s of %s collide",
+			yamlScalar(collision.previous), yamlScalar(collision.value), name)
+		if c.enumCollisionPolicy == EnumCollisionError {
+			if c.err == nil {
+				c.err = errors.New(description)
+			}
+			continue
+		}
+		c.renamedEnumValues = append(c.renamedEnumValues, fmt.Sprintf("%s, so the second is named %s", description, collision.renamed))
+	}
+}
+
+// GetEnumTypeSpec returns the enum type for a schema of the given scalar
+// `schemaType` that lists its allowed values in `enum`. Returns false if the
+// schema has no `enum`, or if its values can't be represented as a Pulumi enum.
+// Values whose member names collide with an earlier value's, e.g. `Active`
+// and `active`, are suffixed with a number, e.g. `Active2`.
+func GetEnumTypeSpec(schema map[string]interface{}, schemaType string) (pschema.ComplexTypeSpec, bool) {
+	enumTypeSpec, _, ok := getEnumTypeSpec(schema, schemaType)
+	return enumTypeSpec, ok
+}
+
+// getEnumTypeSpec is GetEnumTypeSpec, and also returns the collisions of the
+// member names.
+func getEnumTypeSpec(schema map[string]interface{}, schemaType string) (pschema.ComplexTypeSpec, []enumCollision, bool) {
+	if schemaType != String && schemaType != Integer && schemaType != Number {
+		return pschema.ComplexTypeSpec{}, nil, false
+	}
+	values, foundValues, _ := unstruct.NestedSlice(schema, "enum")
+	if !foundValues || len(values) == 0 {
+		return pschema.ComplexTypeSpec{}, nil, false
+	}
+	names := nestedEnumAnnotations(schema, enumNameExtensions, len(values))
+	descriptions := nestedEnumAnnotations(schema, enumDescriptionExtensions, len(values))
+
+	// The member names are compared case-insensitively, since they can't
+	// differ only by case in some languages and file systems
+	memberNames := make([]string, len(values))
+	takenNames := map[string]bool{}
+	for i, value := range values {
+		// Nullable enums list `null` as an allowed value, which isn't a member
+		if value == nil {
+			continue
+		}
+		if !isEnumValueOfType(value, schemaType) {
+			return pschema.ComplexTypeSpec{}, nil, false
+		}
+		memberNames[i] = EnumMemberName(value)
+		if names != nil && enumWordRegex.MatchString(names[i]) {
+			memberNames[i] = EnumMemberName(names[i])
+		}
+		takenNames[strings.ToLower(memberNames[i])] = true
+	}
+
+	enumValues := make([]pschema.EnumValueSpec, 0, len(values))
+	var collisions []enumCollision
+	// seenNames maps the member names of the earlier values to the values
+	seenNames := map[string]interface{}{}
+	seenValues := map[interface{}]bool{}
+	for i, value := range values {
+		// A repeated value is the same member, not a collision
+		if value == nil || seenValues[value] {
+			continue
+		}
+		seenValues[value] = true
+		name := memberNames[i]
+		if previous, ok := seenNames[strings.ToLower(name)]; ok {
+			// The suffixed name can't be taken by any other value
+			renamed := name
+			for suffix := 2; takenNames[strings.ToLower(renamed)]; suffix++ {
+				renamed = name + strconv.Itoa(suffix)
+			}
+			takenNames[strings.ToLower(renamed)] = true
+			collisions = append(collisions, enumCollision{value: value, previous: previous, renamed: renamed})
+			name = renamed
+		}
+		seenNames[strings.ToLower(name)] = value
+		enumValueSpec := pschema.EnumValueSpec{
+			Name:  name,
+			Value: value,
+		}
+		if descriptions != nil {
+			enumValueSpec.Description = descriptions[i]
+		}
+		enumValues = append(enumValues, enumValueSpec)
+	}
+	if len(enumValues) == 0 {
+		return pschema.ComplexTypeSpec{}, nil, false
+	}
+
+	description, _, _ := unstruct.NestedString(schema, "description")
+	return pschema.ComplexTypeSpec{
+		ObjectTypeSpec: pschema.ObjectTypeSpec{
+			Type:        schemaType,
+			Description: description,
+		},
+		Enum: enumValues,
+	}, collisions, true
+}
+
+// EnumMemberName returns a readable, identifier-safe member name for the given
+// enum value. For example, "if-not-present" becomes "IfNotPresent", and 3
+// becomes "Value3".
+func EnumMemberName(value interface{}) string {
+	var sb strings.Builder
+	for _, word := range enumWordRegex.FindAllString(fmt.Sprintf("%v", value), -1) {
+		// Keep the digits of values like "1.5" apart so they don't collide with "15"
+		if sb.Len() > 0 && unicode.IsDigit(rune(word[0])) {
+			sb.WriteRune('_')
+		}
+		sb.WriteString(TitleCase(word))
+	}
+	name := sb.String()
+	if name == "" {
+		return "Empty"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		return "Value" + name
+	}
+	return name
+}
+
+// nestedEnumAnnotations returns the first of the given vendor extensions that
+// is a list of strings with one entry per enum value, or nil if none exist.
+func nestedEnumAnnotations(schema map[string]interface{}, extensions []string, length int) []string {
+	for _, extension := range extensions {
+		annotations, found, err := unstruct.NestedStringSlice(schema, extension)
+		if found && err == nil && len(annotations) == length {
+			return annotations
+		}
+	}
+	return nil
+}
+
+// intOrStringEnumTypeSpecs splits the `enum` of an `x-kubernetes-int-or-string`
+// schema into an enum type of its integer values and one of its string values,
+// either of which is nil if there are no such values, and the collisions of
+// their member names. Returns false if the schema has no `enum`, or if its
+// values can't be represented as Pulumi enums.
+func intOrStringEnumTypeSpecs(schema map[string]interface{}) (*pschema.ComplexTypeSpec, *pschema.ComplexTypeSpec, []enumCollision, bool) {
+	values, foundValues, _ := unstruct.NestedSlice(schema, "enum")
+	if !foundValues || len(values) == 0 {
+		return nil, nil, nil, false
+	}
+	names := nestedEnumAnnotations(schema, enumNameExtensions, len(values))
+	descriptions := nestedEnumAnnotations(schema, enumDescriptionExtensions, len(values))
+
+	// The values of each type keep their annotations
+	schemas := map[string]map[string]interface{}{}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		schemaType := Integer
+		if _, ok := value.(string); ok {
+			schemaType = String
+		}
+		typeSchema, ok := schemas[schemaType]
+		if !ok {
+			typeSchema = map[string]interface{}{"type": schemaType, "enum": []interface{}{}}
+			if description, ok := schema["description"]; ok {
+				typeSchema["description"] = description
+			}
+			schemas[schemaType] = typeSchema
+		}
+		typeSchema["enum"] = append(typeSchema["enum"].([]interface{}), value)
+		for extension, annotations := range map[string][]string{enumNameExtensions[0]: names, enumDescriptionExtensions[0]: descriptions} {
+			if annotations != nil {
+				existing, _ := typeSchema[extension].([]interface{})
+				typeSchema[extension] = append(existing, annotations[i])
+			}
+		}
+	}
+
+	var enumTypeSpecs [2]*pschema.ComplexTypeSpec
+	var collisions []enumCollision
+	for i, schemaType := range []string{Integer, String} {
+		typeSchema, ok := schemas[schemaType]
+		if !ok {
+			continue
+		}
+		enumTypeSpec, typeCollisions, ok := getEnumTypeSpec(typeSchema, schemaType)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		enumTypeSpecs[i] = &enumTypeSpec
+		collisions = append(collisions, typeCollisions...)
+	}
+	if enumTypeSpecs[0] == nil && enumTypeSpecs[1] == nil {
+		return nil, nil, nil, false
+	}
+	return enumTypeSpecs[0], enumTypeSpecs[1], collisions, true
+}
+
+// isEnumValueOfType returns true if the decoded enum value matches the given
+// scalar schema type.
+func isEnumValueOfType(value interface{}, schemaType string) bool {
+	switch v := value.(type) {
+	case string:
+		return schemaType == String
+	case float64:
+		return schemaType == Number || (schemaType == Integer && v == float64(int64(v)))
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// trailingWhitespaceRe matches the whitespace at the end of every line
+var trailingWhitespaceRe = regexp.MustCompile(`(?m)[ \t]+$`)
+
+// FormatFiles formats the generated files in place: Go files with gofmt, and
+// TypeScript files with prettier if it's installed. TypeScript files always
+// have their trailing whitespace removed, which prettier would also do. Files
+// that fail to format are left unchanged, and a warning is returned for each.
+func FormatFiles(files map[string]*bytes.Buffer) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	prettier, _ := exec.LookPath("prettier")
+	var warnings []string
+	for _, path := range paths {
+		var formatted []byte
+		var err error
+		switch filepath.Ext(path) {
+		case ".go":
+			formatted, err = format.Source(files[path].Bytes())
+		case ".ts":
+			formatted = normalizeWhitespace(files[path].Bytes())
+			if prettier != "" {
+				formatted, err = runPrettier(prettier, path, formatted)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not format %s: %v", path, err))
+			continue
+		}
+		files[path] = bytes.NewBuffer(formatted)
+	}
+	return warnings
+}
+
+// normalizeWhitespace strips trailing whitespace from every line and ensures
+// that the code ends with exactly one newline.
+func normalizeWhitespace(code []byte) []byte {
+	code = trailingWhitespaceRe.ReplaceAll(code, nil)
+	return append(bytes.TrimRight(code, "\n"), '\n')
+}
+
+// runPrettier formats the given code with the prettier executable, using the
+// file path to infer the parser.
+func runPrettier(prettier, path string, code []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(prettier, "--stdin-filepath", path)
+	cmd.Stdin = bytes.NewReader(code)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
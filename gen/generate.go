@@ -23,9 +23,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
+	"github.com/blang/semver"
 	"github.com/pkg/errors"
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -48,69 +50,79 @@ const (
 // be fetched from a remote or read from the filesystem
 var fetchUrlRe = regexp.MustCompile(`^\w+://`)
 
-// Version specifies the crd2pulumi version. It should be set by the linker via LDFLAGS. This defaults to dev
-var Version string = "dev"
+// Version specifies the crd2pulumi version. It should be set by the linker via LDFLAGS, e.g.
+// `-ldflags "-X github.com/pulumi/crd2pulumi/gen.Version=1.2.3"`. It's also the version of the generated packages,
+// unless LanguageSettings.PackageVersion is set. This defaults to 0.0.0-dev, since it must be valid semver.
+var Version string = "0.0.0-dev"
 
 // Generate parses the CRDs at the given yamlPaths and outputs the generated
 // code according to the language settings. Only overwrites existing files if
 // force is true.
 func Generate(ls LanguageSettings, yamlPaths []string, force bool) error {
-	if !force {
-		if exists, paths := ls.hasExistingPaths(); exists {
-			return errors.Errorf("path(s) %s already exists; use --force to overwrite", paths)
-		}
-	}
-
-	pg, err := NewPackageGenerator(yamlPaths)
+	loader, err := NewSchemaLoaders(yamlPaths)
 	if err != nil {
 		return err
 	}
+	return GenerateFromLoader(ls, loader, force)
+}
 
-	if ls.NodeJSPath != nil {
-		if err := pg.genNodeJS(*ls.NodeJSPath, ls.NodeJSName); err != nil {
-			return err
-		}
-	}
-	if ls.PythonPath != nil {
-		if err := pg.genPython(*ls.PythonPath, ls.PythonName); err != nil {
-			return err
-		}
-	}
-	if ls.GoPath != nil {
-		if err := pg.genGo(*ls.GoPath, ls.GoName); err != nil {
-			return err
-		}
+// GenerateFromLoader is like Generate, but parses the CRDs from the given
+// loader.
+func GenerateFromLoader(ls LanguageSettings, loader SchemaLoader, force bool) error {
+	return GenerateWithOptions(loader, WithLanguageSettings(ls), WithForce(force))
+}
+
+// writeFiles writes the generated files like the writeFiles function, but
+// annotates them with their source CRDs and formats them first if enabled.
+// Formatting failures are only reported as warnings, since the unformatted
+// code is still usable, unless strict mode is enabled.
+func (pg *PackageGenerator) writeFiles(files map[string]*bytes.Buffer, outputDir string) error {
+	if pg.annotateSource {
+		pg.AnnotateSources(files)
 	}
-	if ls.DotNetPath != nil {
-		if err := pg.genDotNet(*ls.DotNetPath, ls.DotNetName); err != nil {
-			return err
+	if pg.format {
+		for _, warning := range FormatFiles(files) {
+			if pg.strict {
+				return errors.New(warning)
+			}
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
 		}
 	}
-
-	return nil
+	return writeFiles(files, outputDir)
 }
 
 // Writes the contents of each buffer to its file path, relative to `outputDir`.
-// `files` should be a mapping from file path strings to buffers.
+// `files` should be a mapping from file path strings to buffers. Each file is
+// closed before the next is written.
 func writeFiles(files map[string]*bytes.Buffer, outputDir string) error {
 	for path, code := range files {
-		outputFilePath := filepath.Join(outputDir, path)
-		err := os.MkdirAll(filepath.Dir(outputFilePath), 0755)
-		if err != nil {
-			return errors.Wrapf(err, "could not create directory to %s", outputFilePath)
-		}
-		file, err := os.Create(outputFilePath)
-		if err != nil {
-			return errors.Wrapf(err, "could not create file %s", outputFilePath)
-		}
-		defer file.Close()
-		if _, err := code.WriteTo(file); err != nil {
-			return errors.Wrapf(err, "could not write to file %s", outputFilePath)
+		if err := writeFile(filepath.Join(outputDir, path), code); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// writeFile writes the contents of the buffer to the file at the given path,
+// and closes it.
+func writeFile(outputFilePath string, code *bytes.Buffer) error {
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputFilePath)
+	}
+	file, err := os.Create(outputFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "could not create file %s", outputFilePath)
+	}
+	if _, err := code.WriteTo(file); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "could not write to file %s", outputFilePath)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputFilePath)
+	}
+	return nil
+}
+
 // PackageGenerator generates code for multiple CustomResources
 type PackageGenerator struct {
 	// CustomResourceGenerators contains a slice of all CustomResourceGenerators
@@ -122,33 +134,153 @@ type PackageGenerator struct {
 	GroupVersions []string
 	// Types is a mapping from every type's token name to its ComplexTypeSpec
 	Types map[string]pschema.ComplexTypeSpec
+	// methods are the functions of the methods of the resources, by resource
+	// token and method name
+	methods map[string]map[string]pschema.FunctionSpec
+	// maxDepth is how deeply schemas can be nested before they're typed as
+	// `any`, or DefaultMaxDepth if it's 0, and truncatedTypes are the types
+	// that were
+	maxDepth       int
+	truncatedTypes []string
+	// objectMetaRequired is true if the `metadata` of the resources is a
+	// required input
+	objectMetaRequired bool
 	// schemaPackage is the Pulumi schema package used to generate code for
 	// languages that do not need an ObjectMeta type (NodeJS)
 	schemaPackage *pschema.Package
 	// schemaPackageWithObjectMetaType is the Pulumi schema package used to
 	// generate code for languages that need an ObjectMeta type (Python, Go, and .NET)
 	schemaPackageWithObjectMetaType *pschema.Package
+	// format is true if the generated code should be formatted before it's
+	// written
+	format bool
+	// strict is true if warnings should fail generation instead
+	strict bool
+	// compactJSON is true if the JSON files are written without indentation
+	compactJSON bool
+	// anyTypeRef is the ref of the type that untyped properties fall back to,
+	// which is `pulumi.json#/Any` if empty
+	anyTypeRef string
+	// unknownTypes is how schemas of unknown types are converted, which is
+	// UnknownTypeAny if empty
+	unknownTypes UnknownTypePolicy
+	// enumCollisions is how the members of enum values whose names collide
+	// are named, which is EnumCollisionSuffix if empty, and
+	// renamedEnumValues are the members that were suffixed
+	enumCollisions    EnumCollisionPolicy
+	renamedEnumValues []string
+	// partialSchemas are the normalized bare schemas whose types are
+	// generated without resources, by token
+	partialSchemas map[string]map[string]interface{}
+	// keepPlaceholderMeta is true if the generated code should use the
+	// placeholder ObjectMeta type instead of the Kubernetes SDK's
+	keepPlaceholderMeta bool
+	// objectMetaPackages are the packages to import the ObjectMeta type from,
+	// per language, instead of the Kubernetes SDK
+	objectMetaPackages map[string]ObjectMetaPackage
+	// languageOptions are the options to merge into the settings of each
+	// language's code generator
+	languageOptions map[string]map[string]interface{}
+	// topLevelModule is the module that every group's modules are nested
+	// under, if it's set
+	topLevelModule string
+	// groupPrefixStrip is removed from the start or the end of the groups
+	// when deriving their modules, if it's set
+	groupPrefixStrip string
+	// overlayTemplates replace the default templates of the overlays of each
+	// language
+	overlayTemplates map[string]map[string]string
+	// schemaPropertyOrder is true if ordered property listings should follow
+	// the order of the schemas instead of being sorted
+	schemaPropertyOrder bool
+	// pythonDistributionName, dotNetAssemblyName and goPackageName override
+	// the names that the code generators derive from the package names
+	pythonDistributionName string
+	dotNetAssemblyName     string
+	goPackageName          string
+	// dotNetNullable is true if the C# files should enable nullable
+	// reference types themselves
+	dotNetNullable bool
+	// packageVersion overrides the version of the generated packages
+	packageVersion string
+	// schemaVersion is the Pulumi version that the written schema targets
+	schemaVersion semver.Version
+	// testStubs is true if test stubs should be generated for NodeJS, Python
+	// and Go
+	testStubs bool
+	// sdkVersionFile is true if a file that exposes the package version
+	// should be generated for each language
+	sdkVersionFile bool
+	// ownerReferenceHelpers is true if a helper that constructs owner
+	// references should be generated for each language
+	ownerReferenceHelpers bool
+	// goUtilityHelpers is true if the Go package should have helpers that
+	// set its inputs from its plain types
+	goUtilityHelpers bool
+	// crdResources is true if a helper that registers the CRDs as resources
+	// should be generated for NodeJS, Python and Go
+	crdResources bool
+	// awaitAnnotations is true if the example manifest should show the
+	// annotations that customize how the Kubernetes provider waits for the
+	// resources
+	awaitAnnotations bool
+	// annotateSource is true if the generated code should be annotated with
+	// the CRDs that it was generated from
+	annotateSource bool
 }
 
 func FetchFile(u *url.URL) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Accept", "application/x-yaml")
-	req.Header.Add("Accept", "text/yaml")
+	return fetchFile(u, nil, nil, "")
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to HTTP server: %s", err)
+// fetchFile returns the file at the URL, from the cache if it's fresh there.
+// The file is fetched with the given client, or http.DefaultClient if it's
+// nil, and with the bearer token, if it's set. The bearer token is only sent
+// over https, so plain http URLs and redirects to them are refused with it.
+func fetchFile(u *url.URL, cache *Cache, client *http.Client, bearerToken string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error getting CRD. Status=%d", resp.StatusCode)
+	if bearerToken != "" {
+		if u.Scheme != "https" {
+			return nil, errors.Errorf("the bearer token is only sent over https, not to %s", u.Redacted())
+		}
+		httpsClient := *client
+		httpsClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return errors.Errorf("the bearer token is only sent over https, not to %s", req.URL.Redacted())
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		client = &httpsClient
 	}
+	data, _, err := cache.get(u.String(), func(header http.Header) (*http.Response, error) {
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header
+		req.Header.Add("Accept", "application/x-yaml")
+		req.Header.Add("Accept", "text/yaml")
+		if bearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+bearerToken)
+		}
 
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to HTTP server: %s", err)
+		}
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+			resp.Body.Close()
+			return nil, fmt.Errorf("error getting CRD. Status=%d", resp.StatusCode)
+		}
+		return resp, nil
+	})
+	return data, err
 }
 
 // Read contents of file, with special case for stdin '-'
@@ -160,57 +292,61 @@ func ReadFileOrStdin(path string) ([]byte, error) {
 	}
 }
 
-func LoadCRD(pathOrUrl string) ([]byte, error) {
-	if fetchUrlRe.MatchString(pathOrUrl) {
-		u, err := url.Parse(pathOrUrl)
-		if err != nil {
-			return nil, err
-		}
+// ReadCRDs loads every file or URL in yamlPaths and returns the CRDs found in
+// them. Returns an error if no CRDs could be found.
+func ReadCRDs(yamlPaths []string) ([]unstruct.Unstructured, error) {
+	loader, err := NewSchemaLoaders(yamlPaths)
+	if err != nil {
+		return nil, err
+	}
+	return LoadCRDs(loader)
+}
 
-		switch u.Scheme {
-		case "https", "http":
-			return FetchFile(u)
-		default:
-			return nil, fmt.Errorf("scheme %q is not supported", u.Scheme)
+// NewCustomResourceGenerators returns a CustomResourceGenerator for each of
+// the given CRDs.
+func NewCustomResourceGenerators(crds []unstruct.Unstructured) ([]CustomResourceGenerator, error) {
+	crgs := make([]CustomResourceGenerator, 0, len(crds))
+	for i, crd := range crds {
+		crg, err := NewCustomResourceGenerator(crd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse crd %d", i)
 		}
+		crgs = append(crgs, crg)
 	}
-
-	// If it isn't a http/s scheme, try it as a file
-	return ReadFileOrStdin(pathOrUrl)
+	return crgs, nil
 }
 
 func NewPackageGenerator(yamlPaths []string) (PackageGenerator, error) {
-	yamlFiles := make([][]byte, 0, len(yamlPaths))
-
-	for _, yamlPath := range yamlPaths {
-		yamlFile, err := LoadCRD(yamlPath)
-		if err != nil {
-			return PackageGenerator{}, errors.Wrapf(err, "could not read file %s", yamlPath)
-		}
-		yamlFiles = append(yamlFiles, yamlFile)
+	loader, err := NewSchemaLoaders(yamlPaths)
+	if err != nil {
+		return PackageGenerator{}, err
 	}
+	return NewPackageGeneratorFromLoader(loader)
+}
 
-	crds, err := UnmarshalYamls(yamlFiles)
+// NewPackageGeneratorFromLoader returns a PackageGenerator for the CRDs from
+// the given loader.
+func NewPackageGeneratorFromLoader(loader SchemaLoader) (PackageGenerator, error) {
+	crds, err := LoadCRDs(loader)
 	if err != nil {
-		return PackageGenerator{}, errors.Wrapf(err, "could not unmarshal yaml file(s)")
+		return PackageGenerator{}, err
 	}
+	return newPackageGeneratorFromCRDs(crds)
+}
 
-	if len(crds) == 0 {
-		return PackageGenerator{}, errors.New("could not find any CRD YAML files")
+// newPackageGeneratorFromCRDs returns a PackageGenerator for the given CRDs,
+// which may be empty.
+func newPackageGeneratorFromCRDs(crds []unstruct.Unstructured) (PackageGenerator, error) {
+	crgs, err := NewCustomResourceGenerators(crds)
+	if err != nil {
+		return PackageGenerator{}, err
 	}
 
 	resourceTokensSize := 0
 	groupVersionsSize := 0
-
-	crgs := make([]CustomResourceGenerator, 0, len(crds))
-	for i, crd := range crds {
-		crg, err := NewCustomResourceGenerator(crd)
-		if err != nil {
-			return PackageGenerator{}, errors.Wrapf(err, "could not parse crd %d", i)
-		}
+	for _, crg := range crgs {
 		resourceTokensSize += len(crg.ResourceTokens)
 		groupVersionsSize += len(crg.GroupVersions)
-		crgs = append(crgs, crg)
 	}
 
 	baseRefs := make([]string, 0, resourceTokensSize)
@@ -229,11 +365,20 @@ func NewPackageGenerator(yamlPaths []string) (PackageGenerator, error) {
 	return pg, nil
 }
 
+// PackageVersion returns the version of the generated packages: the
+// overridden package version if set, and the crd2pulumi Version otherwise.
+func (pg *PackageGenerator) PackageVersion() string {
+	if pg.packageVersion != "" {
+		return pg.packageVersion
+	}
+	return Version
+}
+
 // SchemaPackage returns the Pulumi schema package with no ObjectMeta type.
 // This is only necessary for NodeJS and Python.
 func (pg *PackageGenerator) SchemaPackage() *pschema.Package {
 	if pg.schemaPackage == nil {
-		pkg, err := genPackage(pg.Types, pg.ResourceTokens, false)
+		pkg, err := genPackage(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, false, pg.objectMetaRequired)
 		contract.AssertNoErrorf(err, "could not parse Pulumi package")
 		pg.schemaPackage = pkg
 	}
@@ -244,7 +389,7 @@ func (pg *PackageGenerator) SchemaPackage() *pschema.Package {
 // an ObjectMeta type. This is only necessary for Go and .NET.
 func (pg *PackageGenerator) SchemaPackageWithObjectMetaType() *pschema.Package {
 	if pg.schemaPackageWithObjectMetaType == nil {
-		pkg, err := genPackage(pg.Types, pg.ResourceTokens, true)
+		pkg, err := genPackage(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, true, pg.objectMetaRequired)
 		contract.AssertNoErrorf(err, "could not parse Pulumi package")
 		pg.schemaPackageWithObjectMetaType = pkg
 	}
@@ -256,12 +401,42 @@ func (pg *PackageGenerator) SchemaPackageWithObjectMetaType() *pschema.Package {
 func (pg *PackageGenerator) moduleToPackage() map[string]string {
 	moduleToPackage := map[string]string{}
 	for _, groupVersion := range pg.GroupVersions {
-		group, version := splitGroupVersion(groupVersion)
-		moduleToPackage[groupVersion] = groupPrefix(group) + "/" + version
+		moduleToPackage[groupVersion] = pg.modulePath(groupVersion)
 	}
 	return moduleToPackage
 }
 
+// modulePath returns the path of the module that the resources and types of
+// the given <group>/<version> are generated in, i.e. <groupPrefix>/<version>,
+// nested under the top-level module if it's set.
+func (pg *PackageGenerator) modulePath(groupVersion string) string {
+	group, version := splitGroupVersion(groupVersion)
+	if pg.topLevelModule == "" {
+		return pg.groupModule(group) + "/" + version
+	}
+	return pg.topLevelModule + "/" + pg.groupModule(group) + "/" + version
+}
+
+// groupModule returns the module of the given group, i.e. its groupPrefix
+// once the groupPrefixStrip is removed from its start or its end. The group
+// is kept whole if it would have no module otherwise.
+func (pg *PackageGenerator) groupModule(group string) string {
+	if pg.groupPrefixStrip == "" {
+		return groupPrefix(group)
+	}
+	stripped := group
+	if strings.HasPrefix(group, pg.groupPrefixStrip) {
+		stripped = strings.TrimPrefix(group, pg.groupPrefixStrip)
+	} else if strings.HasSuffix(group, pg.groupPrefixStrip) {
+		stripped = strings.TrimSuffix(group, pg.groupPrefixStrip)
+	}
+	stripped = strings.Trim(stripped, ".")
+	if stripped == "" || removeNonAlphanumeric(strings.Split(stripped, ".")[0]) == "" {
+		return groupPrefix(group)
+	}
+	return groupPrefix(stripped)
+}
+
 // HasSchemas returns true if there exists at least one CustomResource with a schema in this package.
 func (pg *PackageGenerator) HasSchemas() bool {
 	for _, crg := range pg.CustomResourceGenerators {
@@ -283,10 +458,27 @@ type CustomResourceGenerator struct {
 	APIVersion string
 	// Kind represents the `spec.names.kind` field in the CRD YAML
 	Kind string
-	// Plural represents the `spec.names.plural` field in the CRD YAML
+	// TypeName is the name of the generated resource type, which is the Kind
+	// unless the resource is renamed
+	TypeName string
+	// Plural represents the `spec.names.plural` field in the CRD YAML, which
+	// is the resource of the CRD's API paths, e.g. `crontabs`
 	Plural string
+	// Singular represents the `spec.names.singular` field in the CRD YAML,
+	// which defaults to the lowercased Kind
+	Singular string
+	// ListKind represents the `spec.names.listKind` field in the CRD YAML,
+	// which defaults to the Kind with a `List` suffix
+	ListKind string
+	// ShortNames represents the `spec.names.shortNames` field in the CRD YAML
+	ShortNames []string
+	// Categories represents the `spec.names.categories` field in the CRD YAML
+	Categories []string
 	// Group represents the `spec.group` field in the CRD YAML
 	Group string
+	// Scope represents the `spec.scope` field in the CRD YAML, either
+	// `Namespaced` or `Cluster`
+	Scope string
 	// Versions is a slice of names of each version supported by this CRD
 	Versions []string
 	// GroupVersions is a slice of names of each version, in the format
@@ -295,6 +487,22 @@ type CustomResourceGenerator struct {
 	// ResourceTokens is a slice of the token types of every versioned
 	// CustomResource
 	ResourceTokens []string
+	// PrinterColumns represents the `additionalPrinterColumns` of each version
+	// in the CRD YAML
+	PrinterColumns map[string][]PrinterColumn
+	// PreserveUnknownFields represents the `spec.preserveUnknownFields` field
+	// in the CRD YAML, which makes the whole resource schemaless
+	PreserveUnknownFields bool
+	// SchemalessVersions are the sorted versions that have no schema, but are
+	// still generated, with an untyped `spec` and `status`, because the CRD
+	// preserves unknown fields
+	SchemalessVersions []string
+	// ConversionStrategy represents the `spec.conversion.strategy` field in the
+	// CRD YAML, either `None` or `Webhook`, or "" if it isn't set
+	ConversionStrategy string
+	// Source is the file, URL or OCI reference that the CRD was loaded from,
+	// as recorded in its SourceAnnotation, or "" if it's unknown
+	Source string
 }
 
 func NewCustomResourceGenerator(crd unstruct.Unstructured) (CustomResourceGenerator, error) {
@@ -303,14 +511,8 @@ func NewCustomResourceGenerator(crd unstruct.Unstructured) (CustomResourceGenera
 
 	validation, foundValidation, _ := unstruct.NestedMap(crd.Object, "spec", "validation", "openAPIV3Schema")
 	if foundValidation { // If present, use the top-level schema to validate all versions
-		versionName, foundVersionName, _ := unstruct.NestedString(crd.Object, "spec", "version")
-		if foundVersionName {
+		for _, versionName := range crdVersionNames(crd) {
 			schemas[versionName] = validation
-		} else if versionInfos, foundVersionInfos, _ := NestedMapSlice(crd.Object, "spec", "versions"); foundVersionInfos {
-			for _, versionInfo := range versionInfos {
-				versionName, _, _ := unstruct.NestedString(versionInfo, "name")
-				schemas[versionName] = validation
-			}
 		}
 	} else { // Otherwise use per-version schemas to validate each version
 		versionInfos, foundVersionInfos, _ := NestedMapSlice(crd.Object, "spec", "versions")
@@ -324,6 +526,28 @@ func NewCustomResourceGenerator(crd unstruct.Unstructured) (CustomResourceGenera
 		}
 	}
 
+	// The whole resource is schemaless if the CRD preserves unknown fields, so
+	// the versions without a schema get an empty one, and are untyped
+	preserveUnknownFields, _, _ := unstruct.NestedBool(crd.Object, "spec", "preserveUnknownFields")
+	var schemalessVersions []string
+	if preserveUnknownFields {
+		for _, versionName := range crdVersionNames(crd) {
+			if _, ok := schemas[versionName]; !ok {
+				schemas[versionName] = map[string]interface{}{}
+				schemalessVersions = append(schemalessVersions, versionName)
+			}
+		}
+		sort.Strings(schemalessVersions)
+	}
+
+	for version, schema := range schemas {
+		normalizedSchema, err := NormalizeSchema(schema)
+		if err != nil {
+			return CustomResourceGenerator{}, errors.Wrapf(err, "could not normalize schema of version %s", version)
+		}
+		schemas[version] = normalizedSchema
+	}
+
 	kind, foundKind, _ := unstruct.NestedString(crd.Object, "spec", "names", "kind")
 	if !foundKind {
 		return CustomResourceGenerator{}, errors.New("could not find `spec.names.kind` field in the CRD")
@@ -332,16 +556,36 @@ func NewCustomResourceGenerator(crd unstruct.Unstructured) (CustomResourceGenera
 	if !foundPlural {
 		return CustomResourceGenerator{}, errors.New("could not find `spec.names.plural` field in the CRD")
 	}
+	// The API server defaults the singular name and the list kind
+	singular, foundSingular, _ := unstruct.NestedString(crd.Object, "spec", "names", "singular")
+	if !foundSingular || singular == "" {
+		singular = strings.ToLower(kind)
+	}
+	listKind, foundListKind, _ := unstruct.NestedString(crd.Object, "spec", "names", "listKind")
+	if !foundListKind || listKind == "" {
+		listKind = kind + "List"
+	}
+	shortNames, _, _ := unstruct.NestedStringSlice(crd.Object, "spec", "names", "shortNames")
+	categories, _, _ := unstruct.NestedStringSlice(crd.Object, "spec", "names", "categories")
 	group, foundGroup, _ := unstruct.NestedString(crd.Object, "spec", "group")
 	if !foundGroup {
 		return CustomResourceGenerator{}, errors.New("could not find `spec.group` field in the CRD")
 	}
+	scope, foundScope, _ := unstruct.NestedString(crd.Object, "spec", "scope")
+	if !foundScope {
+		scope = "Namespaced"
+	}
+	conversionStrategy, _, _ := unstruct.NestedString(crd.Object, "spec", "conversion", "strategy")
 
 	versions := make([]string, 0, len(schemas))
-	groupVersions := make([]string, 0, len(schemas))
-	resourceTokens := make([]string, 0, len(schemas))
 	for version := range schemas {
 		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	groupVersions := make([]string, 0, len(schemas))
+	resourceTokens := make([]string, 0, len(schemas))
+	for _, version := range versions {
 		groupVersions = append(groupVersions, group+"/"+version)
 		resourceTokens = append(resourceTokens, getToken(group, version, kind))
 	}
@@ -351,21 +595,105 @@ func NewCustomResourceGenerator(crd unstruct.Unstructured) (CustomResourceGenera
 		Schemas:                  schemas,
 		APIVersion:               apiVersion,
 		Kind:                     kind,
+		TypeName:                 kind,
 		Plural:                   plural,
+		Singular:                 singular,
+		ListKind:                 listKind,
+		ShortNames:               shortNames,
+		Categories:               categories,
 		Group:                    group,
+		Scope:                    scope,
 		Versions:                 versions,
 		GroupVersions:            groupVersions,
 		ResourceTokens:           resourceTokens,
+		PrinterColumns:           printerColumns(crd, versions),
+		PreserveUnknownFields:    preserveUnknownFields,
+		SchemalessVersions:       schemalessVersions,
+		ConversionStrategy:       conversionStrategy,
+		Source:                   crd.GetAnnotations()[SourceAnnotation],
 	}
 
 	return crg, nil
 }
 
+// crdVersionNames returns the names of the versions that the CRD declares,
+// either in the deprecated `spec.version` field or in `spec.versions`.
+func crdVersionNames(crd unstruct.Unstructured) []string {
+	if versionName, foundVersionName, _ := unstruct.NestedString(crd.Object, "spec", "version"); foundVersionName {
+		return []string{versionName}
+	}
+	versionInfos, _, _ := NestedMapSlice(crd.Object, "spec", "versions")
+	versionNames := make([]string, 0, len(versionInfos))
+	for _, versionInfo := range versionInfos {
+		versionName, _, _ := unstruct.NestedString(versionInfo, "name")
+		versionNames = append(versionNames, versionName)
+	}
+	return versionNames
+}
+
 // HasSchemas returns true if the CustomResource specifies at least some schema, and false otherwise.
 func (crg *CustomResourceGenerator) HasSchemas() bool {
 	return len(crg.Schemas) > 0
 }
 
+// UntypedVersions returns the sorted versions of the CustomResource whose root
+// schema isn't an object with properties, nor preserves unknown fields. Their
+// `spec` and `status` are generated as untyped values.
+func (crg *CustomResourceGenerator) UntypedVersions() []string {
+	var versions []string
+	for _, version := range crg.Versions {
+		if !isTypedRoot(crg.Schemas[version]) {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// IsStructural returns true if every version of the CustomResource has a
+// structural schema: each node in the schema specifies its type, and the
+// CRD doesn't opt out of pruning via `spec.preserveUnknownFields`.
+func (crg *CustomResourceGenerator) IsStructural() bool {
+	if !crg.HasSchemas() {
+		return false
+	}
+	if crg.PreserveUnknownFields {
+		return false
+	}
+	for _, schema := range crg.Schemas {
+		if !isStructuralSchema(schema) {
+			return false
+		}
+	}
+	return true
+}
+
+// isStructuralSchema returns true if the given schema and all of its nested
+// schemas specify a type, unless they're int-or-string or preserve unknown fields.
+func isStructuralSchema(schema map[string]interface{}) bool {
+	if intOrString, _, _ := unstruct.NestedBool(schema, "x-kubernetes-int-or-string"); intOrString {
+		return true
+	}
+	if _, foundType, _ := unstruct.NestedString(schema, "type"); !foundType {
+		if preserveUnknownFields, _, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields"); !preserveUnknownFields {
+			return false
+		}
+	}
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	for propertyName := range properties {
+		propertySchema, _, _ := unstruct.NestedMap(properties, propertyName)
+		if !isStructuralSchema(propertySchema) {
+			return false
+		}
+	}
+	if items, foundItems, _ := unstruct.NestedMap(schema, "items"); foundItems && !isStructuralSchema(items) {
+		return false
+	}
+	if additionalProperties, found, _ := unstruct.NestedMap(schema, "additionalProperties"); found && !isStructuralSchema(additionalProperties) {
+		return false
+	}
+	return true
+}
+
 // Returns the type token for a Kubernetes CustomResource with the given group,
 // version, and kind.
 func getToken(group, version, kind string) string {
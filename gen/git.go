@@ -0,0 +1,182 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GitReference is a reference to a revision of a Git repository, e.g.
+// `https://github.com/myorg/operator@v1.2.3`.
+type GitReference struct {
+	// Repository is the URL of the repository that `git` clones, e.g.
+	// `https://github.com/myorg/operator` or `git@github.com:myorg/operator`.
+	Repository string
+	// Ref is the branch, the tag or the commit of the revision, e.g.
+	// `v1.2.3`. Defaults to the default branch of the repository.
+	Ref string
+}
+
+// ParseGitReference parses a `<repository>[@<ref>]` reference. The ref is
+// after the last `@` that follows the path of the repository, so that the
+// user of SSH URLs, e.g. `git@github.com:myorg/operator`, isn't a ref.
+func ParseGitReference(ref string) (GitReference, error) {
+	repository, revision := ref, ""
+	if i := strings.LastIndex(ref, "@"); i > strings.LastIndex(ref, "/") {
+		repository, revision = ref[:i], ref[i+1:]
+		if revision == "" || strings.HasPrefix(revision, "-") {
+			return GitReference{}, errors.Errorf("invalid ref %q in Git reference %q", revision, ref)
+		}
+	}
+	if repository == "" || strings.HasPrefix(repository, "-") {
+		return GitReference{}, errors.Errorf("invalid Git reference %q, expected <repository>[@<ref>]", ref)
+	}
+	return GitReference{Repository: repository, Ref: revision}, nil
+}
+
+func (r GitReference) String() string {
+	if r.Ref == "" {
+		return r.Repository
+	}
+	return r.Repository + "@" + r.Ref
+}
+
+// GitLoader loads CRDs from a revision of a Git repository, which is
+// shallowly cloned with the `git` CLI, and thus with its credentials, into a
+// temporary directory that's removed afterwards.
+type GitLoader struct {
+	Reference GitReference
+	// Path is the file or the directory in the repository to load the CRDs
+	// from. Every YAML and JSON file in a directory and its subdirectories is
+	// loaded, and their documents that aren't CRDs are ignored. Defaults to
+	// the root of the repository.
+	Path string
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+func (l GitLoader) Load() ([]unstruct.Unstructured, error) {
+	dir, err := ioutil.TempDir("", "crd2pulumi-git-")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create a directory to clone into")
+	}
+	defer os.RemoveAll(dir)
+
+	if err := l.clone(dir); err != nil {
+		return nil, err
+	}
+	repositoryPath := path.Clean("/" + filepath.ToSlash(l.Path))[1:]
+	root := filepath.Join(dir, filepath.FromSlash(repositoryPath))
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, errors.Errorf("could not find the path %s in %s", l.Path, l.Reference)
+	}
+	files := []string{root}
+	if info.IsDir() {
+		if files, err = manifestFiles(root); err != nil {
+			return nil, errors.Wrapf(err, "could not read the path %s in %s", l.Path, l.Reference)
+		}
+		if len(files) == 0 {
+			return nil, errors.Errorf("the path %s in %s has no YAML or JSON files", l.Path, l.Reference)
+		}
+	}
+
+	var crds []unstruct.Unstructured
+	for _, file := range files {
+		loaded, err := FileLoader{Path: file, Embedded: l.Embedded}.Load()
+		if err != nil {
+			return nil, err
+		}
+		// The source is the file in the repository, rather than in the clone
+		relative, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		source := l.Reference.String() + "/" + filepath.ToSlash(relative)
+		for i := range loaded {
+			annotations := loaded[i].GetAnnotations()
+			if annotations[SourceAnnotation] == file {
+				annotations[SourceAnnotation] = source
+				loaded[i].SetAnnotations(annotations)
+			}
+		}
+		crds = append(crds, loaded...)
+	}
+	return crds, nil
+}
+
+// clone fetches the revision of the repository, without its history, into
+// the given empty directory. Fetching the ref, rather than cloning its
+// branch, also supports the refs of commits.
+func (l GitLoader) clone(dir string) error {
+	ref := l.Reference.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commands := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", l.Reference.Repository},
+		{"fetch", "--quiet", "--depth", "1", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		// The credentials can't be prompted for, since the output is captured
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "could not clone %s: git %s: %s", l.Reference, args[0], strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+// manifestFiles returns the sorted YAML and JSON files in the directory and
+// its subdirectories, skipping the hidden ones, e.g. `.git` and `.github`.
+func manifestFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, file)
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,131 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GlobLoader loads CRDs from every file that matches a glob pattern, e.g.
+// `manifests/**/*.yaml`. The pattern is expanded in-process, so that it
+// doesn't depend on the shell: `**` matches any number of directories, and
+// the other segments are matched with path.Match. The documents of the
+// matched files that aren't CRDs are ignored.
+type GlobLoader struct {
+	Pattern string
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+func (l GlobLoader) Load() ([]unstruct.Unstructured, error) {
+	paths, err := Glob(l.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, errors.Errorf("no files match %s", l.Pattern)
+	}
+	loaders := make(MultiLoader, 0, len(paths))
+	for _, path := range paths {
+		loaders = append(loaders, FileLoader{Path: path, Embedded: l.Embedded})
+	}
+	return loaders.Load()
+}
+
+// IsGlobPattern returns true if the given path contains glob metacharacters.
+func IsGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Glob returns the files that match the given pattern, in lexical order. The
+// pattern's separators are slashes, or the OS's separator, and `**` matches
+// any number of directories.
+func Glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid glob pattern %s", pattern)
+		}
+	}
+
+	// Only walk the directory that the pattern's segments without
+	// metacharacters lead to
+	i := 0
+	for i < len(segments)-1 && !IsGlobPattern(segments[i]) {
+		i++
+	}
+	root := strings.Join(segments[:i], "/")
+	if root == "" && i > 0 {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+	root = filepath.FromSlash(root)
+	segments = segments[i:]
+
+	var matches []string
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if file == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments, strings.Split(filepath.ToSlash(relative), "/")) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not expand glob pattern %s", pattern)
+	}
+	return matches, nil
+}
+
+// matchSegments returns true if the given path segments match the given
+// pattern segments.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
@@ -0,0 +1,151 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"go/format"
+	"path"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var goClientTemplate = template.Must(template.New("goClient").Parse(`// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package {{.Package}}
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// {{.TypeName}}GroupVersionKind is the GroupVersionKind of {{.Kind}} resources.
+var {{.TypeName}}GroupVersionKind = schema.GroupVersionKind{
+	Group:   {{printf "%q" .Group}},
+	Version: {{printf "%q" .Version}},
+	Kind:    {{printf "%q" .Kind}},
+}
+
+// {{.TypeName}}GroupVersionResource is the GroupVersionResource of {{.Kind}} resources.
+var {{.TypeName}}GroupVersionResource = schema.GroupVersionResource{
+	Group:    {{printf "%q" .Group}},
+	Version:  {{printf "%q" .Version}},
+	Resource: {{printf "%q" .Plural}},
+}
+
+// {{.TypeName}}Client lists and watches {{.Kind}} resources in a cluster, e.g. to build informers.
+type {{.TypeName}}Client struct {
+	resource dynamic.NamespaceableResourceInterface
+}
+
+// New{{.TypeName}}Client returns a {{.TypeName}}Client that uses the given dynamic client.
+func New{{.TypeName}}Client(client dynamic.Interface) *{{.TypeName}}Client {
+	return &{{.TypeName}}Client{resource: client.Resource({{.TypeName}}GroupVersionResource)}
+}
+{{if .Namespaced}}
+// List lists the {{.Kind}} resources in the given namespace, or in all namespaces if namespace is empty.
+func (c *{{.TypeName}}Client) List(ctx context.Context, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return c.resource.Namespace(namespace).List(ctx, opts)
+}
+
+// Watch watches the {{.Kind}} resources in the given namespace, or in all namespaces if namespace is empty.
+func (c *{{.TypeName}}Client) Watch(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.Namespace(namespace).Watch(ctx, opts)
+}
+{{else}}
+// List lists the {{.Kind}} resources.
+func (c *{{.TypeName}}Client) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return c.resource.List(ctx, opts)
+}
+
+// Watch watches the {{.Kind}} resources.
+func (c *{{.TypeName}}Client) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.Watch(ctx, opts)
+}
+{{end}}`))
+
+// goClient contains the values used to render goClientTemplate for a single
+// versioned CustomResource.
+type goClient struct {
+	Package string
+	Group   string
+	Version string
+	Kind    string
+	// TypeName is the name of the generated resource type, which the
+	// identifiers of the client are named after
+	TypeName   string
+	Plural     string
+	Namespaced bool
+}
+
+// genGoClientFiles returns a typed list/watch helper for each CustomResource
+// that GetTypes generated a resource type for. The group, version, and kind
+// are read from the resource type's `apiVersion` and `kind` constants, so the
+// helpers always match the generated resources.
+func (pg *PackageGenerator) genGoClientFiles() (map[string]*bytes.Buffer, error) {
+	moduleToPackage := pg.moduleToPackage()
+	buffers := map[string]*bytes.Buffer{}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			resourceType, ok := pg.Types[getToken(crg.Group, version, crg.TypeName)]
+			if !ok {
+				continue
+			}
+			apiVersion, _ := resourceType.Properties["apiVersion"].Const.(string)
+			kind, _ := resourceType.Properties["kind"].Const.(string)
+			group, resourceVersion := splitGroupVersion(apiVersion)
+			packagePath := moduleToPackage[apiVersion]
+
+			var buffer bytes.Buffer
+			err := goClientTemplate.Execute(&buffer, goClient{
+				Package:    path.Base(packagePath),
+				Group:      group,
+				Version:    resourceVersion,
+				Kind:       kind,
+				TypeName:   crg.TypeName,
+				Plural:     crg.Plural,
+				Namespaced: crg.Scope != "Cluster",
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not generate Go client for %s", kind)
+			}
+			code, err := format.Source(buffer.Bytes())
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not format Go client for %s", kind)
+			}
+			buffers[packagePath+"/"+lowerFirst(crg.TypeName)+"Client.go"] = bytes.NewBuffer(code)
+		}
+	}
+	return buffers, nil
+}
+
+// lowerFirst returns the given string with its first letter in lowercase, to
+// match the file names of the Go code generator.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
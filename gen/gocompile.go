@@ -0,0 +1,112 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The versions of the dependencies of the generated Go code that crd2pulumi
+// doesn't depend on itself: the Kubernetes SDK with the ObjectMeta type, and
+// client-go for the client helpers, with the apimachinery of its release.
+const (
+	dryRunKubernetesSDKVersion = "v3.12.0"
+	dryRunClientGoVersion      = "v0.23.4"
+)
+
+// DryRunGoMod returns the go.mod of the temporary module that CompileGoFiles
+// compiles the generated Go code in. The Pulumi SDK is pinned to the version
+// of the Pulumi code generators that crd2pulumi uses, MaxSchemaVersion, which
+// is the one the generated code targets. `go mod tidy` drops the unused
+// dependencies and resolves the rest.
+func DryRunGoMod() string {
+	return fmt.Sprintf(`module crd2pulumi.dev/dryrun
+
+go 1.16
+
+require (
+	github.com/pulumi/pulumi-kubernetes/sdk/v3 %s
+	github.com/pulumi/pulumi/sdk/v3 v%s
+	k8s.io/apimachinery %s
+	k8s.io/client-go %s
+)
+`, dryRunKubernetesSDKVersion, MaxSchemaVersion, dryRunClientGoVersion, dryRunClientGoVersion)
+}
+
+// CompileGoFiles verifies that the generated Go files compile, by running
+// `go build` in a temporary module that contains them. The generated tests,
+// if any, are run too. The dependencies of the files are downloaded, so it
+// needs the Go toolchain and access to the Go module proxy. Returns an error
+// with the output of the Go toolchain if the files don't compile.
+func CompileGoFiles(files map[string]*bytes.Buffer) error {
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		return errors.Wrap(err, "could not find the Go toolchain to compile the generated code with")
+	}
+
+	moduleDir, err := ioutil.TempDir("", "crd2pulumi-dry-run-")
+	if err != nil {
+		return errors.Wrap(err, "could not create temporary module")
+	}
+	defer os.RemoveAll(moduleDir)
+
+	moduleFiles := map[string]*bytes.Buffer{"go.mod": bytes.NewBufferString(DryRunGoMod())}
+	hasTests := false
+	for path, code := range files {
+		if filepath.Ext(path) == ".go" {
+			moduleFiles[path] = bytes.NewBuffer(code.Bytes())
+			hasTests = hasTests || strings.HasSuffix(path, "_test.go")
+		}
+	}
+	if err := writeFiles(moduleFiles, moduleDir); err != nil {
+		return err
+	}
+
+	if err := runGo(goPath, moduleDir, "mod", "tidy"); err != nil {
+		return errors.Wrap(err, "could not resolve the dependencies of the generated Go code")
+	}
+	if err := runGo(goPath, moduleDir, "build", "./..."); err != nil {
+		return errors.Wrap(err, "generated Go code does not compile")
+	}
+	if hasTests {
+		if err := runGo(goPath, moduleDir, "test", "./..."); err != nil {
+			return errors.Wrap(err, "generated Go tests fail")
+		}
+	}
+	return nil
+}
+
+// runGo runs the Go toolchain in the given directory. The returned error
+// contains the output of the command.
+func runGo(goPath, dir string, args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.Command(goPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, bytes.TrimSpace(output.Bytes()))
+	}
+	return nil
+}
@@ -16,12 +16,16 @@ package gen
 
 import (
 	"bytes"
+	"path"
+	"path/filepath"
+
 	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/pkg/v3/codegen"
 	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
-	"path/filepath"
 )
 
+const goImportBasePath = "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes"
+
 var unneededGoFiles = codegen.NewStringSet(
 	// The root directory doesn't define any resources:
 	"doc.go",
@@ -32,12 +36,38 @@ var unneededGoFiles = codegen.NewStringSet(
 	"meta/v1/pulumiTypes.go",
 )
 
-func (pg *PackageGenerator) genGo(outputDir, name string) error {
-	if files, err := pg.genGoFiles(name); err != nil {
+func (pg *PackageGenerator) genGo(outputDir, name string, clientHelpers, dryRunCompile bool) error {
+	files, err := pg.genGoFiles(name)
+	if err != nil {
 		return err
-	} else if err := writeFiles(files, outputDir); err != nil {
+	}
+	if clientHelpers {
+		clientFiles, err := pg.genGoClientFiles()
+		if err != nil {
+			return err
+		}
+		for path, code := range clientFiles {
+			files[path] = code
+		}
+	}
+	pg.addTestStubs(files, Go, name)
+
+	// Writing the files drains their buffers, so the compiled copies are
+	// taken first. The files are still written if they don't compile, so
+	// that they can be inspected.
+	var compiledFiles map[string]*bytes.Buffer
+	if dryRunCompile {
+		compiledFiles = make(map[string]*bytes.Buffer, len(files))
+		for path, code := range files {
+			compiledFiles[path] = bytes.NewBuffer(code.Bytes())
+		}
+	}
+	if err := pg.writeFiles(files, outputDir); err != nil {
 		return err
 	}
+	if dryRunCompile {
+		return CompileGoFiles(compiledFiles)
+	}
 	return nil
 }
 
@@ -48,8 +78,8 @@ func (pg *PackageGenerator) genGoFiles(name string) (map[string]*bytes.Buffer, e
 	pkg.Name = name
 	moduleToPackage := pg.moduleToPackage()
 	moduleToPackage["meta/v1"] = "meta/v1"
-	pkg.Language["go"] = rawMessage(map[string]interface{}{
-		"importBasePath":  "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes",
+	pkg.Language["go"] = pg.languageInfo(Go, map[string]interface{}{
+		"importBasePath":  goImportBasePath,
 		"moduleToPackage": moduleToPackage,
 		"packageImportAliases": map[string]interface{}{
 			"github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1": "metav1",
@@ -64,14 +94,42 @@ func (pg *PackageGenerator) genGoFiles(name string) (map[string]*bytes.Buffer, e
 	pkg.Name = oldName
 	delete(pkg.Language, Go)
 
+	if err := pg.appendTypeTokens(files, Go); err != nil {
+		return nil, err
+	}
+
 	buffers := map[string]*bytes.Buffer{}
 
+	// The Go code generator places every file under a root directory named
+	// after the last element of the import base path
+	root := path.Base(goImportBasePath)
 	for path, code := range files {
-		newPath, _ := filepath.Rel(name, path)
+		newPath, _ := filepath.Rel(root, path)
 		if !unneededGoFiles.Has(newPath) {
 			buffers[newPath] = bytes.NewBuffer(code)
 		}
 	}
+	if pg.ownerReferenceHelpers {
+		buffers[goOwnerReferencePath] = bytes.NewBufferString(goOwnerReferenceFile)
+	}
+	if pg.goUtilityHelpers {
+		if err := addGoUtilityHelpers(buffers); err != nil {
+			return nil, err
+		}
+	}
+	if pg.crdResources {
+		crdResources, err := pg.goCRDResources()
+		if err != nil {
+			return nil, err
+		}
+		buffers[goCRDResourcesPath] = crdResources
+	}
+	if pg.sdkVersionFile {
+		buffers[goVersionPath] = bytes.NewBufferString(goVersionFile(pg.PackageVersion()))
+	}
+	if pg.goPackageName != "" {
+		renameGoRootPackage(buffers, pg.goPackageName)
+	}
 
 	return buffers, nil
 }
@@ -0,0 +1,148 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The Go utility helpers let the inputs of a resource be set from the plain
+// types of the Go package, e.g. `CronTabSpec{Replicas: kubernetes.IntRef(3)}`,
+// instead of wrapping each field in a Pulumi input type, e.g.
+// `CronTabSpecArgs{Replicas: pulumi.IntPtr(3)}`.
+
+const goUtilityHelpersPath = "utilityHelpers.go"
+const goUtilityHelpersFile = `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package kubernetes
+
+// StringRef returns a pointer to the given string, to set the optional string fields of the plain types.
+func StringRef(v string) *string {
+	return &v
+}
+
+// IntRef returns a pointer to the given int, to set the optional integer fields of the plain types.
+func IntRef(v int) *int {
+	return &v
+}
+
+// Float64Ref returns a pointer to the given float64, to set the optional number fields of the plain types.
+func Float64Ref(v float64) *float64 {
+	return &v
+}
+
+// BoolRef returns a pointer to the given bool, to set the optional boolean fields of the plain types.
+func BoolRef(v bool) *bool {
+	return &v
+}
+`
+
+// goTypeFileRe matches the files of the Go code generator that declare the
+// types and the enums of a package
+var goTypeFileRe = regexp.MustCompile(`(^|/)pulumi(Types|Enums)\.go$`)
+
+// goPlainTypeRe matches the plain struct types of the Go code generator, and
+// goOutputTypeRe their output types
+var goPlainTypeRe = regexp.MustCompile(`(?m)^type ([A-Z]\w*) struct \{$`)
+var goOutputTypeRe = regexp.MustCompile(`(?m)^type ([A-Z]\w*)Output struct\{ \*pulumi\.OutputState \}$`)
+
+// goEnumTypeRe matches the enum types of the Go code generator
+var goEnumTypeRe = regexp.MustCompile(`(?m)^type ([A-Z]\w*) (?:string|int|float64|bool)$`)
+
+// goPackageClauseNameRe matches the package clause of a Go file
+var goPackageClauseNameRe = regexp.MustCompile(`(?m)^package (\w+)$`)
+
+// addGoUtilityHelpers adds the pointer helpers to the root of the generated
+// Go package, and a helper file to each of its packages with types:
+//
+//   - `<Type>From` converts a value of a plain type to its output, which
+//     is an input of the fields of that type. Only the types with an output
+//     type, i.e. that are used in the inputs, have one.
+//   - `<Enum>Ref` returns a pointer to an enum value, to set the optional
+//     enum fields of the plain types.
+//
+// The helpers are derived from the generated types, so they always match.
+func addGoUtilityHelpers(files map[string]*bytes.Buffer) error {
+	plainTypes, outputTypes, enumTypes := map[string][]string{}, map[string]map[string]bool{}, map[string][]string{}
+	packageNames := map[string]string{}
+	for filePath, code := range files {
+		if !goTypeFileRe.MatchString(filePath) || !strings.Contains(filePath, "/") {
+			continue
+		}
+		dir := path.Dir(filePath)
+		if match := goPackageClauseNameRe.FindSubmatch(code.Bytes()); match != nil {
+			packageNames[dir] = string(match[1])
+		}
+		if outputTypes[dir] == nil {
+			outputTypes[dir] = map[string]bool{}
+		}
+		for _, match := range goPlainTypeRe.FindAllSubmatch(code.Bytes(), -1) {
+			plainTypes[dir] = append(plainTypes[dir], string(match[1]))
+		}
+		for _, match := range goOutputTypeRe.FindAllSubmatch(code.Bytes(), -1) {
+			outputTypes[dir][string(match[1])] = true
+		}
+		for _, match := range goEnumTypeRe.FindAllSubmatch(code.Bytes(), -1) {
+			enumTypes[dir] = append(enumTypes[dir], string(match[1]))
+		}
+	}
+
+	for dir, packageName := range packageNames {
+		var fromTypes []string
+		for _, typeName := range plainTypes[dir] {
+			if outputTypes[dir][typeName] {
+				fromTypes = append(fromTypes, typeName)
+			}
+		}
+		enums := enumTypes[dir]
+		if len(fromTypes) == 0 && len(enums) == 0 {
+			continue
+		}
+		sort.Strings(fromTypes)
+		sort.Strings(enums)
+
+		var buffer bytes.Buffer
+		buffer.WriteString("// *** WARNING: this file was generated by crd2pulumi. ***\n")
+		buffer.WriteString("// *** Do not edit by hand unless you're certain you know what you are doing! ***\n\n")
+		fmt.Fprintf(&buffer, "package %s\n", packageName)
+		if len(fromTypes) > 0 {
+			buffer.WriteString("\nimport (\n\t\"github.com/pulumi/pulumi/sdk/v3/go/pulumi\"\n)\n")
+		}
+		for _, typeName := range fromTypes {
+			fmt.Fprintf(&buffer, "\n// %sFrom returns the output of the given plain %s, to set an input of its type with it.\n", typeName, typeName)
+			fmt.Fprintf(&buffer, "func %sFrom(v %s) %sOutput {\n\treturn pulumi.ToOutput(v).(%sOutput)\n}\n", typeName, typeName, typeName, typeName)
+		}
+		for _, typeName := range enums {
+			fmt.Fprintf(&buffer, "\n// %sRef returns a pointer to the given %s, to set the optional fields of its type.\n", typeName, typeName)
+			fmt.Fprintf(&buffer, "func %sRef(v %s) *%s {\n\treturn &v\n}\n", typeName, typeName, typeName)
+		}
+		code, err := format.Source(buffer.Bytes())
+		if err != nil {
+			return errors.Wrapf(err, "could not format the Go utility helpers of %s", dir)
+		}
+		files[path.Join(dir, goUtilityHelpersPath)] = bytes.NewBuffer(code)
+	}
+	files[goUtilityHelpersPath] = bytes.NewBufferString(goUtilityHelpersFile)
+	return nil
+}
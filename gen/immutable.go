@@ -0,0 +1,117 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// immutableRuleRe matches the CEL validation rule that forbids changing a field
+var immutableRuleRe = regexp.MustCompile(`^\s*self\s*==\s*oldSelf\s*$`)
+
+// ImmutablePaths returns the dot-separated paths of the properties in the
+// schema that have an `x-kubernetes-validations` rule forbidding changes to
+// them (`self == oldSelf`). Properties inside arrays aren't detected.
+func ImmutablePaths(schema map[string]interface{}) []string {
+	var paths []string
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	for propertyName := range properties {
+		propertySchema, _, _ := unstruct.NestedMap(properties, propertyName)
+		if isImmutable(propertySchema) {
+			paths = append(paths, propertyName)
+			continue
+		}
+		for _, childPath := range ImmutablePaths(propertySchema) {
+			paths = append(paths, propertyName+"."+childPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func isImmutable(schema map[string]interface{}) bool {
+	validations, _, _ := NestedMapSlice(schema, "x-kubernetes-validations")
+	for _, validation := range validations {
+		if rule, _, _ := unstruct.NestedString(validation, "rule"); immutableRuleRe.MatchString(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddReplaceOnChanges marks the properties at the given dot-separated paths,
+// e.g. `spec.bucketName`, as `replaceOnChanges` in every resource that has
+// them, so that Pulumi replaces the resource when they change. If
+// detectImmutable is true, then the paths found by ImmutablePaths are also
+// marked. Returns an error if an explicitly given path isn't found in any
+// resource.
+func (pg *PackageGenerator) AddReplaceOnChanges(paths []string, detectImmutable bool) error {
+	found := map[string]bool{}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
+			for _, path := range paths {
+				if pg.markReplaceOnChanges(resourceToken, path) {
+					found[path] = true
+				}
+			}
+			if detectImmutable {
+				for _, path := range ImmutablePaths(crg.Schemas[version]) {
+					pg.markReplaceOnChanges(resourceToken, path)
+				}
+			}
+		}
+	}
+	for _, path := range paths {
+		if !found[path] {
+			return errors.Errorf("could not find immutable path %q in any resource", path)
+		}
+	}
+	return nil
+}
+
+// markReplaceOnChanges marks the property at the given path of the type as
+// `replaceOnChanges`, following the references to nested object types.
+// Returns false if the type doesn't have the property.
+func (pg *PackageGenerator) markReplaceOnChanges(typeToken, path string) bool {
+	propertyName := path
+	rest := ""
+	if i := strings.Index(path, "."); i >= 0 {
+		propertyName, rest = path[:i], path[i+1:]
+	}
+
+	complexTypeSpec, ok := pg.Types[typeToken]
+	if !ok {
+		return false
+	}
+	property, ok := complexTypeSpec.Properties[propertyName]
+	if !ok {
+		return false
+	}
+	if rest != "" {
+		if !strings.HasPrefix(property.Ref, "#/types/") {
+			return false
+		}
+		return pg.markReplaceOnChanges(strings.TrimPrefix(property.Ref, "#/types/"), rest)
+	}
+	property.ReplaceOnChanges = true
+	complexTypeSpec.Properties[propertyName] = property
+	return true
+}
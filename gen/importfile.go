@@ -0,0 +1,174 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ImportFile is a bulk import file of existing resources, in the format that
+// `pulumi import --file` reads.
+type ImportFile struct {
+	Resources []ImportResource `json:"resources"`
+}
+
+// ImportResource is a resource of an ImportFile.
+type ImportResource struct {
+	// Type is the token of the resource type, e.g.
+	// `kubernetes:stable.example.com/v1:CronTab`
+	Type string `json:"type"`
+	// Name is the name of the resource in the Pulumi program
+	Name string `json:"name"`
+	// ID is the ID that the Kubernetes provider imports the resource with:
+	// `<namespace>/<name>` for namespaced resources, and `<name>` for
+	// cluster-scoped ones
+	ID string `json:"id"`
+}
+
+// ImportFile returns the bulk import file of the given existing custom
+// resources, e.g. the items of `kubectl get crontabs -A -o yaml`, with the
+// token of the generated resource type of each. The resources are named
+// after their `metadata.name`, prefixed with their namespace if other
+// resources of their type have the same name. Namespaced resources without a
+// namespace are in the `default` namespace. Also returns a warning for each
+// manifest that isn't a resource of the package, which is left out.
+func (pg *PackageGenerator) ImportFile(manifests []unstruct.Unstructured) (ImportFile, []string) {
+	resourceTokens := map[string]string{}
+	scopes := map[string]string{}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			groupVersionKind := crg.Group + "/" + version + "/" + crg.Kind
+			resourceTokens[groupVersionKind] = getToken(crg.Group, version, crg.TypeName)
+			scopes[groupVersionKind] = crg.Scope
+		}
+	}
+
+	var warnings []string
+	var resources []ImportResource
+	var namespaces []string
+	names := map[string]int{}
+	for _, manifest := range manifests {
+		groupVersionKind := manifest.GetAPIVersion() + "/" + manifest.GetKind()
+		resourceToken, ok := resourceTokens[groupVersionKind]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("the existing %s %s isn't a resource of the package, so it's not imported",
+				manifest.GetKind(), manifest.GetName()))
+			continue
+		}
+		if manifest.GetName() == "" {
+			warnings = append(warnings, fmt.Sprintf("an existing %s has no name, so it's not imported", manifest.GetKind()))
+			continue
+		}
+		id, namespace := manifest.GetName(), ""
+		if scopes[groupVersionKind] != "Cluster" {
+			if namespace = manifest.GetNamespace(); namespace == "" {
+				namespace = "default"
+			}
+			id = namespace + "/" + id
+		}
+		resources = append(resources, ImportResource{Type: resourceToken, Name: manifest.GetName(), ID: id})
+		namespaces = append(namespaces, namespace)
+		names[resourceToken+"::"+manifest.GetName()]++
+	}
+	// The names of the resources of a type must be unique in the program
+	for i, resource := range resources {
+		if names[resource.Type+"::"+resource.Name] > 1 && namespaces[i] != "" {
+			resources[i].Name = namespaces[i] + "-" + resource.Name
+		}
+	}
+	return ImportFile{Resources: resources}, warnings
+}
+
+// LoadManifests reads the Kubernetes manifests of the YAML or JSON documents
+// of the file, with the items of the `List`s, e.g. the output of
+// `kubectl get -o yaml`, in place of the lists.
+func LoadManifests(path string) ([]unstruct.Unstructured, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the manifests %s", path)
+	}
+	var manifests []unstruct.Unstructured
+	dec := yaml.NewYAMLOrJSONDecoder(ioutil.NopCloser(bytes.NewReader(data)), 128)
+	for {
+		var value interface{}
+		if err := dec.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not read the manifests %s", path)
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		manifest := unstruct.Unstructured{Object: object}
+		if !manifest.IsList() {
+			manifests = append(manifests, manifest)
+			continue
+		}
+		if err := manifest.EachListItem(func(item runtime.Object) error {
+			manifests = append(manifests, *item.(*unstruct.Unstructured))
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "could not read the list of manifests %s", path)
+		}
+	}
+	return manifests, nil
+}
+
+// writeImportFile writes the bulk import file of the existing resources of
+// the manifest files to the given path.
+func (pg *PackageGenerator) writeImportFile(outputPath string, manifestPaths []string) error {
+	var manifests []unstruct.Unstructured
+	for _, manifestPath := range manifestPaths {
+		fileManifests, err := LoadManifests(manifestPath)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	importFile, warnings := pg.ImportFile(manifests)
+	for _, warning := range warnings {
+		if pg.strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if len(importFile.Resources) == 0 {
+		return errors.Errorf("none of the manifests %s are existing resources of the package", strings.Join(manifestPaths, ", "))
+	}
+
+	data, err := marshalJSON(importFile, pg.compactJSON)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the import file")
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
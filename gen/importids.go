@@ -0,0 +1,52 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DocumentImportIDs appends how to import an existing resource into Pulumi
+// to the description of each resource type, since the ID that the Kubernetes
+// provider expects depends on the scope of the CRD: `<namespace>/<name>` for
+// namespaced resources, and `<name>` for cluster-scoped ones.
+func (pg *PackageGenerator) DocumentImportIDs() {
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
+			resourceType, ok := pg.Types[resourceToken]
+			if !ok {
+				continue
+			}
+			resourceType.Description = appendParagraph(resourceType.Description, formatImportID(resourceToken, crg.Kind, crg.Scope))
+			pg.Types[resourceToken] = resourceType
+		}
+	}
+}
+
+// formatImportID formats the import section of a resource's description,
+// with an example `pulumi import` command.
+func formatImportID(resourceToken, kind, scope string) string {
+	name := "my-" + strings.ToLower(kind)
+	if scope == "Cluster" {
+		return fmt.Sprintf("## Import\n\n"+
+			"An existing %s can be imported with its name as the ID, since it's cluster-scoped, e.g.:\n\n"+
+			"```sh\n$ pulumi import %s %s %s\n```", kind, resourceToken, name, name)
+	}
+	return fmt.Sprintf("## Import\n\n"+
+		"An existing %s can be imported with `<namespace>/<name>` as the ID, e.g.:\n\n"+
+		"```sh\n$ pulumi import %s %s default/%s\n```", kind, resourceToken, name, name)
+}
@@ -0,0 +1,265 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+const jsonSchemaDraft7 = "http://json-schema.org/draft-07/schema#"
+
+func (pg *PackageGenerator) genJSONSchemas(outputDir string) error {
+	files := map[string]*bytes.Buffer{}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
+			schema, err := pg.JSONSchema(resourceToken)
+			if err != nil {
+				return err
+			}
+			data, err := marshalJSON(schema, pg.compactJSON)
+			if err != nil {
+				return errors.Wrapf(err, "could not marshal the JSON schema of %s", resourceToken)
+			}
+			path := crg.Group + "/" + version + "/" + strings.ToLower(crg.Kind) + ".json"
+			files[path] = bytes.NewBuffer(data)
+		}
+	}
+	// The types of the partial schemas are named like resources
+	for _, token := range pg.partialSchemaTokens() {
+		schema, err := pg.JSONSchema(token)
+		if err != nil {
+			return err
+		}
+		data, err := marshalJSON(schema, pg.compactJSON)
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal the JSON schema of %s", token)
+		}
+		member := tokens.ModuleMember(token)
+		path := string(member.Module().Name()) + "/" + strings.ToLower(string(member.Name())) + ".json"
+		files[path] = bytes.NewBuffer(data)
+	}
+	return writeFiles(files, outputDir)
+}
+
+// JSONSchema converts the generated type of the resource back into a draft 7
+// JSON Schema, that standard tools can validate manifests of the resource
+// with. The object types it refers to, including ObjectMeta, are included
+// as `definitions`.
+func (pg *PackageGenerator) JSONSchema(resourceToken string) (map[string]interface{}, error) {
+	resourceType, ok := pg.Types[resourceToken]
+	if !ok {
+		return nil, errors.Errorf("could not find resource %s", resourceToken)
+	}
+	types := make(map[string]pschema.ComplexTypeSpec, len(pg.Types)+1)
+	for token, complexTypeSpec := range pg.Types {
+		types[token] = complexTypeSpec
+	}
+	types[objectMetaToken] = objectMetaTypeSpec
+
+	w := jsonSchemaWriter{
+		types:       types,
+		definitions: map[string]interface{}{},
+		names:       map[string]string{},
+		tokens:      map[string]string{},
+	}
+	schema, err := w.objectSchema(resourceType.ObjectTypeSpec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not convert %s to JSON Schema", resourceToken)
+	}
+	schema["$schema"] = jsonSchemaDraft7
+	if len(w.definitions) > 0 {
+		schema["definitions"] = w.definitions
+	}
+	return schema, nil
+}
+
+// jsonSchemaWriter converts Pulumi types to JSON Schema
+type jsonSchemaWriter struct {
+	types map[string]pschema.ComplexTypeSpec
+	// definitions are the converted types that are referred to, by name
+	definitions map[string]interface{}
+	// names are the definition names of the type tokens, and tokens the
+	// reverse
+	names, tokens map[string]string
+}
+
+func (w *jsonSchemaWriter) objectSchema(objectTypeSpec pschema.ObjectTypeSpec) (map[string]interface{}, error) {
+	schema := map[string]interface{}{"type": Object}
+	if objectTypeSpec.Description != "" {
+		schema["description"] = objectTypeSpec.Description
+	}
+	if len(objectTypeSpec.Properties) > 0 {
+		propertyNames := make([]string, 0, len(objectTypeSpec.Properties))
+		for propertyName := range objectTypeSpec.Properties {
+			propertyNames = append(propertyNames, propertyName)
+		}
+		sort.Strings(propertyNames)
+		properties := make(map[string]interface{}, len(propertyNames))
+		for _, propertyName := range propertyNames {
+			// Forbidden properties convert back to the `false` schema
+			if propertySpec := objectTypeSpec.Properties[propertyName]; isForbidden(propertySpec) {
+				properties[propertyName] = false
+				continue
+			}
+			propertySchema, err := w.propertySchema(objectTypeSpec.Properties[propertyName])
+			if err != nil {
+				return nil, errors.Wrapf(err, "in property %q", propertyName)
+			}
+			properties[propertyName] = propertySchema
+		}
+		schema["properties"] = properties
+	}
+	if len(objectTypeSpec.Required) > 0 {
+		schema["required"] = toInterfaceSlice(objectTypeSpec.Required)
+	}
+	return schema, nil
+}
+
+func (w *jsonSchemaWriter) propertySchema(propertySpec pschema.PropertySpec) (map[string]interface{}, error) {
+	schema, err := w.typeSchema(propertySpec.TypeSpec)
+	if err != nil {
+		return nil, err
+	}
+	if propertySpec.Description != "" {
+		if _, ok := schema["$ref"]; ok {
+			// Keywords next to `$ref` are ignored in draft 7
+			schema = map[string]interface{}{"allOf": []interface{}{schema}}
+		}
+		schema["description"] = propertySpec.Description
+	}
+	if propertySpec.Const != nil {
+		schema["const"] = propertySpec.Const
+	}
+	if propertySpec.Default != nil {
+		schema["default"] = propertySpec.Default
+	}
+	if propertySpec.Secret && schema["type"] == String {
+		schema["format"] = "password"
+	}
+	return schema, nil
+}
+
+func (w *jsonSchemaWriter) typeSchema(typeSpec pschema.TypeSpec) (map[string]interface{}, error) {
+	if len(typeSpec.OneOf) > 0 {
+		oneOf := make([]interface{}, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			schema, err := w.typeSchema(oneOfTypeSpec)
+			if err != nil {
+				return nil, err
+			}
+			oneOf = append(oneOf, schema)
+		}
+		return map[string]interface{}{"oneOf": oneOf}, nil
+	}
+	if typeSpec.Ref == anyTypeRef || typeSpec.Ref == jsonTypeRef {
+		return map[string]interface{}{}, nil
+	}
+	if strings.HasPrefix(typeSpec.Ref, "#/types/") {
+		name, err := w.define(strings.TrimPrefix(typeSpec.Ref, "#/types/"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}, nil
+	}
+	if typeSpec.Ref != "" {
+		return nil, errors.Errorf("unsupported type reference %q", typeSpec.Ref)
+	}
+
+	schema := map[string]interface{}{"type": typeSpec.Type}
+	switch typeSpec.Type {
+	case Array:
+		if typeSpec.Items != nil {
+			items, err := w.typeSchema(*typeSpec.Items)
+			if err != nil {
+				return nil, err
+			}
+			schema["items"] = items
+		}
+	case Object:
+		if typeSpec.AdditionalProperties != nil {
+			additionalProperties, err := w.typeSchema(*typeSpec.AdditionalProperties)
+			if err != nil {
+				return nil, err
+			}
+			schema["additionalProperties"] = additionalProperties
+		}
+	case "":
+		return map[string]interface{}{}, nil
+	}
+	return schema, nil
+}
+
+// define converts the type to a definition, if it hasn't been already, and
+// returns its name.
+func (w *jsonSchemaWriter) define(token string) (string, error) {
+	if name, ok := w.names[token]; ok {
+		return name, nil
+	}
+	complexTypeSpec, ok := w.types[token]
+	if !ok {
+		return "", errors.Errorf("could not find type %s", token)
+	}
+
+	// Types are named after their module member, unless that's taken by a
+	// type of another module
+	name := token[strings.LastIndex(token, ":")+1:]
+	if _, taken := w.tokens[name]; taken {
+		name = strings.NewReplacer(":", ".", "/", ".").Replace(token)
+	}
+	w.names[token] = name
+	w.tokens[name] = token
+
+	if len(complexTypeSpec.Enum) > 0 {
+		values := make([]interface{}, 0, len(complexTypeSpec.Enum))
+		names := make([]interface{}, 0, len(complexTypeSpec.Enum))
+		descriptions := make([]interface{}, 0, len(complexTypeSpec.Enum))
+		described := false
+		for _, enumValue := range complexTypeSpec.Enum {
+			values = append(values, enumValue.Value)
+			names = append(names, enumValue.Name)
+			descriptions = append(descriptions, enumValue.Description)
+			described = described || enumValue.Description != ""
+		}
+		// The member names and descriptions are kept as the vendor extensions
+		// they were read from, which validators ignore
+		definition := map[string]interface{}{
+			"type":                complexTypeSpec.Type,
+			"enum":                values,
+			enumNameExtensions[0]: names,
+		}
+		if described {
+			definition[enumDescriptionExtensions[0]] = descriptions
+		}
+		if complexTypeSpec.Description != "" {
+			definition["description"] = complexTypeSpec.Description
+		}
+		w.definitions[name] = definition
+		return name, nil
+	}
+
+	definition, err := w.objectSchema(complexTypeSpec.ObjectTypeSpec)
+	if err != nil {
+		return "", errors.Wrapf(err, "in type %s", token)
+	}
+	w.definitions[name] = definition
+	return name, nil
+}
@@ -27,6 +27,246 @@ type LanguageSettings struct {
 	PythonName string
 	DotNetName string
 	GoName     string
+	// NodeJSScope is the npm scope to publish the NodeJS package under, e.g.
+	// `myorg` for `@myorg/crds`. Defaults to `pulumi` if empty.
+	NodeJSScope string
+	// PythonDistributionName is the name that the Python package is published
+	// under, in setup.py. Defaults to the module name, `pulumi_<PythonName>`.
+	PythonDistributionName string
+	// DotNetAssemblyName is the name of the .NET assembly and NuGet package.
+	// Defaults to the root namespace, `Pulumi.<DotNetName>`.
+	DotNetAssemblyName string
+	// DotNetNullable adds a `#nullable enable` directive to each generated C#
+	// file, so that its nullable reference type annotations, e.g. `string?`,
+	// also apply when the files are compiled into a project that doesn't
+	// enable them, instead of producing warnings. The generated project
+	// always enables them.
+	DotNetNullable bool
+	// GoPackageName is the name of the root Go package, which contains the
+	// shared utilities. Defaults to `kubernetes`.
+	GoPackageName string
+	// NodeJSBarrel re-exports each resource and type module from the root
+	// module of the NodeJS package, so that they can be imported without
+	// their group and version paths.
+	NodeJSBarrel bool
+	// GoClientHelpers generates a typed list/watch client for each resource
+	// in the Go package, on top of the standard Pulumi SDK.
+	GoClientHelpers bool
+	// GoUtilityHelpers generates helpers in the Go package that set the
+	// inputs of the resources from its plain types, e.g. `CronTabSpecFrom`,
+	// and pointer helpers for their optional fields, e.g. `StringRef`.
+	GoUtilityHelpers bool
+	// PythonImportCheck verifies that the generated Python files compile, and
+	// that the package and its subpackages can be imported. Requires a Python
+	// interpreter with the `pulumi` and Kubernetes SDK packages.
+	PythonImportCheck bool
+	// GoDryRunCompile verifies that the generated Go package compiles, with
+	// `go build` in a temporary module. Requires the Go toolchain.
+	GoDryRunCompile bool
+	// ExampleManifestPath is the path to write an example Kubernetes YAML
+	// manifest to, with an instance of each CustomResource.
+	ExampleManifestPath *string
+	// JSONSchemaPath is the directory to write a draft 7 JSON Schema of each
+	// CustomResource version to, converted back from the generated types, to
+	// validate manifests with standard JSON Schema tools.
+	JSONSchemaPath *string
+	// ProtoPath is the directory to write the generated types to as
+	// experimental proto3 message definitions, a `.proto` file per module,
+	// for tools that integrate the CustomResources with gRPC.
+	ProtoPath *string
+	// TypeDeclarationsPath is the path to write a standalone TypeScript
+	// declaration file (`.d.ts`) of the generated types to, without the
+	// resource classes, for NodeJS consumers that only need the types.
+	TypeDeclarationsPath *string
+	// YAMLReferencePath is the path to write a YAML reference of the
+	// resources to, with the required and optional inputs of each resource
+	// token and their types, for Pulumi YAML programs that don't use an SDK.
+	YAMLReferencePath *string
+	// ImportFilePath is the path to write a bulk import file to, for
+	// `pulumi import --file`, with the resource type token and the ID of each
+	// existing resource of the ImportManifests.
+	ImportFilePath *string
+	// ImportManifests are the paths of the YAML or JSON manifests of the
+	// existing resources to import, e.g. the output of
+	// `kubectl get crontabs -A -o yaml`.
+	ImportManifests []string
+	// MetricsPath is the path to write the statistics of the run to, as
+	// Prometheus metrics for the textfile collector of the node exporter. The
+	// file is overwritten on every run, even without Force.
+	MetricsPath *string
+	// AnyTypesReportPath is the path to write the paths of the fields that
+	// are typed as `any` to, as JSON sorted by resource and path, or `-` for
+	// stderr. The file is overwritten on every run, even without Force.
+	AnyTypesReportPath *string
+	// CompactNamesPath enables compacting the names of the types that are
+	// longer than MaxTypeNameLength to short hashed names, and is the path to
+	// write the JSON mapping of the compact names to the original names and
+	// fields to, or `-` for stderr. Off if nil.
+	CompactNamesPath *string
+	// ChangelogPath is the path to write the changes of the generated
+	// package from the one of ChangelogBase to, as JSON if it ends in
+	// `.json` and as Markdown otherwise, or `-` for stderr. The file is
+	// overwritten on every run, even without Force.
+	ChangelogPath *string
+	// ChangelogBase is the path of the package to diff against for
+	// ChangelogPath: a Pulumi package schema if it ends in `.json`, e.g. one
+	// written with MergeSchemaPath, or otherwise the CRDs of the previous
+	// version, generated with the default options.
+	ChangelogBase string
+	// MergeSchemaPath is the path of a Pulumi package schema to merge into
+	// the generated package, if it exists, e.g. to add the CRDs of this run
+	// to an SDK generated in earlier runs. The merged schema is written back
+	// to it, even without Force. Types and resources with conflicting
+	// definitions are an error.
+	MergeSchemaPath *string
+	// SchemaVersion is the Pulumi version, from MinSchemaVersion to
+	// MaxSchemaVersion, that the schema written to MergeSchemaPath targets.
+	// The schema features that it doesn't support are omitted, with a
+	// warning, or an error if the generation is strict. Defaults to
+	// MaxSchemaVersion.
+	SchemaVersion string
+	// ObjectMetaRequired makes the `metadata` of every resource a required
+	// input, instead of an optional one that Pulumi auto-names the resource
+	// without.
+	ObjectMetaRequired bool
+	// MaxDepth is how deeply schemas can be nested before they're typed as
+	// `any`, with a warning, or an error if the generation is strict.
+	// Defaults to DefaultMaxDepth if 0.
+	MaxDepth int
+	// KeepPlaceholderMeta generates the ObjectMeta type instead of importing
+	// it from the Kubernetes SDK, so that the generated SDK doesn't depend on
+	// it. Only supported for NodeJS and Python.
+	KeepPlaceholderMeta bool
+	// ObjectMetaPackages maps NodeJS and Python to an existing Kubernetes SDK
+	// to import the ObjectMeta type from, instead of the Pulumi Kubernetes SDK.
+	ObjectMetaPackages map[string]ObjectMetaPackage
+	// LanguageOptions maps each language to the options to merge into the
+	// settings of its Pulumi code generator, e.g. `typescriptVersion` for
+	// NodeJS, overriding the ones that crd2pulumi sets.
+	LanguageOptions map[string]map[string]interface{}
+	// OverlayTemplates maps each language to the text/template that replaces
+	// the default template of each of its overlays, e.g. UtilitiesOverlay for
+	// Python. The templates are rendered with OverlayData.
+	OverlayTemplates map[string]map[string]string
+	// PackageVersion is the version of the generated packages. Defaults to the
+	// crd2pulumi Version if empty.
+	PackageVersion string
+	// TopLevelModule nests the modules of every group under a single module,
+	// e.g. `crds`, so that the resources of `stable/v1` are generated in
+	// `crds/stable/v1` instead of at the root of the package.
+	TopLevelModule string
+	// GroupPrefixStrip is removed from the start or the end of every group
+	// that starts or ends with it when deriving the modules of the groups,
+	// e.g. `acme-` for the `compute` module of `acme-compute.example.com`
+	// instead of `acmecompute`. The tokens and the `apiVersion`s of the
+	// resources keep their whole groups.
+	GroupPrefixStrip string
+	// RootPath is a dot-separated path to a property of every CustomResource,
+	// e.g. `spec.forProvider`. If set, only the types reachable from it are
+	// generated.
+	RootPath string
+	// ExcludeStatus removes the `status` of every CustomResource, so that no
+	// status types are generated, e.g. for SDKs that only create resources.
+	ExcludeStatus bool
+	// CompactJSON writes the JSON files, i.e. the JSON Schemas and the merged
+	// Pulumi schema, without indentation, e.g. for machines rather than diffs.
+	CompactJSON bool
+	// AnyTypeRef is the ref of the type that the properties whose schemas
+	// don't describe them fall back to, e.g. `pulumi.json#/Json`. Defaults to
+	// `pulumi.json#/Any` if empty.
+	AnyTypeRef string
+	// SDKVersionFile generates a file in each language that exposes the
+	// package version at runtime: `version.ts`, `_version.py` with
+	// `__version__`, `version.go` and the `version.txt` that .NET embeds.
+	SDKVersionFile bool
+	// VersionSelection is which versions of each CRD are generated. Defaults
+	// to ConversionVersions if empty.
+	VersionSelection VersionSelection
+	// Renames maps the `<group>/<Kind>` of CRDs to the names to generate their
+	// resource types with instead of their kinds, e.g.
+	// `stable.example.com/CronTab` to `ScheduledJob`. The `kind` constants
+	// of the resources are still the CRDs' kinds.
+	Renames map[string]string
+	// Methods maps the `<group>/<Kind>` of CRDs to the names of placeholder
+	// methods to add to their resources, e.g. `stable.example.com/CronTab`
+	// to `trigger`, for the actions of imperative CRDs. The methods only
+	// take the resource, and the Kubernetes provider doesn't implement them.
+	Methods map[string][]string
+	// PartialSchemas maps the tokens of types, e.g.
+	// `kubernetes:stable.example.com/v1:CronTabSpec`, to the paths of bare
+	// OpenAPI schemas to generate them from, without resources, in addition
+	// to the types of the CRDs, which can then be left out.
+	PartialSchemas map[string]string
+	// UnknownTypes is how schemas whose `type` isn't an OpenAPI v3 type are
+	// converted. Defaults to UnknownTypeAny if empty.
+	UnknownTypes UnknownTypePolicy
+	// EnumCollisions is how the members of enum values whose names collide
+	// once sanitized, e.g. `Active` and `active`, are named. Defaults to
+	// EnumCollisionSuffix if empty.
+	EnumCollisions EnumCollisionPolicy
+	// ImmutablePaths are dot-separated paths of properties, e.g.
+	// `spec.bucketName`, that can't be changed once the resource is created.
+	// They're marked as `replaceOnChanges`, so Pulumi replaces the resource
+	// when they change.
+	ImmutablePaths []string
+	// DetectImmutable also marks the properties with a `self == oldSelf`
+	// validation rule as `replaceOnChanges`.
+	DetectImmutable bool
+	// PrinterColumns documents the `additionalPrinterColumns` of each
+	// CustomResource version in the description of its resource.
+	PrinterColumns bool
+	// ResourceDocLinks is a URL template of the documentation of each
+	// CustomResource version, e.g. `https://docs.example.com/{group}/{kind}`,
+	// whose link is added to the description of its resource. The template
+	// can use the `{group}`, `{version}`, `{kind}` and `{plural}` of the
+	// resource.
+	ResourceDocLinks string
+	// StripKubebuilderMarkers removes the lines of the descriptions that are
+	// Kubebuilder markers, e.g. `+kubebuilder:validation:Optional`,
+	// `+optional` or `+required`, which some CRDs leak into them.
+	StripKubebuilderMarkers bool
+	// AwaitAnnotations documents the `pulumi.com/skipAwait` and
+	// `pulumi.com/timeoutSeconds` annotations on the `metadata` of each
+	// resource, and shows them in the example manifest.
+	AwaitAnnotations bool
+	// OwnerReferenceHelpers generates a helper for each language that
+	// constructs the owner reference to a resource from its `apiVersion`,
+	// `kind` and `metadata`, with the ObjectMeta types of the Kubernetes SDK.
+	OwnerReferenceHelpers bool
+	// CRDResources generates a helper for NodeJS, Python and Go that
+	// registers the CRDs that the package was generated from as
+	// `apiextensions.k8s.io` CustomResourceDefinition resources of the
+	// Kubernetes SDK, so that a program can install the CRDs before the
+	// CustomResources that depend on them.
+	CRDResources bool
+	// Format formats the generated Go and TypeScript code before writing it.
+	Format bool
+	// SchemaPropertyOrder lists properties in the order that the schemas
+	// declare them in, instead of alphabetically, where crd2pulumi controls
+	// the order, e.g. in the example manifest and the test stubs. The order
+	// is recorded when the CRDs are read, and doesn't change the types.
+	SchemaPropertyOrder bool
+	// OmitDefaults removes the `default` of every property, so that the
+	// generated SDKs don't set any values that the user didn't.
+	OmitDefaults bool
+	// ExcludeDescriptions removes the description of every type, property,
+	// enum value and method, including the ones that crd2pulumi adds, for the
+	// smallest SDKs, without inline docs.
+	ExcludeDescriptions bool
+	// TestStubs generates a test for each resource, which constructs it with
+	// placeholders for its required properties against the mocks of the
+	// Pulumi runtime. Only supported for NodeJS, Python and Go.
+	TestStubs bool
+	// AnnotateSource adds a comment to each generated code file with the
+	// CRDs that it was generated from, and the crd2pulumi version.
+	AnnotateSource bool
+	// OnlyLanguagesChanged skips the languages whose output is current, i.e.
+	// whose LanguageManifestFile was written by a run that generated the same
+	// package schema from the same CRDs and settings, and regenerates the
+	// languages whose output is stale. The output of the other languages
+	// still needs GenerateOptions.Force to be overwritten, which also
+	// regenerates every language.
+	OnlyLanguagesChanged bool
 }
 
 // Returns true if at least one of the language-specific output paths already exists. If true, then a slice of the
@@ -49,9 +289,64 @@ func (ls LanguageSettings) hasExistingPaths() (bool, []string) {
 	if ls.GoPath != nil && pathExists(*ls.GoPath) {
 		existingPaths = append(existingPaths, *ls.GoPath)
 	}
+	if ls.ExampleManifestPath != nil && pathExists(*ls.ExampleManifestPath) {
+		existingPaths = append(existingPaths, *ls.ExampleManifestPath)
+	}
+	if ls.JSONSchemaPath != nil && pathExists(*ls.JSONSchemaPath) {
+		existingPaths = append(existingPaths, *ls.JSONSchemaPath)
+	}
+	if ls.ProtoPath != nil && pathExists(*ls.ProtoPath) {
+		existingPaths = append(existingPaths, *ls.ProtoPath)
+	}
+	if ls.TypeDeclarationsPath != nil && pathExists(*ls.TypeDeclarationsPath) {
+		existingPaths = append(existingPaths, *ls.TypeDeclarationsPath)
+	}
+	if ls.YAMLReferencePath != nil && pathExists(*ls.YAMLReferencePath) {
+		existingPaths = append(existingPaths, *ls.YAMLReferencePath)
+	}
+	if ls.ImportFilePath != nil && pathExists(*ls.ImportFilePath) {
+		existingPaths = append(existingPaths, *ls.ImportFilePath)
+	}
 	return len(existingPaths) > 0, existingPaths
 }
 
+// languages returns the languages that would be generated.
+func (ls LanguageSettings) languages() []string {
+	var languages []string
+	if ls.NodeJSPath != nil {
+		languages = append(languages, NodeJS)
+	}
+	if ls.PythonPath != nil {
+		languages = append(languages, Python)
+	}
+	if ls.DotNetPath != nil {
+		languages = append(languages, DotNet)
+	}
+	if ls.GoPath != nil {
+		languages = append(languages, Go)
+	}
+	return languages
+}
+
+// languagePaths returns the output path of each language that would be
+// generated.
+func (ls LanguageSettings) languagePaths() map[string]string {
+	paths := map[string]string{}
+	if ls.NodeJSPath != nil {
+		paths[NodeJS] = *ls.NodeJSPath
+	}
+	if ls.PythonPath != nil {
+		paths[Python] = *ls.PythonPath
+	}
+	if ls.DotNetPath != nil {
+		paths[DotNet] = *ls.DotNetPath
+	}
+	if ls.GoPath != nil {
+		paths[Go] = *ls.GoPath
+	}
+	return paths
+}
+
 // GeneratesAtLeastOneLanguage returns true if and only if at least one language would be generated.
 func (ls LanguageSettings) GeneratesAtLeastOneLanguage() bool {
 	return ls.NodeJSPath != nil || ls.PythonPath != nil || ls.DotNetPath != nil || ls.GoPath != nil
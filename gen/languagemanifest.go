@@ -0,0 +1,134 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// LanguageManifestFile is the manifest that LanguageSettings.OnlyLanguagesChanged
+// keeps in the output directory of each language, to tell whether the output
+// is current.
+const LanguageManifestFile = ".crd2pulumi-manifest.json"
+
+// languageManifest records what the output of a language was generated from.
+type languageManifest struct {
+	// Language is the language of the output, e.g. `python`
+	Language string `json:"language"`
+	// Version is the crd2pulumi version that generated the output
+	Version string `json:"version"`
+	// Fingerprint is the SHA-256 hash of the package schema, the CRDs and
+	// the settings that the output was generated from
+	Fingerprint string `json:"fingerprint"`
+}
+
+// languageFingerprint returns the fingerprint of the language's package
+// generated with the given settings. The output paths and the settings of the
+// other languages are left out, so that generating another language or output
+// doesn't make the existing ones stale.
+func (pg *PackageGenerator) languageFingerprint(ls LanguageSettings, language string) (string, error) {
+	ls.NodeJSPath, ls.PythonPath, ls.DotNetPath, ls.GoPath = nil, nil, nil, nil
+	ls.ExampleManifestPath, ls.JSONSchemaPath, ls.ProtoPath, ls.TypeDeclarationsPath, ls.YAMLReferencePath = nil, nil, nil, nil, nil
+	ls.MetricsPath, ls.AnyTypesReportPath, ls.CompactNamesPath, ls.ChangelogPath, ls.MergeSchemaPath = nil, nil, nil, nil, nil
+	ls.ImportFilePath, ls.ImportManifests = nil, nil
+	if language != NodeJS {
+		ls.NodeJSName, ls.NodeJSScope, ls.NodeJSBarrel = "", "", false
+	}
+	if language != Python {
+		ls.PythonName, ls.PythonDistributionName, ls.PythonImportCheck = "", "", false
+	}
+	if language != DotNet {
+		ls.DotNetName, ls.DotNetAssemblyName, ls.DotNetNullable = "", "", false
+	}
+	if language != Go {
+		ls.GoName, ls.GoPackageName, ls.GoClientHelpers, ls.GoUtilityHelpers, ls.GoDryRunCompile = "", "", false, false, false
+	}
+	objectMetaPackage, languageOptions, overlayTemplates := ls.ObjectMetaPackages[language], ls.LanguageOptions[language], ls.OverlayTemplates[language]
+	ls.ObjectMetaPackages, ls.LanguageOptions, ls.OverlayTemplates = nil, nil, nil
+
+	crdManifests, err := pg.crdManifests()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(struct {
+		Version           string
+		Language          string
+		Settings          LanguageSettings
+		ObjectMetaPackage ObjectMetaPackage
+		LanguageOptions   map[string]interface{}
+		OverlayTemplates  map[string]string
+		Schema            interface{}
+		CRDs              []string
+	}{
+		Version:           Version,
+		Language:          language,
+		Settings:          ls,
+		ObjectMetaPackage: objectMetaPackage,
+		LanguageOptions:   languageOptions,
+		OverlayTemplates:  overlayTemplates,
+		Schema:            genPackageSpec(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, pg.objectMetaRequired),
+		CRDs:              crdManifests,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "could not marshal the inputs of the %s package", language)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// isLanguageCurrent returns true if the output directory of the language has
+// a manifest with the given fingerprint.
+func isLanguageCurrent(language, outputDir, fingerprint string) bool {
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, LanguageManifestFile))
+	if err != nil {
+		return false
+	}
+	var manifest languageManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return false
+	}
+	return manifest.Language == language && manifest.Fingerprint == fingerprint
+}
+
+// hasLanguageManifest returns true if the output directory has a manifest,
+// i.e. if crd2pulumi generated it and can regenerate it when it's stale.
+func hasLanguageManifest(outputDir string) bool {
+	_, err := os.Stat(filepath.Join(outputDir, LanguageManifestFile))
+	return err == nil
+}
+
+// writeLanguageManifest writes the manifest of the language's output.
+func writeLanguageManifest(language, outputDir, fingerprint string) error {
+	data, err := json.MarshalIndent(languageManifest{
+		Language:    language,
+		Version:     Version,
+		Fingerprint: fingerprint,
+	}, "", "    ")
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal the manifest of %s", language)
+	}
+	outputPath := filepath.Join(outputDir, LanguageManifestFile)
+	if err := ioutil.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
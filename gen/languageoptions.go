@@ -0,0 +1,95 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// languageOptionKeyRe matches the key of a Pulumi language option, e.g.
+// `typescriptVersion`
+var languageOptionKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseLanguageOption parses a `<language>:<key>=<value>` spec, e.g.
+// `nodejs:typescriptVersion=4.9`, of an option of the Pulumi code generator of
+// the language. Values that start with `{` or `[`, and `true` and `false`, are
+// decoded as JSON, and other values are strings.
+func ParseLanguageOption(spec string) (string, string, interface{}, error) {
+	i, j := strings.Index(spec, ":"), strings.Index(spec, "=")
+	if i <= 0 || j < i {
+		return "", "", nil, errors.Errorf("invalid language option %q, expected <language>:<key>=<value>", spec)
+	}
+	language, key, value := spec[:i], spec[i+1:j], spec[j+1:]
+	switch language {
+	case NodeJS, Python, Go, DotNet:
+	default:
+		return "", "", nil, errors.Errorf("unsupported language %q of language option %q", language, spec)
+	}
+	if !languageOptionKeyRe.MatchString(key) {
+		return "", "", nil, errors.Errorf("invalid key %q of language option %q", key, spec)
+	}
+
+	if value == "true" || value == "false" || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return "", "", nil, errors.Wrapf(err, "invalid JSON value of language option %q", spec)
+		}
+		return language, key, decoded, nil
+	}
+	return language, key, value, nil
+}
+
+// validateLanguageOptions returns an error if language options are set for
+// languages that aren't generated.
+func (ls LanguageSettings) validateLanguageOptions() error {
+	for language := range ls.LanguageOptions {
+		if !contains(ls.languages(), language) {
+			return errors.Errorf("language options are set for %s, which isn't generated", language)
+		}
+	}
+	return nil
+}
+
+// languageInfo returns the Pulumi language settings of the given language,
+// with the language options merged into them. An option replaces the setting
+// of the same name, unless both are objects, in which case the option's
+// fields are added to the setting's.
+func (pg *PackageGenerator) languageInfo(language string, info map[string]interface{}) json.RawMessage {
+	options := pg.languageOptions[language]
+	if len(options) == 0 {
+		return rawMessage(info)
+	}
+
+	// The settings are decoded, so that nested objects have a single type
+	var merged map[string]interface{}
+	contract.AssertNoError(json.Unmarshal(rawMessage(info), &merged))
+	for key, value := range options {
+		setting, settingIsObject := merged[key].(map[string]interface{})
+		option, optionIsObject := value.(map[string]interface{})
+		if !settingIsObject || !optionIsObject {
+			merged[key] = value
+			continue
+		}
+		for field, fieldValue := range option {
+			setting[field] = fieldValue
+		}
+	}
+	return rawMessage(merged)
+}
@@ -0,0 +1,203 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SchemaLoader loads CRDs from an input source. Library consumers can
+// implement it to generate code from CRDs that don't live in a file or URL.
+type SchemaLoader interface {
+	// Load returns every CRD found in the input source. Manifests that aren't
+	// CRDs are ignored.
+	Load() ([]unstruct.Unstructured, error)
+}
+
+// SourceAnnotation is the annotation that the FileLoader, URLLoader and
+// OCILoader record the file, URL or OCI reference of each CRD in, to annotate
+// the generated code with. Custom loaders may set it too.
+const SourceAnnotation = "crd2pulumi.pulumi.com/source"
+
+// FileLoader loads CRDs from a YAML or JSON file. A Path of "-" reads from
+// stdin.
+type FileLoader struct {
+	Path string
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+func (l FileLoader) Load() ([]unstruct.Unstructured, error) {
+	yamlFile, err := ReadFileOrStdin(l.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read file %s", l.Path)
+	}
+	crds, err := YAMLLoader{Data: yamlFile, Embedded: l.Embedded}.Load()
+	if err != nil {
+		return nil, err
+	}
+	// The `$ref`s to other files are relative to the file, or to the working
+	// directory for stdin
+	for i := range crds {
+		resolved, err := ResolveExternalRefs(crds[i].Object, filepath.Dir(l.Path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not resolve the schemas of %s", l.Path)
+		}
+		crds[i].Object = resolved.(map[string]interface{})
+	}
+	source := l.Path
+	if source == "-" {
+		source = "stdin"
+	}
+	return setSource(crds, source), nil
+}
+
+// URLLoader loads CRDs from a YAML or JSON file served over HTTP(S).
+type URLLoader struct {
+	URL *url.URL
+	// Client is the HTTP client to fetch the file with. Defaults to
+	// http.DefaultClient.
+	Client *http.Client
+	// BearerToken is sent in the `Authorization` header, if it's set. The
+	// URL, and the URLs it redirects to, must then be https.
+	BearerToken string
+	// Cache is the cache of the fetched file, if any
+	Cache *Cache
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+func (l URLLoader) Load() ([]unstruct.Unstructured, error) {
+	yamlFile, err := fetchFile(l.URL, l.Cache, l.Client, l.BearerToken)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read file %s", l.URL)
+	}
+	crds, err := YAMLLoader{Data: yamlFile, Embedded: l.Embedded}.Load()
+	return setSource(crds, l.URL.String()), err
+}
+
+// YAMLLoader loads CRDs from YAML or JSON documents that are already in
+// memory.
+type YAMLLoader struct {
+	Data []byte
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+func (l YAMLLoader) Load() ([]unstruct.Unstructured, error) {
+	crds, err := unmarshalYamls([][]byte{l.Data}, l.Embedded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal yaml file(s)")
+	}
+	return crds, nil
+}
+
+// setSource records the given source in the SourceAnnotation of each CRD,
+// unless it's already recorded.
+func setSource(crds []unstruct.Unstructured, source string) []unstruct.Unstructured {
+	for i := range crds {
+		annotations := crds[i].GetAnnotations()
+		if _, ok := annotations[SourceAnnotation]; ok {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SourceAnnotation] = source
+		crds[i].SetAnnotations(annotations)
+	}
+	return crds
+}
+
+// MultiLoader loads the CRDs from each of its loaders, in order.
+type MultiLoader []SchemaLoader
+
+func (l MultiLoader) Load() ([]unstruct.Unstructured, error) {
+	var crds []unstruct.Unstructured
+	for _, loader := range l {
+		loaded, err := loader.Load()
+		if err != nil {
+			return nil, err
+		}
+		crds = append(crds, loaded...)
+	}
+	return crds, nil
+}
+
+// NewSchemaLoader returns the SchemaLoader for the given CLI argument: a
+// URLLoader for http(s) URLs, an OCILoader for `oci://` references, a
+// GlobLoader for glob patterns that aren't the path of an existing file, and a
+// FileLoader for anything else.
+func NewSchemaLoader(pathOrUrl string) (SchemaLoader, error) {
+	if fetchUrlRe.MatchString(pathOrUrl) {
+		u, err := url.Parse(pathOrUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		switch u.Scheme {
+		case "https", "http":
+			return URLLoader{URL: u}, nil
+		case "oci":
+			reference, err := ParseOCIReference(pathOrUrl)
+			if err != nil {
+				return nil, err
+			}
+			return OCILoader{Reference: reference}, nil
+		default:
+			return nil, fmt.Errorf("scheme %q is not supported", u.Scheme)
+		}
+	}
+	if IsGlobPattern(pathOrUrl) {
+		if _, err := os.Stat(pathOrUrl); err != nil {
+			return GlobLoader{Pattern: pathOrUrl}, nil
+		}
+	}
+	return FileLoader{Path: pathOrUrl}, nil
+}
+
+// NewSchemaLoaders returns a MultiLoader with the SchemaLoader for each of the
+// given CLI arguments.
+func NewSchemaLoaders(pathsOrUrls []string) (MultiLoader, error) {
+	loaders := make(MultiLoader, 0, len(pathsOrUrls))
+	for _, pathOrUrl := range pathsOrUrls {
+		loader, err := NewSchemaLoader(pathOrUrl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read file %s", pathOrUrl)
+		}
+		loaders = append(loaders, loader)
+	}
+	return loaders, nil
+}
+
+// LoadCRDs returns the CRDs from the given loader. Returns an error if no CRDs
+// could be found.
+func LoadCRDs(loader SchemaLoader) ([]unstruct.Unstructured, error) {
+	crds, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(crds) == 0 {
+		return nil, errors.New("could not find any CRD YAML files")
+	}
+	return crds, nil
+}
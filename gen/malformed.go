@@ -0,0 +1,86 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"sort"
+
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MalformedSchemas returns a sentence for each obvious structural mistake in
+// the schema of the given version, e.g. "`spec.ports` is an array with
+// `properties` but no `items`, ..." Such schemas are still converted, but
+// their values are typed as `any`, or their keywords ignored, so the CRD
+// should be fixed. Returns nil if the schema has no such mistakes.
+func (crg *CustomResourceGenerator) MalformedSchemas(version string) []string {
+	var mistakes []string
+	describeMalformedSchemas("", crg.Schemas[version], &mistakes)
+	return mistakes
+}
+
+// describeMalformedSchemas appends the mistakes of the schema at the given
+// path, and of its subschemas, to the mistakes.
+func describeMalformedSchemas(path string, schema map[string]interface{}, mistakes *[]string) {
+	field := "the root"
+	if path != "" {
+		field = "`" + path + "`"
+	}
+	_, hasProperties := schema["properties"].(map[string]interface{})
+	items, hasItems := schema["items"].(map[string]interface{})
+	_, itemsHaveProperties := items["properties"].(map[string]interface{})
+	schemaType, _, _ := unstruct.NestedString(schema, "type")
+	switch schemaType {
+	case Array:
+		if hasProperties && !hasItems {
+			*mistakes = append(*mistakes, fmt.Sprintf("%s is an array with `properties` but no `items`, so its items are typed as any; move the properties under `items`.", field))
+		}
+	case Object:
+		if hasItems && !hasProperties && itemsHaveProperties {
+			*mistakes = append(*mistakes, fmt.Sprintf("%s is an object with its `properties` under `items`, so it's typed as any; set `type: array` or move the properties out of `items`.", field))
+		} else if hasItems {
+			*mistakes = append(*mistakes, fmt.Sprintf("%s is an object with `items`, which are ignored; set `type: array` or remove `items`.", field))
+		}
+	case Integer, Number, String, Boolean:
+		if hasProperties || hasItems {
+			*mistakes = append(*mistakes, fmt.Sprintf("%s is a %s with `properties` or `items`, which are ignored; fix its `type`.", field, schemaType))
+		}
+	}
+
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	propertyNames := make([]string, 0, len(properties))
+	for propertyName := range properties {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	for _, propertyName := range propertyNames {
+		if propertySchema, ok := properties[propertyName].(map[string]interface{}); ok {
+			describeMalformedSchemas(joinFieldPath(path, propertyName), propertySchema, mistakes)
+		}
+	}
+	if hasItems {
+		describeMalformedSchemas(path+"[*]", items, mistakes)
+	}
+	if additionalProperties, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		describeMalformedSchemas(joinFieldPath(path, "*"), additionalProperties, mistakes)
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		subschemas, _, _ := NestedMapSlice(schema, keyword)
+		for _, subschema := range subschemas {
+			describeMalformedSchemas(path, subschema, mistakes)
+		}
+	}
+}
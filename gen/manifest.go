@@ -0,0 +1,222 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// StorageVersion returns the version that the CustomResource is persisted as,
+// i.e. the version marked with `storage: true`. Falls back to the first
+// version if none is marked, or if the marked version has no schema.
+func (crg *CustomResourceGenerator) StorageVersion() string {
+	if len(crg.Versions) == 0 {
+		return ""
+	}
+	storageVersion, _, _ := unstruct.NestedString(crg.CustomResourceDefinition.Object, "spec", "version")
+	versionInfos, _, _ := NestedMapSlice(crg.CustomResourceDefinition.Object, "spec", "versions")
+	for _, versionInfo := range versionInfos {
+		if storage, _, _ := unstruct.NestedBool(versionInfo, "storage"); storage {
+			storageVersion, _, _ = unstruct.NestedString(versionInfo, "name")
+		}
+	}
+	if _, ok := crg.Schemas[storageVersion]; ok {
+		return storageVersion
+	}
+	return crg.Versions[0]
+}
+
+func (pg *PackageGenerator) genExampleManifest(outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, pg.ExampleManifest(), 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
+
+// ExampleManifest returns a multi-document Kubernetes YAML manifest with an
+// example instance of each CustomResource, at its storage version. Only the
+// `spec` and the required properties are included, stubbed with placeholder
+// values that are marked with a TODO comment. The properties are sorted,
+// unless schema property order is enabled.
+func (pg *PackageGenerator) ExampleManifest() []byte {
+	var buffer bytes.Buffer
+	for _, crg := range pg.CustomResourceGenerators {
+		version := crg.StorageVersion()
+		resourceType, ok := pg.Types[getToken(crg.Group, version, crg.TypeName)]
+		if !ok {
+			continue
+		}
+		if buffer.Len() > 0 {
+			buffer.WriteString("---\n")
+		}
+
+		fmt.Fprintf(&buffer, "# An example %s. Replace the TODO values before applying it.\n", crg.Kind)
+		fmt.Fprintf(&buffer, "apiVersion: %s/%s\n", crg.Group, version)
+		fmt.Fprintf(&buffer, "kind: %s\n", crg.Kind)
+		buffer.WriteString("metadata:\n")
+		fmt.Fprintf(&buffer, "  name: %s # TODO: name\n", "example-"+strings.ToLower(crg.Kind))
+		if crg.Scope != "Cluster" {
+			buffer.WriteString("  namespace: default\n")
+		}
+		if pg.awaitAnnotations {
+			writeAwaitAnnotations(&buffer)
+		}
+
+		m := manifestWriter{buffer: &buffer, types: pg.Types, schemaPropertyOrder: pg.schemaPropertyOrder}
+		schema := crg.Schemas[version]
+		for _, propertyName := range m.propertyNames(resourceType.ObjectTypeSpec, schema, "spec") {
+			switch propertyName {
+			case "apiVersion", "kind", "metadata", "status":
+				continue
+			}
+			m.writeProperty(propertyName, resourceType.Properties[propertyName].TypeSpec, propertySchema(schema, propertyName), 0)
+		}
+	}
+	return buffer.Bytes()
+}
+
+// manifestWriter writes the placeholder values of an example manifest
+type manifestWriter struct {
+	buffer *bytes.Buffer
+	types  map[string]pschema.ComplexTypeSpec
+	// schemaPropertyOrder is true if properties are written in the order
+	// that their schema declares them in, instead of sorted
+	schemaPropertyOrder bool
+	// visiting contains the types currently being written, to stop recursive types
+	visiting map[string]bool
+}
+
+// propertyNames returns the names of the object's required properties, and of
+// the given extra properties if the object has them, ordered according to the
+// object's schema.
+func (m *manifestWriter) propertyNames(objectTypeSpec pschema.ObjectTypeSpec, schema map[string]interface{}, extra ...string) []string {
+	var names []string
+	for _, required := range [][]string{objectTypeSpec.Required, extra} {
+		for _, name := range required {
+			if _, ok := objectTypeSpec.Properties[name]; ok {
+				names = appendMissing(names, name)
+			}
+		}
+	}
+	return orderProperties(schema, names, m.schemaPropertyOrder)
+}
+
+// writeProperty writes `name: <placeholder>` at the given indentation level,
+// followed by the object's own properties if the property is an object.
+func (m *manifestWriter) writeProperty(name string, typeSpec pschema.TypeSpec, schema map[string]interface{}, indent int) {
+	prefix := strings.Repeat("  ", indent) + name + ":"
+
+	if objectType, ok := m.objectType(typeSpec); ok {
+		token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+		names := m.propertyNames(objectType, schema)
+		if len(names) == 0 || m.visiting[token] {
+			fmt.Fprintf(m.buffer, "%s {}\n", prefix)
+			return
+		}
+		fmt.Fprintf(m.buffer, "%s\n", prefix)
+		if m.visiting == nil {
+			m.visiting = map[string]bool{}
+		}
+		m.visiting[token] = true
+		for _, propertyName := range names {
+			m.writeProperty(propertyName, objectType.Properties[propertyName].TypeSpec, propertySchema(schema, propertyName), indent+1)
+		}
+		delete(m.visiting, token)
+		return
+	}
+
+	value, comment := m.placeholder(typeSpec)
+	fmt.Fprintf(m.buffer, "%s %s # TODO: %s\n", prefix, value, comment)
+}
+
+// propertySchema returns the schema of the object schema's property, or nil
+// if there's none.
+func propertySchema(schema map[string]interface{}, propertyName string) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	propertySchema, _ := properties[propertyName].(map[string]interface{})
+	return propertySchema
+}
+
+// objectType returns the object type that the TypeSpec refers to, if any.
+func (m *manifestWriter) objectType(typeSpec pschema.TypeSpec) (pschema.ObjectTypeSpec, bool) {
+	if !strings.HasPrefix(typeSpec.Ref, "#/types/") {
+		return pschema.ObjectTypeSpec{}, false
+	}
+	complexType, ok := m.types[strings.TrimPrefix(typeSpec.Ref, "#/types/")]
+	if !ok || len(complexType.Enum) > 0 {
+		return pschema.ObjectTypeSpec{}, false
+	}
+	return complexType.ObjectTypeSpec, true
+}
+
+// placeholder returns a YAML placeholder value for a non-object TypeSpec, and
+// a comment that describes the expected value.
+func (m *manifestWriter) placeholder(typeSpec pschema.TypeSpec) (string, string) {
+	if complexType, ok := m.types[strings.TrimPrefix(typeSpec.Ref, "#/types/")]; ok && len(complexType.Enum) > 0 {
+		values := make([]string, 0, len(complexType.Enum))
+		for _, enumValue := range complexType.Enum {
+			values = append(values, yamlScalar(enumValue.Value))
+		}
+		return values[0], "one of " + strings.Join(values, ", ")
+	}
+	if len(typeSpec.OneOf) > 0 {
+		value, _ := m.placeholder(typeSpec.OneOf[0])
+		types := make([]string, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			_, comment := m.placeholder(oneOfTypeSpec)
+			types = append(types, comment)
+		}
+		return value, strings.Join(types, " or ")
+	}
+
+	switch typeSpec.Type {
+	case String:
+		return `""`, String
+	case Integer:
+		return "0", Integer
+	case Number:
+		return "0.0", Number
+	case Boolean:
+		return "false", Boolean
+	case Array:
+		return "[]", Array
+	case Object:
+		return "{}", Object
+	default:
+		return "{}", "any value"
+	}
+}
+
+// yamlScalar formats a scalar as YAML. JSON scalars are valid YAML.
+func yamlScalar(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
@@ -0,0 +1,69 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"regexp"
+	"strings"
+)
+
+// kubebuilderMarkerRe matches the lines of descriptions that are Kubebuilder
+// markers, e.g. `+kubebuilder:validation:Minimum=1`, `+optional` or
+// `+required`, which controller-gen leaks from Go doc comments into the
+// descriptions of some CRDs.
+var kubebuilderMarkerRe = regexp.MustCompile(`^\s*(\+kubebuilder:\S.*|\+optional|\+required)\s*$`)
+
+// StripKubebuilderMarkers removes the Kubebuilder marker lines from the
+// descriptions of every type and property, keeping the rest of them.
+func (pg *PackageGenerator) StripKubebuilderMarkers() {
+	for token, complexTypeSpec := range pg.Types {
+		complexTypeSpec.Description = stripKubebuilderMarkers(complexTypeSpec.Description)
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			propertySpec.Description = stripKubebuilderMarkers(propertySpec.Description)
+			complexTypeSpec.Properties[propertyName] = propertySpec
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+}
+
+// stripKubebuilderMarkers returns the description without its Kubebuilder
+// marker lines. The blank lines around the markers are merged, so that they
+// leave no empty paragraphs behind.
+func stripKubebuilderMarkers(description string) string {
+	if !strings.Contains(description, "+") {
+		return description
+	}
+	lines := strings.Split(description, "\n")
+	kept := make([]string, 0, len(lines))
+	stripped, afterMarker := false, false
+	for _, line := range lines {
+		if kubebuilderMarkerRe.MatchString(line) {
+			stripped, afterMarker = true, true
+			continue
+		}
+		blank := strings.TrimSpace(line) == ""
+		if blank && afterMarker && (len(kept) == 0 || strings.TrimSpace(kept[len(kept)-1]) == "") {
+			continue
+		}
+		if !blank {
+			afterMarker = false
+		}
+		kept = append(kept, line)
+	}
+	if !stripped {
+		return description
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), " \t\n")
+}
@@ -0,0 +1,62 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxDepth is how deeply schemas can be nested in the root schema of
+// a CustomResource before they're typed as `any`. Real CRDs are nested far
+// less deeply, so the limit only bounds the time and memory that pathological
+// schemas take to convert. Recursive schemas are handled separately, since
+// their types refer to themselves.
+const DefaultMaxDepth = 100
+
+// SetMaxDepth sets how deeply schemas can be nested before they're typed as
+// `any`, and regenerates the types with it. The truncated types are returned
+// by TruncatedTypes.
+func (pg *PackageGenerator) SetMaxDepth(maxDepth int) error {
+	if maxDepth <= 0 {
+		return errors.Errorf("invalid max depth %d, expected a positive number", maxDepth)
+	}
+	pg.maxDepth = maxDepth
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
+
+// MaxDepth returns how deeply schemas can be nested before they're typed as
+// `any`.
+func (pg *PackageGenerator) MaxDepth() int {
+	if pg.maxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return pg.maxDepth
+}
+
+// TruncatedTypes returns the sorted names of the types whose schemas were
+// nested deeper than the MaxDepth, and were typed as `any` instead, when the
+// types were last generated.
+func (pg *PackageGenerator) TruncatedTypes() []string {
+	names := append([]string(nil), pg.truncatedTypes...)
+	sort.Strings(names)
+	return names
+}
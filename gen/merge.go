@@ -0,0 +1,186 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// ReadSchema reads the Pulumi package schema at the given path, e.g. one
+// written by a previous run with LanguageSettings.MergeSchemaPath.
+func ReadSchema(path string) (pschema.PackageSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pschema.PackageSpec{}, errors.Wrapf(err, "could not read schema %s", path)
+	}
+	var spec pschema.PackageSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return pschema.PackageSpec{}, errors.Wrapf(err, "could not parse schema %s", path)
+	}
+	return spec, nil
+}
+
+// MergeSchema adds the types and resources of the given Pulumi package schema
+// to the package, so that the generated SDKs also contain the ones generated
+// from other CRDs in previous runs. Types and resources that the package
+// already has are kept if their definitions are the same, and an error is
+// returned if they differ. The merged resources have no
+// CustomResourceGenerator, so only the SDKs are generated for them.
+//
+// The schema is expected to target the same schema version as the package, so
+// the package's types and resources are compared to it without the features
+// that the version doesn't support.
+func (pg *PackageGenerator) MergeSchema(spec pschema.PackageSpec) error {
+	isResource := map[string]bool{}
+	for _, token := range pg.ResourceTokens {
+		isResource[token] = true
+	}
+	generated, _ := pg.targetedSchema()
+
+	typeTokens := make([]string, 0, len(spec.Types))
+	for token := range spec.Types {
+		typeTokens = append(typeTokens, token)
+	}
+	sort.Strings(typeTokens)
+	for _, token := range typeTokens {
+		if token == objectMetaToken {
+			continue
+		}
+		complexTypeSpec := spec.Types[token]
+		if _, ok := pg.Types[token]; ok {
+			if isResource[token] || !sameSpec(generated.Types[token], complexTypeSpec) {
+				return errors.Errorf("conflicting definitions of type %s", token)
+			}
+			continue
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+
+	resourceTokens := make([]string, 0, len(spec.Resources))
+	for token := range spec.Resources {
+		resourceTokens = append(resourceTokens, token)
+	}
+	sort.Strings(resourceTokens)
+	for _, token := range resourceTokens {
+		resource := spec.Resources[token]
+		// The methods are merged separately, since they refer to functions
+		methods := resource.Methods
+		resource.Methods = nil
+		if err := pg.mergeMethods(token, methods, spec.Functions); err != nil {
+			return err
+		}
+		if _, ok := pg.Types[token]; ok {
+			existing := generated.Resources[token]
+			existing.Methods = nil
+			if !isResource[token] || !sameSpec(existing, resource) {
+				return errors.Errorf("conflicting definitions of resource %s", token)
+			}
+			continue
+		}
+		objectTypeSpec := resource.ObjectTypeSpec
+		objectTypeSpec.Properties = resource.InputProperties
+		pg.Types[token] = pschema.ComplexTypeSpec{ObjectTypeSpec: objectTypeSpec}
+		pg.ResourceTokens = append(pg.ResourceTokens, token)
+		isResource[token] = true
+
+		groupVersion := string(tokens.Type(token).Module().Name())
+		if !contains(pg.GroupVersions, groupVersion) {
+			pg.GroupVersions = append(pg.GroupVersions, groupVersion)
+		}
+	}
+	return nil
+}
+
+// mergeMethods adds the given methods of a resource of a merged schema, with
+// their functions, to the methods of the resource. Methods that the resource
+// already has are kept if their functions are the same.
+func (pg *PackageGenerator) mergeMethods(resourceToken string, methods map[string]string, functions map[string]pschema.FunctionSpec) error {
+	for name, functionToken := range methods {
+		function, ok := functions[functionToken]
+		if !ok {
+			return errors.Errorf("could not find the function %s of the method %s of %s", functionToken, name, resourceToken)
+		}
+		if existing, ok := pg.methods[resourceToken][name]; ok {
+			if !sameSpec(existing, function) {
+				return errors.Errorf("conflicting definitions of method %s of %s", name, resourceToken)
+			}
+			continue
+		}
+		pg.setMethod(resourceToken, name, function)
+	}
+	return nil
+}
+
+// sameSpec returns true if the given specs have the same JSON encoding, so
+// that specs decoded from a schema can be compared to generated ones.
+func sameSpec(a, b interface{}) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aData, bData)
+}
+
+// targetedSchema returns the Pulumi package schema of the package without the
+// features that the target schema version doesn't support, and a warning for
+// each omitted feature that it used.
+func (pg *PackageGenerator) targetedSchema() (pschema.PackageSpec, []string) {
+	types := map[string]pschema.ComplexTypeSpec{}
+	for token, complexTypeSpec := range pg.Types {
+		types[token] = complexTypeSpec
+	}
+	// The resources are only written once, as resources
+	for _, token := range pg.ResourceTokens {
+		delete(types, token)
+	}
+	spec := genPackageSpec(pg.PackageVersion(), pg.Types, pg.ResourceTokens, pg.methods, pg.objectMetaRequired)
+	spec.Types = types
+	if pg.schemaVersion.Major == 0 {
+		return spec, nil
+	}
+	return spec, targetSchemaVersion(&spec, pg.schemaVersion)
+}
+
+// writeSchema writes the Pulumi package schema of the package to the given
+// path, to be merged into the package of a later run.
+func (pg *PackageGenerator) writeSchema(outputPath string) error {
+	spec, warnings := pg.targetedSchema()
+	for _, warning := range warnings {
+		if pg.strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	data, err := marshalJSON(spec, pg.compactJSON)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal schema")
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
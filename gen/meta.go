@@ -0,0 +1,75 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+var stringMapTypeSpec = pschema.TypeSpec{
+	Type:                 Object,
+	AdditionalProperties: &pschema.TypeSpec{Type: String},
+}
+
+// objectMetaTypeSpec is the placeholder ObjectMeta type. Each language
+// generator usually replaces it with the ObjectMeta type of the Kubernetes SDK,
+// unless the placeholder is kept to generate a standalone SDK. It only has the
+// fields of ObjectMeta that don't need types of their own.
+var objectMetaTypeSpec = pschema.ComplexTypeSpec{
+	ObjectTypeSpec: pschema.ObjectTypeSpec{
+		Type:        Object,
+		Description: "ObjectMeta is metadata that all persisted resources must have, which includes all objects users must create.",
+		Properties: map[string]pschema.PropertySpec{
+			"annotations":                {TypeSpec: stringMapTypeSpec},
+			"creationTimestamp":          {TypeSpec: pschema.TypeSpec{Type: String}},
+			"deletionGracePeriodSeconds": {TypeSpec: pschema.TypeSpec{Type: Integer}},
+			"deletionTimestamp":          {TypeSpec: pschema.TypeSpec{Type: String}},
+			"finalizers":                 {TypeSpec: pschema.TypeSpec{Type: Array, Items: &pschema.TypeSpec{Type: String}}},
+			"generateName":               {TypeSpec: pschema.TypeSpec{Type: String}},
+			"generation":                 {TypeSpec: pschema.TypeSpec{Type: Integer}},
+			"labels":                     {TypeSpec: stringMapTypeSpec},
+			"name":                       {TypeSpec: pschema.TypeSpec{Type: String}},
+			"namespace":                  {TypeSpec: pschema.TypeSpec{Type: String}},
+			"resourceVersion":            {TypeSpec: pschema.TypeSpec{Type: String}},
+			"selfLink":                   {TypeSpec: pschema.TypeSpec{Type: String}},
+			"uid":                        {TypeSpec: pschema.TypeSpec{Type: String}},
+		},
+	},
+}
+
+// nodejsPlaceholderMetaFile replaces nodejsMetaFile when the placeholder
+// ObjectMeta type is kept. The NodeJS package is generated without the
+// placeholder type, so it's declared by hand.
+const nodejsPlaceholderMetaFile = `import * as pulumi from "@pulumi/pulumi";
+
+/**
+ * ObjectMeta is metadata that all persisted resources must have, which includes all objects users must create.
+ */
+export interface ObjectMeta {
+    annotations?: pulumi.Input<{[key: string]: pulumi.Input<string>}>;
+    creationTimestamp?: pulumi.Input<string>;
+    deletionGracePeriodSeconds?: pulumi.Input<number>;
+    deletionTimestamp?: pulumi.Input<string>;
+    finalizers?: pulumi.Input<pulumi.Input<string>[]>;
+    generateName?: pulumi.Input<string>;
+    generation?: pulumi.Input<number>;
+    labels?: pulumi.Input<{[key: string]: pulumi.Input<string>}>;
+    name?: pulumi.Input<string>;
+    namespace?: pulumi.Input<string>;
+    resourceVersion?: pulumi.Input<string>;
+    selfLink?: pulumi.Input<string>;
+    uid?: pulumi.Input<string>;
+}
+`
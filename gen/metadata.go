@@ -0,0 +1,124 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MetadataConstraints returns a sentence for each constraint that the schema
+// of the given version puts on the `metadata` of its resources, e.g.
+// "`metadata.labels.app` is required." The `metadata` property is always
+// the ObjectMeta type, which doesn't enforce them, so they're documented on
+// the property instead. Returns nil if the schema doesn't constrain it.
+func (crg *CustomResourceGenerator) MetadataConstraints(version string) []string {
+	return metadataConstraints(crg.Schemas[version])
+}
+
+func metadataConstraints(schema map[string]interface{}) []string {
+	metadataSchema, found, _ := unstruct.NestedMap(schema, "properties", "metadata")
+	if !found {
+		return nil
+	}
+	var constraints []string
+	describeMetadataConstraints("metadata", metadataSchema, &constraints)
+	return constraints
+}
+
+// describeMetadataConstraints appends the constraints of the schema at the
+// given path of the metadata, and of its subschemas, to the constraints.
+func describeMetadataConstraints(path string, schema map[string]interface{}, constraints *[]string) {
+	field := "`" + path + "`"
+	required, _, _ := unstruct.NestedStringSlice(schema, "required")
+	sort.Strings(required)
+	for _, propertyName := range required {
+		*constraints = append(*constraints, fmt.Sprintf("`%s` is required.", joinFieldPath(path, propertyName)))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		*constraints = append(*constraints, fmt.Sprintf("%s must match `%s`.", field, pattern))
+	}
+	if length := formatRange(schema["minLength"], schema["maxLength"], "character"); length != "" {
+		*constraints = append(*constraints, fmt.Sprintf("%s must be %s long.", field, length))
+	}
+	if keys := formatRange(schema["minProperties"], schema["maxProperties"], "key"); keys != "" {
+		*constraints = append(*constraints, fmt.Sprintf("%s must have %s.", field, keys))
+	}
+	if values := formatEnumValues(schema["enum"]); values != "" {
+		*constraints = append(*constraints, fmt.Sprintf("%s must be one of %s.", field, values))
+	}
+	if other := describeConstraints(schema); other != "" {
+		*constraints = append(*constraints, fmt.Sprintf("%s is constrained to %s.", field, other))
+	}
+	rules, _, _ := NestedMapSlice(schema, "x-kubernetes-validations")
+	for _, rule := range rules {
+		if expression, ok := rule["rule"].(string); ok {
+			*constraints = append(*constraints, fmt.Sprintf("%s must satisfy the rule `%s`.", field, expression))
+		}
+	}
+
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	propertyNames := make([]string, 0, len(properties))
+	for propertyName := range properties {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	for _, propertyName := range propertyNames {
+		if propertySchema, ok := properties[propertyName].(map[string]interface{}); ok {
+			describeMetadataConstraints(joinFieldPath(path, propertyName), propertySchema, constraints)
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		describeMetadataConstraints(path+"[*]", items, constraints)
+	}
+	if additionalProperties, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		describeMetadataConstraints(joinFieldPath(path, "*"), additionalProperties, constraints)
+	}
+}
+
+// metadataDescription documents the given constraints of the metadata.
+func metadataDescription(constraints []string) string {
+	var sb strings.Builder
+	sb.WriteString("The CRD constrains the metadata further than the ObjectMeta type, which doesn't enforce it:\n")
+	for _, constraint := range constraints {
+		sb.WriteString("\n- " + constraint)
+	}
+	return sb.String()
+}
+
+// withoutMetadata returns a shallow copy of the schema of a resource without
+// its `metadata` property, which is replaced by the ObjectMeta type, so that
+// no types are generated for it.
+func withoutMetadata(schema map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if _, ok := properties["metadata"]; !ok {
+		return schema
+	}
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	copiedProperties := make(map[string]interface{}, len(properties))
+	for propertyName, property := range properties {
+		if propertyName != "metadata" {
+			copiedProperties[propertyName] = property
+		}
+	}
+	copied["properties"] = copiedProperties
+	return copied
+}
@@ -0,0 +1,112 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// methodNameRe matches the names of resource methods, which are camelCase
+// identifiers in every language.
+var methodNameRe = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+
+// ParseMethod parses a `<group>/<Kind>=<method>` spec, e.g.
+// `stable.example.com/CronTab=trigger`, of a method to add to the resources
+// of a CRD. Returns the `<group>/<Kind>` and the name of the method.
+func ParseMethod(spec string) (string, string, error) {
+	i, j := strings.LastIndex(spec, "/"), strings.Index(spec, "=")
+	if i <= 0 || j < i+2 {
+		return "", "", errors.Errorf("invalid method %q, expected <group>/<Kind>=<method>", spec)
+	}
+	groupKind, name := spec[:j], spec[j+1:]
+	if !methodNameRe.MatchString(name) {
+		return "", "", errors.Errorf("invalid name %q of method %q, expected a camelCase identifier", name, spec)
+	}
+	return groupKind, name, nil
+}
+
+// AddMethods adds the methods in the map, keyed by the `<group>/<Kind>` of
+// CRDs, to the resources of every version of the CRDs. The methods only take
+// the resource, and return nothing. The Kubernetes provider doesn't implement
+// them, so they're placeholders for the actions of imperative CRDs, that a
+// provider that does can be swapped in for. Returns an error if no CRD has a
+// kind of the map, or if a method has the name of a property of a resource.
+func (pg *PackageGenerator) AddMethods(methods map[string][]string) error {
+	groupKinds := make([]string, 0, len(methods))
+	for groupKind := range methods {
+		groupKinds = append(groupKinds, groupKind)
+	}
+	sort.Strings(groupKinds)
+	for _, groupKind := range groupKinds {
+		found := false
+		for _, crg := range pg.CustomResourceGenerators {
+			if crg.Group+"/"+crg.Kind != groupKind {
+				continue
+			}
+			found = true
+			for _, resourceToken := range crg.ResourceTokens {
+				for _, name := range methods[groupKind] {
+					if err := pg.addMethod(resourceToken, name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if !found {
+			return errors.Errorf("could not find the CRD of the methods of %s", groupKind)
+		}
+	}
+	return nil
+}
+
+// addMethod adds a placeholder method with the given name to the resource.
+func (pg *PackageGenerator) addMethod(resourceToken, name string) error {
+	if _, ok := pg.Types[resourceToken].Properties[name]; ok {
+		return errors.Errorf("the method %s of %s has the name of one of its properties", name, resourceToken)
+	}
+	pg.setMethod(resourceToken, name, pschema.FunctionSpec{
+		Description: fmt.Sprintf("Placeholder for the `%s` action of the resource. The Kubernetes provider doesn't "+
+			"implement it, so calling it fails unless the resource is managed by a provider that does.", name),
+		Inputs: &pschema.ObjectTypeSpec{
+			Properties: map[string]pschema.PropertySpec{
+				"__self__": {TypeSpec: pschema.TypeSpec{Ref: "#/resources/" + resourceToken}},
+			},
+		},
+	})
+	return nil
+}
+
+// setMethod sets the function of the resource's method with the given name.
+func (pg *PackageGenerator) setMethod(resourceToken, name string, function pschema.FunctionSpec) {
+	if pg.methods == nil {
+		pg.methods = map[string]map[string]pschema.FunctionSpec{}
+	}
+	if pg.methods[resourceToken] == nil {
+		pg.methods[resourceToken] = map[string]pschema.FunctionSpec{}
+	}
+	pg.methods[resourceToken][name] = function
+}
+
+// methodToken returns the token of the function of a resource's method,
+// which Pulumi requires to be `<resource token>/<method>`.
+func methodToken(resourceToken, name string) string {
+	return resourceToken + "/" + name
+}
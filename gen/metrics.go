@@ -0,0 +1,138 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// GenerationStats are the statistics of a generation run, which
+// GenerateWithOptions writes as Prometheus metrics if
+// LanguageSettings.MetricsPath is set.
+type GenerationStats struct {
+	// CRDs is the number of CRDs that were processed
+	CRDs int
+	// Resources is the number of resources generated, one per CRD version
+	Resources int
+	// Types is the number of object and enum types generated, besides the
+	// resources
+	Types int
+	// AnyTypes is the number of properties, items and map values typed as
+	// `any`, because their schema doesn't describe them, e.g. the untyped
+	// `spec` of a CRD without a structural schema
+	AnyTypes int
+	// Duration is how long the run took
+	Duration time.Duration
+	// Succeeded is true if the code was generated without errors
+	Succeeded bool
+	// Timestamp is when the run finished
+	Timestamp time.Time
+}
+
+// Stats returns the statistics of the CRDs and types of the package.
+func (pg *PackageGenerator) Stats() GenerationStats {
+	stats := GenerationStats{
+		CRDs:      len(pg.CustomResourceGenerators),
+		Resources: len(pg.ResourceTokens),
+		Types:     len(pg.Types) - len(pg.ResourceTokens),
+	}
+	for _, complexTypeSpec := range pg.Types {
+		for _, propertySpec := range complexTypeSpec.Properties {
+			stats.AnyTypes += countAnyTypes(propertySpec.TypeSpec, pg.anyTypeRefOrDefault())
+		}
+	}
+	return stats
+}
+
+// countAnyTypes returns the number of `any` types, with the given ref, in the
+// given type, including its array items, map values and union members.
+func countAnyTypes(typeSpec pschema.TypeSpec, anyTypeRef string) int {
+	if typeSpec.Ref == anyTypeRef {
+		return 1
+	}
+	count := 0
+	if typeSpec.Items != nil {
+		count += countAnyTypes(*typeSpec.Items, anyTypeRef)
+	}
+	if typeSpec.AdditionalProperties != nil {
+		count += countAnyTypes(*typeSpec.AdditionalProperties, anyTypeRef)
+	}
+	for _, oneOf := range typeSpec.OneOf {
+		count += countAnyTypes(oneOf, anyTypeRef)
+	}
+	return count
+}
+
+// Prometheus returns the statistics as metrics in the Prometheus text format,
+// as read by the textfile collector of the node exporter.
+func (s GenerationStats) Prometheus() []byte {
+	succeeded := 0
+	if s.Succeeded {
+		succeeded = 1
+	}
+	var buf bytes.Buffer
+	for _, metric := range []struct {
+		name, help string
+		value      interface{}
+	}{
+		{"crd2pulumi_crds_processed", "Number of CRDs processed.", s.CRDs},
+		{"crd2pulumi_resources_generated", "Number of resources generated, one per CRD version.", s.Resources},
+		{"crd2pulumi_types_generated", "Number of object and enum types generated, besides the resources.", s.Types},
+		{"crd2pulumi_any_type_fallbacks", "Number of properties, items and map values typed as any.", s.AnyTypes},
+		{"crd2pulumi_generation_duration_seconds", "Duration of the generation run in seconds.", s.Duration.Seconds()},
+		{"crd2pulumi_generation_success", "Whether the generation run succeeded.", succeeded},
+		{"crd2pulumi_generation_timestamp_seconds", "Unix time when the generation run finished.", s.Timestamp.Unix()},
+	} {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", metric.name, metric.help, metric.name, metric.name, metric.value)
+	}
+	return buf.Bytes()
+}
+
+// writeMetrics writes the statistics as Prometheus metrics to the given path.
+// The file is replaced atomically, so that the textfile collector never reads
+// a partial file.
+func (s GenerationStats) writeMetrics(outputPath string) error {
+	dir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	file, err := ioutil.TempFile(dir, filepath.Base(outputPath)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "could not create file %s", outputPath)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(s.Prometheus()); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	if err := os.Rename(file.Name(), outputPath); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
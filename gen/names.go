@@ -0,0 +1,119 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// identifierRe matches the names that every language can use as an
+// identifier, e.g. in the `pulumi_<name>` Python module or the
+// `Pulumi.<Name>` .NET namespace
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// npmUnscopedNameRe matches an npm package name without a scope
+var npmUnscopedNameRe = regexp.MustCompile(`^[a-z0-9-~][a-z0-9-._~]*$`)
+
+// goPackageNameRe matches a Go package name, which is lowercase by convention
+var goPackageNameRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// dotNetAssemblyNameRe matches a .NET assembly name of dot-separated
+// identifiers, e.g. `MyOrg.Crds`
+var dotNetAssemblyNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// pythonDistributionNameRe matches a Python distribution name, as defined by
+// PEP 508
+var pythonDistributionNameRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// goPackageClauseRe matches the package clause of the files of the root Go
+// package, which the code generator names after the import base path
+var goPackageClauseRe = regexp.MustCompile(`(?m)^package kubernetes$`)
+
+// goRootFileRe matches the paths of the Go files in the root package
+var goRootFileRe = regexp.MustCompile(`^[^/]+\.go$`)
+
+// validatePackageNames returns an error if the package name or one of the
+// name overrides of a generated language isn't valid in that language.
+func (ls LanguageSettings) validatePackageNames() error {
+	if ls.TopLevelModule != "" && (!goPackageNameRe.MatchString(ls.TopLevelModule) || token.IsKeyword(ls.TopLevelModule) || ls.TopLevelModule == "meta") {
+		return errors.Errorf("invalid top-level module %q", ls.TopLevelModule)
+	}
+	if ls.NodeJSPath != nil && !npmUnscopedNameRe.MatchString(ls.NodeJSName) {
+		return errors.Errorf("invalid NodeJS package name %q", ls.NodeJSName)
+	}
+	if ls.PythonPath != nil {
+		if !identifierRe.MatchString(ls.PythonName) {
+			return errors.Errorf("invalid Python package name %q", ls.PythonName)
+		}
+		if ls.PythonDistributionName != "" && !pythonDistributionNameRe.MatchString(ls.PythonDistributionName) {
+			return errors.Errorf("invalid Python distribution name %q", ls.PythonDistributionName)
+		}
+	}
+	if ls.DotNetPath != nil {
+		if !identifierRe.MatchString(ls.DotNetName) {
+			return errors.Errorf("invalid .NET package name %q", ls.DotNetName)
+		}
+		if ls.DotNetAssemblyName != "" && !dotNetAssemblyNameRe.MatchString(ls.DotNetAssemblyName) {
+			return errors.Errorf("invalid .NET assembly name %q", ls.DotNetAssemblyName)
+		}
+	}
+	if ls.GoPath != nil {
+		if !identifierRe.MatchString(ls.GoName) {
+			return errors.Errorf("invalid Go package name %q", ls.GoName)
+		}
+		if ls.GoPackageName != "" && (!goPackageNameRe.MatchString(ls.GoPackageName) || token.IsKeyword(ls.GoPackageName)) {
+			return errors.Errorf("invalid Go package name %q", ls.GoPackageName)
+		}
+	}
+	return nil
+}
+
+// renameGoRootPackage renames the root package of the generated Go files,
+// which only contains the shared utilities.
+func renameGoRootPackage(files map[string]*bytes.Buffer, name string) {
+	for path, code := range files {
+		if !goRootFileRe.MatchString(path) {
+			continue
+		}
+		files[path] = bytes.NewBuffer(goPackageClauseRe.ReplaceAll(code.Bytes(), []byte("package "+name)))
+	}
+}
+
+// renameDotNetAssembly renames the project file of the generated .NET files,
+// and sets the name of the assembly and of the NuGet package that it builds,
+// which default to the root namespace, e.g. `Pulumi.Crds`.
+func renameDotNetAssembly(files map[string][]byte, rootNamespace, name string) {
+	projectPath := rootNamespace + ".csproj"
+	project, ok := files[projectPath]
+	if !ok {
+		return
+	}
+	delete(files, projectPath)
+	names := fmt.Sprintf("<PropertyGroup>\n    <AssemblyName>%s</AssemblyName>\n    <PackageId>%s</PackageId>\n    <RootNamespace>%s</RootNamespace>", name, name, rootNamespace)
+	files[name+".csproj"] = bytes.Replace(project, []byte("<PropertyGroup>"), []byte(names), 1)
+}
+
+// renamePythonDistribution sets the distribution name in the generated
+// setup.py, which defaults to the name of the module, e.g. `pulumi_crds`.
+func renamePythonDistribution(files map[string][]byte, moduleName, name string) {
+	if setupPy, ok := files["setup.py"]; ok {
+		files["setup.py"] = bytes.Replace(setupPy, []byte(fmt.Sprintf("setup(name='%s',", moduleName)), []byte(fmt.Sprintf("setup(name='%s',", name)), 1)
+	}
+}
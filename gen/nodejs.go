@@ -16,33 +16,61 @@ package gen
 
 import (
 	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/nodejs"
 )
 
 const nodejsMetaPath = "meta/v1.ts"
-const nodejsMetaFile = `import * as k8s from "@pulumi/kubernetes";
+const nodejsMetaFile = `import * as k8s from "{{.KubernetesPackage}}";
 
 export type ObjectMeta = k8s.types.input.meta.v1.ObjectMeta;
 `
 
-func (pg *PackageGenerator) genNodeJS(outputDir string, name string) error {
-	if files, err := pg.genNodeJSFiles(name); err != nil {
-		return err
-	} else if err := writeFiles(files, outputDir); err != nil {
+// npmScopeRe matches the characters allowed in an npm scope, without the leading `@`
+var npmScopeRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-._~]*$`)
+
+const nodejsIndexPath = "index.ts"
+
+// nodejsTypeModules are the modules of the `types` module that the barrel
+// re-exports
+var nodejsTypeModules = []string{"enums", "input", "output"}
+
+func (pg *PackageGenerator) genNodeJS(outputDir, name, scope string, barrel bool) error {
+	files, err := pg.genNodeJSFiles(name, scope, barrel)
+	if err != nil {
 		return err
 	}
-	return nil
+	pg.addTestStubs(files, NodeJS, name)
+	return pg.writeFiles(files, outputDir)
 }
 
-func (pg *PackageGenerator) genNodeJSFiles(name string) (map[string]*bytes.Buffer, error) {
+func (pg *PackageGenerator) genNodeJSFiles(name, scope string, barrel bool) (map[string]*bytes.Buffer, error) {
+	nodejsInfo := map[string]interface{}{
+		"moduleToPackage": pg.moduleToPackage(),
+	}
+	if scope != "" {
+		scope = strings.TrimPrefix(scope, "@")
+		if !npmScopeRe.MatchString(scope) {
+			return nil, errors.Errorf("invalid npm scope %q", scope)
+		}
+		nodejsInfo["packageName"] = "@" + scope + "/" + name
+	}
+	objectMetaPackage, importObjectMeta := pg.objectMetaPackages[NodeJS]
+	if importObjectMeta {
+		nodejsInfo["dependencies"] = map[string]string{objectMetaPackage.Name: objectMetaPackage.Version}
+	}
+
 	pkg := pg.SchemaPackage()
 
 	oldName := pkg.Name
 	pkg.Name = name
-	pkg.Language["nodejs"] = rawMessage(map[string]interface{}{
-		"moduleToPackage": pg.moduleToPackage(),
-	})
+	pkg.Language["nodejs"] = pg.languageInfo(NodeJS, nodejsInfo)
 
 	files, err := nodejs.GeneratePackage(tool, pkg, nil)
 	if err != nil {
@@ -52,19 +80,56 @@ func (pg *PackageGenerator) genNodeJSFiles(name string) (map[string]*bytes.Buffe
 	pkg.Name = oldName
 	delete(pkg.Language, NodeJS)
 
-	// Remove ${VERSION} in package.json
+	if err := pg.appendTypeTokens(files, NodeJS); err != nil {
+		return nil, err
+	}
+	if barrel {
+		if err := pg.appendNodeJSBarrel(files); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace ${VERSION} in package.json with the package version, if it's set
 	packageJSON, ok := files["package.json"]
 	if !ok {
 		return nil, errors.New("cannot find generated package.json")
 	}
-	files["package.json"] = bytes.ReplaceAll(packageJSON, []byte("${VERSION}"), []byte(""))
+	files["package.json"] = bytes.ReplaceAll(packageJSON, []byte("${VERSION}"), []byte(pg.packageVersion))
+
+	kubernetesPackage := "@pulumi/kubernetes"
+	if importObjectMeta {
+		kubernetesPackage = objectMetaPackage.Name
+	}
 
-	// Create a helper `meta/v1.ts` script that exports the ObjectMeta class from the SDK. If there happens to already
-	// be a `meta/v1.ts` file, then just append the script.
+	// Create a helper `meta/v1.ts` script that exports the ObjectMeta class from the SDK, or declares the placeholder
+	// one. If there happens to already be a `meta/v1.ts` file, then just append the script.
+	metaFile := []byte(nodejsPlaceholderMetaFile)
+	if !pg.keepPlaceholderMeta {
+		metaFile, err = pg.renderOverlay(NodeJS, MetaOverlay, pg.overlayData(name, kubernetesPackage))
+		if err != nil {
+			return nil, err
+		}
+	}
 	if code, ok := files[nodejsMetaPath]; !ok {
-		files[nodejsMetaPath] = []byte(nodejsMetaFile)
+		files[nodejsMetaPath] = metaFile
 	} else {
-		files[nodejsMetaPath] = append(code, []byte("\n"+nodejsMetaFile)...)
+		files[nodejsMetaPath] = append(code, append([]byte("\n"), metaFile...)...)
+	}
+
+	if pg.ownerReferenceHelpers {
+		if err := addNodeJSOwnerReference(files, kubernetesPackage); err != nil {
+			return nil, err
+		}
+	}
+	if pg.crdResources {
+		if err := pg.addNodeJSCRDResources(files, kubernetesPackage); err != nil {
+			return nil, err
+		}
+	}
+	if pg.sdkVersionFile {
+		if err := addNodeJSVersion(files, pg.PackageVersion()); err != nil {
+			return nil, err
+		}
 	}
 
 	buffers := map[string]*bytes.Buffer{}
@@ -74,3 +139,65 @@ func (pg *PackageGenerator) genNodeJSFiles(name string) (map[string]*bytes.Buffe
 
 	return buffers, nil
 }
+
+// appendNodeJSBarrel appends exports to the root `index.ts` of the NodeJS
+// package, so that each resource and type module can be imported from the
+// package itself, e.g. `import { CronTab, input } from "@pulumi/crds"`. A
+// resource with several versions is exported at its storage version. Kinds
+// that several groups declare, and names that the root module already
+// exports, are only exported from their own modules.
+func (pg *PackageGenerator) appendNodeJSBarrel(files map[string][]byte) error {
+	index, ok := files[nodejsIndexPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", nodejsIndexPath)
+	}
+
+	resourcePaths := map[string]string{}
+	for filePath, code := range files {
+		if match := nodejsResourceTokenRe.FindSubmatch(code); match != nil {
+			resourcePaths[string(match[1])] = filePath
+		}
+	}
+	kindTokens := map[string][]string{}
+	for _, crg := range pg.CustomResourceGenerators {
+		if version := crg.StorageVersion(); version != "" {
+			kindTokens[crg.TypeName] = append(kindTokens[crg.TypeName], getToken(crg.Group, version, crg.TypeName))
+		}
+	}
+	kinds := make([]string, 0, len(kindTokens))
+	for kind, tokens := range kindTokens {
+		// The root module exports the package's own Provider resource
+		if len(tokens) == 1 && kind != "Provider" {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	var sb strings.Builder
+	sb.WriteString("\n// Export resources:\n")
+	for _, kind := range kinds {
+		resourcePath, ok := resourcePaths[kindTokens[kind][0]]
+		if !ok {
+			return errors.Errorf("could not find the generated nodejs code of %s", kindTokens[kind][0])
+		}
+		modulePath := "./" + strings.TrimSuffix(resourcePath, path.Ext(resourcePath))
+		fmt.Fprintf(&sb, "export { %s, %sArgs, %sTypeToken } from %q;\n", kind, kind, kind, modulePath)
+	}
+
+	// The type modules are skipped if a group module has the same name
+	var typeModules []string
+	for _, module := range nodejsTypeModules {
+		_, isGroupModule := files[module+"/index.ts"]
+		_, isFile := files["types/"+module+".ts"]
+		_, isDir := files["types/"+module+"/index.ts"]
+		if !isGroupModule && (isFile || isDir) {
+			typeModules = append(typeModules, module)
+		}
+	}
+	if len(typeModules) > 0 {
+		fmt.Fprintf(&sb, "\n// Export type modules:\nexport { %s } from \"./types\";\n", strings.Join(typeModules, ", "))
+	}
+
+	files[nodejsIndexPath] = append(index, []byte(sb.String())...)
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// npmPackageNameRe matches an npm package name, optionally scoped
+var npmPackageNameRe = regexp.MustCompile(`^(@[a-z0-9-~][a-z0-9-._~]*/)?[a-z0-9-~][a-z0-9-._~]*$`)
+
+// pythonPackageNameRe matches a Python distribution name that can be imported
+// once its dashes are replaced with underscores
+var pythonPackageNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// ObjectMetaPackage is an existing Kubernetes SDK to import the ObjectMeta
+// type from, instead of the canonical Pulumi Kubernetes SDK. The generated
+// package depends on it.
+type ObjectMetaPackage struct {
+	// Name is the name of the package, e.g. `@myorg/kubernetes` for NodeJS or
+	// `myorg-kubernetes` for Python.
+	Name string
+	// Version is the version requirement of the dependency, in the syntax of
+	// the language's package manager, e.g. `^3.0.0` or `>=3.0.0,<4.0.0`.
+	Version string
+}
+
+// ParseObjectMetaPackage parses a `<language>=<name>@<version>` spec, e.g.
+// `nodejs=@myorg/kubernetes@^3.0.0`, and validates the package name for the
+// language. Only NodeJS and Python are supported.
+func ParseObjectMetaPackage(spec string) (string, ObjectMetaPackage, error) {
+	language, nameVersion := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		language, nameVersion = spec[:i], spec[i+1:]
+	}
+	// Scoped npm packages start with an `@` of their own
+	i := strings.LastIndex(nameVersion, "@")
+	if i <= 0 || i == len(nameVersion)-1 {
+		return "", ObjectMetaPackage{}, errors.Errorf("invalid ObjectMeta package %q, expected <language>=<name>@<version>", spec)
+	}
+	pkg := ObjectMetaPackage{Name: nameVersion[:i], Version: nameVersion[i+1:]}
+
+	switch language {
+	case NodeJS:
+		if !npmPackageNameRe.MatchString(pkg.Name) {
+			return "", ObjectMetaPackage{}, errors.Errorf("invalid npm package name %q", pkg.Name)
+		}
+	case Python:
+		if !pythonPackageNameRe.MatchString(pkg.Name) {
+			return "", ObjectMetaPackage{}, errors.Errorf("invalid Python package name %q", pkg.Name)
+		}
+	default:
+		return "", ObjectMetaPackage{}, errors.Errorf("the ObjectMeta type can only be imported from another package for NodeJS and Python, not %q", language)
+	}
+	return language, pkg, nil
+}
+
+// validateObjectMetaPackages returns an error if ObjectMeta packages are set
+// for languages that aren't generated, or along with the placeholder
+// ObjectMeta type.
+func (ls LanguageSettings) validateObjectMetaPackages() error {
+	if len(ls.ObjectMetaPackages) == 0 {
+		return nil
+	}
+	if ls.KeepPlaceholderMeta {
+		return errors.New("the placeholder ObjectMeta type can't be kept when importing it from another package")
+	}
+	for language := range ls.ObjectMetaPackages {
+		if (language == NodeJS && ls.NodeJSPath == nil) || (language == Python && ls.PythonPath == nil) {
+			return errors.Errorf("an ObjectMeta package is set for %s, which isn't generated", language)
+		}
+	}
+	return nil
+}
+
+// pythonModuleName returns the module name that a Python distribution is
+// imported as.
+func pythonModuleName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// RequireObjectMeta makes the `metadata` of every resource a required input,
+// so that users have to set it, e.g. to name the resources themselves instead
+// of letting Pulumi auto-name them. The types generated afterwards, e.g. by
+// ExcludeStatus, require it too.
+func (pg *PackageGenerator) RequireObjectMeta() {
+	pg.objectMetaRequired = true
+	for _, resourceToken := range pg.ResourceTokens {
+		if resourceType, ok := pg.Types[resourceToken]; ok {
+			resourceType.Required = appendMissing(resourceType.Required, "metadata")
+			pg.Types[resourceToken] = resourceType
+		}
+	}
+}
@@ -0,0 +1,468 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const ociScheme = "oci://"
+
+// The manifest media types of single artifacts, and of image indexes
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	ociIndexMediaType       = "application/vnd.oci.image.index.v1+json"
+	dockerListMediaType     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ociTitleAnnotation is the file name of a layer, that e.g. `oras push` sets
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// ociYAMLMediaTypes are the media types of the layers that contain YAML
+var ociYAMLMediaTypes = []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}
+
+// ociRepositoryRe matches a repository name of the OCI distribution spec
+var ociRepositoryRe = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// ociTagRe matches a tag, and ociDigestRe a digest, of the OCI distribution spec
+var (
+	ociTagRe    = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+	ociDigestRe = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+)
+
+// bearerParamRe matches a parameter of a `WWW-Authenticate` challenge
+var bearerParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// OCIReference is a reference to an OCI artifact, e.g.
+// `oci://ghcr.io/myorg/crds:v1.0.0`.
+type OCIReference struct {
+	// Registry is the host of the registry, e.g. `ghcr.io`.
+	Registry string
+	// Repository is the repository in the registry, e.g. `myorg/crds`.
+	Repository string
+	// Reference is the tag, e.g. `v1.0.0`, or the digest, e.g.
+	// `sha256:...`, of the artifact. Defaults to `latest`.
+	Reference string
+}
+
+// ParseOCIReference parses an `oci://<registry>/<repository>[:<tag>|@<digest>]`
+// reference. The `oci://` scheme is optional.
+func ParseOCIReference(ref string) (OCIReference, error) {
+	invalid := errors.Errorf("invalid OCI reference %q, expected oci://<registry>/<repository>[:<tag>|@<digest>]", ref)
+	trimmed := strings.TrimPrefix(ref, ociScheme)
+	i := strings.Index(trimmed, "/")
+	if i <= 0 {
+		return OCIReference{}, invalid
+	}
+	registry, repository := trimmed[:i], trimmed[i+1:]
+	reference := "latest"
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, reference = repository[:i], repository[i+1:]
+		if !ociDigestRe.MatchString(reference) {
+			return OCIReference{}, errors.Errorf("invalid digest %q in OCI reference %q", reference, ref)
+		}
+	} else if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository, reference = repository[:i], repository[i+1:]
+		if !ociTagRe.MatchString(reference) {
+			return OCIReference{}, errors.Errorf("invalid tag %q in OCI reference %q", reference, ref)
+		}
+	}
+	if !ociRepositoryRe.MatchString(repository) {
+		return OCIReference{}, invalid
+	}
+	return OCIReference{Registry: registry, Repository: repository, Reference: reference}, nil
+}
+
+func (r OCIReference) String() string {
+	separator := ":"
+	if strings.Contains(r.Reference, ":") {
+		separator = "@"
+	}
+	return ociScheme + r.Registry + "/" + r.Repository + separator + r.Reference
+}
+
+// apiHost returns the host that serves the registry API, which differs from
+// the registry's name for Docker Hub
+func (r OCIReference) apiHost() string {
+	if r.Registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return r.Registry
+}
+
+// OCILoader loads CRDs from the YAML layers of an OCI artifact, e.g. one
+// pushed with `oras push`. Layers are YAML if their media type is, or if
+// their file name ends with `.yaml` or `.yml`. It authenticates with the
+// credentials of the registry in the Docker config, if there are any.
+type OCILoader struct {
+	Reference OCIReference
+	// Client is the HTTP client to pull the artifact with. Defaults to
+	// http.DefaultClient.
+	Client *http.Client
+	// Cache is the cache of the pulled manifest and layers, if any
+	Cache *Cache
+	// Embedded also loads the CRDs embedded in ConfigMaps
+	Embedded bool
+}
+
+// ociDescriptor describes a manifest or a layer
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+func (l OCILoader) Load() ([]unstruct.Unstructured, error) {
+	p := ociPuller{reference: l.Reference, client: l.Client, cache: l.Cache}
+	if p.client == nil {
+		p.client = http.DefaultClient
+	}
+
+	manifestData, mediaType, err := p.get("manifests/"+l.Reference.Reference,
+		ociManifestMediaType, dockerManifestMediaType, ociIndexMediaType, dockerListMediaType)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "could not parse the manifest of %s", l.Reference)
+	}
+	if manifest.MediaType != "" {
+		mediaType = manifest.MediaType
+	}
+	switch mediaType {
+	case ociManifestMediaType, dockerManifestMediaType:
+	case ociIndexMediaType, dockerListMediaType:
+		return nil, errors.Errorf("%s is an image index, not a single artifact; reference one of its manifests by digest", l.Reference)
+	default:
+		return nil, errors.Errorf("unsupported manifest media type %q of %s", mediaType, l.Reference)
+	}
+
+	var yamlFiles [][]byte
+	var mediaTypes []string
+	for _, layer := range manifest.Layers {
+		if !isYAMLLayer(layer) {
+			mediaTypes = appendMissing(mediaTypes, layer.MediaType)
+			continue
+		}
+		data, _, err := p.get("blobs/" + layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyDigest(data, layer.Digest); err != nil {
+			return nil, errors.Wrapf(err, "layer %s of %s", layer.Digest, l.Reference)
+		}
+		yamlFiles = append(yamlFiles, data)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, errors.Errorf("%s has no layers", l.Reference)
+	}
+	if len(yamlFiles) == 0 {
+		return nil, errors.Errorf("%s has no YAML layers, only layers of media type(s) %s", l.Reference, strings.Join(mediaTypes, ", "))
+	}
+
+	crds, err := unmarshalYamls(yamlFiles, l.Embedded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal the YAML layers of %s", l.Reference)
+	}
+	return setSource(crds, l.Reference.String()), nil
+}
+
+func isYAMLLayer(layer ociDescriptor) bool {
+	if contains(ociYAMLMediaTypes, layer.MediaType) {
+		return true
+	}
+	title := strings.ToLower(layer.Annotations[ociTitleAnnotation])
+	return strings.HasSuffix(title, ".yaml") || strings.HasSuffix(title, ".yml")
+}
+
+// verifyDigest returns an error if the data doesn't match the digest. Only
+// SHA-256 digests, which registries use, can be verified.
+func verifyDigest(data []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != strings.TrimPrefix(digest, "sha256:") {
+		return errors.New("the content doesn't match its digest")
+	}
+	return nil
+}
+
+// ociPuller gets the manifests and blobs of a repository, authenticating
+// once the registry asks it to
+type ociPuller struct {
+	reference OCIReference
+	client    *http.Client
+	cache     *Cache
+	// authorization is the `Authorization` header that the registry accepted
+	authorization string
+}
+
+// get returns the content, and its media type, at the path of the
+// repository's API, e.g. `manifests/latest`, from the cache if it's fresh
+// there.
+func (p *ociPuller) get(path string, accept ...string) ([]byte, string, error) {
+	u := "https://" + p.reference.apiHost() + "/v2/" + p.reference.Repository + "/" + path
+	data, mediaType, err := p.cache.get(u, func(header http.Header) (*http.Response, error) {
+		return p.fetch(u, accept, header)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mediaType, nil
+}
+
+// fetch returns the response of the registry to a request with the given
+// headers, if its status is OK or Not Modified.
+func (p *ociPuller) fetch(u string, accept []string, header http.Header) (*http.Response, error) {
+	resp, err := p.do(u, accept, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && p.authorization == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if p.authorization, err = p.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = p.do(u, accept, header); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotModified:
+		return resp, nil
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, p.authError(resp.Status)
+	case http.StatusNotFound:
+		return nil, errors.Errorf("could not find %s in the registry", p.reference)
+	default:
+		return nil, errors.Errorf("could not pull %s: %s", p.reference, resp.Status)
+	}
+}
+
+func (p *ociPuller) do(u string, accept []string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+	for _, mediaType := range accept {
+		req.Header.Add("Accept", mediaType)
+	}
+	if p.authorization != "" {
+		req.Header.Set("Authorization", p.authorization)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to registry %s", p.reference.Registry)
+	}
+	return resp, nil
+}
+
+// authenticate returns the `Authorization` header that answers the
+// registry's `WWW-Authenticate` challenge, for the credentials of the registry
+// in the Docker config. Bearer challenges are exchanged for a token.
+func (p *ociPuller) authenticate(challenge string) (string, error) {
+	username, password, err := dockerCredentials(p.reference.Registry)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return "", p.authError("no credentials")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+	default:
+		return "", errors.Errorf("registry %s asks for unsupported authentication %q", p.reference.Registry, challenge)
+	}
+
+	params := map[string]string{}
+	for _, match := range bearerParamRe.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	if params["realm"] == "" {
+		return "", errors.Errorf("registry %s asks for a token without a realm to get it from", p.reference.Registry)
+	}
+	// The realm can be on any host, so the credentials are only sent to it
+	// over TLS
+	if realm, err := url.Parse(params["realm"]); err != nil || realm.Scheme != "https" {
+		return "", errors.Errorf("registry %s asks for a token from %q, which isn't an https URL", p.reference.Registry, params["realm"])
+	}
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + p.reference.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequest("GET", params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid token realm of registry %s", p.reference.Registry)
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not get a token for registry %s", p.reference.Registry)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", p.authError(resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", errors.Wrapf(err, "could not parse the token of registry %s", p.reference.Registry)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+func (p *ociPuller) authError(reason string) error {
+	return errors.Errorf("could not authenticate to registry %s to pull %s (%s); log in with `docker login %s`",
+		p.reference.Registry, p.reference, reason, p.reference.Registry)
+}
+
+// dockerConfig is the part of the Docker CLI config that stores credentials
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// dockerCredentials returns the username and password of the registry from
+// the Docker config at `$DOCKER_CONFIG/config.json`, or
+// `~/.docker/config.json`, and its credential helpers. Returns empty
+// credentials if there are none.
+func dockerCredentials(registry string) (string, string, error) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		configDir = filepath.Join(home, ".docker")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(configDir, "config.json"))
+	if os.IsNotExist(err) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", errors.Wrap(err, "could not read the Docker config")
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", "", errors.Wrap(err, "could not parse the Docker config")
+	}
+
+	// Docker Hub's credentials are stored under its legacy index URL
+	serverURL := registry
+	if registry == "docker.io" {
+		serverURL = "https://index.docker.io/v1/"
+	}
+	helper := config.CredsStore
+	if credHelper, ok := config.CredHelpers[serverURL]; ok {
+		helper = credHelper
+	}
+	if helper != "" {
+		return credentialHelperCredentials(helper, serverURL)
+	}
+	for server, auth := range config.Auths {
+		if server != serverURL && strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://") != serverURL {
+			continue
+		}
+		if auth.Auth == "" {
+			return auth.Username, auth.Password, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "invalid credentials of registry %s in the Docker config", registry)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return "", "", errors.Errorf("invalid credentials of registry %s in the Docker config", registry)
+		}
+		return parts[0], parts[1], nil
+	}
+	return "", "", nil
+}
+
+// credentialHelperCredentials returns the credentials of the server from the
+// given `docker-credential-<helper>`.
+func credentialHelperCredentials(helper, serverURL string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// Helpers report missing credentials on stdout, and fail
+		if strings.Contains(string(output), "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", errors.Wrapf(err, "could not get the credentials of %s from docker-credential-%s: %s",
+			serverURL, helper, strings.TrimSpace(stderr.String()+string(output)))
+	}
+	var credentials struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(output, &credentials); err != nil {
+		return "", "", errors.Wrapf(err, "could not parse the output of docker-credential-%s", helper)
+	}
+	return credentials.Username, credentials.Secret, nil
+}
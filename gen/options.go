@@ -0,0 +1,747 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// ObjectMetaSource is where the generated SDKs get the ObjectMeta type from.
+type ObjectMetaSource int
+
+const (
+	// KubernetesObjectMeta imports the ObjectMeta type from the Kubernetes SDK
+	KubernetesObjectMeta ObjectMetaSource = iota
+	// PlaceholderObjectMeta generates a placeholder ObjectMeta type in the SDK
+	// itself, so that it doesn't depend on the Kubernetes SDK. Only supported
+	// for NodeJS and Python.
+	PlaceholderObjectMeta
+)
+
+// GenerateOptions configures GenerateWithOptions. It's built from Options, so
+// that new settings can be added without breaking library consumers.
+type GenerateOptions struct {
+	LanguageSettings
+	// Force overwrites the existing output paths.
+	Force bool
+	// Strict fails generation instead of warning about code that can't be
+	// formatted, about CRDs without a structural schema, whose types can't be
+	// generated faithfully, about CRDs whose root schema isn't an object, and
+	// about CRD versions whose schemas differ across the inputs.
+	Strict bool
+	// FailOnEmpty fails generation if no resources would be generated, e.g.
+	// because the inputs contain no CRDs, instead of generating an empty SDK.
+	// It's set by default.
+	FailOnEmpty bool
+
+	// errs are the errors of invalid Options, reported by GenerateWithOptions
+	errs []error
+}
+
+// Option sets a GenerateOptions setting.
+type Option func(*GenerateOptions)
+
+// NewGenerateOptions returns the GenerateOptions with the given Options
+// applied in order, or the first error of an invalid Option.
+func NewGenerateOptions(opts ...Option) (GenerateOptions, error) {
+	options := GenerateOptions{
+		LanguageSettings: LanguageSettings{
+			NodeJSName: DefaultName,
+			PythonName: DefaultName,
+			DotNetName: DefaultName,
+			GoName:     DefaultName,
+		},
+		FailOnEmpty: true,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.errs) > 0 {
+		return GenerateOptions{}, options.errs[0]
+	}
+	return options, nil
+}
+
+// WithLanguageSettings replaces the LanguageSettings, e.g. with the ones
+// parsed from the CLI flags. Options after it modify the given settings.
+func WithLanguageSettings(ls LanguageSettings) Option {
+	return func(options *GenerateOptions) {
+		options.LanguageSettings = ls
+	}
+}
+
+// WithLanguages generates each language of the map, i.e. NodeJS, Python,
+// DotNet, or Go, to its output directory. Languages that aren't in the map
+// aren't changed.
+func WithLanguages(outputPaths map[string]string) Option {
+	return func(options *GenerateOptions) {
+		languages := make([]string, 0, len(outputPaths))
+		for language := range outputPaths {
+			languages = append(languages, language)
+		}
+		sort.Strings(languages)
+		for _, language := range languages {
+			outputPath := outputPaths[language]
+			switch language {
+			case NodeJS:
+				options.NodeJSPath = &outputPath
+			case Python:
+				options.PythonPath = &outputPath
+			case DotNet:
+				options.DotNetPath = &outputPath
+			case Go:
+				options.GoPath = &outputPath
+			default:
+				options.errs = append(options.errs, errors.Errorf("unsupported language %q", language))
+			}
+		}
+	}
+}
+
+// WithPackageName sets the name of the generated package in every language.
+func WithPackageName(name string) Option {
+	return func(options *GenerateOptions) {
+		options.NodeJSName = name
+		options.PythonName = name
+		options.DotNetName = name
+		options.GoName = name
+	}
+}
+
+// WithStrict sets GenerateOptions.Strict.
+func WithStrict(strict bool) Option {
+	return func(options *GenerateOptions) {
+		options.Strict = strict
+	}
+}
+
+// WithForce sets GenerateOptions.Force.
+func WithForce(force bool) Option {
+	return func(options *GenerateOptions) {
+		options.Force = force
+	}
+}
+
+// WithFailOnEmpty sets GenerateOptions.FailOnEmpty.
+func WithFailOnEmpty(failOnEmpty bool) Option {
+	return func(options *GenerateOptions) {
+		options.FailOnEmpty = failOnEmpty
+	}
+}
+
+// WithObjectMeta sets where the generated SDKs get the ObjectMeta type from.
+func WithObjectMeta(source ObjectMetaSource) Option {
+	return func(options *GenerateOptions) {
+		switch source {
+		case KubernetesObjectMeta:
+			options.KeepPlaceholderMeta = false
+		case PlaceholderObjectMeta:
+			options.KeepPlaceholderMeta = true
+		default:
+			options.errs = append(options.errs, errors.Errorf("unsupported ObjectMeta source %d", source))
+		}
+	}
+}
+
+// WithObjectMetaFrom imports the ObjectMeta type from existing Kubernetes SDKs,
+// given as `<language>=<name>@<version>` specs that ParseObjectMetaPackage
+// parses, e.g. `nodejs=@myorg/kubernetes@^3.0.0`.
+func WithObjectMetaFrom(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			language, pkg, err := ParseObjectMetaPackage(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if _, ok := options.ObjectMetaPackages[language]; ok {
+				options.errs = append(options.errs, errors.Errorf("the ObjectMeta package for %s is set more than once", language))
+				continue
+			}
+			if options.ObjectMetaPackages == nil {
+				options.ObjectMetaPackages = map[string]ObjectMetaPackage{}
+			}
+			options.ObjectMetaPackages[language] = pkg
+		}
+	}
+}
+
+// WithRenames renames the resource types of CRDs, given as
+// `<group>/<Kind>=<NewName>` specs that ParseRename parses, e.g.
+// `stable.example.com/CronTab=ScheduledJob`.
+func WithRenames(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			groupKind, name, err := ParseRename(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if _, ok := options.Renames[groupKind]; ok {
+				options.errs = append(options.errs, errors.Errorf("%s is renamed more than once", groupKind))
+				continue
+			}
+			if options.Renames == nil {
+				options.Renames = map[string]string{}
+			}
+			options.Renames[groupKind] = name
+		}
+	}
+}
+
+// WithMethods adds placeholder methods to the resources of CRDs, given as
+// `<group>/<Kind>=<method>` specs that ParseMethod parses, e.g.
+// `stable.example.com/CronTab=trigger`.
+func WithMethods(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			groupKind, name, err := ParseMethod(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if contains(options.Methods[groupKind], name) {
+				options.errs = append(options.errs, errors.Errorf("the method %s of %s is added more than once", name, groupKind))
+				continue
+			}
+			if options.Methods == nil {
+				options.Methods = map[string][]string{}
+			}
+			options.Methods[groupKind] = append(options.Methods[groupKind], name)
+		}
+	}
+}
+
+// WithPartialSchemas generates types from bare OpenAPI schemas, given as
+// `<group>/<version>:<Name>=<path>` specs that ParsePartialSchema parses, e.g.
+// `stable.example.com/v1:CronTabSpec=spec.yaml`.
+func WithPartialSchemas(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			token, path, err := ParsePartialSchema(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if _, ok := options.PartialSchemas[token]; ok {
+				options.errs = append(options.errs, errors.Errorf("the partial schema %s is given more than once", token))
+				continue
+			}
+			if options.PartialSchemas == nil {
+				options.PartialSchemas = map[string]string{}
+			}
+			options.PartialSchemas[token] = path
+		}
+	}
+}
+
+// WithLanguageOptions sets options of the Pulumi code generators, given as
+// `<language>:<key>=<value>` specs that ParseLanguageOption parses, e.g.
+// `nodejs:typescriptVersion=4.9`.
+func WithLanguageOptions(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			language, key, value, err := ParseLanguageOption(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if options.LanguageOptions == nil {
+				options.LanguageOptions = map[string]map[string]interface{}{}
+			}
+			if options.LanguageOptions[language] == nil {
+				options.LanguageOptions[language] = map[string]interface{}{}
+			}
+			options.LanguageOptions[language][key] = value
+		}
+	}
+}
+
+// WithOverlayTemplates replaces the default templates of overlays with the
+// template files of the given `<language>:<overlay>=<path>` specs, which
+// ParseOverlayTemplate parses, e.g. `python:utilities=utilities.py.tmpl`.
+func WithOverlayTemplates(specs ...string) Option {
+	return func(options *GenerateOptions) {
+		for _, spec := range specs {
+			language, overlay, path, err := ParseOverlayTemplate(spec)
+			if err != nil {
+				options.errs = append(options.errs, err)
+				continue
+			}
+			if _, ok := options.OverlayTemplates[language][overlay]; ok {
+				options.errs = append(options.errs, errors.Errorf("the template of the %s %s overlay is set more than once", language, overlay))
+				continue
+			}
+			text, err := ioutil.ReadFile(path)
+			if err != nil {
+				options.errs = append(options.errs, errors.Wrapf(err, "could not read overlay template %s", path))
+				continue
+			}
+			if options.OverlayTemplates == nil {
+				options.OverlayTemplates = map[string]map[string]string{}
+			}
+			if options.OverlayTemplates[language] == nil {
+				options.OverlayTemplates[language] = map[string]string{}
+			}
+			options.OverlayTemplates[language][overlay] = string(text)
+		}
+	}
+}
+
+// GenerateWithOptions parses the CRDs from the given loader and outputs the
+// generated code according to the Options. If LanguageSettings.MetricsPath is
+// set, then the GenerationStats of the run are written to it, even if it fails.
+func GenerateWithOptions(loader SchemaLoader, opts ...Option) error {
+	options, err := NewGenerateOptions(opts...)
+	if err != nil {
+		return err
+	}
+	if options.MetricsPath == nil {
+		return generateWithOptions(loader, options, &GenerationStats{})
+	}
+
+	// The metrics are written even if the generation fails, to record it
+	start := time.Now()
+	var stats GenerationStats
+	err = generateWithOptions(loader, options, &stats)
+	stats.Timestamp = time.Now()
+	stats.Duration = stats.Timestamp.Sub(start)
+	stats.Succeeded = err == nil
+	if metricsErr := stats.writeMetrics(*options.MetricsPath); metricsErr != nil && err == nil {
+		return metricsErr
+	}
+	return err
+}
+
+// generateWithOptions generates the code like GenerateWithOptions, and sets
+// the statistics of the package once its types are final.
+func generateWithOptions(loader SchemaLoader, options GenerateOptions, stats *GenerationStats) error {
+	ls := options.LanguageSettings
+
+	if !options.Force {
+		_, paths := ls.hasExistingPaths()
+		if ls.OnlyLanguagesChanged {
+			// The outputs of languages with a manifest are regenerated if
+			// they're stale, and skipped otherwise
+			languagePaths := map[string]bool{}
+			for _, path := range ls.languagePaths() {
+				languagePaths[path] = true
+			}
+			var unmanaged []string
+			for _, path := range paths {
+				if !languagePaths[path] || !hasLanguageManifest(path) {
+					unmanaged = append(unmanaged, path)
+				}
+			}
+			paths = unmanaged
+		}
+		if len(paths) > 0 {
+			return errors.Errorf("path(s) %s already exists; use --force to overwrite", paths)
+		}
+	}
+
+	// The version is written as is to package.json and setup.py, so it must
+	// be strict semver, e.g. 1.2.0 rather than v1.2
+	if ls.PackageVersion != "" {
+		if _, err := semver.Parse(ls.PackageVersion); err != nil {
+			return errors.Wrapf(err, "invalid package version %q", ls.PackageVersion)
+		}
+	}
+	schemaVersion, err := parseSchemaVersion(ls.SchemaVersion)
+	if err != nil {
+		return err
+	}
+	if ls.KeepPlaceholderMeta && (ls.GoPath != nil || ls.DotNetPath != nil) {
+		return errors.New("the placeholder ObjectMeta type can only be kept for NodeJS and Python")
+	}
+	if ls.ChangelogPath != nil && ls.ChangelogBase == "" {
+		return errors.New("the changelog needs a base to diff the generated package against")
+	}
+	if ls.ImportFilePath != nil && len(ls.ImportManifests) == 0 {
+		return errors.New("the import file needs the manifests of the existing resources to import")
+	}
+	if err := ls.validatePackageNames(); err != nil {
+		return err
+	}
+	if err := ls.validateObjectMetaPackages(); err != nil {
+		return err
+	}
+	if err := ls.validateLanguageOptions(); err != nil {
+		return err
+	}
+	if err := ls.validateOwnerReferenceHelpers(); err != nil {
+		return err
+	}
+	if err := ls.validateCRDResources(); err != nil {
+		return err
+	}
+	if err := ls.validateOverlayTemplates(); err != nil {
+		return err
+	}
+	if ls.UnknownTypes != "" {
+		if _, err := ParseUnknownTypePolicy(string(ls.UnknownTypes)); err != nil {
+			return err
+		}
+	}
+	if ls.EnumCollisions != "" {
+		if _, err := ParseEnumCollisionPolicy(string(ls.EnumCollisions)); err != nil {
+			return err
+		}
+	}
+	if err := validateDocLinkTemplate(ls.ResourceDocLinks); err != nil {
+		return err
+	}
+	versionSelection := ls.VersionSelection
+	if versionSelection == "" {
+		versionSelection = ConversionVersions
+	}
+	if _, err := ParseVersionSelection(string(versionSelection)); err != nil {
+		return err
+	}
+
+	crds, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	for _, notice := range embeddedNotices(crds) {
+		fmt.Fprintf(os.Stderr, "notice: %s\n", notice)
+	}
+	if len(crds) == 0 && len(ls.PartialSchemas) == 0 && options.FailOnEmpty {
+		return errEmpty("the inputs contain no CRDs")
+	}
+	pg, err := newPackageGeneratorFromCRDs(crds)
+	if err != nil {
+		return err
+	}
+	// Skewed schemas are inconsistencies of the inputs, so they're reported
+	// whether or not their versions are selected
+	for _, skew := range FindSchemaSkews(pg.CustomResourceGenerators) {
+		if options.Strict {
+			return errors.New(skew.String())
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", skew)
+	}
+	// The versions are selected first, so that the checks and transforms
+	// only see the generated ones
+	if err := pg.SelectVersions(versionSelection); err != nil {
+		return err
+	}
+	if err := pg.RenameResources(ls.Renames); err != nil {
+		return err
+	}
+	// The types of the partial schemas are added before the types are
+	// transformed, so that they're transformed too
+	partialTokens := make([]string, 0, len(ls.PartialSchemas))
+	for token := range ls.PartialSchemas {
+		partialTokens = append(partialTokens, token)
+	}
+	sort.Strings(partialTokens)
+	for _, token := range partialTokens {
+		schema, err := LoadPartialSchema(ls.PartialSchemas[token])
+		if err != nil {
+			return err
+		}
+		if err := pg.AddPartialSchema(token, schema); err != nil {
+			return err
+		}
+	}
+	if len(partialTokens) > 0 && ls.GeneratesAtLeastOneLanguage() {
+		warning := fmt.Sprintf("the language SDKs only generate the types of resources, so the types of the partial schemas %s "+
+			"are only in the type declarations, the JSON schemas, the Protobuf files and the YAML reference", strings.Join(partialTokens, ", "))
+		if options.Strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if options.Strict {
+		for _, crg := range pg.CustomResourceGenerators {
+			if !crg.IsStructural() {
+				return errors.Errorf("%s.%s does not have a structural schema", crg.Plural, crg.Group)
+			}
+		}
+	}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.UntypedVersions() {
+			warning := fmt.Sprintf("the schema of %s %s isn't an object with properties, so its spec and status are untyped", crg.Kind, version)
+			if contains(crg.SchemalessVersions, version) {
+				warning = fmt.Sprintf("%s %s has no schema and its CRD sets spec.preserveUnknownFields, so its spec and status are untyped", crg.Kind, version)
+			}
+			if options.Strict {
+				return errors.New(warning)
+			}
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			if constraints := crg.MetadataConstraints(version); len(constraints) > 0 {
+				warning := fmt.Sprintf("the schema of %s %s constrains its metadata, which the ObjectMeta type doesn't enforce, so the constraints are only documented: %s",
+					crg.Kind, version, strings.Join(constraints, " "))
+				if options.Strict {
+					return errors.New(warning)
+				}
+				fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+			}
+		}
+	}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			for _, mistake := range crg.MalformedSchemas(version) {
+				warning := fmt.Sprintf("the schema of %s %s is malformed: %s", crg.Kind, version, mistake)
+				if options.Strict {
+					return errors.New(warning)
+				}
+				fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+			}
+		}
+	}
+	if ls.UnknownTypes != "" && ls.UnknownTypes != UnknownTypeAny {
+		if err := pg.SetUnknownTypePolicy(ls.UnknownTypes); err != nil {
+			return err
+		}
+	}
+	if ls.MaxDepth != 0 {
+		if err := pg.SetMaxDepth(ls.MaxDepth); err != nil {
+			return err
+		}
+	}
+	for _, name := range pg.TruncatedTypes() {
+		warning := fmt.Sprintf("the schema of %s is nested deeper than %d levels, so it's typed as any", name, pg.MaxDepth())
+		if options.Strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if ls.EnumCollisions != "" && ls.EnumCollisions != EnumCollisionSuffix {
+		if err := pg.SetEnumCollisionPolicy(ls.EnumCollisions); err != nil {
+			return err
+		}
+	}
+	for _, warning := range pg.RenamedEnumValues() {
+		if options.Strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if ls.ObjectMetaRequired {
+		pg.RequireObjectMeta()
+	}
+	if ls.ExcludeStatus {
+		pg.ExcludeStatus()
+	}
+	if ls.RootPath != "" {
+		if err := pg.TrimToRootPath(ls.RootPath); err != nil {
+			return err
+		}
+	}
+	// Only the types that are still generated are checked
+	for _, warning := range pg.ReservedPropertyWarnings(ls.languages()...) {
+		if options.Strict {
+			return errors.New(warning)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if len(ls.ImmutablePaths) > 0 || ls.DetectImmutable {
+		if err := pg.AddReplaceOnChanges(ls.ImmutablePaths, ls.DetectImmutable); err != nil {
+			return err
+		}
+	}
+	if err := pg.AddMethods(ls.Methods); err != nil {
+		return err
+	}
+	if ls.StripKubebuilderMarkers {
+		pg.StripKubebuilderMarkers()
+	}
+	pg.DocumentImportIDs()
+	if ls.PrinterColumns {
+		pg.DocumentPrinterColumns()
+	}
+	if ls.ResourceDocLinks != "" {
+		if err := pg.AddResourceDocLinks(ls.ResourceDocLinks); err != nil {
+			return err
+		}
+	}
+	if ls.AwaitAnnotations {
+		pg.DocumentAwaitAnnotations()
+	}
+	if ls.OmitDefaults {
+		pg.RemoveDefaults()
+	}
+	// The descriptions are removed last, after the options that document
+	// the resources
+	if ls.ExcludeDescriptions {
+		pg.RemoveDescriptions()
+	}
+	if ls.AnyTypeRef != "" {
+		if err := pg.SetAnyTypeRef(ls.AnyTypeRef); err != nil {
+			return err
+		}
+	}
+	var compactTypeNames []CompactTypeName
+	if ls.CompactNamesPath != nil {
+		compactTypeNames = pg.CompactTypeNames()
+	}
+	// The schema is merged last, since its types were already transformed
+	// by the run that wrote them, for the same schema version
+	pg.schemaVersion = schemaVersion
+	if ls.MergeSchemaPath != nil {
+		if _, err := os.Stat(*ls.MergeSchemaPath); err == nil {
+			spec, err := ReadSchema(*ls.MergeSchemaPath)
+			if err != nil {
+				return err
+			}
+			if err := pg.MergeSchema(spec); err != nil {
+				return errors.Wrapf(err, "could not merge schema %s", *ls.MergeSchemaPath)
+			}
+		}
+	}
+	if len(pg.ResourceTokens) == 0 && len(ls.PartialSchemas) == 0 && options.FailOnEmpty {
+		return errEmpty("no resources were generated")
+	}
+	*stats = pg.Stats()
+	pg.format = ls.Format
+	pg.strict = options.Strict
+	pg.keepPlaceholderMeta = ls.KeepPlaceholderMeta
+	pg.objectMetaPackages = ls.ObjectMetaPackages
+	pg.languageOptions = ls.LanguageOptions
+	pg.topLevelModule = ls.TopLevelModule
+	pg.groupPrefixStrip = ls.GroupPrefixStrip
+	pg.overlayTemplates = ls.OverlayTemplates
+	pg.packageVersion = ls.PackageVersion
+	pg.pythonDistributionName = ls.PythonDistributionName
+	pg.dotNetAssemblyName = ls.DotNetAssemblyName
+	pg.dotNetNullable = ls.DotNetNullable
+	pg.goPackageName = ls.GoPackageName
+	pg.schemaPropertyOrder = ls.SchemaPropertyOrder
+	pg.annotateSource = ls.AnnotateSource
+	pg.compactJSON = ls.CompactJSON
+	pg.testStubs = ls.TestStubs
+	pg.awaitAnnotations = ls.AwaitAnnotations
+	pg.ownerReferenceHelpers = ls.OwnerReferenceHelpers
+	pg.crdResources = ls.CRDResources
+	pg.goUtilityHelpers = ls.GoUtilityHelpers
+	pg.sdkVersionFile = ls.SDKVersionFile
+
+	// The languages whose output is current are skipped, unless forced
+	fingerprints, current := map[string]string{}, map[string]bool{}
+	if ls.OnlyLanguagesChanged {
+		for language, outputDir := range ls.languagePaths() {
+			fingerprint, err := pg.languageFingerprint(ls, language)
+			if err != nil {
+				return err
+			}
+			fingerprints[language] = fingerprint
+			current[language] = !options.Force && isLanguageCurrent(language, outputDir, fingerprint)
+		}
+	}
+	if ls.NodeJSPath != nil && !current[NodeJS] {
+		if err := pg.genNodeJS(*ls.NodeJSPath, ls.NodeJSName, ls.NodeJSScope, ls.NodeJSBarrel); err != nil {
+			return err
+		}
+	}
+	if ls.PythonPath != nil && !current[Python] {
+		if err := pg.genPython(*ls.PythonPath, ls.PythonName, ls.PythonImportCheck); err != nil {
+			return err
+		}
+	}
+	if ls.GoPath != nil && !current[Go] {
+		if err := pg.genGo(*ls.GoPath, ls.GoName, ls.GoClientHelpers, ls.GoDryRunCompile); err != nil {
+			return err
+		}
+	}
+	if ls.DotNetPath != nil && !current[DotNet] {
+		if err := pg.genDotNet(*ls.DotNetPath, ls.DotNetName); err != nil {
+			return err
+		}
+	}
+	if ls.OnlyLanguagesChanged {
+		for language, outputDir := range ls.languagePaths() {
+			if current[language] {
+				continue
+			}
+			if err := writeLanguageManifest(language, outputDir, fingerprints[language]); err != nil {
+				return err
+			}
+		}
+	}
+	if ls.ExampleManifestPath != nil {
+		if err := pg.genExampleManifest(*ls.ExampleManifestPath); err != nil {
+			return err
+		}
+	}
+	if ls.JSONSchemaPath != nil {
+		if err := pg.genJSONSchemas(*ls.JSONSchemaPath); err != nil {
+			return err
+		}
+	}
+	if ls.ProtoPath != nil {
+		if err := pg.genProto(*ls.ProtoPath); err != nil {
+			return err
+		}
+	}
+	if ls.TypeDeclarationsPath != nil {
+		if err := pg.genTypeDeclarations(*ls.TypeDeclarationsPath); err != nil {
+			return err
+		}
+	}
+	if ls.YAMLReferencePath != nil {
+		if err := pg.genYAMLReference(*ls.YAMLReferencePath); err != nil {
+			return err
+		}
+	}
+	if ls.ImportFilePath != nil {
+		if err := pg.writeImportFile(*ls.ImportFilePath, ls.ImportManifests); err != nil {
+			return err
+		}
+	}
+	if ls.MergeSchemaPath != nil {
+		if err := pg.writeSchema(*ls.MergeSchemaPath); err != nil {
+			return err
+		}
+	}
+	if ls.AnyTypesReportPath != nil {
+		if err := pg.writeAnyTypePaths(*ls.AnyTypesReportPath); err != nil {
+			return err
+		}
+	}
+	if ls.CompactNamesPath != nil {
+		if err := pg.writeCompactTypeNames(*ls.CompactNamesPath, compactTypeNames); err != nil {
+			return err
+		}
+	}
+	if ls.ChangelogPath != nil {
+		if err := pg.writeChangelog(*ls.ChangelogPath, ls.ChangelogBase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errEmpty returns the error of a generation that would output an empty SDK,
+// with the likely causes.
+func errEmpty(reason string) error {
+	return errors.Errorf("%s, so the SDK would be empty: check that the files and glob patterns match "+
+		"the CRD manifests, that the URLs and OCI artifacts point to CRDs, and that the CRDs aren't all "+
+		"excluded; use --fail-on-empty=false to generate an empty SDK anyway", reason)
+}
@@ -0,0 +1,229 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v3"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PropertyOrderKey is the schema extension that records the order that the
+// schema's properties are declared in, since unstructured maps lose it. It's
+// added to every schema with properties of the CRDs read by UnmarshalYamls.
+const PropertyOrderKey = "x-crd2pulumi-property-order"
+
+// schemaListKeys are the schema keywords whose values are lists of schemas
+var schemaListKeys = []string{"allOf", "anyOf", "oneOf"}
+
+// schemaMapKeys are the schema keywords whose values are maps of schemas
+var schemaMapKeys = []string{"properties", "patternProperties", "definitions", "$defs"}
+
+// annotatePropertyOrder adds the PropertyOrderKey to every schema with
+// properties of the given CRDs, by parsing the YAML documents they were read
+// from again while keeping the order of the keys. CRDs are matched to their
+// documents by name, and are left as-is if the documents can't be parsed.
+func annotatePropertyOrder(yamlFile []byte, crds []unstruct.Unstructured) {
+	crdsByName := map[string]unstruct.Unstructured{}
+	for _, crd := range crds {
+		crdsByName[crd.GetName()] = crd
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(yamlFile))
+	for {
+		var document yaml.Node
+		if err := dec.Decode(&document); err != nil {
+			if err != io.EOF {
+				return
+			}
+			break
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		root := document.Content[0]
+		crd, ok := crdsByName[yamlScalarAt(root, "metadata", "name")]
+		if !ok || yamlScalarAt(root, "kind") != CRD {
+			continue
+		}
+
+		// The schemas are annotated in place, so they mustn't be copied
+		if schema, _, _ := unstruct.NestedFieldNoCopy(crd.Object, "spec", "validation", "openAPIV3Schema"); schema != nil {
+			if schema, ok := schema.(map[string]interface{}); ok {
+				annotateSchema(yamlNodeAt(root, "spec", "validation", "openAPIV3Schema"), schema)
+			}
+		}
+		versionInfos, _, _ := unstruct.NestedFieldNoCopy(crd.Object, "spec", "versions")
+		versionNodes := yamlNodeAt(root, "spec", "versions")
+		if versionInfos, ok := versionInfos.([]interface{}); ok && versionNodes != nil && versionNodes.Kind == yaml.SequenceNode {
+			for i, versionInfo := range versionInfos {
+				versionInfo, ok := versionInfo.(map[string]interface{})
+				if !ok || i >= len(versionNodes.Content) {
+					continue
+				}
+				schema, _, _ := unstruct.NestedFieldNoCopy(versionInfo, "schema", "openAPIV3Schema")
+				if schema, ok := schema.(map[string]interface{}); ok {
+					annotateSchema(yamlNodeAt(versionNodes.Content[i], "schema", "openAPIV3Schema"), schema)
+				}
+			}
+		}
+	}
+}
+
+// annotateSchema adds the PropertyOrderKey to the schema and its subschemas,
+// in place, from the YAML node that the schema was decoded from.
+func annotateSchema(node *yaml.Node, schema map[string]interface{}) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if propertiesNode := yamlNodeAt(node, "properties"); propertiesNode != nil && propertiesNode.Kind == yaml.MappingNode {
+		order := make([]interface{}, 0, len(propertiesNode.Content)/2)
+		for i := 0; i+1 < len(propertiesNode.Content); i += 2 {
+			order = append(order, propertiesNode.Content[i].Value)
+		}
+		schema[PropertyOrderKey] = order
+	}
+
+	for _, key := range schemaMapKeys {
+		schemas, _ := schema[key].(map[string]interface{})
+		for name, subschema := range schemas {
+			if subschema, ok := subschema.(map[string]interface{}); ok {
+				annotateSchema(yamlNodeAt(node, key, name), subschema)
+			}
+		}
+	}
+	for _, key := range schemaListKeys {
+		schemas, _ := schema[key].([]interface{})
+		listNode := resolveAlias(yamlNodeAt(node, key))
+		for i, subschema := range schemas {
+			if subschema, ok := subschema.(map[string]interface{}); ok && listNode != nil && i < len(listNode.Content) {
+				annotateSchema(listNode.Content[i], subschema)
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if subschema, ok := schema[key].(map[string]interface{}); ok {
+			annotateSchema(yamlNodeAt(node, key), subschema)
+		}
+	}
+}
+
+// yamlNodeAt returns the node at the given path of mapping keys, or nil if
+// there's none.
+func yamlNodeAt(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		node = resolveAlias(node)
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				value = node.Content[i+1]
+			}
+		}
+		node = value
+	}
+	return resolveAlias(node)
+}
+
+// yamlScalarAt returns the value of the scalar node at the given path of
+// mapping keys, or "" if there's none.
+func yamlScalarAt(node *yaml.Node, keys ...string) string {
+	if node = yamlNodeAt(node, keys...); node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// orderProperties returns the names of the properties in the order that the
+// schema declares them in if inSchemaOrder is true, and sorted otherwise. The
+// properties that the schema doesn't record the order of come last, sorted.
+func orderProperties(schema map[string]interface{}, names []string, inSchemaOrder bool) []string {
+	ordered := make([]string, 0, len(names))
+	if inSchemaOrder {
+		order, _ := schema[PropertyOrderKey].([]interface{})
+		for _, name := range order {
+			if name, ok := name.(string); ok && contains(names, name) {
+				ordered = appendMissing(ordered, name)
+			}
+		}
+	}
+	var rest []string
+	for _, name := range names {
+		if !contains(ordered, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(ordered, rest...)
+}
+
+// typeSchemas returns the schema that each object type of the resources was
+// generated from, by token, to order their properties like the schemas
+// without changing the types. A type that several schemas generate, e.g.
+// because a merged schema added it, maps to the first one.
+func (pg *PackageGenerator) typeSchemas() map[string]map[string]interface{} {
+	schemas := map[string]map[string]interface{}{}
+	var visit func(typeSpec pschema.TypeSpec, schema map[string]interface{})
+	visit = func(typeSpec pschema.TypeSpec, schema map[string]interface{}) {
+		if schema == nil {
+			return
+		}
+		if token := strings.TrimPrefix(typeSpec.Ref, "#/types/"); token != typeSpec.Ref {
+			if complexTypeSpec, ok := pg.Types[token]; ok && schemas[token] == nil {
+				schemas[token] = schema
+				for propertyName, propertySpec := range complexTypeSpec.Properties {
+					visit(propertySpec.TypeSpec, propertySchema(schema, propertyName))
+				}
+			}
+		}
+		if typeSpec.Items != nil {
+			items, _ := schema["items"].(map[string]interface{})
+			visit(*typeSpec.Items, items)
+		}
+		if typeSpec.AdditionalProperties != nil {
+			additionalProperties, _ := schema["additionalProperties"].(map[string]interface{})
+			visit(*typeSpec.AdditionalProperties, additionalProperties)
+		}
+		// A partially open object is a union of its object type and a map,
+		// which are both generated from its schema
+		if _, isOneOf := schema["oneOf"]; !isOneOf {
+			for _, oneOf := range typeSpec.OneOf {
+				visit(oneOf, schema)
+			}
+		}
+	}
+	for _, crg := range pg.CustomResourceGenerators {
+		for _, version := range crg.Versions {
+			visit(pschema.TypeSpec{Ref: "#/types/" + getToken(crg.Group, version, crg.TypeName)}, crg.Schemas[version])
+		}
+	}
+	return schemas
+}
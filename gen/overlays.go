@@ -0,0 +1,158 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// The overlays are the files that crd2pulumi adds to, or replaces in, the
+// code that the Pulumi code generators output. They're rendered with
+// text/template from OverlayData, so that their templates can be replaced
+// without changing crd2pulumi.
+const (
+	// MetaOverlay is the module that exports the ObjectMeta types of the
+	// Kubernetes SDK, i.e. `meta/v1.ts` in NodeJS, and the code appended to
+	// `meta/v1/__init__.py` in Python.
+	MetaOverlay = "meta"
+	// UtilitiesOverlay is the `_utilities.py` module in Python, which
+	// delegates to the one of the Kubernetes SDK.
+	UtilitiesOverlay = "utilities"
+)
+
+// defaultOverlayTemplates maps each language to the default template of each
+// of its overlays.
+var defaultOverlayTemplates = map[string]map[string]string{
+	NodeJS: {
+		MetaOverlay: nodejsMetaFile,
+	},
+	Python: {
+		MetaOverlay:      pythonMetaFile,
+		UtilitiesOverlay: pythonUtilitiesFile,
+	},
+}
+
+// OverlayData is the data that the overlay templates are rendered with.
+type OverlayData struct {
+	// PackageName is the name of the generated package, e.g. `crds`.
+	PackageName string
+	// PackageVersion is the version of the generated package.
+	PackageVersion string
+	// KubernetesPackage is the package that the ObjectMeta types are
+	// imported from, i.e. `@pulumi/kubernetes` in NodeJS and
+	// `pulumi_kubernetes` in Python, unless ObjectMetaPackages sets another.
+	KubernetesPackage string
+	// Resources are the generated resources, sorted by their token.
+	Resources []OverlayResource
+}
+
+// OverlayResource is a generated resource of OverlayData.
+type OverlayResource struct {
+	Group   string
+	Version string
+	Kind    string
+	// Token is the Pulumi type token of the resource, e.g.
+	// `kubernetes:stable.example.com/v1:CronTab`.
+	Token string
+}
+
+// ParseOverlayTemplate parses a `<language>:<overlay>=<path>` spec, e.g.
+// `python:utilities=templates/utilities.py.tmpl`, of the template file that
+// replaces the default template of an overlay.
+func ParseOverlayTemplate(spec string) (string, string, string, error) {
+	i, j := strings.Index(spec, ":"), strings.Index(spec, "=")
+	if i <= 0 || j < i || j == len(spec)-1 {
+		return "", "", "", errors.Errorf("invalid overlay template %q, expected <language>:<overlay>=<path>", spec)
+	}
+	language, overlay, path := spec[:i], spec[i+1:j], spec[j+1:]
+	overlays, ok := defaultOverlayTemplates[language]
+	if !ok {
+		return "", "", "", errors.Errorf("unsupported language %q of overlay template %q", language, spec)
+	}
+	if _, ok := overlays[overlay]; !ok {
+		return "", "", "", errors.Errorf("unknown %s overlay %q of overlay template %q", language, overlay, spec)
+	}
+	return language, overlay, path, nil
+}
+
+// validateOverlayTemplates returns an error if overlay templates are set for
+// languages that aren't generated, or can't be parsed.
+func (ls LanguageSettings) validateOverlayTemplates() error {
+	for language, templates := range ls.OverlayTemplates {
+		if !contains(ls.languages(), language) {
+			return errors.Errorf("overlay templates are set for %s, which isn't generated", language)
+		}
+		for overlay, text := range templates {
+			if _, ok := defaultOverlayTemplates[language][overlay]; !ok {
+				return errors.Errorf("unknown %s overlay %q", language, overlay)
+			}
+			if _, err := parseOverlayTemplate(overlay, text); err != nil {
+				return errors.Wrapf(err, "invalid template of the %s %s overlay", language, overlay)
+			}
+		}
+	}
+	return nil
+}
+
+func parseOverlayTemplate(overlay, text string) (*template.Template, error) {
+	return template.New(overlay).Option("missingkey=error").Parse(text)
+}
+
+// renderOverlay renders the given overlay of the language, with its
+// template from the OverlayTemplates, or its default one.
+func (pg *PackageGenerator) renderOverlay(language, overlay string, data OverlayData) ([]byte, error) {
+	text, ok := pg.overlayTemplates[language][overlay]
+	if !ok {
+		text = defaultOverlayTemplates[language][overlay]
+	}
+	tmpl, err := parseOverlayTemplate(overlay, text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid template of the %s %s overlay", language, overlay)
+	}
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return nil, errors.Wrapf(err, "could not render the %s %s overlay", language, overlay)
+	}
+	return buffer.Bytes(), nil
+}
+
+// overlayData returns the OverlayData of the package with the given name,
+// whose ObjectMeta types are imported from the given package.
+func (pg *PackageGenerator) overlayData(name, kubernetesPackage string) OverlayData {
+	resourceTokens := append([]string(nil), pg.ResourceTokens...)
+	sort.Strings(resourceTokens)
+	resources := make([]OverlayResource, 0, len(resourceTokens))
+	for _, token := range resourceTokens {
+		member := tokens.ModuleMember(token)
+		groupVersion := string(member.Module().Name())
+		resource := OverlayResource{Version: groupVersion, Kind: string(member.Name()), Token: token}
+		if i := strings.LastIndex(groupVersion, "/"); i >= 0 {
+			resource.Group, resource.Version = groupVersion[:i], groupVersion[i+1:]
+		}
+		resources = append(resources, resource)
+	}
+	return OverlayData{
+		PackageName:       name,
+		PackageVersion:    pg.PackageVersion(),
+		KubernetesPackage: kubernetesPackage,
+		Resources:         resources,
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The owner reference helpers construct the `metadata.ownerReferences` entry
+// of a resource from the `apiVersion`, `kind` and `metadata` of its owner,
+// with the ObjectMeta types of the Kubernetes SDK. The owner can be a
+// CustomResource of the generated package, or any resource of the Kubernetes
+// SDK.
+
+const nodejsOwnerReferencePath = "ownerReference.ts"
+const nodejsOwnerReferenceFile = `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+import * as pulumi from "@pulumi/pulumi";
+import * as k8s from "@pulumi/kubernetes";
+
+/**
+ * A Kubernetes resource that can own other resources, e.g. a CustomResource of this package, or a resource of the
+ * Kubernetes SDK.
+ */
+export interface OwnerResource {
+    readonly apiVersion: pulumi.Input<string>;
+    readonly kind: pulumi.Input<string>;
+    readonly metadata: pulumi.Input<k8s.types.input.meta.v1.ObjectMeta>;
+}
+
+/**
+ * Returns the owner reference to the given resource. Add it to the ` + "`metadata.ownerReferences`" + ` of the resources that
+ * it owns, so that Kubernetes garbage collects them along with it.
+ */
+export function ownerReference(owner: OwnerResource, controller?: boolean, blockOwnerDeletion?: boolean): pulumi.Output<k8s.types.input.meta.v1.OwnerReference> {
+    return pulumi.all([owner.apiVersion, owner.kind, owner.metadata]).apply(([apiVersion, kind, metadata]) => ({
+        apiVersion,
+        kind,
+        name: metadata.name!,
+        uid: metadata.uid!,
+        controller,
+        blockOwnerDeletion,
+    }));
+}
+`
+
+const pythonOwnerReferenceModule = "owner_reference"
+const pythonOwnerReferenceFile = `# coding=utf-8
+# *** WARNING: this file was generated by crd2pulumi. ***
+# *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+from typing import Any, Optional
+
+import pulumi
+from pulumi_kubernetes.meta.v1 import OwnerReferenceArgs
+
+__all__ = ['owner_reference']
+
+
+def _get(metadata: Any, key: str) -> Any:
+    if isinstance(metadata, dict):
+        return metadata.get(key)
+    return getattr(metadata, key, None)
+
+
+def owner_reference(owner: pulumi.CustomResource,
+                    controller: Optional[bool] = None,
+                    block_owner_deletion: Optional[bool] = None) -> pulumi.Output[OwnerReferenceArgs]:
+    """
+    Returns the owner reference to the given resource, e.g. a CustomResource of this package, or a resource of the
+    Kubernetes SDK. Add it to the ` + "`metadata.owner_references`" + ` of the resources that it owns, so that Kubernetes
+    garbage collects them along with it.
+    """
+    return pulumi.Output.all(owner.api_version, owner.kind, owner.metadata).apply(
+        lambda args: OwnerReferenceArgs(
+            api_version=args[0],
+            kind=args[1],
+            name=_get(args[2], 'name'),
+            uid=_get(args[2], 'uid'),
+            controller=controller,
+            block_owner_deletion=block_owner_deletion))
+`
+
+const goOwnerReferencePath = "ownerReference.go"
+const goOwnerReferenceFile = `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package kubernetes
+
+import (
+	metav1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// NewOwnerReference returns the owner reference to a resource, e.g. a CustomResource of this package, or a resource
+// of the Kubernetes SDK, given its ApiVersion, Kind and Metadata outputs. Add it to the Metadata.OwnerReferences of
+// the resources that it owns, so that Kubernetes garbage collects them along with it.
+func NewOwnerReference(apiVersion, kind pulumi.StringPtrInput, metadata metav1.ObjectMetaPtrInput, controller, blockOwnerDeletion bool) metav1.OwnerReferenceOutput {
+	return pulumi.All(apiVersion.ToStringPtrOutput(), kind.ToStringPtrOutput(), metadata.ToObjectMetaPtrOutput()).ApplyT(func(args []interface{}) metav1.OwnerReference {
+		ownerReference := metav1.OwnerReference{
+			Controller:         &controller,
+			BlockOwnerDeletion: &blockOwnerDeletion,
+		}
+		if apiVersion := args[0].(*string); apiVersion != nil {
+			ownerReference.ApiVersion = *apiVersion
+		}
+		if kind := args[1].(*string); kind != nil {
+			ownerReference.Kind = *kind
+		}
+		if meta := args[2].(*metav1.ObjectMeta); meta != nil {
+			if meta.Name != nil {
+				ownerReference.Name = *meta.Name
+			}
+			if meta.Uid != nil {
+				ownerReference.Uid = *meta.Uid
+			}
+		}
+		return ownerReference
+	}).(metav1.OwnerReferenceOutput)
+}
+`
+
+const dotNetOwnerReferencePath = "OwnerReferences.cs"
+
+func dotNetOwnerReferenceFile(name string) string {
+	return `// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+using Pulumi.Kubernetes.Types.Inputs.Meta.V1;
+using Pulumi.Kubernetes.Types.Outputs.Meta.V1;
+
+namespace Pulumi.` + name + `
+{
+    /// <summary>
+    /// Constructs the owner references of Kubernetes resources.
+    /// </summary>
+    public static class OwnerReferences
+    {
+        /// <summary>
+        /// Returns the owner reference to a resource, e.g. a CustomResource of this package, or a resource of the
+        /// Kubernetes SDK, given its ApiVersion, Kind and Metadata outputs. Add it to the Metadata.OwnerReferences of
+        /// the resources that it owns, so that Kubernetes garbage collects them along with it.
+        /// </summary>
+        public static Output<OwnerReferenceArgs> Create(Output<string> apiVersion, Output<string> kind, Output<ObjectMeta> metadata, bool controller = false, bool blockOwnerDeletion = false)
+        {
+            return Output.Tuple(apiVersion, kind, metadata).Apply(values => new OwnerReferenceArgs
+            {
+                ApiVersion = values.Item1,
+                Kind = values.Item2,
+                Name = values.Item3.Name!,
+                Uid = values.Item3.Uid!,
+                Controller = controller,
+                BlockOwnerDeletion = blockOwnerDeletion,
+            });
+        }
+    }
+}
+`
+}
+
+// validateOwnerReferenceHelpers returns an error if the owner reference
+// helpers are generated along with the placeholder ObjectMeta type, since
+// they need the ObjectMeta types of the Kubernetes SDK.
+func (ls LanguageSettings) validateOwnerReferenceHelpers() error {
+	if ls.OwnerReferenceHelpers && ls.KeepPlaceholderMeta {
+		return errors.New("the owner reference helpers can't be generated with the placeholder ObjectMeta type")
+	}
+	return nil
+}
+
+// addNodeJSOwnerReference adds the owner reference helper, with the ObjectMeta
+// types of the given Kubernetes SDK, to the generated NodeJS files, and
+// exports it from the root of the package.
+func addNodeJSOwnerReference(files map[string][]byte, kubernetesPackage string) error {
+	index, ok := files[nodejsIndexPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", nodejsIndexPath)
+	}
+	files[nodejsOwnerReferencePath] = []byte(strings.Replace(nodejsOwnerReferenceFile, `"@pulumi/kubernetes"`, strconv.Quote(kubernetesPackage), 1))
+	files[nodejsIndexPath] = bytes.Replace(index, []byte("// Export members:\n"), []byte("// Export members:\nexport * from \"./ownerReference\";\n"), 1)
+	return nil
+}
+
+// addPythonOwnerReference adds the owner reference helper to the generated
+// Python files, and exports it from the root of the package.
+func addPythonOwnerReference(files map[string][]byte, pythonPackageDir string) error {
+	initPath := filepath.Join(pythonPackageDir, "__init__.py")
+	init, ok := files[initPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", initPath)
+	}
+	files[filepath.Join(pythonPackageDir, pythonOwnerReferenceModule+".py")] = []byte(pythonOwnerReferenceFile)
+	files[initPath] = bytes.Replace(init, []byte("# Export this package's modules as members:\n"), []byte("# Export this package's modules as members:\nfrom ."+pythonOwnerReferenceModule+" import *\n"), 1)
+	return nil
+}
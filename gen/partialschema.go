@@ -0,0 +1,99 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ParsePartialSchema parses a `<group>/<version>:<Name>=<path>` spec of a
+// partial schema, e.g. `stable.example.com/v1:CronTabSpec=spec.yaml`, into the
+// token of its type and the path of its schema.
+func ParsePartialSchema(spec string) (string, string, error) {
+	j := strings.Index(spec, "=")
+	if j < 0 || j == len(spec)-1 {
+		return "", "", errors.Errorf("invalid partial schema %q, expected <group>/<version>:<Name>=<path>", spec)
+	}
+	typeName, path := spec[:j], spec[j+1:]
+	i, k := strings.LastIndex(typeName, ":"), strings.Index(typeName, "/")
+	if k <= 0 || i < k+2 {
+		return "", "", errors.Errorf("invalid partial schema %q, expected <group>/<version>:<Name>=<path>", spec)
+	}
+	if name := typeName[i+1:]; !typeNameRe.MatchString(name) {
+		return "", "", errors.Errorf("invalid name %q of partial schema %q, expected letters and digits", name, spec)
+	}
+	return "kubernetes:" + typeName, path, nil
+}
+
+// LoadPartialSchema reads the bare OpenAPI schema of a YAML or JSON file.
+func LoadPartialSchema(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the partial schema %s", path)
+	}
+	schema, err := UnmarshalYaml(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the partial schema %s", path)
+	}
+	return schema, nil
+}
+
+// AddPartialSchema generates the types of a bare OpenAPI schema, e.g. a
+// subtree of the schema of a CRD, under the given token, without a resource.
+// The schema must be an object with properties, and its nested types are
+// named after it, like the ones of resources. Returns an error if the token
+// is already a type.
+func (pg *PackageGenerator) AddPartialSchema(token string, schema map[string]interface{}) error {
+	if _, ok := pg.Types[token]; ok {
+		return errors.Errorf("the type of the partial schema %s already exists", token)
+	}
+	normalized, err := NormalizeSchema(schema)
+	if err != nil {
+		return errors.Wrapf(err, "could not normalize the partial schema %s", token)
+	}
+	if _, foundProperties, _ := unstruct.NestedMap(normalized, "properties"); !foundProperties {
+		return errors.Errorf("the partial schema %s isn't an object with properties", token)
+	}
+	if pg.partialSchemas == nil {
+		pg.partialSchemas = map[string]map[string]interface{}{}
+	}
+	pg.partialSchemas[token] = normalized
+	if groupVersion := string(tokens.ModuleMember(token).Module().Name()); !contains(pg.GroupVersions, groupVersion) {
+		pg.GroupVersions = append(pg.GroupVersions, groupVersion)
+	}
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
+
+// partialSchemaTokens returns the sorted tokens of the types of the partial
+// schemas.
+func (pg *PackageGenerator) partialSchemaTokens() []string {
+	partialTokens := make([]string, 0, len(pg.partialSchemas))
+	for token := range pg.partialSchemas {
+		partialTokens = append(partialTokens, token)
+	}
+	sort.Strings(partialTokens)
+	return partialTokens
+}
@@ -0,0 +1,120 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PrinterColumn is a column that `kubectl get` shows for a CustomResource, as
+// declared in its `additionalPrinterColumns`.
+type PrinterColumn struct {
+	Name        string
+	Type        string
+	Format      string
+	Description string
+	// JSONPath is the path of the column's value in the resource, e.g. `.status.phase`
+	JSONPath string
+	// Priority is 0 for the columns shown by default, and greater for the
+	// columns only shown in the wide output
+	Priority int64
+}
+
+// printerColumns returns the `additionalPrinterColumns` of each version of the
+// CRD. In apiextensions.k8s.io/v1 they're declared per version, and in
+// apiextensions.k8s.io/v1beta1 they may be declared once for every version.
+func printerColumns(crd unstruct.Unstructured, versions []string) map[string][]PrinterColumn {
+	columns := map[string][]PrinterColumn{}
+	if topLevelColumns, found, _ := NestedMapSlice(crd.Object, "spec", "additionalPrinterColumns"); found {
+		for _, version := range versions {
+			columns[version] = parsePrinterColumns(topLevelColumns)
+		}
+	}
+	versionInfos, _, _ := NestedMapSlice(crd.Object, "spec", "versions")
+	for _, versionInfo := range versionInfos {
+		version, _, _ := unstruct.NestedString(versionInfo, "name")
+		if versionColumns, found, _ := NestedMapSlice(versionInfo, "additionalPrinterColumns"); found {
+			columns[version] = parsePrinterColumns(versionColumns)
+		}
+	}
+	return columns
+}
+
+func parsePrinterColumns(columnInfos []map[string]interface{}) []PrinterColumn {
+	columns := make([]PrinterColumn, 0, len(columnInfos))
+	for _, columnInfo := range columnInfos {
+		column := PrinterColumn{}
+		column.Name, _, _ = unstruct.NestedString(columnInfo, "name")
+		column.Type, _, _ = unstruct.NestedString(columnInfo, "type")
+		column.Format, _, _ = unstruct.NestedString(columnInfo, "format")
+		column.Description, _, _ = unstruct.NestedString(columnInfo, "description")
+		column.JSONPath, _, _ = unstruct.NestedString(columnInfo, "jsonPath")
+		if column.JSONPath == "" {
+			// apiextensions.k8s.io/v1beta1 spells it differently
+			column.JSONPath, _, _ = unstruct.NestedString(columnInfo, "JSONPath")
+		}
+		if priority, ok := toFloat64(columnInfo["priority"]); ok {
+			column.Priority = int64(priority)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// DocumentPrinterColumns appends the printer columns of each CustomResource
+// version to the description of its resource type, so that users know which
+// fields are the most meaningful.
+func (pg *PackageGenerator) DocumentPrinterColumns() {
+	for _, crg := range pg.CustomResourceGenerators {
+		for version, columns := range crg.PrinterColumns {
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
+			resourceType, ok := pg.Types[resourceToken]
+			if !ok || len(columns) == 0 {
+				continue
+			}
+			resourceType.Description = appendParagraph(resourceType.Description, formatPrinterColumns(columns))
+			pg.Types[resourceToken] = resourceType
+		}
+	}
+}
+
+// formatPrinterColumns formats the columns as a Markdown list, e.g.
+// "- `Status` (`.status.phase`): The phase of the resource".
+func formatPrinterColumns(columns []PrinterColumn) string {
+	var sb strings.Builder
+	sb.WriteString("Columns shown by `kubectl get`:")
+	for _, column := range columns {
+		fmt.Fprintf(&sb, "\n- `%s` (`%s`)", column.Name, column.JSONPath)
+		if column.Priority > 0 {
+			sb.WriteString(", wide output only")
+		}
+		if column.Description != "" {
+			sb.WriteString(": " + column.Description)
+		}
+	}
+	return sb.String()
+}
+
+// appendParagraph appends the paragraph to the description, separated by a
+// blank line.
+func appendParagraph(description, paragraph string) string {
+	if description == "" {
+		return paragraph
+	}
+	return description + "\n\n" + paragraph
+}
@@ -0,0 +1,421 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+const (
+	protoValue     = "google.protobuf.Value"
+	protoListValue = "google.protobuf.ListValue"
+	protoStruct    = "google.protobuf.Struct"
+	protoStructs   = "google/protobuf/struct.proto"
+)
+
+// protoScalars maps the Pulumi scalar types to Protobuf's
+var protoScalars = map[string]string{
+	Boolean: "bool",
+	Integer: "int64",
+	Number:  "double",
+	String:  "string",
+}
+
+func (pg *PackageGenerator) genProto(outputDir string) error {
+	files, err := pg.ProtoFiles()
+	if err != nil {
+		return err
+	}
+	return writeFiles(files, outputDir)
+}
+
+// ProtoFiles converts the generated types of the resources into proto3
+// message definitions, for tools that integrate the CustomResources with
+// gRPC. Each module, e.g. `stable.example.com/v1`, is a `.proto` file of its
+// own, with the messages of the object types that the resources refer to,
+// including ObjectMeta. The conversion is experimental:
+//
+//   - Fields are named in snake case, with the property name as their
+//     `json_name`, so that the JSON mapping of a message matches manifests.
+//   - Fields are numbered in alphabetical order, so adding a property
+//     renumbers the fields after it.
+//   - Enums are their scalar type, since Protobuf enums can't be strings.
+//   - `any` is google.protobuf.Value, and arrays and maps in arrays, maps
+//     and unions, which Protobuf can't nest, are google.protobuf.ListValue
+//     and Struct.
+//   - Unions are a `oneof` of a field per type, e.g. `port_int64` and
+//     `port_string`, since JSON names can't be shared, unless they're in an
+//     array or a map, where they're google.protobuf.Value.
+func (pg *PackageGenerator) ProtoFiles() (map[string]*bytes.Buffer, error) {
+	types := make(map[string]pschema.ComplexTypeSpec, len(pg.Types)+1)
+	for token, complexTypeSpec := range pg.Types {
+		types[token] = complexTypeSpec
+	}
+	types[objectMetaToken] = objectMetaTypeSpec
+	w := protoWriter{types: types, anyTypeRef: pg.anyTypeRefOrDefault()}
+
+	// The messages of each module are the object types reachable from the
+	// resources and the partial schemas
+	messages := map[string][]string{}
+	visited := map[string]bool{}
+	queue := append(append([]string(nil), pg.ResourceTokens...), pg.partialSchemaTokens()...)
+	sort.Strings(queue)
+	for len(queue) > 0 {
+		token := queue[0]
+		queue = queue[1:]
+		if visited[token] {
+			continue
+		}
+		visited[token] = true
+		complexTypeSpec, ok := types[token]
+		if !ok {
+			return nil, errors.Errorf("could not find type %s", token)
+		}
+		if len(complexTypeSpec.Enum) > 0 {
+			continue
+		}
+		module := protoModule(token)
+		messages[module] = append(messages[module], token)
+		queue = append(queue, typeRefs(complexTypeSpec.ObjectTypeSpec)...)
+	}
+
+	files := map[string]*bytes.Buffer{}
+	for module, messageTokens := range messages {
+		sort.Strings(messageTokens)
+		file, err := w.file(module, messageTokens)
+		if err != nil {
+			return nil, err
+		}
+		files[module+".proto"] = file
+	}
+	return files, nil
+}
+
+// typeRefs returns the tokens of the types that the properties refer to.
+func typeRefs(objectTypeSpec pschema.ObjectTypeSpec) []string {
+	var refs []string
+	var walk func(typeSpec pschema.TypeSpec)
+	walk = func(typeSpec pschema.TypeSpec) {
+		if strings.HasPrefix(typeSpec.Ref, "#/types/") {
+			refs = append(refs, strings.TrimPrefix(typeSpec.Ref, "#/types/"))
+		}
+		if typeSpec.Items != nil {
+			walk(*typeSpec.Items)
+		}
+		if typeSpec.AdditionalProperties != nil {
+			walk(*typeSpec.AdditionalProperties)
+		}
+		for _, oneOf := range typeSpec.OneOf {
+			walk(oneOf)
+		}
+	}
+	propertyNames := sortedPropertyNames(objectTypeSpec.Properties)
+	for _, propertyName := range propertyNames {
+		walk(objectTypeSpec.Properties[propertyName].TypeSpec)
+	}
+	return refs
+}
+
+func sortedPropertyNames(properties map[string]pschema.PropertySpec) []string {
+	propertyNames := make([]string, 0, len(properties))
+	for propertyName := range properties {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	return propertyNames
+}
+
+// protoModule returns the module of the type token, e.g. `meta/v1`.
+func protoModule(token string) string {
+	return string(tokens.ModuleMember(token).Module().Name())
+}
+
+// protoPackage returns the Protobuf package of the module, e.g.
+// `kubernetes.stable.example.com.v1` for `stable.example.com/v1`.
+func protoPackage(module string) string {
+	segments := strings.FieldsFunc("kubernetes."+module, func(r rune) bool { return r == '.' || r == '/' })
+	for i, segment := range segments {
+		segments[i] = protoIdentifier(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// protoIdentifier replaces the characters that Protobuf identifiers can't
+// contain with underscores.
+func protoIdentifier(name string) string {
+	identifier := []rune(name)
+	for i, r := range identifier {
+		if r > unicode.MaxASCII || !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			identifier[i] = '_'
+		}
+	}
+	if len(identifier) == 0 || unicode.IsDigit(identifier[0]) {
+		return "_" + string(identifier)
+	}
+	return string(identifier)
+}
+
+// protoFieldName returns the snake case name of the property, e.g.
+// `cron_spec` for `cronSpec`.
+func protoFieldName(propertyName string) string {
+	var sb strings.Builder
+	runes := []rune(propertyName)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			sb.WriteRune('_')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return protoIdentifier(sb.String())
+}
+
+// protoJSONName returns the JSON name that protoc derives from the field
+// name, by removing the underscores and capitalizing the letters after them.
+func protoJSONName(fieldName string) string {
+	var sb strings.Builder
+	capitalize := false
+	for _, r := range fieldName {
+		if r == '_' {
+			capitalize = true
+			continue
+		}
+		if capitalize {
+			r = unicode.ToUpper(r)
+			capitalize = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// protoWriter converts Pulumi types to Protobuf messages
+type protoWriter struct {
+	types      map[string]pschema.ComplexTypeSpec
+	anyTypeRef string
+	// module is the module of the file being written, and imports are the
+	// files that it imports
+	module  string
+	imports map[string]bool
+}
+
+// protoField is a field of a message, or a member of a oneof
+type protoField struct {
+	typeName string
+	repeated bool
+}
+
+// file returns the `.proto` file of the module with the messages of the
+// given types.
+func (w *protoWriter) file(module string, messageTokens []string) (*bytes.Buffer, error) {
+	w.module, w.imports = module, map[string]bool{}
+	var body bytes.Buffer
+	for _, token := range messageTokens {
+		if err := w.message(&body, token); err != nil {
+			return nil, errors.Wrapf(err, "could not convert %s to Protobuf", token)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("// *** WARNING: this file was generated by crd2pulumi. ***\n")
+	file.WriteString("// *** Do not edit by hand unless you're certain you know what you are doing! ***\n\n")
+	file.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&file, "package %s;\n\n", protoPackage(module))
+	if len(w.imports) > 0 {
+		imports := make([]string, 0, len(w.imports))
+		for path := range w.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		for _, path := range imports {
+			fmt.Fprintf(&file, "import %q;\n", path)
+		}
+		file.WriteString("\n")
+	}
+	file.Write(body.Bytes())
+	return &file, nil
+}
+
+func (w *protoWriter) message(body *bytes.Buffer, token string) error {
+	objectTypeSpec := w.types[token].ObjectTypeSpec
+	if body.Len() > 0 {
+		body.WriteString("\n")
+	}
+	writeProtoComment(body, "", objectTypeSpec.Description)
+	fmt.Fprintf(body, "message %s {\n", protoIdentifier(string(tokens.ModuleMember(token).Name())))
+
+	number := 1
+	fieldNames := map[string]bool{}
+	uniqueName := func(name string) string {
+		unique := name
+		for i := 2; fieldNames[unique]; i++ {
+			unique = name + "_" + strconv.Itoa(i)
+		}
+		fieldNames[unique] = true
+		return unique
+	}
+	for i, propertyName := range sortedPropertyNames(objectTypeSpec.Properties) {
+		propertySpec := objectTypeSpec.Properties[propertyName]
+		fieldName := uniqueName(protoFieldName(propertyName))
+		if i > 0 && propertySpec.Description != "" {
+			body.WriteString("\n")
+		}
+		writeProtoComment(body, "  ", propertySpec.Description)
+
+		members, err := w.oneOfMembers(propertySpec.TypeSpec)
+		if err != nil {
+			return errors.Wrapf(err, "in property %q", propertyName)
+		}
+		if members != nil {
+			fmt.Fprintf(body, "  oneof %s {\n", fieldName)
+			for _, member := range members {
+				memberName := uniqueName(fieldName + "_" + protoIdentifier(member.typeName[strings.LastIndex(member.typeName, ".")+1:]))
+				fmt.Fprintf(body, "    %s %s = %d;\n", member.typeName, memberName, number)
+				number++
+			}
+			body.WriteString("  }\n")
+			continue
+		}
+
+		field, err := w.field(propertySpec.TypeSpec, false)
+		if err != nil {
+			return errors.Wrapf(err, "in property %q", propertyName)
+		}
+		label := ""
+		if field.repeated {
+			label = "repeated "
+		}
+		fmt.Fprintf(body, "  %s%s %s = %d%s;\n", label, field.typeName, fieldName, number, protoJSONNameOption(fieldName, propertyName))
+		number++
+	}
+	body.WriteString("}\n")
+	return nil
+}
+
+// protoJSONNameOption returns the json_name option of the field, if protoc
+// wouldn't derive the property name from it.
+func protoJSONNameOption(fieldName, propertyName string) string {
+	if protoJSONName(fieldName) == propertyName {
+		return ""
+	}
+	return fmt.Sprintf(" [json_name = %q]", propertyName)
+}
+
+// oneOfMembers returns the members of the oneof that the union converts to,
+// or nil if the type isn't a union. The members are deduplicated, since the
+// enums of a union convert to their scalar types.
+func (w *protoWriter) oneOfMembers(typeSpec pschema.TypeSpec) ([]protoField, error) {
+	if len(typeSpec.OneOf) == 0 {
+		return nil, nil
+	}
+	var members []protoField
+	seen := map[string]bool{}
+	for _, oneOf := range typeSpec.OneOf {
+		member, err := w.field(oneOf, true)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[member.typeName] {
+			seen[member.typeName] = true
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// field converts the type to the type of a field. The items of arrays, the
+// values of maps and the members of oneofs can't be repeated or maps
+// themselves, so their arrays and maps are nested as the ListValue and Struct
+// messages of arbitrary JSON.
+func (w *protoWriter) field(typeSpec pschema.TypeSpec, nested bool) (protoField, error) {
+	value := func(typeName string) (protoField, error) {
+		w.imports[protoStructs] = true
+		return protoField{typeName: typeName}, nil
+	}
+	if len(typeSpec.OneOf) > 0 || typeSpec.Ref == w.anyTypeRef || typeSpec.Ref == anyTypeRef || typeSpec.Ref == jsonTypeRef {
+		return value(protoValue)
+	}
+	if strings.HasPrefix(typeSpec.Ref, "#/types/") {
+		token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+		complexTypeSpec, ok := w.types[token]
+		if !ok {
+			return protoField{}, errors.Errorf("could not find type %s", token)
+		}
+		if len(complexTypeSpec.Enum) > 0 {
+			if scalar, ok := protoScalars[complexTypeSpec.Type]; ok {
+				return protoField{typeName: scalar}, nil
+			}
+			return value(protoValue)
+		}
+		name := protoIdentifier(string(tokens.ModuleMember(token).Name()))
+		if module := protoModule(token); module != w.module {
+			w.imports[module+".proto"] = true
+			name = "." + protoPackage(module) + "." + name
+		}
+		return protoField{typeName: name}, nil
+	}
+	if typeSpec.Ref != "" {
+		return protoField{}, errors.Errorf("unsupported type reference %q", typeSpec.Ref)
+	}
+
+	switch typeSpec.Type {
+	case Array:
+		if nested || typeSpec.Items == nil {
+			return value(protoListValue)
+		}
+		items, err := w.field(*typeSpec.Items, true)
+		if err != nil {
+			return protoField{}, err
+		}
+		return protoField{typeName: items.typeName, repeated: true}, nil
+	case Object:
+		if nested || typeSpec.AdditionalProperties == nil {
+			return value(protoStruct)
+		}
+		values, err := w.field(*typeSpec.AdditionalProperties, true)
+		if err != nil {
+			return protoField{}, err
+		}
+		return protoField{typeName: "map<string, " + values.typeName + ">"}, nil
+	}
+	if scalar, ok := protoScalars[typeSpec.Type]; ok {
+		return protoField{typeName: scalar}, nil
+	}
+	return value(protoValue)
+}
+
+// writeProtoComment writes the description as a comment with the given
+// indentation.
+func writeProtoComment(body *bytes.Buffer, indent, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			fmt.Fprintf(body, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(body, "%s// %s\n", indent, line)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sourceComment is the line comment syntax and the resource registration of
+// the code files of a language
+type sourceComment struct {
+	prefix          string
+	resourceTokenRe *regexp.Regexp
+}
+
+// sourceComments maps the extension of each language's code files to their
+// sourceComment. Other files, e.g. package.json, aren't annotated.
+var sourceComments = map[string]sourceComment{
+	".ts": {"//", nodejsResourceTokenRe},
+	".py": {"#", pythonResourceTokenRe},
+	".go": {"//", goResourceTokenRe},
+	".cs": {"//", dotnetResourceTokenRe},
+}
+
+// AnnotateSources adds a comment to each generated code file with the CRDs
+// that it was generated from, and the crd2pulumi version that generated it.
+// The file of a resource names its CRD version, and other files name every
+// CRD of the package. The comment follows the file's leading comments, so
+// that e.g. Python encoding declarations stay on the first line.
+func (pg *PackageGenerator) AnnotateSources(files map[string]*bytes.Buffer) {
+	resourceSources := map[string]string{}
+	packageSources := make([]string, 0, len(pg.CustomResourceGenerators))
+	for _, crg := range pg.CustomResourceGenerators {
+		for i, token := range crg.ResourceTokens {
+			resourceSources[token] = describeSource(crg, crg.GroupVersions[i])
+		}
+		packageSources = append(packageSources, describeSource(crg, crg.Group))
+	}
+
+	for path, code := range files {
+		comment, ok := sourceComments[filepath.Ext(path)]
+		if !ok {
+			continue
+		}
+		sources := packageSources
+		if match := comment.resourceTokenRe.FindSubmatch(code.Bytes()); match != nil {
+			if source, ok := resourceSources[string(match[1])]; ok {
+				sources = []string{source}
+			}
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s Generated by crd2pulumi %s from:\n", comment.prefix, Version)
+		for _, source := range sources {
+			fmt.Fprintf(&sb, "%s   %s\n", comment.prefix, source)
+		}
+		files[path] = bytes.NewBuffer(insertAfterLeadingComments(code.Bytes(), comment.prefix, sb.String()))
+	}
+}
+
+// describeSource describes a CRD at the given group or group/version, e.g.
+// "CronTab stable.example.com/v1 (crontabs.yaml)". The source is omitted if
+// it's unknown.
+func describeSource(crg CustomResourceGenerator, groupVersion string) string {
+	description := crg.Kind + " " + groupVersion
+	if crg.Source != "" {
+		description += " (" + crg.Source + ")"
+	}
+	return description
+}
+
+// insertAfterLeadingComments inserts the text after the lines at the start of
+// the code that begin with the given comment prefix, or before the code,
+// separated by a blank line, if there are no such lines.
+func insertAfterLeadingComments(code []byte, prefix, text string) []byte {
+	offset := 0
+	for offset < len(code) && bytes.HasPrefix(code[offset:], []byte(prefix)) {
+		end := bytes.IndexByte(code[offset:], '\n')
+		if end == -1 {
+			offset = len(code)
+			break
+		}
+		offset += end + 1
+	}
+	annotated := make([]byte, 0, len(code)+len(text))
+	annotated = append(annotated, code[:offset]...)
+	if offset > 0 && code[offset-1] != '\n' {
+		annotated = append(annotated, '\n')
+	}
+	annotated = append(annotated, text...)
+	if offset == 0 {
+		annotated = append(annotated, '\n')
+	}
+	return append(annotated, code[offset:]...)
+}
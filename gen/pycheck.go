@@ -0,0 +1,103 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pythonImportScript imports each of the packages given as arguments
+const pythonImportScript = `import importlib
+import sys
+
+for name in sys.argv[1:]:
+    importlib.import_module(name)
+`
+
+// CheckPythonFiles verifies that the generated Python files compile, and that
+// the package and each of its subpackages can be imported, by running the
+// Python interpreter in a temporary directory that contains them. The imports
+// need the `pulumi` package, and the Kubernetes SDK that the ObjectMeta types
+// are imported from, to be installed. Returns an error with the output of the
+// interpreter if a file doesn't compile or a package can't be imported.
+func CheckPythonFiles(files map[string]*bytes.Buffer) error {
+	pythonPath, err := exec.LookPath("python3")
+	if err != nil {
+		if pythonPath, err = exec.LookPath("python"); err != nil {
+			return errors.Wrap(err, "could not find the Python interpreter to check the generated code with")
+		}
+	}
+
+	packageDir, err := ioutil.TempDir("", "crd2pulumi-python-check-")
+	if err != nil {
+		return errors.Wrap(err, "could not create temporary directory")
+	}
+	defer os.RemoveAll(packageDir)
+
+	packageFiles := map[string]*bytes.Buffer{}
+	var packages []string
+	for file, code := range files {
+		if filepath.Ext(file) != ".py" {
+			continue
+		}
+		file = filepath.ToSlash(file)
+		packageFiles[file] = bytes.NewBuffer(code.Bytes())
+		// The generated tests aren't a package of the SDK
+		if path.Base(file) == "__init__.py" && !strings.HasPrefix(file, "tests/") {
+			packages = append(packages, strings.ReplaceAll(path.Dir(file), "/", "."))
+		}
+	}
+	sort.Strings(packages)
+	if err := writeFiles(packageFiles, packageDir); err != nil {
+		return err
+	}
+
+	if err := runPython(pythonPath, packageDir, "-m", "compileall", "-q", "."); err != nil {
+		return errors.Wrap(err, "generated Python code does not compile")
+	}
+	if err := runPython(pythonPath, packageDir, append([]string{"-c", pythonImportScript}, packages...)...); err != nil {
+		return errors.Wrap(err, "generated Python packages cannot be imported")
+	}
+	return nil
+}
+
+// runPython runs the Python interpreter in the given directory, which the
+// imports are resolved against first. The returned error contains the
+// output of the interpreter.
+func runPython(pythonPath, dir string, args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.Command(pythonPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PYTHONPATH="+dir+string(os.PathListSeparator)+os.Getenv("PYTHONPATH"))
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		command := "python " + strings.Join(args, " ")
+		if args[0] == "-c" {
+			command = "python -c <import script> " + strings.Join(args[2:], " ")
+		}
+		return errors.Errorf("%s: %v\n%s", command, err, bytes.TrimSpace(output.Bytes()))
+	}
+	return nil
+}
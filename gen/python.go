@@ -16,40 +16,76 @@ package gen
 
 import (
 	"bytes"
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"unicode"
 
 	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/python"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
 
-const pythonMetaFile = `from pulumi_kubernetes.meta.v1._inputs import *
-import pulumi_kubernetes.meta.v1.outputs
+// pythonKubernetesImportRe matches the imports of the Kubernetes SDK's modules
+var pythonKubernetesImportRe = regexp.MustCompile(`(?m)^(\s*from )pulumi_kubernetes import`)
+
+// pythonKubernetesModuleRe matches every import of the Kubernetes SDK
+var pythonKubernetesModuleRe = regexp.MustCompile(`(?m)^(\s*(?:from|import) )pulumi_kubernetes\b`)
+
+const pythonMetaFile = `from {{.KubernetesPackage}}.meta.v1._inputs import *
+import {{.KubernetesPackage}}.meta.v1.outputs
 `
 
-func (pg *PackageGenerator) genPython(outputDir, name string) error {
-	if files, err := pg.genPythonFiles(name); err != nil {
+func (pg *PackageGenerator) genPython(outputDir, name string, importCheck bool) error {
+	files, err := pg.genPythonFiles(name)
+	if err != nil {
 		return err
-	} else if err := writeFiles(files, outputDir); err != nil {
+	}
+	pg.addTestStubs(files, Python, name)
+
+	// Writing the files drains their buffers, so the checked copies are
+	// taken first, like the compiled copies of the Go files
+	var checkedFiles map[string]*bytes.Buffer
+	if importCheck {
+		checkedFiles = make(map[string]*bytes.Buffer, len(files))
+		for path, code := range files {
+			checkedFiles[path] = bytes.NewBuffer(code.Bytes())
+		}
+	}
+	if err := pg.writeFiles(files, outputDir); err != nil {
 		return err
 	}
+	if importCheck {
+		return CheckPythonFiles(checkedFiles)
+	}
 	return nil
 }
 
 func (pg *PackageGenerator) genPythonFiles(name string) (map[string]*bytes.Buffer, error) {
 	pkg := pg.SchemaPackageWithObjectMetaType()
 
+	requires := map[string]string{
+		"pulumi":   "\u003e=3.0.0,\u003c4.0.0",
+		"pyyaml":   "\u003e=5.3",
+		"requests": "\u003e=2.21.0,\u003c2.22.0",
+	}
+	objectMetaPackage, importObjectMeta := pg.objectMetaPackages[Python]
+	if importObjectMeta {
+		version := objectMetaPackage.Version
+		if version != "" && unicode.IsDigit(rune(version[0])) {
+			// A bare version pins the dependency
+			version = "==" + version
+		}
+		requires[objectMetaPackage.Name] = version
+	}
+
 	oldName := pkg.Name
 	pkg.Name = name
-	pkg.Language[Python] = rawMessage(map[string]interface{}{
+	pkg.Language[Python] = pg.languageInfo(Python, map[string]interface{}{
 		"compatibility":       "kubernetes20",
 		"moduleNameOverrides": pg.moduleToPackage(),
-		"requires": map[string]string{
-			"pulumi":   "\u003e=3.0.0,\u003c4.0.0",
-			"pyyaml":   "\u003e=5.3",
-			"requests": "\u003e=2.21.0,\u003c2.22.0",
-		},
-		"ignorePyNamePanic": true,
+		"requires":            requires,
+		"ignorePyNamePanic":   true,
 	})
 
 	files, err := python.GeneratePackage(tool, pkg, nil)
@@ -60,7 +96,19 @@ func (pg *PackageGenerator) genPythonFiles(name string) (map[string]*bytes.Buffe
 	pkg.Name = oldName
 	delete(pkg.Language, Python)
 
+	if err := pg.appendTypeTokens(files, Python); err != nil {
+		return nil, err
+	}
+
 	pythonPackageDir := "pulumi_" + name
+	kubernetesModule := "pulumi_kubernetes"
+	if importObjectMeta {
+		kubernetesModule = pythonModuleName(objectMetaPackage.Name)
+	}
+	overlayData := pg.overlayData(name, kubernetesModule)
+	if pg.pythonDistributionName != "" {
+		renamePythonDistribution(files, pythonPackageDir, pg.pythonDistributionName)
+	}
 
 	// Remove unneeded files
 	var unneededPythonFiles = []string{
@@ -70,18 +118,64 @@ func (pg *PackageGenerator) genPythonFiles(name string) (map[string]*bytes.Buffe
 		delete(files, unneededFile)
 	}
 
-	// Replace _utilities.py with our own hard-coded version
-	utilitiesPath := filepath.Join(pythonPackageDir, "_utilities.py")
-	_, ok := files[utilitiesPath]
-	contract.Assertf(ok, "missing _utilities.py file")
-	files[utilitiesPath] = []byte(pythonUtilitiesFile)
+	if pg.keepPlaceholderMeta {
+		// The code generator imports every type outside of the resource's own
+		// module from `pulumi_kubernetes`, so point them at this package instead
+		for path, code := range files {
+			files[path] = pythonKubernetesImportRe.ReplaceAll(code, []byte("${1}pulumi_"+name+" import"))
+		}
+	} else {
+		// Replace _utilities.py with our own version
+		utilitiesPath := filepath.Join(pythonPackageDir, "_utilities.py")
+		_, ok := files[utilitiesPath]
+		contract.Assertf(ok, "missing _utilities.py file")
+		utilities, err := pg.renderOverlay(Python, UtilitiesOverlay, overlayData)
+		if err != nil {
+			return nil, err
+		}
+		files[utilitiesPath] = utilities
+	}
+
+	// Stamp setup.py with the package version, if it's set
+	if pg.packageVersion != "" {
+		setupPy, ok := files["setup.py"]
+		contract.Assertf(ok, "missing setup.py file")
+		files["setup.py"] = bytes.Replace(setupPy, []byte(`VERSION = "0.0.0"`), []byte(fmt.Sprintf("VERSION = %q", pg.packageVersion)), 1)
+	}
 
 	// Import the actual SDK ObjectMeta types in place of our placeholder ones
-	if pg.HasSchemas() {
+	if pg.HasSchemas() && !pg.keepPlaceholderMeta {
 		metaPath := filepath.Join(pythonPackageDir, "meta/v1", "__init__.py")
 		code, ok := files[metaPath]
 		contract.Assertf(ok, "missing meta/v1/__init__.py file")
-		files[metaPath] = append(code, []byte(pythonMetaFile)...)
+		meta, err := pg.renderOverlay(Python, MetaOverlay, overlayData)
+		if err != nil {
+			return nil, err
+		}
+		files[metaPath] = append(code, meta...)
+	}
+
+	if pg.ownerReferenceHelpers {
+		if err := addPythonOwnerReference(files, pythonPackageDir); err != nil {
+			return nil, err
+		}
+	}
+	if pg.crdResources {
+		if err := pg.addPythonCRDResources(files, pythonPackageDir); err != nil {
+			return nil, err
+		}
+	}
+	if pg.sdkVersionFile {
+		if err := addPythonVersion(files, pythonPackageDir, pg.PackageVersion()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Import the ObjectMeta types, and the utilities, from the given SDK instead
+	if importObjectMeta {
+		for path, code := range files {
+			files[path] = pythonKubernetesModuleRe.ReplaceAll(code, []byte("${1}"+kubernetesModule))
+		}
 	}
 
 	buffers := map[string]*bytes.Buffer{}
@@ -91,7 +185,7 @@ func (pg *PackageGenerator) genPythonFiles(name string) (map[string]*bytes.Buffe
 	return buffers, nil
 }
 
-const pythonUtilitiesFile = `from pulumi_kubernetes import _utilities
+const pythonUtilitiesFile = `from {{.KubernetesPackage}} import _utilities
 
 
 def get_env(*args):
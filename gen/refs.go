@@ -0,0 +1,157 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ResolveExternalRefs returns a copy of the given CRD, or any other YAML
+// value, with the `$ref`s to other files, e.g. `shared.json#/Foo`, replaced
+// by the schemas they point to. Relative paths are resolved against the
+// given directory, which is the directory of the CRD's file, and the `$ref`s
+// of the referenced files against their own directory. The keywords next to
+// a `$ref`, e.g. its `description`, are merged into the referenced schema.
+// Local `$ref`s of the CRD, and `$ref`s to URLs, are kept, and local `$ref`s
+// are inlined by NormalizeSchema. Recursive references become arbitrary
+// JSON.
+//
+// Returns an error if a referenced file can't be read, or the `$ref` can't
+// be resolved in it.
+func ResolveExternalRefs(value interface{}, baseDir string) (interface{}, error) {
+	r := refResolver{
+		documents: map[string]interface{}{},
+		resolving: map[string]bool{},
+	}
+	return r.resolve(value, "", baseDir)
+}
+
+type refResolver struct {
+	// documents contains the referenced files that were read, by path
+	documents map[string]interface{}
+	// resolving contains the `$ref`s currently being inlined, as
+	// `<path>#<pointer>`, to detect cycles
+	resolving map[string]bool
+}
+
+// resolve resolves the `$ref`s in the given value of the given document,
+// which is the path of a referenced file, or empty for the CRD.
+func (r *refResolver) resolve(value interface{}, document, baseDir string) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && (document != "" || !strings.HasPrefix(ref, "#")) && !fetchUrlRe.MatchString(ref) {
+			return r.resolveRef(ref, value, document, baseDir)
+		}
+		resolved := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			resolvedElement, err := r.resolve(element, document, baseDir)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = resolvedElement
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, 0, len(value))
+		for _, element := range value {
+			resolvedElement, err := r.resolve(element, document, baseDir)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, resolvedElement)
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveRef returns the schema with the given `$ref`, with the `$ref`
+// replaced by the schema it points to.
+func (r *refResolver) resolveRef(ref string, schema map[string]interface{}, document, baseDir string) (interface{}, error) {
+	path, pointer := document, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		pointer = ref[i+1:]
+		if i > 0 {
+			path = ref[:i]
+		}
+	} else {
+		path = ref
+	}
+	if path != document && !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	key := path + "#" + pointer
+	if r.resolving[key] {
+		return map[string]interface{}{
+			"type":                                 Object,
+			"x-kubernetes-preserve-unknown-fields": true,
+		}, nil
+	}
+
+	root, err := r.readDocument(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not resolve $ref %q", ref)
+	}
+	target, ok := resolvePointer(root, pointer)
+	if !ok {
+		return nil, errors.Errorf("could not resolve $ref %q in %s", ref, path)
+	}
+	referenced, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("$ref %q does not point to a schema", ref)
+	}
+
+	r.resolving[key] = true
+	defer delete(r.resolving, key)
+	resolved, err := r.resolve(referenced, path, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	merged := resolved.(map[string]interface{})
+	for keyword, value := range schema {
+		if keyword == "$ref" {
+			continue
+		}
+		resolvedValue, err := r.resolve(value, document, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		merged[keyword] = resolvedValue
+	}
+	return merged, nil
+}
+
+// readDocument returns the YAML or JSON document at the given path.
+func (r *refResolver) readDocument(path string) (interface{}, error) {
+	if document, ok := r.documents[path]; ok {
+		return document, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read file %s", path)
+	}
+	document, err := UnmarshalYaml(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse file %s", path)
+	}
+	r.documents[path] = document
+	return document, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// typeNameRe matches the names that resources can be renamed to, which are
+// identifiers in every language.
+var typeNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// ParseRename parses a `<group>/<Kind>=<NewName>` spec, e.g.
+// `stable.example.com/CronTab=ScheduledJob`, of the name to generate the
+// resource type of a CRD with instead of its kind. Returns the
+// `<group>/<Kind>` and the new name.
+func ParseRename(spec string) (string, string, error) {
+	i, j := strings.LastIndex(spec, "/"), strings.Index(spec, "=")
+	if i <= 0 || j < i+2 {
+		return "", "", errors.Errorf("invalid rename %q, expected <group>/<Kind>=<NewName>", spec)
+	}
+	groupKind, name := spec[:j], spec[j+1:]
+	if !typeNameRe.MatchString(name) {
+		return "", "", errors.Errorf("invalid name %q of rename %q, expected letters and digits", name, spec)
+	}
+	return groupKind, name, nil
+}
+
+// RenameResources generates the resource type of each CRD in the map, keyed
+// by `<group>/<Kind>`, with the given name instead of its kind, e.g. because
+// the kind makes an awkward type name. The `kind` of the resources is still
+// the CRD's. Returns an error if no CRD has a renamed kind, or if a new name
+// is taken by another resource of the group.
+func (pg *PackageGenerator) RenameResources(renames map[string]string) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	groupKinds := make([]string, 0, len(renames))
+	for groupKind := range renames {
+		groupKinds = append(groupKinds, groupKind)
+	}
+	sort.Strings(groupKinds)
+	for _, groupKind := range groupKinds {
+		found := false
+		for i := range pg.CustomResourceGenerators {
+			crg := &pg.CustomResourceGenerators[i]
+			if crg.Group+"/"+crg.Kind == groupKind {
+				crg.TypeName = renames[groupKind]
+				found = true
+			}
+		}
+		if !found {
+			return errors.Errorf("could not find the CRD of rename %s=%s", groupKind, renames[groupKind])
+		}
+	}
+
+	typeNames := map[string]string{}
+	var resourceTokens []string
+	for i := range pg.CustomResourceGenerators {
+		crg := &pg.CustomResourceGenerators[i]
+		groupTypeName := crg.Group + "/" + crg.TypeName
+		if kind, ok := typeNames[groupTypeName]; ok && kind != crg.Kind {
+			return errors.Errorf("%s and %s would both generate the resource type %s", crg.Group+"/"+kind, crg.Group+"/"+crg.Kind, groupTypeName)
+		}
+		typeNames[groupTypeName] = crg.Kind
+
+		crg.ResourceTokens = nil
+		for _, version := range crg.Versions {
+			crg.ResourceTokens = append(crg.ResourceTokens, getToken(crg.Group, version, crg.TypeName))
+		}
+		resourceTokens = append(resourceTokens, crg.ResourceTokens...)
+	}
+	pg.ResourceTokens = resourceTokens
+
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// resourcePropertyNames are the properties of every Pulumi resource, which the
+// top-level properties of a CustomResource can't redeclare in NodeJS, Python
+// and .NET
+var resourcePropertyNames = codegen.NewStringSet("id", "urn")
+
+// pythonClassNames are the names that the Python code generator uses in the
+// bodies of the classes that it generates, after declaring the properties as
+// methods. A property with the same name shadows them, so the class fails to
+// load. Python keywords are already renamed, e.g. `class` to `class_`.
+var pythonClassNames = codegen.NewStringSet("__self__", "property", "pulumi", "str", "int", "float", "bool")
+
+// ReservedPropertyWarnings returns a warning for each property whose name the
+// code generator of one of the given languages doesn't rename, but that
+// clashes with a name that the generated code already declares or uses, so
+// that the generated SDK doesn't compile or load. Keywords such as `type`,
+// `class` or `default` are renamed by the code generators, and aren't
+// reported.
+func (pg *PackageGenerator) ReservedPropertyWarnings(languages ...string) []string {
+	resources := map[string]bool{}
+	for _, token := range pg.ResourceTokens {
+		resources[token] = true
+	}
+
+	var warnings []string
+	for token, complexTypeSpec := range pg.Types {
+		typeName := string(tokens.ModuleMember(token).Name())
+		for propertyName := range complexTypeSpec.Properties {
+			for _, language := range languages {
+				if reason := reservedPropertyReason(language, propertyName, typeName, resources[token]); reason != "" {
+					warnings = append(warnings, fmt.Sprintf("the %s property of %s %s, so the %s SDK may not compile; rename the property in the CRD",
+						propertyName, token, reason, languageTitle(language)))
+				}
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// reservedPropertyReason returns why the given property of a type or resource
+// clashes with the code that the language generates for it, or "" if it
+// doesn't.
+func reservedPropertyReason(language, propertyName, typeName string, isResource bool) string {
+	if isResource && resourcePropertyNames.Has(propertyName) && language != Go {
+		return "redeclares a property of every Pulumi resource"
+	}
+	switch language {
+	case Python:
+		if pythonClassNames.Has(python.PyName(propertyName)) {
+			return "shadows a name that the generated class uses"
+		}
+	case Go:
+		fieldName := go_gen.Title(propertyName)
+		if fieldName == "ElementType" || fieldName == "Elem" || strings.HasPrefix(fieldName, "To"+typeName) {
+			return "has the same name as a generated method"
+		}
+	case DotNet:
+		memberName := dotnet.Title(propertyName)
+		if memberName == typeName || memberName == typeName+"Args" {
+			return "has the same name as its class"
+		}
+	}
+	return ""
+}
+
+// languageTitle returns the name of a language as it's usually written, e.g.
+// "NodeJS" for "nodejs".
+func languageTitle(language string) string {
+	switch language {
+	case NodeJS:
+		return "NodeJS"
+	case Python:
+		return "Python"
+	case Go:
+		return "Go"
+	case DotNet:
+		return ".NET"
+	}
+	return language
+}
@@ -0,0 +1,75 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TrimSchema returns a copy of the schema that only has the properties along
+// the given dot-separated root path, e.g. `spec.forProvider`. The subschema at
+// the root path is kept as-is, so only the types reachable from it (and the
+// objects along the path) are generated. Returns an error if any property
+// along the path doesn't exist.
+func TrimSchema(schema map[string]interface{}, rootPath string) (map[string]interface{}, error) {
+	if rootPath == "" {
+		return schema, nil
+	}
+	propertyName := rootPath
+	rest := ""
+	if i := strings.Index(rootPath, "."); i >= 0 {
+		propertyName, rest = rootPath[:i], rootPath[i+1:]
+	}
+
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	propertySchema, ok := properties[propertyName].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("could not find property %q", propertyName)
+	}
+	trimmedPropertySchema, err := TrimSchema(propertySchema, rest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in property %q", propertyName)
+	}
+
+	trimmed := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		trimmed[key] = value
+	}
+	trimmed["properties"] = map[string]interface{}{propertyName: trimmedPropertySchema}
+	delete(trimmed, "required")
+	if required, _, _ := unstruct.NestedStringSlice(schema, "required"); contains(required, propertyName) {
+		trimmed["required"] = []interface{}{propertyName}
+	}
+	return trimmed, nil
+}
+
+// TrimToRootPath trims the schema of every CustomResource version with
+// TrimSchema, and regenerates the types from the trimmed schemas.
+func (pg *PackageGenerator) TrimToRootPath(rootPath string) error {
+	for i, crg := range pg.CustomResourceGenerators {
+		for version, schema := range crg.Schemas {
+			trimmed, err := TrimSchema(schema, rootPath)
+			if err != nil {
+				return errors.Wrapf(err, "could not find root path %q in %s %s", rootPath, crg.Kind, version)
+			}
+			pg.CustomResourceGenerators[i].Schemas[version] = trimmed
+		}
+	}
+	pg.Types = pg.GetTypes()
+	return nil
+}
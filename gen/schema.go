@@ -15,6 +15,8 @@
 package gen
 
 import (
+	"encoding/json"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -48,11 +50,34 @@ var arbitraryJSONTypeSpec = pschema.TypeSpec{
 	AdditionalProperties: &anyTypeSpec,
 }
 
-var emptySpec = pschema.ComplexTypeSpec{
-	ObjectTypeSpec: pschema.ObjectTypeSpec{
-		Type:       Object,
-		Properties: map[string]pschema.PropertySpec{},
-	},
+// newEmptySpec returns an object type without any properties. Each resource
+// needs its own, since its properties are added to it.
+func newEmptySpec() pschema.ComplexTypeSpec {
+	return pschema.ComplexTypeSpec{
+		ObjectTypeSpec: pschema.ObjectTypeSpec{
+			Type:       Object,
+			Properties: map[string]pschema.PropertySpec{},
+		},
+	}
+}
+
+// isTypedRoot returns true if the root schema of a CustomResource describes
+// its properties, or explicitly preserves unknown fields.
+func isTypedRoot(schema map[string]interface{}) bool {
+	_, foundProperties, _ := unstruct.NestedMap(schema, "properties")
+	preserveUnknownFields, _, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields")
+	return foundProperties || preserveUnknownFields
+}
+
+// untypedRootSpec returns the type of a CustomResource whose root schema
+// isn't an object with properties, e.g. because it has no type at all. Its
+// `spec` and `status` can be any value, so the resource is still usable.
+func untypedRootSpec(schema map[string]interface{}) pschema.ComplexTypeSpec {
+	complexTypeSpec := newEmptySpec()
+	complexTypeSpec.Description, _, _ = unstruct.NestedString(schema, "description")
+	complexTypeSpec.Properties["spec"] = pschema.PropertySpec{TypeSpec: anyTypeSpec}
+	complexTypeSpec.Properties["status"] = pschema.PropertySpec{TypeSpec: anyTypeSpec}
+	return complexTypeSpec
 }
 
 const objectMetaRef = "#/types/kubernetes:meta/v1:ObjectMeta"
@@ -71,62 +96,108 @@ var intOrStringTypeSpec = pschema.TypeSpec{
 }
 
 func (pg *PackageGenerator) GetTypes() map[string]pschema.ComplexTypeSpec {
+	types, _ := pg.getTypes()
+	return types
+}
+
+// getTypes returns the types of every CustomResource version, and the error
+// of the first unknown schema type if the policy is UnknownTypeError.
+func (pg *PackageGenerator) getTypes() (map[string]pschema.ComplexTypeSpec, error) {
 	types := map[string]pschema.ComplexTypeSpec{}
+	c := typeConverter{types: types, unknownTypes: pg.unknownTypes, maxDepth: pg.MaxDepth(), enumCollisionPolicy: pg.enumCollisions}
 	for _, crg := range pg.CustomResourceGenerators {
 		for version, schema := range crg.Schemas {
-			resourceToken := getToken(crg.Group, version, crg.Kind)
+			resourceToken := getToken(crg.Group, version, crg.TypeName)
 			_, foundProperties, _ := unstruct.NestedMap(schema, "properties")
+			preserveUnknownFields, _, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields")
 			if foundProperties {
-				AddType(schema, resourceToken, types)
+				c.addType(withoutMetadata(schema), resourceToken)
 			}
-			preserveUnknownFields, _, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields")
 			if preserveUnknownFields {
-				types[resourceToken] = emptySpec
+				types[resourceToken] = newEmptySpec()
+			} else if !foundProperties {
+				types[resourceToken] = untypedRootSpec(schema)
+			}
+			types[resourceToken].Properties["apiVersion"] = pschema.PropertySpec{
+				TypeSpec: pschema.TypeSpec{
+					Type: String,
+				},
+				Const: crg.Group + "/" + version,
+			}
+			types[resourceToken].Properties["kind"] = pschema.PropertySpec{
+				TypeSpec: pschema.TypeSpec{
+					Type: String,
+				},
+				Const: crg.Kind,
 			}
-			if foundProperties || preserveUnknownFields {
-				types[resourceToken].Properties["apiVersion"] = pschema.PropertySpec{
-					TypeSpec: pschema.TypeSpec{
-						Type: String,
-					},
-					Const: crg.Group + "/" + version,
-				}
-				types[resourceToken].Properties["kind"] = pschema.PropertySpec{
-					TypeSpec: pschema.TypeSpec{
-						Type: String,
-					},
-					Const: crg.Kind,
-				}
-				types[resourceToken].Properties["metadata"] = pschema.PropertySpec{
-					TypeSpec: pschema.TypeSpec{
-						Ref: objectMetaRef,
-					},
-				}
+			metadata := pschema.PropertySpec{
+				TypeSpec: pschema.TypeSpec{
+					Ref: objectMetaRef,
+				},
 			}
+			if constraints := metadataConstraints(schema); len(constraints) > 0 {
+				metadata.Description = metadataDescription(constraints)
+			}
+			types[resourceToken].Properties["metadata"] = metadata
+			if pg.objectMetaRequired {
+				resourceType := types[resourceToken]
+				resourceType.Required = appendMissing(resourceType.Required, "metadata")
+				types[resourceToken] = resourceType
+			}
+			markSecretStatus(types, resourceToken)
 		}
 	}
-	return types
+	for _, token := range pg.partialSchemaTokens() {
+		c.addType(pg.partialSchemas[token], token)
+	}
+	pg.truncatedTypes = c.truncated
+	pg.renamedEnumValues = c.renamedEnumValues
+	return types, c.err
 }
 
-// Returns the Pulumi package given a types map and a slice of the token types
-// of every CustomResource. If includeObjectMetaType is true, then a
-// ObjectMetaType type is also generated.
-func genPackage(types map[string]pschema.ComplexTypeSpec, resourceTokens []string, includeObjectMetaType bool) (*pschema.Package, error) {
+// Returns the Pulumi package of the given version given a types map, a
+// slice of the token types of every CustomResource and the methods of the
+// resources. If includeObjectMetaType is true, then a ObjectMetaType type is
+// also generated. If metadataRequired is true, the `metadata` of the
+// resources is a required input.
+func genPackage(version string, types map[string]pschema.ComplexTypeSpec, resourceTokens []string, methods map[string]map[string]pschema.FunctionSpec, includeObjectMetaType, metadataRequired bool) (*pschema.Package, error) {
 	if includeObjectMetaType {
-		types[objectMetaToken] = pschema.ComplexTypeSpec{
-			ObjectTypeSpec: pschema.ObjectTypeSpec{
-				Type: "object",
-			},
-		}
+		types[objectMetaToken] = objectMetaTypeSpec
 	}
 
+	pkgSpec := genPackageSpec(version, types, resourceTokens, methods, metadataRequired)
+	pkg, err := pschema.ImportSpec(pkgSpec, nil)
+	if err != nil {
+		return &pschema.Package{}, errors.Wrapf(err, "could not import spec")
+	}
+
+	if includeObjectMetaType {
+		delete(types, objectMetaToken)
+	}
+
+	return pkg, nil
+}
+
+// genPackageSpec returns the Pulumi package spec of the given version, with
+// the given types, a resource for each of the resource tokens, and a
+// function for each of the methods of the resources.
+func genPackageSpec(version string, types map[string]pschema.ComplexTypeSpec, resourceTokens []string, methods map[string]map[string]pschema.FunctionSpec, metadataRequired bool) pschema.PackageSpec {
 	packages := map[string]bool{DefaultName: true, "kubernetes": true}
 	resources := map[string]pschema.ResourceSpec{}
+	var functions map[string]pschema.FunctionSpec
 	for _, baseRef := range resourceTokens {
-		complexTypeSpec := types[baseRef]
-		resources[baseRef] = pschema.ResourceSpec{
-			ObjectTypeSpec:  complexTypeSpec.ObjectTypeSpec,
-			InputProperties: complexTypeSpec.Properties,
+		resource := resourceSpec(types[baseRef], metadataRequired)
+		for name, function := range methods[baseRef] {
+			if resource.Methods == nil {
+				resource.Methods = map[string]string{}
+			}
+			if functions == nil {
+				functions = map[string]pschema.FunctionSpec{}
+			}
+			resource.Methods[name] = methodToken(baseRef, name)
+			functions[methodToken(baseRef, name)] = function
 		}
+		resources[baseRef] = resource
 		packages[string(tokens.ModuleMember(baseRef).Package())] = true
 	}
 
@@ -136,24 +207,76 @@ func genPackage(types map[string]pschema.ComplexTypeSpec, resourceTokens []strin
 	}
 	sort.Strings(allowedPackages)
 
-	pkgSpec := pschema.PackageSpec{
+	return pschema.PackageSpec{
 		Name:                DefaultName,
-		Version:             Version,
+		Version:             version,
 		Types:               types,
 		Resources:           resources,
+		Functions:           functions,
 		AllowedPackageNames: allowedPackages,
 	}
+}
 
-	pkg, err := pschema.ImportSpec(pkgSpec, nil)
-	if err != nil {
-		return &pschema.Package{}, errors.Wrapf(err, "could not import spec")
+// resourceSpec returns the spec of the resource with the given type.
+func resourceSpec(complexTypeSpec pschema.ComplexTypeSpec, metadataRequired bool) pschema.ResourceSpec {
+	objectTypeSpec := complexTypeSpec.ObjectTypeSpec
+	requiredInputs := resourceRequiredInputs(objectTypeSpec, metadataRequired)
+	// The constructors of every language always set `apiVersion` and `kind`
+	// to their `Const` values, so they're always present in the outputs
+	if _, ok := objectTypeSpec.Properties["apiVersion"]; ok {
+		objectTypeSpec.Required = appendMissing([]string{"apiVersion", "kind"}, objectTypeSpec.Required...)
+	}
+	return pschema.ResourceSpec{
+		ObjectTypeSpec:  objectTypeSpec,
+		InputProperties: complexTypeSpec.Properties,
+		RequiredInputs:  requiredInputs,
 	}
+}
 
-	if includeObjectMetaType {
-		delete(types, objectMetaToken)
+// resourceRequiredInputs returns the input properties of a resource that are
+// listed in its top-level `required` field. Only the listed properties
+// themselves become required; the `required` fields of nested types such as
+// `spec` are handled by their own types. `apiVersion` and `kind` are set by
+// the constructors, `metadata` is optional since Pulumi auto-names resources,
+// unless metadataRequired is true, and `status` is populated by the cluster,
+// so none of them are ever required.
+func resourceRequiredInputs(objectTypeSpec pschema.ObjectTypeSpec, metadataRequired bool) []string {
+	var requiredInputs []string
+	for _, propertyName := range objectTypeSpec.Required {
+		switch propertyName {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			if !metadataRequired {
+				continue
+			}
+		}
+		if _, ok := objectTypeSpec.Properties[propertyName]; ok {
+			requiredInputs = appendMissing(requiredInputs, propertyName)
+		}
 	}
+	return requiredInputs
+}
 
-	return pkg, nil
+// appendMissing appends each of the given values to the slice, unless the
+// slice already contains it.
+func appendMissing(slice []string, values ...string) []string {
+	for _, value := range values {
+		if !contains(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
+
+// contains returns true if the slice contains the given value.
+func contains(slice []string, value string) bool {
+	for _, existing := range slice {
+		if existing == value {
+			return true
+		}
+	}
+	return false
 }
 
 // Returns true if the given TypeSpec is of type any; returns false otherwise
@@ -163,22 +286,33 @@ func isAnyType(typeSpec pschema.TypeSpec) bool {
 
 // AddType converts the given OpenAPI `schema` to a ObjectTypeSpec and adds it
 // to the `types` map under the given `name`. Recursively converts and adds all
-// nested schemas as well.
+// nested schemas as well, up to DefaultMaxDepth.
 func AddType(schema map[string]interface{}, name string, types map[string]pschema.ComplexTypeSpec) {
+	c := typeConverter{types: types, unknownTypes: UnknownTypeAny, maxDepth: DefaultMaxDepth}
+	c.addType(schema, name)
+}
+
+func (c *typeConverter) addType(schema map[string]interface{}, name string) {
 	properties, foundProperties, _ := unstruct.NestedMap(schema, "properties")
 	description, _, _ := unstruct.NestedString(schema, "description")
 	schemaType, _, _ := unstruct.NestedString(schema, "type")
 	required, _, _ := unstruct.NestedStringSlice(schema, "required")
 
 	propertySpecs := map[string]pschema.PropertySpec{}
-	for propertyName := range properties {
+	for propertyName, property := range properties {
+		if allowed, ok := property.(bool); ok {
+			propertySpecs[propertyName] = booleanSchemaPropertySpec(allowed)
+			continue
+		}
 		propertySchema, _, _ := unstruct.NestedMap(properties, propertyName)
 		propertyDescription, _, _ := unstruct.NestedString(propertySchema, "description")
 		defaultValue, _, _ := unstruct.NestedFieldNoCopy(propertySchema, "default")
+		typeSpec := c.typeSpec(propertySchema, name+TitleCase(propertyName))
 		propertySpecs[propertyName] = pschema.PropertySpec{
-			TypeSpec:    GetTypeSpec(propertySchema, name+strings.Title(propertyName), types),
-			Description: propertyDescription,
-			Default:     defaultValue,
+			TypeSpec:    typeSpec,
+			Description: appendConstraints(propertyDescription, propertySchema),
+			Default:     CoerceDefault(defaultValue, typeSpec),
+			Secret:      isSecret(propertySchema),
 		}
 	}
 
@@ -187,7 +321,11 @@ func AddType(schema map[string]interface{}, name string, types map[string]pschem
 		schemaType = Object
 	}
 
-	types[name] = pschema.ComplexTypeSpec{
+	description = appendDependencies(description, schema)
+	if foundProperties && preservesUnknownFields(schema) {
+		description = appendParagraph(description, preservedUnknownFieldsDescription)
+	}
+	c.types[name] = pschema.ComplexTypeSpec{
 		ObjectTypeSpec: pschema.ObjectTypeSpec{
 			Type:        schemaType,
 			Properties:  propertySpecs,
@@ -196,18 +334,263 @@ func AddType(schema map[string]interface{}, name string, types map[string]pschem
 		}}
 }
 
+// preservedUnknownFieldsDescription documents a type whose schema declares
+// properties but also preserves unknown fields. Pulumi types can't have
+// fields other than their properties, so they can only be set untyped.
+const preservedUnknownFieldsDescription = "Unknown fields are preserved, so fields other than these properties are " +
+	"also accepted, e.g. when set with a transformation."
+
+// preservesUnknownFields returns true if the schema sets
+// `x-kubernetes-preserve-unknown-fields`.
+func preservesUnknownFields(schema map[string]interface{}) bool {
+	preserveUnknownFields, _, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields")
+	return preserveUnknownFields
+}
+
+// forbiddenPropertyDescription documents a property whose schema is `false`
+const forbiddenPropertyDescription = "Forbidden: the schema of this property is `false`, so it can't be set."
+
+// propertyLanguage is the language-specific data that crd2pulumi keeps for
+// itself on a property, under its own name, which the code generators ignore.
+type propertyLanguage struct {
+	// Forbidden is true if the schema of the property is `false`
+	Forbidden bool `json:"forbidden,omitempty"`
+}
+
+// booleanSchemaPropertySpec returns the property of a boolean schema, which
+// JSON Schema allows in place of a schema object: `true` accepts any value,
+// and `false` accepts no value. Pulumi has no type without values, so a
+// forbidden property is of the any type, marked and documented as forbidden.
+func booleanSchemaPropertySpec(allowed bool) pschema.PropertySpec {
+	if allowed {
+		return pschema.PropertySpec{TypeSpec: anyTypeSpec}
+	}
+	return pschema.PropertySpec{
+		TypeSpec:    anyTypeSpec,
+		Description: forbiddenPropertyDescription,
+		Language: map[string]pschema.RawMessage{
+			tool: pschema.RawMessage(rawMessage(propertyLanguage{Forbidden: true})),
+		},
+	}
+}
+
+// isForbidden returns true if the property is marked as forbidden, i.e. if
+// its schema is `false`.
+func isForbidden(propertySpec pschema.PropertySpec) bool {
+	raw, ok := propertySpec.Language[tool]
+	if !ok {
+		return false
+	}
+	var language propertyLanguage
+	return json.Unmarshal(raw, &language) == nil && language.Forbidden
+}
+
+// isSecret returns true if the schema is of a string with `format: password`,
+// which conventionally marks credentials, so that Pulumi masks the value.
+func isSecret(schema map[string]interface{}) bool {
+	schemaType, _, _ := unstruct.NestedString(schema, "type")
+	format, _, _ := unstruct.NestedString(schema, "format")
+	return schemaType == String && format == "password"
+}
+
+// markSecretStatus marks the `status` of the resource as secret if it has
+// secret fields, e.g. a generated password, so that the code generators add it
+// to the `additionalSecretOutputs` of the resource. Pulumi can only mask the
+// top-level outputs of resources, so the whole status is masked in the state.
+func markSecretStatus(types map[string]pschema.ComplexTypeSpec, resourceToken string) {
+	status, ok := types[resourceToken].Properties["status"]
+	if !ok || status.Secret || !hasSecretFields(types, status.TypeSpec, map[string]bool{}) {
+		return
+	}
+	status.Secret = true
+	types[resourceToken].Properties["status"] = status
+}
+
+// hasSecretFields returns true if the type has a secret property, at any
+// depth. The types that were already visited are skipped, as types can be
+// recursive.
+func hasSecretFields(types map[string]pschema.ComplexTypeSpec, typeSpec pschema.TypeSpec, visited map[string]bool) bool {
+	if typeSpec.Items != nil && hasSecretFields(types, *typeSpec.Items, visited) {
+		return true
+	}
+	if typeSpec.AdditionalProperties != nil && hasSecretFields(types, *typeSpec.AdditionalProperties, visited) {
+		return true
+	}
+	for _, oneOf := range typeSpec.OneOf {
+		if hasSecretFields(types, oneOf, visited) {
+			return true
+		}
+	}
+	token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+	if token == typeSpec.Ref || visited[token] {
+		return false
+	}
+	visited[token] = true
+	for _, property := range types[token].Properties {
+		if property.Secret || hasSecretFields(types, property.TypeSpec, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// CoerceDefault converts a `default` value decoded from YAML or JSON into the
+// representation Pulumi expects for a property of the given type. Integers and
+// numbers are both represented as float64, since that's what the Pulumi schema
+// binds to the property's declared type (e.g. float64(3) becomes int 3 for an
+// integer property). Returns nil if the value can't be a default for the type,
+// which is always the case for non-scalar types.
+func CoerceDefault(value interface{}, typeSpec pschema.TypeSpec) interface{} {
+	if value == nil {
+		return nil
+	}
+	if len(typeSpec.OneOf) > 0 {
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			if v := CoerceDefault(value, oneOfTypeSpec); v != nil {
+				return v
+			}
+		}
+		return nil
+	}
+
+	switch typeSpec.Type {
+	case Integer:
+		if v, ok := toFloat64(value); ok && v == math.Trunc(v) {
+			return v
+		}
+		if v, ok := value.(string); ok {
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return float64(i)
+			}
+		}
+	case Number:
+		if v, ok := toFloat64(value); ok {
+			return v
+		}
+		if v, ok := value.(string); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case String:
+		switch v := value.(type) {
+		case string:
+			return v
+		case bool:
+			return strconv.FormatBool(v)
+		}
+		if v, ok := toFloat64(value); ok {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case Boolean:
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveDefaults removes the `default` of every property, so that the
+// generated SDKs don't set any values that the user didn't, and that the API
+// server would otherwise default itself.
+func (pg *PackageGenerator) RemoveDefaults() {
+	for token, complexTypeSpec := range pg.Types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			propertySpec.Default = nil
+			complexTypeSpec.Properties[propertyName] = propertySpec
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+}
+
+// RemoveDescriptions removes the description of every type, property, enum
+// value and method, for the smallest SDKs, without inline docs. The
+// properties whose schema is `false` are still documented as forbidden, since
+// their type can't tell.
+func (pg *PackageGenerator) RemoveDescriptions() {
+	for token, complexTypeSpec := range pg.Types {
+		complexTypeSpec.Description = ""
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			propertySpec.Description = ""
+			if isForbidden(propertySpec) {
+				propertySpec.Description = forbiddenPropertyDescription
+			}
+			complexTypeSpec.Properties[propertyName] = propertySpec
+		}
+		for i := range complexTypeSpec.Enum {
+			complexTypeSpec.Enum[i].Description = ""
+		}
+		pg.Types[token] = complexTypeSpec
+	}
+	for _, functions := range pg.methods {
+		for name, function := range functions {
+			function.Description = ""
+			functions[name] = function
+		}
+	}
+}
+
+// toFloat64 returns the given numeric value as a float64.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
 // GetTypeSpec returns the corresponding pschema.TypeSpec for a OpenAPI v3
 // schema. Handles nested pschema.TypeSpecs in case the schema type is an array,
 // object, or "combined schema" (oneOf, allOf, anyOf). Also recursively converts
-// and adds all schemas of type object to the types map.
+// and adds all schemas of type object to the types map. Schemas nested deeper
+// than DefaultMaxDepth are typed as `any`.
 func GetTypeSpec(schema map[string]interface{}, name string, types map[string]pschema.ComplexTypeSpec) pschema.TypeSpec {
+	c := typeConverter{types: types, unknownTypes: UnknownTypeAny, maxDepth: DefaultMaxDepth}
+	return c.typeSpec(schema, name)
+}
+
+func (c *typeConverter) typeSpec(schema map[string]interface{}, name string) pschema.TypeSpec {
 	if schema == nil {
 		return anyTypeSpec
 	}
 
+	// Schemas that are nested too deeply are truncated, to bound the time and
+	// memory that pathological schemas take
+	c.depth++
+	defer func() { c.depth-- }()
+	if c.depth > c.maxDepth {
+		c.truncated = append(c.truncated, name)
+		return anyTypeSpec
+	}
+
+	// A recursive reference has the type of the schema it refers to, if
+	// that's an object type that's being converted
+	if ref, ok := schema[recursiveRefKey].(string); ok {
+		if typeName, ok := c.converting[ref]; ok {
+			return pschema.TypeSpec{
+				Type: Object,
+				Ref:  "#/types/" + typeName,
+			}
+		}
+	}
+
 	intOrString, foundIntOrString, _ := unstruct.NestedBool(schema, "x-kubernetes-int-or-string")
 	if foundIntOrString && intOrString {
-		return intOrStringTypeSpec
+		return c.intOrStringTypeSpec(schema, name)
 	}
 
 	// If the schema is of the `oneOf` type: return a TypeSpec with the `OneOf`
@@ -216,7 +599,7 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 	if foundOneOf {
 		oneOfTypeSpecs := make([]pschema.TypeSpec, 0, len(oneOf))
 		for i, oneOfSchema := range oneOf {
-			oneOfTypeSpec := GetTypeSpec(oneOfSchema, name+"OneOf"+strconv.Itoa(i), types)
+			oneOfTypeSpec := c.typeSpec(oneOfSchema, name+"OneOf"+strconv.Itoa(i))
 			if isAnyType(oneOfTypeSpec) {
 				return anyTypeSpec
 			}
@@ -233,7 +616,7 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 	allOf, foundAllOf, _ := NestedMapSlice(schema, "allOf")
 	if foundAllOf {
 		combinedSchema := CombineSchemas(true, allOf...)
-		return GetTypeSpec(combinedSchema, name, types)
+		return c.typeSpec(combinedSchema, name)
 	}
 
 	// If the schema is of `anyOf` type: combine only `properties` of
@@ -242,38 +625,62 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 	anyOf, foundAnyOf, _ := NestedMapSlice(schema, "anyOf")
 	if foundAnyOf {
 		combinedSchema := CombineSchemas(false, anyOf...)
-		return GetTypeSpec(combinedSchema, name, types)
+		return c.typeSpec(combinedSchema, name)
 	}
 
-	preserveUnknownFields, foundPreserveUnknownFields, _ := unstruct.NestedBool(schema, "x-kubernetes-preserve-unknown-fields")
-	if foundPreserveUnknownFields && preserveUnknownFields {
-		return arbitraryJSONTypeSpec
+	// A schema that preserves unknown fields is arbitrary JSON, unless it
+	// also declares properties, e.g. the items of an array of partially
+	// typed objects, which are still typed
+	if preservesUnknownFields(schema) {
+		if _, foundProperties, _ := unstruct.NestedMap(schema, "properties"); !foundProperties {
+			return arbitraryJSONTypeSpec
+		}
 	}
 
 	// If the the schema wasn't some combination of other types (`oneOf`,
 	// `allOf`, `anyOf`), then it must have a "type" field, otherwise we
 	// cannot represent it. If we cannot represent it, we simply set it to be
-	// any type.
+	// any type. Schemas with properties are objects, like in addType, as
+	// older CRDs often leave their types out.
 	schemaType, foundSchemaType, _ := unstruct.NestedString(schema, "type")
 	if !foundSchemaType {
-		return anyTypeSpec
+		if _, foundProperties, _ := unstruct.NestedMap(schema, "properties"); !foundProperties {
+			return anyTypeSpec
+		}
+		schemaType = Object
 	}
 
 	switch schemaType {
 	case Array:
 		items, _, _ := unstruct.NestedMap(schema, "items")
-		arrayTypeSpec := GetTypeSpec(items, name, types)
+		arrayTypeSpec := c.typeSpec(items, name)
 		return pschema.TypeSpec{
 			Type:  Array,
 			Items: &arrayTypeSpec,
 		}
 	case Object:
-		AddType(schema, name, types)
-		// If `additionalProperties` has a sub-schema, then we generate a type for a map from string --> sub-schema type
+		// The recursive references in the properties of a recursive schema
+		// refer to its type
+		_, foundProperties, _ := unstruct.NestedMap(schema, "properties")
+		if ref, ok := schema[refKey].(string); ok && foundProperties {
+			if c.converting == nil {
+				c.converting = map[string]string{}
+			}
+			c.converting[ref] = name
+			defer delete(c.converting, ref)
+		}
+		c.addType(schema, name)
+		objectTypeSpec := pschema.TypeSpec{
+			Type: Object,
+			Ref:  "#/types/" + name,
+		}
+		// If `additionalProperties` has a sub-schema, then we generate a type for a map from string --> sub-schema type.
+		// A `$ref` sub-schema was already inlined by NormalizeSchema, so the map's values have the referenced type.
+		var mapTypeSpec *pschema.TypeSpec
 		additionalProperties, foundAdditionalProperties, _ := unstruct.NestedMap(schema, "additionalProperties")
 		if foundAdditionalProperties {
-			additionalPropertiesTypeSpec := GetTypeSpec(additionalProperties, name, types)
-			return pschema.TypeSpec{
+			additionalPropertiesTypeSpec := c.typeSpec(additionalProperties, name)
+			mapTypeSpec = &pschema.TypeSpec{
 				Type:                 Object,
 				AdditionalProperties: &additionalPropertiesTypeSpec,
 			}
@@ -281,21 +688,27 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 		// `additionalProperties: true` is equivalent to `additionalProperties: {}`, meaning a map from string -> any
 		additionalPropertiesIsTrue, additionalPropertiesIsTrueFound, _ := unstruct.NestedBool(schema, "additionalProperties")
 		if additionalPropertiesIsTrueFound && additionalPropertiesIsTrue {
-			return pschema.TypeSpec{
+			mapTypeSpec = &pschema.TypeSpec{
 				Type:                 Object,
 				AdditionalProperties: &anyTypeSpec,
 			}
 		}
-		// If no properties are found, then it can be arbitrary JSON
-		_, foundProperties, _ := unstruct.NestedMap(schema, "properties")
-		if !foundProperties {
+		switch {
+		case mapTypeSpec != nil && foundProperties:
+			// A partially open object declares some of its keys and types the
+			// rest. Object types can't have additional properties, so it's
+			// either its object type, with the declared keys, or a map
+			return pschema.TypeSpec{
+				OneOf: []pschema.TypeSpec{objectTypeSpec, *mapTypeSpec},
+			}
+		case mapTypeSpec != nil:
+			return *mapTypeSpec
+		case !foundProperties:
+			// If no properties are found, then it can be arbitrary JSON
 			return arbitraryJSONTypeSpec
 		}
 		// If properties are found, then we must specify those in a seperate interface
-		return pschema.TypeSpec{
-			Type: Object,
-			Ref:  "#/types/" + name,
-		}
+		return objectTypeSpec
 	case Integer:
 		fallthrough
 	case Boolean:
@@ -303,11 +716,47 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 	case String:
 		fallthrough
 	case Number:
+		// If the schema restricts its values with `enum`, then we generate an enum type for it
+		if enumTypeSpec, collisions, ok := getEnumTypeSpec(schema, schemaType); ok {
+			c.enumCollisions(name, collisions)
+			c.types[name] = enumTypeSpec
+			return pschema.TypeSpec{
+				Type: schemaType,
+				Ref:  "#/types/" + name,
+			}
+		}
 		return pschema.TypeSpec{
 			Type: schemaType,
 		}
 	default:
-		return anyTypeSpec
+		return c.unknownTypeSpec(schemaType, name)
+	}
+}
+
+// intOrStringTypeSpec returns the type of an `x-kubernetes-int-or-string`
+// schema. If the schema restricts its values with `enum`, then the type is the
+// enum of its integer or of its string values, or a union of both enums.
+func (c *typeConverter) intOrStringTypeSpec(schema map[string]interface{}, name string) pschema.TypeSpec {
+	integerEnum, stringEnum, collisions, ok := intOrStringEnumTypeSpecs(schema)
+	c.enumCollisions(name, collisions)
+	switch {
+	case !ok:
+		return intOrStringTypeSpec
+	case stringEnum == nil:
+		c.types[name] = *integerEnum
+		return pschema.TypeSpec{Type: Integer, Ref: "#/types/" + name}
+	case integerEnum == nil:
+		c.types[name] = *stringEnum
+		return pschema.TypeSpec{Type: String, Ref: "#/types/" + name}
+	}
+	// The enums are named like the types of `oneOf` schemas
+	c.types[name+"OneOf0"] = *integerEnum
+	c.types[name+"OneOf1"] = *stringEnum
+	return pschema.TypeSpec{
+		OneOf: []pschema.TypeSpec{
+			{Type: Integer, Ref: "#/types/" + name + "OneOf0"},
+			{Type: String, Ref: "#/types/" + name + "OneOf1"},
+		},
 	}
 }
 
@@ -316,6 +765,10 @@ func GetTypeSpec(schema map[string]interface{}, name string, types map[string]ps
 // only 1 schema is given. If combineRequired == true, then each sub-schema's
 // `required` fields are also combined. In this case the combined schema's
 // `required` field is of type []interface{}, not []string.
+//
+// Nested `allOf` and `anyOf` sub-schemas are combined too, and a nested
+// `oneOf` is kept: the result is a `oneOf` schema whose alternatives are each
+// of its alternatives combined with the other sub-schemas.
 func CombineSchemas(combineRequired bool, schemas ...map[string]interface{}) map[string]interface{} {
 	if len(schemas) == 0 {
 		return nil
@@ -324,6 +777,26 @@ func CombineSchemas(combineRequired bool, schemas ...map[string]interface{}) map
 		return schemas[0]
 	}
 
+	schemas = flattenSchemas(combineRequired, schemas)
+	for i, schema := range schemas {
+		oneOf, foundOneOf, _ := NestedMapSlice(schema, "oneOf")
+		if !foundOneOf {
+			continue
+		}
+		others := make([]map[string]interface{}, 0, len(schemas))
+		others = append(others, schemas[:i]...)
+		others = append(others, schemas[i+1:]...)
+		if rest := withoutKeys(schema, "oneOf"); hasProperties(rest) {
+			others = append(others, rest)
+		}
+		alternatives := make([]interface{}, 0, len(oneOf))
+		for _, oneOfSchema := range oneOf {
+			alternative := append(append([]map[string]interface{}{}, others...), oneOfSchema)
+			alternatives = append(alternatives, CombineSchemas(combineRequired, alternative...))
+		}
+		return map[string]interface{}{"oneOf": alternatives}
+	}
+
 	combinedProperties := map[string]interface{}{}
 	combinedRequired := make([]string, 0)
 
@@ -350,3 +823,46 @@ func CombineSchemas(combineRequired bool, schemas ...map[string]interface{}) map
 	}
 	return combinedSchema
 }
+
+// flattenSchemas replaces the nested `allOf` and `anyOf` sub-schemas of the
+// given schemas with their own sub-schemas, recursively. The `required` fields
+// of `anyOf` sub-schemas are removed, and those of the others too unless
+// combineRequired is true.
+func flattenSchemas(combineRequired bool, schemas []map[string]interface{}) []map[string]interface{} {
+	flattened := make([]map[string]interface{}, 0, len(schemas))
+	for _, schema := range schemas {
+		allOf, foundAllOf, _ := NestedMapSlice(schema, "allOf")
+		anyOf, foundAnyOf, _ := NestedMapSlice(schema, "anyOf")
+		if !foundAllOf && !foundAnyOf && combineRequired {
+			flattened = append(flattened, schema)
+			continue
+		}
+		if combineRequired {
+			flattened = append(flattened, withoutKeys(schema, "allOf", "anyOf"))
+		} else {
+			flattened = append(flattened, withoutKeys(schema, "allOf", "anyOf", "required"))
+		}
+		flattened = append(flattened, flattenSchemas(combineRequired, allOf)...)
+		flattened = append(flattened, flattenSchemas(false, anyOf)...)
+	}
+	return flattened
+}
+
+// withoutKeys returns a shallow copy of the schema without the given keys.
+func withoutKeys(schema map[string]interface{}, keys ...string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	for _, key := range keys {
+		delete(copied, key)
+	}
+	return copied
+}
+
+// hasProperties returns true if the schema declares or requires properties.
+func hasProperties(schema map[string]interface{}) bool {
+	_, foundProperties := schema["properties"]
+	_, foundRequired := schema["required"]
+	return foundProperties || foundRequired
+}
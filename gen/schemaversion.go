@@ -0,0 +1,140 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// MinSchemaVersion and MaxSchemaVersion bound the Pulumi versions that the
+// written package schema can target. MaxSchemaVersion is the version of the
+// Pulumi code generators that crd2pulumi uses, and the default.
+const (
+	MinSchemaVersion = "3.0.0"
+	MaxSchemaVersion = "3.21.0"
+)
+
+// schemaFeature is a feature of the package schema that crd2pulumi populates,
+// with the Pulumi version that introduced it.
+type schemaFeature struct {
+	name    string
+	version semver.Version
+	// omit removes the feature from the schema, and returns true if the
+	// schema used it
+	omit func(spec *pschema.PackageSpec) bool
+	// silent is true if omitting the feature doesn't need a warning, since
+	// crd2pulumi always populates it
+	silent bool
+}
+
+var schemaFeatures = []schemaFeature{
+	{name: "resource methods", version: semver.MustParse("3.6.0"), omit: omitMethods},
+	{name: "replaceOnChanges properties", version: semver.MustParse("3.11.0"), omit: omitReplaceOnChanges},
+	{name: "allowedPackageNames", version: semver.MustParse("3.20.0"), omit: omitAllowedPackageNames, silent: true},
+}
+
+// parseSchemaVersion returns the Pulumi version that the schema targets, or
+// an error if it isn't between MinSchemaVersion and MaxSchemaVersion.
+func parseSchemaVersion(version string) (semver.Version, error) {
+	if version == "" {
+		return semver.MustParse(MaxSchemaVersion), nil
+	}
+	parsed, err := semver.ParseTolerant(version)
+	if err != nil {
+		return semver.Version{}, errors.Wrapf(err, "invalid schema version %q", version)
+	}
+	if parsed.LT(semver.MustParse(MinSchemaVersion)) || parsed.GT(semver.MustParse(MaxSchemaVersion)) {
+		return semver.Version{}, errors.Errorf("unsupported schema version %s, expected a Pulumi version from %s to %s",
+			version, MinSchemaVersion, MaxSchemaVersion)
+	}
+	return parsed, nil
+}
+
+// targetSchemaVersion removes the features that the given Pulumi version
+// doesn't support from the schema, so that its CLI can import it. Returns a
+// warning for each omitted feature that the schema used.
+func targetSchemaVersion(spec *pschema.PackageSpec, version semver.Version) []string {
+	var warnings []string
+	for _, feature := range schemaFeatures {
+		if version.GTE(feature.version) {
+			continue
+		}
+		if feature.omit(spec) && !feature.silent {
+			warnings = append(warnings, fmt.Sprintf("the schema omits its %s, which need Pulumi %s or later", feature.name, feature.version))
+		}
+	}
+	return warnings
+}
+
+func omitMethods(spec *pschema.PackageSpec) bool {
+	omitted := false
+	for token, resource := range spec.Resources {
+		if len(resource.Methods) > 0 {
+			resource.Methods = nil
+			spec.Resources[token] = resource
+			omitted = true
+		}
+	}
+	if len(spec.Functions) > 0 {
+		spec.Functions = nil
+		omitted = true
+	}
+	return omitted
+}
+
+func omitReplaceOnChanges(spec *pschema.PackageSpec) bool {
+	omitted := false
+	omit := func(properties map[string]pschema.PropertySpec) map[string]pschema.PropertySpec {
+		var copied map[string]pschema.PropertySpec
+		for name, property := range properties {
+			if !property.ReplaceOnChanges {
+				continue
+			}
+			if copied == nil {
+				copied = make(map[string]pschema.PropertySpec, len(properties))
+				for name, property := range properties {
+					copied[name] = property
+				}
+			}
+			property.ReplaceOnChanges = false
+			copied[name] = property
+			omitted = true
+		}
+		if copied == nil {
+			return properties
+		}
+		return copied
+	}
+	for token, resource := range spec.Resources {
+		resource.Properties = omit(resource.Properties)
+		resource.InputProperties = omit(resource.InputProperties)
+		spec.Resources[token] = resource
+	}
+	for token, complexTypeSpec := range spec.Types {
+		complexTypeSpec.Properties = omit(complexTypeSpec.Properties)
+		spec.Types[token] = complexTypeSpec
+	}
+	return omitted
+}
+
+func omitAllowedPackageNames(spec *pschema.PackageSpec) bool {
+	omitted := len(spec.AllowedPackageNames) > 0
+	spec.AllowedPackageNames = nil
+	return omitted
+}
@@ -0,0 +1,93 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// The SDK version files expose the package version at runtime, in the way
+// that's idiomatic for each language, e.g. so that programs can check which
+// version of the SDK they vendor.
+
+const nodejsVersionPath = "version.ts"
+
+func nodejsVersionFile(version string) string {
+	return fmt.Sprintf(`// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+/**
+ * The version of this SDK.
+ */
+export const version = %q;
+`, version)
+}
+
+const pythonVersionModule = "_version"
+
+func pythonVersionFile(version string) string {
+	return fmt.Sprintf(`# coding=utf-8
+# *** WARNING: this file was generated by crd2pulumi. ***
+# *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+__version__ = %q
+`, version)
+}
+
+const goVersionPath = "version.go"
+
+func goVersionFile(version string) string {
+	return fmt.Sprintf(`// *** WARNING: this file was generated by crd2pulumi. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package kubernetes
+
+// Version is the version of this SDK.
+const Version = %q
+`, version)
+}
+
+// dotNetVersionPath is the file that the generated project embeds as the
+// version of the assembly
+const dotNetVersionPath = "version.txt"
+
+// addNodeJSVersion adds the version file to the generated NodeJS files, and
+// exports the version from the root of the package.
+func addNodeJSVersion(files map[string][]byte, version string) error {
+	index, ok := files[nodejsIndexPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", nodejsIndexPath)
+	}
+	files[nodejsVersionPath] = []byte(nodejsVersionFile(version))
+	files[nodejsIndexPath] = bytes.Replace(index, []byte("// Export members:\n"), []byte("// Export members:\nexport * from \"./version\";\n"), 1)
+	return nil
+}
+
+// addPythonVersion adds the version file to the generated Python files, and
+// exports `__version__` from the root of the package.
+func addPythonVersion(files map[string][]byte, pythonPackageDir, version string) error {
+	initPath := filepath.Join(pythonPackageDir, "__init__.py")
+	init, ok := files[initPath]
+	if !ok {
+		return errors.Errorf("cannot find generated %s", initPath)
+	}
+	files[filepath.Join(pythonPackageDir, pythonVersionModule+".py")] = []byte(pythonVersionFile(version))
+	files[initPath] = bytes.Replace(init, []byte("# Export this package's modules as members:\n"), []byte("# Export this package's modules as members:\nfrom ."+pythonVersionModule+" import __version__\n"), 1)
+	return nil
+}
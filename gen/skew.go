@@ -0,0 +1,194 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSkewDifferences is how many differences a SchemaSkew lists in its
+// summary
+const maxSkewDifferences = 10
+
+// SchemaSkew is a version of a CRD whose schema differs across the inputs,
+// e.g. because the CRD is in several files that are maintained separately.
+type SchemaSkew struct {
+	Group   string
+	Kind    string
+	Version string
+	// Sources are the inputs that the CRD was loaded from, in order, which
+	// are `crd <index>` if they're unknown
+	Sources []string
+	// Differences summarize how the schema of each other source differs
+	// from the first one, e.g. `spec.image: removed in b.yaml`
+	Differences []string
+}
+
+// String summarizes the skew, with the first of its differences.
+func (skew SchemaSkew) String() string {
+	differences := skew.Differences
+	more := ""
+	if len(differences) > maxSkewDifferences {
+		more = fmt.Sprintf("; and %d more", len(differences)-maxSkewDifferences)
+		differences = differences[:maxSkewDifferences]
+	}
+	return fmt.Sprintf("the schemas of %s %s/%s differ across %s: %s%s", skew.Kind, skew.Group, skew.Version,
+		strings.Join(skew.Sources, ", "), strings.Join(differences, "; "), more)
+}
+
+// FindSchemaSkews returns the versions of the CRDs whose schemas differ across
+// the inputs, sorted by group, kind and version. The keywords of the schemas
+// are compared like MergeSchema compares types, once they're normalized, and
+// the order of the properties isn't compared. Only one of the schemas of a
+// skewed version is generated.
+func FindSchemaSkews(crgs []CustomResourceGenerator) []SchemaSkew {
+	type occurrence struct {
+		source string
+		schema map[string]interface{}
+	}
+	occurrences := map[[3]string][]occurrence{}
+	var keys [][3]string
+	for i, crg := range crgs {
+		source := crg.Source
+		if source == "" {
+			source = fmt.Sprintf("crd %d", i)
+		}
+		for _, version := range crg.Versions {
+			key := [3]string{crg.Group, crg.Kind, version}
+			if _, ok := occurrences[key]; !ok {
+				keys = append(keys, key)
+			}
+			occurrences[key] = append(occurrences[key], occurrence{source, crg.Schemas[version]})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for k := range keys[i] {
+			if keys[i][k] != keys[j][k] {
+				return keys[i][k] < keys[j][k]
+			}
+		}
+		return false
+	})
+
+	var skews []SchemaSkew
+	for _, key := range keys {
+		first := occurrences[key][0]
+		skew := SchemaSkew{Group: key[0], Kind: key[1], Version: key[2], Sources: []string{first.source}}
+		for _, other := range occurrences[key][1:] {
+			var differences []string
+			diffSchemas("", first.schema, other.schema, other.source, &differences)
+			if len(differences) > 0 {
+				skew.Sources = append(skew.Sources, other.source)
+				skew.Differences = append(skew.Differences, differences...)
+			}
+		}
+		if len(skew.Differences) > 0 {
+			skews = append(skews, skew)
+		}
+	}
+	return skews
+}
+
+// diffSchemas appends the differences of the other schema from the given one
+// at the path, by property, to the differences.
+func diffSchemas(path string, schema, other map[string]interface{}, source string, differences *[]string) {
+	keys := map[string]bool{}
+	for key := range schema {
+		keys[key] = true
+	}
+	for key := range other {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		value, otherValue := schema[key], other[key]
+		if key == PropertyOrderKey || sameSpec(value, otherValue) {
+			continue
+		}
+		valueSchema, isSchema := value.(map[string]interface{})
+		otherSchema, isOtherSchema := otherValue.(map[string]interface{})
+		switch {
+		case key == "properties" && (value == nil || isSchema) && (otherValue == nil || isOtherSchema):
+			diffProperties(path, valueSchema, otherSchema, source, differences)
+		case key == "items" && isSchema && isOtherSchema:
+			diffSchemas(path+"[*]", valueSchema, otherSchema, source, differences)
+		case key == "additionalProperties" && isSchema && isOtherSchema:
+			diffSchemas(joinFieldPath(path, "*"), valueSchema, otherSchema, source, differences)
+		default:
+			*differences = append(*differences, fmt.Sprintf("%s: %s is %s in %s, instead of %s",
+				schemaPath(path), key, skewValue(otherValue), source, skewValue(value)))
+		}
+	}
+}
+
+// diffProperties appends the properties that the other schema adds, removes
+// or changes at the path to the differences.
+func diffProperties(path string, properties, otherProperties map[string]interface{}, source string, differences *[]string) {
+	propertyNames := map[string]bool{}
+	for propertyName := range properties {
+		propertyNames[propertyName] = true
+	}
+	for propertyName := range otherProperties {
+		propertyNames[propertyName] = true
+	}
+	sortedPropertyNames := make([]string, 0, len(propertyNames))
+	for propertyName := range propertyNames {
+		sortedPropertyNames = append(sortedPropertyNames, propertyName)
+	}
+	sort.Strings(sortedPropertyNames)
+
+	for _, propertyName := range sortedPropertyNames {
+		propertyPath := joinFieldPath(path, propertyName)
+		property, found := properties[propertyName].(map[string]interface{})
+		otherProperty, otherFound := otherProperties[propertyName].(map[string]interface{})
+		switch {
+		case !otherFound:
+			*differences = append(*differences, fmt.Sprintf("%s: removed in %s", propertyPath, source))
+		case !found:
+			*differences = append(*differences, fmt.Sprintf("%s: added in %s", propertyPath, source))
+		default:
+			diffSchemas(propertyPath, property, otherProperty, source, differences)
+		}
+	}
+}
+
+// schemaPath returns the path, or `(root)` for the root schema.
+func schemaPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// skewValue formats a value of a schema keyword as compact JSON, or `unset`.
+func skewValue(value interface{}) string {
+	if value == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return strconv.Quote(fmt.Sprint(value))
+	}
+	return string(data)
+}
@@ -0,0 +1,63 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RemoveStatus returns a copy of the schema without the `status` property.
+func RemoveStatus(schema map[string]interface{}) map[string]interface{} {
+	properties, _, _ := unstruct.NestedMap(schema, "properties")
+	if _, ok := properties["status"]; !ok {
+		return schema
+	}
+	delete(properties, "status")
+
+	trimmed := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		trimmed[key] = value
+	}
+	trimmed["properties"] = properties
+	if required, found, _ := unstruct.NestedStringSlice(schema, "required"); found {
+		trimmedRequired := make([]interface{}, 0, len(required))
+		for _, propertyName := range required {
+			if propertyName != "status" {
+				trimmedRequired = append(trimmedRequired, propertyName)
+			}
+		}
+		trimmed["required"] = trimmedRequired
+	}
+	return trimmed
+}
+
+// ExcludeStatus removes the `status` of every CustomResource version with
+// RemoveStatus, and regenerates the types without it, so that no status types
+// are generated for SDKs that only create resources.
+func (pg *PackageGenerator) ExcludeStatus() {
+	for i, crg := range pg.CustomResourceGenerators {
+		for version, schema := range crg.Schemas {
+			pg.CustomResourceGenerators[i].Schemas[version] = RemoveStatus(schema)
+		}
+	}
+	pg.Types = pg.GetTypes()
+
+	// Resources without a typed schema get an untyped status regardless
+	for _, resourceToken := range pg.ResourceTokens {
+		if resourceType, ok := pg.Types[resourceToken]; ok {
+			delete(resourceType.Properties, "status")
+		}
+	}
+}
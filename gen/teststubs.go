@@ -0,0 +1,457 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// stubResource is a resource that a test stub constructs
+type stubResource struct {
+	// token is the token of the resource
+	token string
+	// module is the path of the resource's module, e.g. `stable/v1`, or
+	// `crds/stable/v1` under a top-level module
+	module string
+	// kind is the name of the resource's class, e.g. `CronTab`
+	kind string
+	// objectTypeSpec is the type of the resource
+	objectTypeSpec pschema.ObjectTypeSpec
+}
+
+// stubResources returns the resources of the package, sorted by module and
+// kind.
+func (pg *PackageGenerator) stubResources() []stubResource {
+	resources := make([]stubResource, 0, len(pg.ResourceTokens))
+	for _, token := range pg.ResourceTokens {
+		member := tokens.ModuleMember(token)
+		resources = append(resources, stubResource{
+			token:          token,
+			module:         pg.modulePath(string(member.Module().Name())),
+			kind:           string(member.Name()),
+			objectTypeSpec: pg.Types[token].ObjectTypeSpec,
+		})
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].module != resources[j].module {
+			return resources[i].module < resources[j].module
+		}
+		return resources[i].kind < resources[j].kind
+	})
+	return resources
+}
+
+// genTestStubs returns a test file for the given language, which constructs
+// each resource with placeholders for its required properties, against the
+// mocks of the Pulumi runtime. The stubs are a starting point for testing the
+// generated SDK, and check that its resources can be constructed. Only
+// NodeJS, Python and Go are supported.
+func (pg *PackageGenerator) genTestStubs(language, name string) map[string]*bytes.Buffer {
+	s := stubWriter{types: pg.Types, schemas: pg.typeSchemas(), schemaPropertyOrder: pg.schemaPropertyOrder, metadataRequired: pg.objectMetaRequired}
+	files := map[string]*bytes.Buffer{}
+	switch language {
+	case NodeJS:
+		files["tests/resources.test.ts"] = s.nodejsStubs(pg.stubResources(), name)
+	case Python:
+		files["tests/test_resources.py"] = s.pythonStubs(pg.stubResources(), name)
+	case Go:
+		resources := map[string][]stubResource{}
+		for _, resource := range pg.stubResources() {
+			resources[resource.module] = append(resources[resource.module], resource)
+		}
+		for module, moduleResources := range resources {
+			files[path.Join(module, "resources_test.go")] = s.goStubs(moduleResources)
+		}
+	}
+	return files
+}
+
+// addTestStubs adds the test stubs of the given language to the generated
+// files, if enabled.
+func (pg *PackageGenerator) addTestStubs(files map[string]*bytes.Buffer, language, name string) {
+	if !pg.testStubs {
+		return
+	}
+	for path, code := range pg.genTestStubs(language, name) {
+		files[path] = code
+	}
+}
+
+// stubWriter writes the placeholder values of the required properties
+type stubWriter struct {
+	types map[string]pschema.ComplexTypeSpec
+	// schemas are the schemas of the types, by token, to order their
+	// properties like the schemas if schemaPropertyOrder is true
+	schemas             map[string]map[string]interface{}
+	schemaPropertyOrder bool
+	// metadataRequired is true if the `metadata` of the resources is a
+	// required input
+	metadataRequired bool
+	// visiting contains the types currently being written, to stop recursive types
+	visiting map[string]bool
+}
+
+// requiredProperties returns the names of the required properties of the
+// object with the given token, ordered according to its schema.
+func (s *stubWriter) requiredProperties(token string, objectTypeSpec pschema.ObjectTypeSpec) []string {
+	var names []string
+	for _, name := range objectTypeSpec.Required {
+		if _, ok := objectTypeSpec.Properties[name]; ok {
+			names = appendMissing(names, name)
+		}
+	}
+	return orderProperties(s.schemas[token], names, s.schemaPropertyOrder)
+}
+
+// requiredInputs returns the names of the required inputs of the resource,
+// ordered according to its schema.
+func (s *stubWriter) requiredInputs(r stubResource) []string {
+	return orderProperties(s.schemas[r.token], resourceRequiredInputs(r.objectTypeSpec, s.metadataRequired), s.schemaPropertyOrder)
+}
+
+// objectType returns the token and the object type that the TypeSpec refers
+// to, if any.
+func (s *stubWriter) objectType(typeSpec pschema.TypeSpec) (string, pschema.ObjectTypeSpec, bool) {
+	token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+	complexType, ok := s.types[token]
+	if !ok || len(complexType.Enum) > 0 || !strings.HasPrefix(typeSpec.Ref, "#/types/") {
+		return "", pschema.ObjectTypeSpec{}, false
+	}
+	return token, complexType.ObjectTypeSpec, true
+}
+
+// enumValue returns the token and the first value of the enum that the
+// TypeSpec refers to, if any.
+func (s *stubWriter) enumValue(typeSpec pschema.TypeSpec) (string, interface{}, bool) {
+	token := strings.TrimPrefix(typeSpec.Ref, "#/types/")
+	complexType, ok := s.types[token]
+	if !ok || len(complexType.Enum) == 0 {
+		return "", nil, false
+	}
+	return token, complexType.Enum[0].Value, true
+}
+
+// enter marks the type as being written, and returns false if it already is.
+func (s *stubWriter) enter(token string) bool {
+	if s.visiting[token] {
+		return false
+	}
+	if s.visiting == nil {
+		s.visiting = map[string]bool{}
+	}
+	s.visiting[token] = true
+	return true
+}
+
+// jsonLiteral formats a scalar as JSON, which is also a valid TypeScript and
+// Python literal for strings and numbers.
+func jsonLiteral(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+const nodejsStubsHeader = `// Test stubs that construct each resource with placeholders for its required
+// properties. Replace the placeholders with real values, and run the tests
+// with mocha, e.g. ` + "`npx mocha -r ts-node/register tests/*.test.ts`" + `.
+import * as assert from "assert";
+import * as pulumi from "@pulumi/pulumi";
+
+pulumi.runtime.setMocks({
+    newResource: (args: pulumi.runtime.MockResourceArgs) => ({ id: ` + "`${args.name}_id`" + `, state: args.inputs }),
+    call: (args: pulumi.runtime.MockCallArgs) => args.inputs,
+});
+
+`
+
+func (s *stubWriter) nodejsStubs(resources []stubResource, name string) *bytes.Buffer {
+	var buffer bytes.Buffer
+	buffer.WriteString(nodejsStubsHeader)
+	fmt.Fprintf(&buffer, "describe(%q, function () {\n", name)
+	buffer.WriteString("    // The package is imported once the mocks are set\n")
+	buffer.WriteString("    let pkg: typeof import(\"..\");\n")
+	buffer.WriteString("    before(async function () {\n        pkg = await import(\"..\");\n    });\n")
+	for _, resource := range resources {
+		accessor := strings.ReplaceAll(resource.module, "/", ".") + "." + resource.kind
+		fmt.Fprintf(&buffer, "\n    it(%q, function (done) {\n", "constructs "+accessor)
+		fmt.Fprintf(&buffer, "        const resource = new pkg.%s(\"example\"", accessor)
+		if properties := s.requiredInputs(resource); len(properties) > 0 {
+			buffer.WriteString(", {\n")
+			for _, property := range properties {
+				fmt.Fprintf(&buffer, "            %s: %s,\n", nodejsKey(property), s.nodejsValue(resource.objectTypeSpec.Properties[property].TypeSpec, 3))
+			}
+			buffer.WriteString("        }")
+		}
+		buffer.WriteString(");\n")
+		buffer.WriteString("        resource.urn.apply(urn => {\n            assert.ok(urn);\n            done();\n        });\n")
+		buffer.WriteString("    });\n")
+	}
+	buffer.WriteString("});\n")
+	return &buffer
+}
+
+// nodejsValue returns a TypeScript placeholder for a value of the type, at
+// the given indentation level.
+func (s *stubWriter) nodejsValue(typeSpec pschema.TypeSpec, indent int) string {
+	if token, objectType, ok := s.objectType(typeSpec); ok {
+		properties := s.requiredProperties(token, objectType)
+		if len(properties) == 0 || !s.enter(token) {
+			return "{}"
+		}
+		defer delete(s.visiting, token)
+		var buffer bytes.Buffer
+		buffer.WriteString("{\n")
+		for _, property := range properties {
+			fmt.Fprintf(&buffer, "%s%s: %s,\n", strings.Repeat("    ", indent+1), nodejsKey(property), s.nodejsValue(objectType.Properties[property].TypeSpec, indent+1))
+		}
+		buffer.WriteString(strings.Repeat("    ", indent) + "}")
+		return buffer.String()
+	}
+	if _, value, ok := s.enumValue(typeSpec); ok {
+		return jsonLiteral(value)
+	}
+	if len(typeSpec.OneOf) > 0 {
+		return s.nodejsValue(typeSpec.OneOf[0], indent)
+	}
+	switch typeSpec.Type {
+	case String:
+		return `""`
+	case Integer, Number:
+		return "0"
+	case Boolean:
+		return "false"
+	case Array:
+		return "[]"
+	default:
+		return "{}"
+	}
+}
+
+// nodejsKey returns the property name as a key of an object literal, quoted
+// unless it's an identifier.
+func nodejsKey(property string) string {
+	if identifierRe.MatchString(property) {
+		return property
+	}
+	return jsonLiteral(property)
+}
+
+const pythonStubsHeader = `# Test stubs that construct each resource with placeholders for its required
+# properties. Replace the placeholders with real values, and run the tests
+# with ` + "`python -m unittest discover tests`" + `.
+import unittest
+
+import pulumi
+
+
+class Mocks(pulumi.runtime.Mocks):
+    def new_resource(self, args: pulumi.runtime.MockResourceArgs):
+        return [args.name + '_id', args.inputs]
+
+    def call(self, args: pulumi.runtime.MockCallArgs):
+        return {}
+
+
+# The package is imported once the mocks are set
+pulumi.runtime.set_mocks(Mocks())
+
+`
+
+func (s *stubWriter) pythonStubs(resources []stubResource, name string) *bytes.Buffer {
+	var buffer bytes.Buffer
+	buffer.WriteString(pythonStubsHeader)
+	var modules []string
+	for _, resource := range resources {
+		if !contains(modules, resource.module) {
+			modules = append(modules, resource.module)
+			parent, version := path.Split(resource.module)
+			fmt.Fprintf(&buffer, "from pulumi_%s.%s import %s as %s  # noqa: E402\n", name, strings.ReplaceAll(strings.TrimSuffix(parent, "/"), "/", "."), version, pythonModuleAlias(resource.module))
+		}
+	}
+
+	buffer.WriteString("\n\nclass TestResources(unittest.TestCase):")
+	for _, resource := range resources {
+		alias := pythonModuleAlias(resource.module)
+		fmt.Fprintf(&buffer, "\n    @pulumi.runtime.test\n    def test_%s_%s(self):\n", alias, python.PyName(resource.kind))
+		fmt.Fprintf(&buffer, "        resource = %s.%s('example'", alias, resource.kind)
+		for _, property := range s.requiredInputs(resource) {
+			fmt.Fprintf(&buffer, ",\n            %s=%s", python.PyName(property), s.pythonValue(resource.objectTypeSpec.Properties[property].TypeSpec, 3))
+		}
+		buffer.WriteString(")\n")
+		buffer.WriteString("        return resource.urn.apply(lambda urn: self.assertTrue(urn))\n")
+	}
+	return &buffer
+}
+
+// pythonModuleAlias returns the name that a module is imported as, e.g.
+// `stable_v1` for `stable/v1`.
+func pythonModuleAlias(module string) string {
+	return strings.ReplaceAll(module, "/", "_")
+}
+
+// pythonValue returns a Python placeholder for a value of the type, at the
+// given indentation level. Objects are dicts with the names of the schema,
+// which the Kubernetes-compatible SDKs pass through as-is.
+func (s *stubWriter) pythonValue(typeSpec pschema.TypeSpec, indent int) string {
+	if token, objectType, ok := s.objectType(typeSpec); ok {
+		properties := s.requiredProperties(token, objectType)
+		if len(properties) == 0 || !s.enter(token) {
+			return "{}"
+		}
+		defer delete(s.visiting, token)
+		var buffer bytes.Buffer
+		buffer.WriteString("{\n")
+		for _, property := range properties {
+			fmt.Fprintf(&buffer, "%s'%s': %s,\n", strings.Repeat("    ", indent+1), property, s.pythonValue(objectType.Properties[property].TypeSpec, indent+1))
+		}
+		buffer.WriteString(strings.Repeat("    ", indent) + "}")
+		return buffer.String()
+	}
+	if _, value, ok := s.enumValue(typeSpec); ok {
+		switch value {
+		case true:
+			return "True"
+		case false:
+			return "False"
+		}
+		return jsonLiteral(value)
+	}
+	if len(typeSpec.OneOf) > 0 {
+		return s.pythonValue(typeSpec.OneOf[0], indent)
+	}
+	switch typeSpec.Type {
+	case String:
+		return "''"
+	case Integer:
+		return "0"
+	case Number:
+		return "0.0"
+	case Boolean:
+		return "False"
+	case Array:
+		return "[]"
+	default:
+		return "{}"
+	}
+}
+
+const goStubsHeader = `// Test stubs that construct each resource with placeholders for its required
+// properties. Replace the placeholders with real values, and run the tests
+// with ` + "`go test ./...`" + `.
+package %s
+
+import (
+	"testing"
+
+%s	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+`
+
+func (s *stubWriter) goStubs(resources []stubResource) *bytes.Buffer {
+	var tests bytes.Buffer
+	for _, resource := range resources {
+		fmt.Fprintf(&tests, "\nfunc Test%s(t *testing.T) {\n", resource.kind)
+		tests.WriteString("\terr := pulumi.RunErr(func(ctx *pulumi.Context) error {\n")
+		fmt.Fprintf(&tests, "\t\t_, err := New%s(ctx, \"example\", &%sArgs{", resource.kind, resource.kind)
+		if properties := s.requiredInputs(resource); len(properties) > 0 {
+			tests.WriteString("\n")
+			for _, property := range properties {
+				fmt.Fprintf(&tests, "\t\t\t%s: %s,\n", go_gen.Title(property), s.goValue(resource.objectTypeSpec.Properties[property].TypeSpec, 3))
+			}
+			tests.WriteString("\t\t")
+		}
+		tests.WriteString("})\n\t\treturn err\n")
+		tests.WriteString("\t}, pulumi.WithMocks(\"project\", \"stack\", mocks(0)))\n")
+		tests.WriteString("\tif err != nil {\n\t\tt.Fatal(err)\n\t}\n}\n")
+	}
+
+	// The ObjectMeta type is only imported if a required metadata uses it
+	var buffer bytes.Buffer
+	metav1Import := ""
+	if strings.Contains(tests.String(), "metav1.") {
+		metav1Import = "\tmetav1 \"github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1\"\n"
+	}
+	fmt.Fprintf(&buffer, goStubsHeader, path.Base(resources[0].module), metav1Import)
+	buffer.Write(tests.Bytes())
+	return &buffer
+}
+
+// goValue returns a Go placeholder for a value of the type, at the given
+// indentation level. Values whose Go type depends on details of the code
+// generator, e.g. unions, maps and arrays of objects, are left nil.
+func (s *stubWriter) goValue(typeSpec pschema.TypeSpec, indent int) string {
+	if typeSpec.Ref == objectMetaRef {
+		return "&metav1.ObjectMetaArgs{}"
+	}
+	if token, objectType, ok := s.objectType(typeSpec); ok {
+		typeName := string(tokens.ModuleMember(token).Name()) + "Args"
+		properties := s.requiredProperties(token, objectType)
+		if len(properties) == 0 || !s.enter(token) {
+			return "&" + typeName + "{}"
+		}
+		defer delete(s.visiting, token)
+		var buffer bytes.Buffer
+		fmt.Fprintf(&buffer, "&%s{\n", typeName)
+		for _, property := range properties {
+			fmt.Fprintf(&buffer, "%s%s: %s,\n", strings.Repeat("\t", indent+1), go_gen.Title(property), s.goValue(objectType.Properties[property].TypeSpec, indent+1))
+		}
+		buffer.WriteString(strings.Repeat("\t", indent) + "}")
+		return buffer.String()
+	}
+	if token, value, ok := s.enumValue(typeSpec); ok {
+		return fmt.Sprintf("%s(%s)", tokens.ModuleMember(token).Name(), jsonLiteral(value))
+	}
+	if len(typeSpec.OneOf) > 0 {
+		return "nil"
+	}
+	switch typeSpec.Type {
+	case String:
+		return `pulumi.String("")`
+	case Integer:
+		return "pulumi.Int(0)"
+	case Number:
+		return "pulumi.Float64(0)"
+	case Boolean:
+		return "pulumi.Bool(false)"
+	case Array:
+		if typeSpec.Items != nil && typeSpec.Items.Ref == "" && typeSpec.Items.Type == String {
+			return "pulumi.StringArray{}"
+		}
+	}
+	return "nil"
+}
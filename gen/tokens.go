@@ -0,0 +1,142 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// The code that each language's generator registers resources with, whose
+// first group is the resource's type token. They find the resource files that
+// the type token constants are appended to.
+var (
+	nodejsResourceTokenRe = regexp.MustCompile(`__pulumiType = '([^']+)';`)
+	pythonResourceTokenRe = regexp.MustCompile(`super\(\w+, __self__\)\.__init__\(\s*'([^']+)',`)
+	goResourceTokenRe     = regexp.MustCompile(`ctx\.RegisterResource\("([^"]+)",`)
+	dotnetResourceTokenRe = regexp.MustCompile(`ResourceType\("([^"]+)"\)\]`)
+)
+
+var pythonAllRe = regexp.MustCompile(`(?m)^__all__ = \[(.*)\]$`)
+
+var dotnetNamespaceRe = regexp.MustCompile(`(?m)^namespace (\S+)$`)
+
+// appendTypeTokens appends a constant with the Pulumi type token of each
+// resource, e.g. `kubernetes:stable.example.com/v1:CronTab`, to the file of
+// the resource that the given language generated. Returns an error if the
+// file of a resource can't be found.
+func (pg *PackageGenerator) appendTypeTokens(files map[string][]byte, language string) error {
+	var resourceTokenRe *regexp.Regexp
+	var typeTokenCode func(code []byte, kind, token string) []byte
+	switch language {
+	case NodeJS:
+		resourceTokenRe, typeTokenCode = nodejsResourceTokenRe, nodejsTypeToken
+	case Python:
+		resourceTokenRe, typeTokenCode = pythonResourceTokenRe, pythonTypeToken
+	case Go:
+		resourceTokenRe, typeTokenCode = goResourceTokenRe, goTypeToken
+	case DotNet:
+		resourceTokenRe, typeTokenCode = dotnetResourceTokenRe, dotnetTypeToken
+	default:
+		return errors.Errorf("unsupported language %q", language)
+	}
+
+	resourcePaths := map[string]string{}
+	for path, code := range files {
+		if match := resourceTokenRe.FindSubmatch(code); match != nil {
+			resourcePaths[string(match[1])] = path
+		}
+	}
+	for _, token := range pg.ResourceTokens {
+		path, ok := resourcePaths[token]
+		if !ok {
+			return errors.Errorf("could not find the generated %s code of %s", language, token)
+		}
+		kind := string(tokens.ModuleMember(token).Name())
+		files[path] = typeTokenCode(files[path], kind, token)
+	}
+	return nil
+}
+
+func nodejsTypeToken(code []byte, kind, token string) []byte {
+	return append(code, fmt.Sprintf(`
+/**
+ * The Pulumi type token of %s resources.
+ */
+export const %sTypeToken = %q;
+`, kind, kind, token)...)
+}
+
+func pythonTypeToken(code []byte, kind, token string) []byte {
+	name := upperSnakeCase(kind) + "_TYPE_TOKEN"
+	// The resource modules are imported with `*`, so the constant is exported
+	code = pythonAllRe.ReplaceAll(code, []byte(fmt.Sprintf("__all__ = [${1}, '%s']", name)))
+	return append(code, fmt.Sprintf(`
+%s = %q
+"""
+The Pulumi type token of %s resources.
+"""
+`, name, token, kind)...)
+}
+
+func goTypeToken(code []byte, kind, token string) []byte {
+	return append(code, fmt.Sprintf(`
+// %sTypeToken is the Pulumi type token of %s resources.
+const %sTypeToken = %q
+`, kind, kind, kind, token)...)
+}
+
+func dotnetTypeToken(code []byte, kind, token string) []byte {
+	namespace := ""
+	if match := dotnetNamespaceRe.FindSubmatch(code); match != nil {
+		namespace = string(match[1])
+	}
+	return append(code, fmt.Sprintf(`
+namespace %s
+{
+    public partial class %s
+    {
+        /// <summary>
+        /// The Pulumi type token of %s resources.
+        /// </summary>
+        public const string TypeToken = %q;
+    }
+}
+`, namespace, kind, kind, token)...)
+}
+
+// upperSnakeCase returns the PascalCase or camelCase string in upper snake
+// case, e.g. "CronTab" becomes "CRON_TAB", and "HTTPRoute" becomes
+// "HTTP_ROUTE".
+func upperSnakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
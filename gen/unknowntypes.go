@@ -0,0 +1,93 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// UnknownTypePolicy is how schemas whose `type` isn't an OpenAPI v3 type, e.g.
+// a typo such as `strnig`, are converted.
+type UnknownTypePolicy string
+
+const (
+	// UnknownTypeAny converts them to the any type, which is the default
+	UnknownTypeAny UnknownTypePolicy = "any"
+	// UnknownTypeObject converts them to an object of arbitrary JSON
+	UnknownTypeObject UnknownTypePolicy = "object"
+	// UnknownTypeError fails the conversion
+	UnknownTypeError UnknownTypePolicy = "error"
+)
+
+// ParseUnknownTypePolicy returns the policy of the given name.
+func ParseUnknownTypePolicy(name string) (UnknownTypePolicy, error) {
+	switch policy := UnknownTypePolicy(name); policy {
+	case UnknownTypeAny, UnknownTypeObject, UnknownTypeError:
+		return policy, nil
+	default:
+		return "", errors.Errorf("invalid unknown type policy %q, expected any, object or error", name)
+	}
+}
+
+// typeConverter converts OpenAPI v3 schemas to Pulumi types, and adds the
+// object and enum types to the types map.
+type typeConverter struct {
+	types        map[string]pschema.ComplexTypeSpec
+	unknownTypes UnknownTypePolicy
+	// err is the error of the first unknown type, with UnknownTypeError
+	err error
+	// converting maps the `$ref`s of the recursive schemas currently being
+	// converted to the names of their types
+	converting map[string]string
+	// depth is how deeply the schema being converted is nested, and the
+	// schemas nested deeper than maxDepth are recorded in truncated and
+	// typed as `any`
+	depth     int
+	maxDepth  int
+	truncated []string
+	// enumCollisionPolicy is how the members of enum values whose names
+	// collide are named, and renamedEnumValues describes the suffixed ones
+	enumCollisionPolicy EnumCollisionPolicy
+	renamedEnumValues   []string
+}
+
+// unknownTypeSpec returns the type of a schema of an unknown type according
+// to the policy, and records the error of the first one with
+// UnknownTypeError.
+func (c *typeConverter) unknownTypeSpec(schemaType, name string) pschema.TypeSpec {
+	switch c.unknownTypes {
+	case UnknownTypeObject:
+		return arbitraryJSONTypeSpec
+	case UnknownTypeError:
+		if c.err == nil {
+			c.err = errors.Errorf("%s has an unknown type %q", name, schemaType)
+		}
+	}
+	return anyTypeSpec
+}
+
+// SetUnknownTypePolicy sets how schemas of unknown types are converted, and
+// regenerates the types with it. Returns an error if the policy is
+// UnknownTypeError and a schema has an unknown type.
+func (pg *PackageGenerator) SetUnknownTypePolicy(policy UnknownTypePolicy) error {
+	pg.unknownTypes = policy
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// URLAuth configures the TLS and the authentication of the requests of the
+// URLLoaders, e.g. to fetch CRDs from internal servers that require client
+// certificates or bearer tokens.
+type URLAuth struct {
+	// CACertPath is the path of a PEM bundle of the CAs to verify the
+	// servers with, instead of the system's CAs
+	CACertPath string
+	// ClientCertPath and ClientKeyPath are the paths of the PEM certificate
+	// and key to authenticate to the servers with. They're set together.
+	ClientCertPath string
+	ClientKeyPath  string
+	// BearerToken is sent in the `Authorization` header of every request,
+	// which must be over https
+	BearerToken string
+	// BearerTokenPath is the path of a file with the bearer token, instead
+	// of BearerToken, so that it isn't on the command line
+	BearerTokenPath string
+}
+
+// IsZero returns true if the URLAuth configures nothing, so the URLLoaders
+// use the default HTTP client.
+func (a URLAuth) IsZero() bool {
+	return a == URLAuth{}
+}
+
+// Client returns the HTTP client that verifies the servers with the CAs, and
+// presents the client certificate, if they're set. Returns an error if the
+// files can't be read or parsed, so that a misconfiguration fails before
+// anything is fetched.
+func (a URLAuth) Client() (*http.Client, error) {
+	if (a.ClientCertPath == "") != (a.ClientKeyPath == "") {
+		return nil, errors.New("the client certificate and key must be set together")
+	}
+	if a.CACertPath == "" && a.ClientCertPath == "" {
+		return http.DefaultClient, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if a.CACertPath != "" {
+		data, err := ioutil.ReadFile(a.CACertPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read the CA certificate %s", a.CACertPath)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("could not parse the CA certificate %s: it has no PEM certificates", a.CACertPath)
+		}
+		config.RootCAs = pool
+	}
+	if a.ClientCertPath != "" {
+		certificate, err := tls.LoadX509KeyPair(a.ClientCertPath, a.ClientKeyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not load the client certificate %s and key %s", a.ClientCertPath, a.ClientKeyPath)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}, nil
+}
+
+// Token returns the bearer token, read from BearerTokenPath if it's set.
+// Returns an error if both are set, or if the file can't be read or is empty.
+func (a URLAuth) Token() (string, error) {
+	if a.BearerTokenPath == "" {
+		return a.BearerToken, nil
+	}
+	if a.BearerToken != "" {
+		return "", errors.New("the bearer token and the bearer token file can't both be set")
+	}
+	data, err := ioutil.ReadFile(a.BearerTokenPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read the bearer token file %s", a.BearerTokenPath)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.Errorf("the bearer token file %s is empty", a.BearerTokenPath)
+	}
+	return token, nil
+}
+
+// WithURLAuth returns a copy of the loaders, with the URLLoaders configured by
+// the given URLAuth. Returns an error if the URLAuth is misconfigured.
+func (l MultiLoader) WithURLAuth(auth URLAuth) (MultiLoader, error) {
+	client, err := auth.Client()
+	if err != nil {
+		return nil, err
+	}
+	bearerToken, err := auth.Token()
+	if err != nil {
+		return nil, err
+	}
+	loaders := make(MultiLoader, 0, len(l))
+	for _, loader := range l {
+		switch loader := loader.(type) {
+		case URLLoader:
+			loader.Client = client
+			loader.BearerToken = bearerToken
+			loaders = append(loaders, loader)
+		default:
+			loaders = append(loaders, loader)
+		}
+	}
+	return loaders, nil
+}
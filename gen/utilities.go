@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"regexp"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/pkg/errors"
@@ -32,27 +33,67 @@ import (
 
 const CRD = "CustomResourceDefinition"
 
+// helmHookAnnotation is the annotation that makes a manifest of a Helm chart a
+// hook, e.g. `test` for the manifests that `helm test` creates
+const helmHookAnnotation = "helm.sh/hook"
+
 // UnmarshalYamls un-marshals the YAML documents in the given file into a slice of unstruct.Unstructureds, one for each
 // CRD. Only returns the YAML files for Kubernetes manifests that are CRDs and ignores others. Returns an error if any
 // document failed to unmarshal.
 func UnmarshalYamls(yamlFiles [][]byte) ([]unstruct.Unstructured, error) {
+	return unmarshalYamls(yamlFiles, false)
+}
+
+// unmarshalYamls is UnmarshalYamls, that also returns the CRDs embedded in
+// the manifests that aren't CRDs if embedded is true.
+func unmarshalYamls(yamlFiles [][]byte, embedded bool) ([]unstruct.Unstructured, error) {
 	var crds []unstruct.Unstructured
 	for _, yamlFile := range yamlFiles {
 		var err error
+		var fileCRDs, embeddedCRDsOfFile []unstruct.Unstructured
 		dec := yaml.NewYAMLOrJSONDecoder(ioutil.NopCloser(bytes.NewReader(yamlFile)), 128)
 		for err != io.EOF {
-			var value map[string]interface{}
+			// Documents that aren't objects, e.g. the empty documents of Helm
+			// templates that render nothing, are skipped
+			var value interface{}
 			if err = dec.Decode(&value); err != nil && err != io.EOF {
 				return nil, errors.Wrap(err, "failed to unmarshal yaml")
 			}
-			if crd := (unstruct.Unstructured{Object: value}); value != nil && crd.GetKind() == CRD {
-				crds = append(crds, crd)
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if manifest := (unstruct.Unstructured{Object: object}); isCRD(manifest) {
+				fileCRDs = append(fileCRDs, manifest)
+			} else if embedded {
+				manifestCRDs, err := embeddedCRDs(manifest)
+				if err != nil {
+					return nil, err
+				}
+				embeddedCRDsOfFile = append(embeddedCRDsOfFile, manifestCRDs...)
 			}
 		}
+		annotatePropertyOrder(yamlFile, fileCRDs)
+		crds = append(crds, fileCRDs...)
+		crds = append(crds, embeddedCRDsOfFile...)
 	}
 	return crds, nil
 }
 
+// isCRD returns true if the manifest is a CRD of the apiextensions.k8s.io API
+// group. Helm test hooks are never CRDs, even if their kind is.
+func isCRD(manifest unstruct.Unstructured) bool {
+	if manifest.GetKind() != CRD || !strings.HasPrefix(manifest.GetAPIVersion(), "apiextensions.k8s.io/") {
+		return false
+	}
+	for _, hook := range strings.Split(manifest.GetAnnotations()[helmHookAnnotation], ",") {
+		if strings.HasPrefix(strings.TrimSpace(hook), "test") {
+			return false
+		}
+	}
+	return true
+}
+
 // UnmarshalYaml un-marshals one and only one YAML document from a file
 func UnmarshalYaml(yamlFile []byte) (map[string]interface{}, error) {
 	dec := yaml.NewYAMLOrJSONDecoder(ioutil.NopCloser(bytes.NewReader(yamlFile)), 128)
@@ -111,6 +152,51 @@ func toLowerFirst(input string) string {
 	return string(unicode.ToLower(rune(input[0]))) + input[1:]
 }
 
+// titleCases memoizes TitleCase, which is called for every property of every
+// type
+var titleCases sync.Map
+
+// TitleCase returns the string with the first letter of each word mapped to
+// its title case, like the deprecated strings.Title that the generated names
+// were built with, e.g. "foo-bar_baz" becomes "Foo-Bar_baz". Results are
+// memoized, and it's safe to call concurrently.
+func TitleCase(input string) string {
+	if titled, ok := titleCases.Load(input); ok {
+		return titled.(string)
+	}
+	var sb strings.Builder
+	sb.Grow(len(input))
+	prev := ' '
+	for _, r := range input {
+		if isWordSeparator(prev) {
+			sb.WriteRune(unicode.ToTitle(r))
+		} else {
+			sb.WriteRune(r)
+		}
+		prev = r
+	}
+	titled := sb.String()
+	titleCases.Store(input, titled)
+	return titled
+}
+
+// isWordSeparator reports whether the rune separates words, with the same
+// rules as strings.Title: ASCII letters, digits and underscores don't,
+// other ASCII characters and Unicode spaces do.
+func isWordSeparator(r rune) bool {
+	if r <= 0x7F {
+		switch {
+		case '0' <= r && r <= '9', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', r == '_':
+			return false
+		}
+		return true
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return false
+	}
+	return unicode.IsSpace(r)
+}
+
 // toInterfaceSlice casts a string slice of type []string to type []interface{}.
 func toInterfaceSlice(stringSlice []string) interface{} {
 	genericSlice := make([]interface{}, len(stringSlice))
@@ -120,6 +206,23 @@ func toInterfaceSlice(stringSlice []string) interface{} {
 	return genericSlice
 }
 
+// marshalJSON returns the given value as JSON, indented unless compact, and
+// followed by a newline. Map keys are sorted and struct fields are in
+// declaration order, so the output is deterministic either way.
+func marshalJSON(v interface{}, compact bool) ([]byte, error) {
+	var data []byte
+	var err error
+	if compact {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "    ")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
 // JSONPrint prints out an unstructured value as a properly formatted and
 // indented JSON string
 func JSONPrint(v interface{}) (err error) {
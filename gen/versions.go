@@ -0,0 +1,99 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"github.com/pkg/errors"
+)
+
+// VersionSelection is which versions of each CRD are generated.
+type VersionSelection string
+
+const (
+	// ConversionVersions generates only the storage version of the CRDs whose
+	// `spec.conversion.strategy` is `None`, since the API server serves their
+	// other versions by only changing the `apiVersion` of the stored objects,
+	// so they aren't really distinct. Every version of the other CRDs is
+	// generated, including the ones that don't set a strategy. This is the
+	// default.
+	ConversionVersions VersionSelection = "conversion"
+	// AllVersions generates every version of each CRD
+	AllVersions VersionSelection = "all"
+	// StorageVersionOnly generates only the storage version of each CRD
+	StorageVersionOnly VersionSelection = "storage"
+)
+
+// ParseVersionSelection returns the VersionSelection with the given name.
+func ParseVersionSelection(name string) (VersionSelection, error) {
+	switch selection := VersionSelection(name); selection {
+	case ConversionVersions, AllVersions, StorageVersionOnly:
+		return selection, nil
+	default:
+		return "", errors.Errorf("invalid version selection %q, expected conversion, all or storage", name)
+	}
+}
+
+// SelectVersions removes the versions of each CRD that the selection doesn't
+// generate, and their resources and types.
+func (pg *PackageGenerator) SelectVersions(selection VersionSelection) error {
+	if _, err := ParseVersionSelection(string(selection)); err != nil {
+		return err
+	}
+	if selection == AllVersions {
+		return nil
+	}
+
+	var resourceTokens, groupVersions []string
+	for i := range pg.CustomResourceGenerators {
+		crg := &pg.CustomResourceGenerators[i]
+		if selection == StorageVersionOnly || crg.ConversionStrategy == "None" {
+			crg.keepVersions(crg.StorageVersion())
+		}
+		resourceTokens = append(resourceTokens, crg.ResourceTokens...)
+		groupVersions = append(groupVersions, crg.GroupVersions...)
+	}
+	pg.ResourceTokens = resourceTokens
+	pg.GroupVersions = groupVersions
+
+	types, err := pg.getTypes()
+	if err != nil {
+		return err
+	}
+	pg.Types = types
+	return nil
+}
+
+// keepVersions removes every version of the CustomResource but the given
+// ones.
+func (crg *CustomResourceGenerator) keepVersions(versions ...string) {
+	var keptVersions, groupVersions, resourceTokens, schemalessVersions []string
+	for _, version := range crg.Versions {
+		if !contains(versions, version) {
+			delete(crg.Schemas, version)
+			delete(crg.PrinterColumns, version)
+			continue
+		}
+		keptVersions = append(keptVersions, version)
+		groupVersions = append(groupVersions, crg.Group+"/"+version)
+		resourceTokens = append(resourceTokens, getToken(crg.Group, version, crg.TypeName))
+		if contains(crg.SchemalessVersions, version) {
+			schemalessVersions = append(schemalessVersions, version)
+		}
+	}
+	crg.Versions = keptVersions
+	crg.GroupVersions = groupVersions
+	crg.ResourceTokens = resourceTokens
+	crg.SchemalessVersions = schemalessVersions
+}
@@ -0,0 +1,205 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const yamlReferenceComment = `*** WARNING: this file was generated by crd2pulumi. ***
+*** Do not edit by hand unless you're certain you know what you are doing! ***
+
+The inputs of the resources, for Pulumi YAML programs. A resource is declared
+with its token as its ` + "`type`" + `, and its inputs as its ` + "`properties`" + `, e.g.
+
+  resources:
+    example:
+      type: <token>
+      properties:
+        <input>: <value>`
+
+func (pg *PackageGenerator) genYAMLReference(outputPath string) error {
+	reference, err := pg.YAMLReference()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrapf(err, "could not create directory to %s", outputPath)
+	}
+	if err := ioutil.WriteFile(outputPath, reference, 0644); err != nil {
+		return errors.Wrapf(err, "could not write to file %s", outputPath)
+	}
+	return nil
+}
+
+// YAMLReference returns a YAML reference of the resources for Pulumi YAML
+// programs, which reference resources by token rather than through an SDK:
+//
+//   - `resources` maps each resource token to its `required` and `optional`
+//     inputs, and each input to its type. `apiVersion` and `kind` are set by
+//     the provider, and `status` by the cluster, so they're left out.
+//   - `types` does the same for the object types of the inputs, and lists
+//     the values of the enum types.
+//
+// Types are tokens, `string`, `integer`, `number`, `boolean`, `any`,
+// `array of <type>`, `map of <type>`, or unions of them separated by ` | `.
+func (pg *PackageGenerator) YAMLReference() ([]byte, error) {
+	resources := map[string]bool{}
+	for _, token := range pg.ResourceTokens {
+		resources[token] = true
+	}
+
+	resourceNodes, typeNodes := mappingNode(), mappingNode()
+	for _, token := range sortedTypeTokens(pg.Types) {
+		complexTypeSpec := pg.Types[token]
+		if resources[token] {
+			required := resourceRequiredInputs(complexTypeSpec.ObjectTypeSpec, pg.objectMetaRequired)
+			inputs := make(map[string]pschema.PropertySpec, len(complexTypeSpec.Properties))
+			for propertyName, propertySpec := range complexTypeSpec.Properties {
+				switch propertyName {
+				case "apiVersion", "kind", "status":
+					continue
+				}
+				inputs[propertyName] = propertySpec
+			}
+			appendMapping(resourceNodes, token, pg.yamlReferenceProperties(inputs, required))
+			continue
+		}
+		if len(complexTypeSpec.Enum) > 0 {
+			values := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, enumValue := range complexTypeSpec.Enum {
+				// JSON is YAML, so the value is decoded with its JSON style,
+				// e.g. strings stay quoted
+				value, err := json.Marshal(enumValue.Value)
+				if err != nil {
+					return nil, errors.Wrapf(err, "could not marshal a value of %s", token)
+				}
+				var document yaml.Node
+				if err := yaml.Unmarshal(value, &document); err != nil {
+					return nil, errors.Wrapf(err, "could not decode a value of %s", token)
+				}
+				values.Content = append(values.Content, document.Content...)
+			}
+			enumNode := mappingNode()
+			appendMapping(enumNode, "type", scalarNode(complexTypeSpec.Type))
+			appendMapping(enumNode, "enum", values)
+			appendMapping(typeNodes, token, enumNode)
+			continue
+		}
+		appendMapping(typeNodes, token, pg.yamlReferenceProperties(complexTypeSpec.Properties, complexTypeSpec.Required))
+	}
+
+	root := mappingNode()
+	root.HeadComment = yamlReferenceComment
+	appendMapping(root, "resources", resourceNodes)
+	if len(typeNodes.Content) > 0 {
+		appendMapping(root, "types", typeNodes)
+	}
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return nil, errors.Wrap(err, "could not encode the YAML reference")
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not encode the YAML reference")
+	}
+	return buffer.Bytes(), nil
+}
+
+// yamlReferenceProperties returns the `required` and `optional` properties,
+// sorted, mapped to their types.
+func (pg *PackageGenerator) yamlReferenceProperties(properties map[string]pschema.PropertySpec, required []string) *yaml.Node {
+	requiredNode, optionalNode := mappingNode(), mappingNode()
+	for _, propertyName := range sortedPropertyNames(properties) {
+		typeNode := scalarNode(pg.yamlReferenceType(properties[propertyName].TypeSpec))
+		if contains(required, propertyName) {
+			appendMapping(requiredNode, propertyName, typeNode)
+		} else {
+			appendMapping(optionalNode, propertyName, typeNode)
+		}
+	}
+	node := mappingNode()
+	if len(requiredNode.Content) > 0 {
+		appendMapping(node, "required", requiredNode)
+	}
+	if len(optionalNode.Content) > 0 {
+		appendMapping(node, "optional", optionalNode)
+	}
+	return node
+}
+
+// yamlReferenceType describes the given type, e.g. `array of string`.
+func (pg *PackageGenerator) yamlReferenceType(typeSpec pschema.TypeSpec) string {
+	switch {
+	case len(typeSpec.OneOf) > 0:
+		oneOf := make([]string, 0, len(typeSpec.OneOf))
+		for _, oneOfTypeSpec := range typeSpec.OneOf {
+			oneOf = append(oneOf, pg.yamlReferenceType(oneOfTypeSpec))
+		}
+		return strings.Join(oneOf, " | ")
+	case typeSpec.Ref == anyTypeRef || typeSpec.Ref == jsonTypeRef || typeSpec.Ref == pg.anyTypeRefOrDefault():
+		return "any"
+	case typeSpec.Ref != "":
+		return strings.TrimPrefix(typeSpec.Ref, "#/types/")
+	case typeSpec.Type == Array:
+		if typeSpec.Items == nil {
+			return "array of any"
+		}
+		return "array of " + pg.yamlReferenceType(*typeSpec.Items)
+	case typeSpec.Type == Object:
+		if typeSpec.AdditionalProperties == nil {
+			return "map of any"
+		}
+		return "map of " + pg.yamlReferenceType(*typeSpec.AdditionalProperties)
+	case typeSpec.Type == "":
+		return "any"
+	default:
+		return typeSpec.Type
+	}
+}
+
+// sortedTypeTokens returns the sorted tokens of the types.
+func sortedTypeTokens(types map[string]pschema.ComplexTypeSpec) []string {
+	typeTokens := make([]string, 0, len(types))
+	for token := range types {
+		typeTokens = append(typeTokens, token)
+	}
+	sort.Strings(typeTokens)
+	return typeTokens
+}
+
+func mappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// appendMapping appends the key and its value to the mapping node.
+func appendMapping(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, scalarNode(key), value)
+}
@@ -3,11 +3,13 @@ module github.com/pulumi/crd2pulumi
 go 1.16
 
 require (
+	github.com/blang/semver v3.5.1+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/pulumi/pulumi/pkg/v3 v3.21.0
 	github.com/pulumi/pulumi/sdk/v3 v3.21.0
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	k8s.io/apimachinery v0.18.0
 )
@@ -20,9 +20,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/pulumi/crd2pulumi/gen"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var languages = []string{"dotnet", "go", "nodejs", "python"}
@@ -47,6 +51,8 @@ func execCrd2Pulumi(t *testing.T, lang, path string) {
 }
 
 // TestCRDsFromFile enumerates all CRD YAML files, and generates them in each language.
+// The inputs that aren't CRDs, e.g. manifests that embed them, are under
+// testdata/ instead.
 func TestCRDsFromFile(t *testing.T) {
 	filepath.WalkDir("crds", func(path string, d fs.DirEntry, err error) error {
 		if !d.IsDir() && (filepath.Ext(path) == ".yml" || filepath.Ext(path) == ".yaml") {
@@ -64,3 +70,100 @@ func TestCRDsFromUrl(t *testing.T) {
 		execCrd2Pulumi(t, lang, gkeManagedCertsUrl)
 	}
 }
+
+// TestListCRDs lists a schemaless CRD, a CRD with a schema that preserves
+// unknown fields, and structural CRDs, without generating anything.
+func TestListCRDs(t *testing.T) {
+	binaryPath, err := filepath.Abs("../bin/crd2pulumi")
+	require.NoError(t, err)
+	crdCmd := exec.Command(binaryPath, "--list-crds", defaultsCRD, gizmosCRD, widgetsCRD, pipelinesCRD, bucketsCRD)
+	crdOut, err := crdCmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"GROUP                         KIND       VERSIONS      SCOPE        STRUCTURAL",
+		"stable.example.com            CronTab    v1            Namespaced   yes",
+		"schemaless.example.com        Gizmo      v1,v1alpha1   Namespaced   no schema",
+		"untyped.example.com           Widget     v1            Namespaced   no",
+		"preserveunknown.example.com   Pipeline   v1            Namespaced   yes",
+		"s3.aws.example.com            Bucket     v1beta1       Cluster      yes",
+		"",
+	}, "\n"), string(crdOut))
+}
+
+// corpora are the real-world CRDs checked in under crds/, with a README of
+// where each was taken from, and the most fields of each that may be typed as
+// `any`. Raising a bound should be a deliberate choice.
+var corpora = []struct {
+	path        string
+	maxAnyTypes int
+}{
+	{"crds/GoogleCloudPlatform/gke-managed-certs/managedcertificates-crd.yaml", 0},
+	// The maps without a schema for their values, e.g. `resources.limits`
+	{"crds/prometheus-operator/prometheus/prometheus-crd.yaml", 27},
+}
+
+// TestCRDCorpora generates the schema and the SDKs of each real-world CRD
+// in-process, and checks that every ref of its types resolves, and that no
+// more of its fields than expected fall back to `any`.
+func TestCRDCorpora(t *testing.T) {
+	for _, corpus := range corpora {
+		corpus := corpus
+		t.Run(filepath.Base(corpus.path), func(t *testing.T) {
+			pg, err := gen.NewPackageGenerator([]string{corpus.path})
+			require.NoError(t, err)
+			require.NotEmpty(t, pg.ResourceTokens)
+			require.NotPanics(t, func() {
+				pg.SchemaPackage()
+				pg.SchemaPackageWithObjectMetaType()
+			})
+			assert.Empty(t, danglingRefs(pg.Types))
+			anyTypePaths := pg.AnyTypePaths()
+			assert.LessOrEqual(t, len(anyTypePaths), corpus.maxAnyTypes)
+			for _, anyTypePath := range anyTypePaths {
+				assert.Contains(t, anyTypePath.Path, ".", "the top-level fields should be typed")
+			}
+
+			nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+			require.NotPanics(t, func() {
+				generate(t, gen.LanguageSettings{
+					NodeJSPath: &nodejsDir,
+					PythonPath: &pythonDir,
+					GoPath:     &goDir,
+					NodeJSName: gen.DefaultName,
+					PythonName: gen.DefaultName,
+					GoName:     gen.DefaultName,
+				}, corpus.path)
+			})
+		})
+	}
+}
+
+// danglingRefs returns the refs of the properties of the types that aren't
+// types, other than the ObjectMeta type that the SDKs import, as
+// `<type token>.<property>: <ref>`.
+func danglingRefs(types map[string]pschema.ComplexTypeSpec) []string {
+	var dangling []string
+	var visit func(token, propertyName string, typeSpec pschema.TypeSpec)
+	visit = func(token, propertyName string, typeSpec pschema.TypeSpec) {
+		if ref := strings.TrimPrefix(typeSpec.Ref, "#/types/"); ref != typeSpec.Ref {
+			if _, ok := types[ref]; !ok && ref != "kubernetes:meta/v1:ObjectMeta" {
+				dangling = append(dangling, token+"."+propertyName+": "+typeSpec.Ref)
+			}
+		}
+		if typeSpec.Items != nil {
+			visit(token, propertyName, *typeSpec.Items)
+		}
+		if typeSpec.AdditionalProperties != nil {
+			visit(token, propertyName, *typeSpec.AdditionalProperties)
+		}
+		for _, oneOf := range typeSpec.OneOf {
+			visit(token, propertyName, oneOf)
+		}
+	}
+	for token, complexTypeSpec := range types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			visit(token, propertyName, propertySpec.TypeSpec)
+		}
+	}
+	return dangling
+}
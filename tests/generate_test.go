@@ -0,0 +1,3194 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/crd2pulumi/gen"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const defaultsCRD = "crds/crd2pulumi/defaults/crontabs-crd.yaml"
+const requiredCRD = "crds/crd2pulumi/required/crontabs-crd.yaml"
+const bucketsCRD = "crds/crd2pulumi/rootpath/buckets-crd.yaml"
+const widgetsCRD = "crds/crd2pulumi/untyped/widgets-crd.yaml"
+const gizmosCRD = "crds/crd2pulumi/preserveunknown/gizmos-crd.yaml"
+const endpointsCRD = "crds/crd2pulumi/intorstring/endpoints-crd.yaml"
+const keywordsCRD = "crds/crd2pulumi/reserved/keywords-crd.yaml"
+const databasesCRD = "crds/crd2pulumi/secrets/databases-crd.yaml"
+const sprocketsCRD = "crds/crd2pulumi/unknowntypes/sprockets-crd.yaml"
+const flagsCRD = "crds/crd2pulumi/booleanschemas/flags-crd.yaml"
+const routesCRD = "crds/crd2pulumi/refmaps/routes-crd.yaml"
+const conversionCRDs = "crds/crd2pulumi/conversion/gadgets-crd.yaml"
+const metadataCRD = "crds/crd2pulumi/metadata/certificates-crd.yaml"
+const treesCRD = "crds/crd2pulumi/recursive/trees-crd.yaml"
+const pipelinesCRD = "crds/crd2pulumi/preserveunknownitems/pipelines-crd.yaml"
+const mixedSchemasCRDs = "crds/crd2pulumi/mixedschemas/widgets-crd.yaml"
+const markersCRD = "crds/crd2pulumi/markers/jobs-crd.yaml"
+const malformedCRD = "crds/crd2pulumi/malformed/appliances-crd.yaml"
+const partialMapsCRD = "crds/crd2pulumi/partialmaps/ingresses-crd.yaml"
+const cronTabSpecSchema = "testdata/partialschema/crontab-spec.yaml"
+const existingResources = "testdata/import/existing.yaml"
+
+// generate runs crd2pulumi in-process for the given language settings
+func generate(t *testing.T, ls gen.LanguageSettings, yamlPaths ...string) {
+	err := gen.Generate(ls, yamlPaths, true)
+	require.NoError(t, err, "expected crd2pulumi to generate %v", yamlPaths)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubDotNetLogo stubs out HTTP requests for the duration of the test, since the .NET code generator downloads a logo
+// from GitHub to bundle with the package
+func stubDotNetLogo(t *testing.T) {
+	transport := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { http.DefaultTransport = transport })
+}
+
+// readFile returns the contents of the generated file at dir/path
+func readFile(t *testing.T, dir, path string) string {
+	code, err := ioutil.ReadFile(filepath.Join(dir, path))
+	require.NoError(t, err, "expected %s to be generated", path)
+	return string(code)
+}
+
+func TestIntegerDefaults(t *testing.T) {
+	nodejsDir, pythonDir := t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		NodeJSName: gen.DefaultName,
+		PythonPath: &pythonDir,
+		PythonName: gen.DefaultName,
+	}, defaultsCRD)
+
+	inputs := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, inputs, "replicas: (val.replicas) ?? 3,")
+	assert.Contains(t, inputs, `image: (val.image) ?? "1",`)
+	assert.Contains(t, inputs, "suspend: (val.suspend) ?? false,")
+	assert.NotContains(t, inputs, "3.0")
+
+	pythonInputs := readFile(t, pythonDir, "pulumi_crds/stable/v1/_inputs.py")
+	assert.Contains(t, pythonInputs, "replicas = 3\n")
+}
+
+func TestOmitDefaults(t *testing.T) {
+	nodejsDir, pythonDir := t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:   &nodejsDir,
+		NodeJSName:   gen.DefaultName,
+		PythonPath:   &pythonDir,
+		PythonName:   gen.DefaultName,
+		OmitDefaults: true,
+	}, defaultsCRD)
+
+	inputs := readFile(t, nodejsDir, "types/input.ts")
+	assert.NotContains(t, inputs, "??")
+	assert.NotContains(t, inputs, "Defaults(")
+	pythonInputs := readFile(t, pythonDir, "pulumi_crds/stable/v1/_inputs.py")
+	assert.NotContains(t, pythonInputs, "replicas = 3")
+	assert.NotContains(t, pythonInputs, "is None:")
+
+	pg, err := gen.NewPackageGenerator([]string{defaultsCRD})
+	require.NoError(t, err)
+	pg.RemoveDefaults()
+	for token, complexTypeSpec := range pg.Types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			assert.Nil(t, propertySpec.Default, "%s.%s", token, propertyName)
+		}
+	}
+}
+
+func TestNodeJSScope(t *testing.T) {
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:  &nodejsDir,
+		NodeJSName:  "crontabs",
+		NodeJSScope: "@myorg",
+	}, defaultsCRD)
+
+	packageJSON := readFile(t, nodejsDir, "package.json")
+	assert.Contains(t, packageJSON, `"name": "@myorg/crontabs"`)
+
+	err := gen.Generate(gen.LanguageSettings{
+		NodeJSPath:  &nodejsDir,
+		NodeJSName:  "crontabs",
+		NodeJSScope: "My Org",
+	}, []string{defaultsCRD}, true)
+	assert.Error(t, err)
+}
+
+func TestNodeJSBarrel(t *testing.T) {
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:   &nodejsDir,
+		NodeJSName:   gen.DefaultName,
+		NodeJSBarrel: true,
+	}, requiredCRD, gizmosCRD, endpointsCRD)
+
+	index := readFile(t, nodejsDir, "index.ts")
+	assert.Contains(t, index, `export { CronTab, CronTabArgs, CronTabTypeToken } from "./stable/v1/cronTab";`)
+	assert.Contains(t, index, `export { Endpoint, EndpointArgs, EndpointTypeToken } from "./intorstring/v1/endpoint";`)
+	assert.Contains(t, index, `export { enums, input, output } from "./types";`)
+	// Resources with several versions are exported at their storage version
+	assert.Contains(t, index, `export { Gizmo, GizmoArgs, GizmoTypeToken } from "./schemaless/v1/gizmo";`)
+	assert.NotContains(t, index, "v1alpha1")
+
+	// Without enums, only the input and output types are exported
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:   &nodejsDir,
+		NodeJSName:   gen.DefaultName,
+		NodeJSBarrel: true,
+	}, requiredCRD)
+	index = readFile(t, nodejsDir, "index.ts")
+	assert.Contains(t, index, `export { input, output } from "./types";`)
+	assert.NotContains(t, index, "Gizmo")
+}
+
+func TestConstAPIVersionAndKind(t *testing.T) {
+	stubDotNetLogo(t)
+	nodejsDir, pythonDir, dotnetDir, goDir := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		NodeJSName: gen.DefaultName,
+		PythonPath: &pythonDir,
+		PythonName: gen.DefaultName,
+		DotNetPath: &dotnetDir,
+		DotNetName: gen.DefaultName,
+		GoPath:     &goDir,
+		GoName:     gen.DefaultName,
+	}, defaultsCRD)
+
+	// Each language's constructor sets `apiVersion` and `kind` regardless of the given args, and exposes them as
+	// non-optional outputs
+	nodejs := readFile(t, nodejsDir, "stable/v1/cronTab.ts")
+	assert.Contains(t, nodejs, `resourceInputs["apiVersion"] = "stable.example.com/v1";`)
+	assert.Contains(t, nodejs, `resourceInputs["kind"] = "CronTab";`)
+	assert.Contains(t, nodejs, `public readonly apiVersion!: pulumi.Output<"stable.example.com/v1">;`)
+	assert.Contains(t, nodejs, `public readonly kind!: pulumi.Output<"CronTab">;`)
+
+	python := readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py")
+	assert.Contains(t, python, `__props__.__dict__["api_version"] = 'stable.example.com/v1'`)
+	assert.Contains(t, python, `__props__.__dict__["kind"] = 'CronTab'`)
+	assert.Contains(t, python, `def api_version(self) -> pulumi.Output[str]:`)
+
+	dotnet := readFile(t, dotnetDir, "Stable/V1/CronTab.cs")
+	assert.Contains(t, dotnet, `args.ApiVersion = "stable.example.com/v1";`)
+	assert.Contains(t, dotnet, `args.Kind = "CronTab";`)
+
+	golang := readFile(t, goDir, "stable/v1/cronTab.go")
+	assert.Contains(t, golang, `args.ApiVersion = pulumi.StringPtr("stable.example.com/v1")`)
+	assert.Contains(t, golang, `args.Kind = pulumi.StringPtr("CronTab")`)
+	assert.Contains(t, golang, "ApiVersion pulumi.StringOutput")
+}
+
+func TestGoClientHelpers(t *testing.T) {
+	goDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		GoPath:          &goDir,
+		GoName:          gen.DefaultName,
+		GoClientHelpers: true,
+	}, defaultsCRD)
+
+	client := readFile(t, goDir, "stable/v1/cronTabClient.go")
+	assert.Contains(t, client, "package v1\n")
+	assert.Contains(t, client, `Group:   "stable.example.com",
+	Version: "v1",
+	Kind:    "CronTab",`)
+	assert.Contains(t, client, `Resource: "crontabs",`)
+	assert.Contains(t, client, "func (c *CronTabClient) List(ctx context.Context, namespace string, opts metav1.ListOptions)")
+	assert.Contains(t, client, "func (c *CronTabClient) Watch(ctx context.Context, namespace string, opts metav1.ListOptions)")
+
+	// Cluster-scoped resources can't be listed by namespace
+	goDir = t.TempDir()
+	err := gen.GenerateFromLoader(gen.LanguageSettings{
+		GoPath:          &goDir,
+		GoName:          gen.DefaultName,
+		GoClientHelpers: true,
+	}, gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterissuers.cert-manager.io
+spec:
+  group: cert-manager.io
+  scope: Cluster
+  names:
+    kind: ClusterIssuer
+    plural: clusterissuers
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            x-kubernetes-preserve-unknown-fields: true
+`)}, true)
+	require.NoError(t, err)
+	client = readFile(t, goDir, "certmanager/v1/clusterIssuerClient.go")
+	assert.Contains(t, client, `Resource: "clusterissuers",`)
+	assert.Contains(t, client, "func (c *ClusterIssuerClient) List(ctx context.Context, opts metav1.ListOptions)")
+
+	// The helpers are opt-in
+	goDir = t.TempDir()
+	generate(t, gen.LanguageSettings{GoPath: &goDir, GoName: gen.DefaultName}, defaultsCRD)
+	assert.NoFileExists(t, filepath.Join(goDir, "stable/v1/cronTabClient.go"))
+}
+
+func TestTopLevelRequired(t *testing.T) {
+	nodejsDir, pythonDir := t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		NodeJSName: gen.DefaultName,
+		PythonPath: &pythonDir,
+		PythonName: gen.DefaultName,
+	}, requiredCRD)
+
+	// `required: [apiVersion, kind, metadata, spec]` only makes `spec` a required input
+	nodejs := readFile(t, nodejsDir, "stable/v1/cronTab.ts")
+	assert.Contains(t, nodejs, "    spec: pulumi.Input<inputs.stable.v1.CronTabSpecArgs>;")
+	assert.Contains(t, nodejs, "    metadata?: pulumi.Input<ObjectMeta>;")
+	assert.Contains(t, nodejs, "    status?: pulumi.Input<inputs.stable.v1.CronTabStatusArgs>;")
+	assert.NotContains(t, nodejs, "Missing required property 'apiVersion'")
+
+	// The spec's own `required` fields still apply, but only to the spec's children
+	nodejsInputs := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, nodejsInputs, "cronSpec: pulumi.Input<string>;")
+	assert.Contains(t, nodejsInputs, "image?: pulumi.Input<string>;")
+
+	python := readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py")
+	assert.Contains(t, python, "spec: pulumi.Input['CronTabSpecArgs'],")
+	assert.Contains(t, python, "metadata: Optional[pulumi.Input['_meta.v1.ObjectMetaArgs']] = None,")
+
+	// Without a top-level `required`, every input is optional
+	nodejsDir = t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, defaultsCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"), "    spec?: pulumi.Input<inputs.stable.v1.CronTabSpecArgs>;")
+}
+
+func TestFormatFiles(t *testing.T) {
+	files := map[string]*bytes.Buffer{
+		"stable/v1/cronTab.go":    bytes.NewBufferString("package v1\nvar   x=1\n"),
+		"stable/v1/broken.go":     bytes.NewBufferString("package v1\nfunc {\n"),
+		"stable/v1/cronTab.ts":    bytes.NewBufferString("export const x = 1;   \n\n\n"),
+		"pulumi_crds/__init__.py": bytes.NewBufferString("x = 1   \n"),
+	}
+	warnings := gen.FormatFiles(files)
+
+	assert.Equal(t, "package v1\n\nvar x = 1\n", files["stable/v1/cronTab.go"].String())
+	assert.Equal(t, "export const x = 1;\n", files["stable/v1/cronTab.ts"].String())
+	// Other languages are left as-is
+	assert.Equal(t, "x = 1   \n", files["pulumi_crds/__init__.py"].String())
+	// Code that fails to format is kept unformatted, with a warning
+	assert.Equal(t, "package v1\nfunc {\n", files["stable/v1/broken.go"].String())
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0], "could not format stable/v1/broken.go")
+	}
+}
+
+func TestDryRunGoMod(t *testing.T) {
+	// The generated Go code is compiled against the Pulumi SDK of the code
+	// generators that crd2pulumi is built with
+	goMod := readFile(t, "..", "go.mod")
+	dryRunGoMod := gen.DryRunGoMod()
+	for _, module := range []string{"github.com/pulumi/pulumi/pkg/v3", "github.com/pulumi/pulumi/sdk/v3"} {
+		version := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(module) + ` (\S+)$`).FindStringSubmatch(goMod)
+		require.Len(t, version, 2, "expected go.mod to require %s", module)
+		assert.Equal(t, "v"+gen.MaxSchemaVersion, version[1], module)
+	}
+	assert.Contains(t, dryRunGoMod, "\tgithub.com/pulumi/pulumi/sdk/v3 v"+gen.MaxSchemaVersion+"\n")
+
+	// apimachinery is pinned to the release of client-go
+	apimachinery := regexp.MustCompile(`k8s.io/apimachinery (\S+)`).FindStringSubmatch(dryRunGoMod)
+	clientGo := regexp.MustCompile(`k8s.io/client-go (\S+)`).FindStringSubmatch(dryRunGoMod)
+	require.Len(t, apimachinery, 2)
+	require.Len(t, clientGo, 2)
+	assert.Equal(t, clientGo[1], apimachinery[1])
+}
+
+func TestCompileGoFiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("the Go toolchain isn't installed")
+	}
+
+	files := map[string]*bytes.Buffer{
+		"stable/v1/cronTab.go": bytes.NewBufferString("package v1\n\nvar Schedule = \"* * * * */5\"\n"),
+		"pulumi-plugin.json":   bytes.NewBufferString("not Go"),
+	}
+	assert.NoError(t, gen.CompileGoFiles(files))
+
+	// The compiler output is part of the error
+	files["stable/v1/broken.go"] = bytes.NewBufferString("package v1\n\nvar Replicas int = Schedule\n")
+	err := gen.CompileGoFiles(files)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "generated Go code does not compile")
+		assert.Contains(t, err.Error(), "broken.go:3")
+	}
+
+	if testing.Short() {
+		t.Skip("skipping downloading the dependencies of the generated Go code in short mode")
+	}
+	goDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		GoPath:          &goDir,
+		GoName:          gen.DefaultName,
+		GoClientHelpers: true,
+		GoDryRunCompile: true,
+	}, defaultsCRD)
+}
+
+func TestCheckPythonFiles(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("the Python interpreter isn't installed")
+	}
+
+	files := map[string]*bytes.Buffer{
+		"pulumi_crds/__init__.py":           bytes.NewBufferString("from . import _utilities\n"),
+		"pulumi_crds/_utilities.py":         bytes.NewBufferString("def get_version():\n    return '1.0.0'\n"),
+		"pulumi_crds/stable/__init__.py":    bytes.NewBufferString(""),
+		"pulumi_crds/stable/v1/__init__.py": bytes.NewBufferString("from .CronTab import *\n"),
+		"pulumi_crds/stable/v1/CronTab.py":  bytes.NewBufferString("SCHEDULE = '* * * * */5'\n"),
+		"pulumi_crds/pulumi-plugin.json":    bytes.NewBufferString("not Python"),
+	}
+	assert.NoError(t, gen.CheckPythonFiles(files))
+
+	// The interpreter output is part of the error
+	files["pulumi_crds/stable/v1/broken.py"] = bytes.NewBufferString("def replicas(:\n")
+	err := gen.CheckPythonFiles(files)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "generated Python code does not compile")
+		assert.Contains(t, err.Error(), "broken.py")
+	}
+
+	// Every subpackage is imported, not only the root one
+	delete(files, "pulumi_crds/stable/v1/broken.py")
+	files["pulumi_crds/stable/v1/__init__.py"] = bytes.NewBufferString("from .CronTab import *\nfrom pulumi_missing import meta\n")
+	err = gen.CheckPythonFiles(files)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "generated Python packages cannot be imported")
+		assert.Contains(t, err.Error(), "No module named 'pulumi_missing'")
+	}
+}
+
+func TestExampleManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "example.yaml")
+	err := gen.GenerateFromLoader(gen.LanguageSettings{ExampleManifestPath: &manifestPath}, gen.MultiLoader{
+		gen.FileLoader{Path: requiredCRD},
+		gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: issuers.cert-manager.io
+spec:
+  group: cert-manager.io
+  scope: Cluster
+  names:
+    kind: Issuer
+    plural: issuers
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [acme, ports, type]
+            properties:
+              acme:
+                type: object
+                required: [server]
+                properties:
+                  server:
+                    type: string
+                  email:
+                    type: string
+              ports:
+                type: array
+                items:
+                  type: integer
+              type:
+                type: string
+                enum: [http, dns]
+              selfSigned:
+                type: object
+`)},
+	}, true)
+	require.NoError(t, err)
+
+	manifest := readFile(t, filepath.Dir(manifestPath), "example.yaml")
+	assert.Equal(t, `# An example CronTab. Replace the TODO values before applying it.
+apiVersion: stable.example.com/v1
+kind: CronTab
+metadata:
+  name: example-crontab # TODO: name
+  namespace: default
+spec:
+  cronSpec: "" # TODO: string
+---
+# An example Issuer. Replace the TODO values before applying it.
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: example-issuer # TODO: name
+spec:
+  acme:
+    server: "" # TODO: string
+  ports: [] # TODO: array
+  type: "http" # TODO: one of "http", "dns"
+`, manifest)
+
+	// The manifest is valid multi-document YAML
+	dec := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 128)
+	var documents []map[string]interface{}
+	for {
+		var document map[string]interface{}
+		if err := dec.Decode(&document); err == io.EOF {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		documents = append(documents, document)
+	}
+	if assert.Len(t, documents, 2) {
+		assert.Equal(t, "Issuer", documents[1]["kind"])
+	}
+}
+
+// walkFiles returns the contents of every file in the directory, keyed by their relative paths
+func walkFiles(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[relPath] = readFile(t, dir, relPath)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestKeepPlaceholderMeta(t *testing.T) {
+	nodejsDir, pythonDir := t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:          &nodejsDir,
+		NodeJSName:          gen.DefaultName,
+		PythonPath:          &pythonDir,
+		PythonName:          gen.DefaultName,
+		KeepPlaceholderMeta: true,
+	}, requiredCRD)
+
+	for path, code := range walkFiles(t, pythonDir) {
+		assert.NotContains(t, code, "pulumi_kubernetes", path)
+	}
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py"), "from pulumi_crds import meta as _meta")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/meta/v1/_inputs.py"), "class ObjectMetaArgs:")
+
+	for path, code := range walkFiles(t, nodejsDir) {
+		assert.NotContains(t, code, "@pulumi/kubernetes", path)
+	}
+	assert.Contains(t, readFile(t, nodejsDir, "meta/v1.ts"), "export interface ObjectMeta {")
+
+	// By default, the Kubernetes SDK's ObjectMeta is used
+	pythonDir = t.TempDir()
+	generate(t, gen.LanguageSettings{PythonPath: &pythonDir, PythonName: gen.DefaultName}, requiredCRD)
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py"), "from pulumi_kubernetes import meta as _meta")
+
+	goDir := t.TempDir()
+	err := gen.Generate(gen.LanguageSettings{GoPath: &goDir, GoName: gen.DefaultName, KeepPlaceholderMeta: true},
+		[]string{requiredCRD}, true)
+	assert.EqualError(t, err, "the placeholder ObjectMeta type can only be kept for NodeJS and Python")
+}
+
+func TestMergeObjectMetaFrom(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	err := gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir, gen.Python: pythonDir}),
+		gen.WithObjectMetaFrom("nodejs=@myorg/kubernetes@^3.0.0", "python=myorg-kubernetes@3.12.0"),
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, readFile(t, nodejsDir, "meta/v1.ts"), `import * as k8s from "@myorg/kubernetes";`)
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"@myorg/kubernetes": "^3.0.0"`)
+
+	assert.Contains(t, readFile(t, pythonDir, "setup.py"), `'myorg-kubernetes==3.12.0'`)
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/meta/v1/__init__.py"), "from myorg_kubernetes.meta.v1._inputs import *")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/_utilities.py"), "from myorg_kubernetes import _utilities")
+	for path, code := range walkFiles(t, pythonDir) {
+		assert.NotContains(t, code, "pulumi_kubernetes", path)
+	}
+
+	// The packages must be consistent with the generated languages
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithForce(true),
+		gen.WithObjectMetaFrom("python=myorg-kubernetes@3.12.0"),
+	)
+	assert.EqualError(t, err, "an ObjectMeta package is set for python, which isn't generated")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithForce(true),
+		gen.WithObjectMeta(gen.PlaceholderObjectMeta),
+		gen.WithObjectMetaFrom("nodejs=@myorg/kubernetes@^3.0.0"),
+	)
+	assert.EqualError(t, err, "the placeholder ObjectMeta type can't be kept when importing it from another package")
+}
+
+func TestPackageVersion(t *testing.T) {
+	// The version can be stamped at build time via `-ldflags -X`
+	version := gen.Version
+	gen.Version = "1.2.3"
+	defer func() { gen.Version = version }()
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", pg.PackageVersion())
+	assert.Equal(t, "1.2.3", pg.SchemaPackage().Version.String())
+
+	// The package version overrides it in the generated packages
+	nodejsDir, pythonDir := t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:     &nodejsDir,
+		NodeJSName:     gen.DefaultName,
+		PythonPath:     &pythonDir,
+		PythonName:     gen.DefaultName,
+		PackageVersion: "2.0.0-alpha.1",
+	}, requiredCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"version": "2.0.0-alpha.1",`)
+	assert.Contains(t, readFile(t, pythonDir, "setup.py"), `VERSION = "2.0.0-alpha.1"`)
+
+	err = gen.Generate(gen.LanguageSettings{
+		NodeJSPath:     &nodejsDir,
+		NodeJSName:     gen.DefaultName,
+		PackageVersion: "latest",
+	}, []string{requiredCRD}, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid package version "latest"`)
+
+	// npm rejects versions that aren't strict semver
+	err = gen.Generate(gen.LanguageSettings{
+		NodeJSPath:     &nodejsDir,
+		NodeJSName:     gen.DefaultName,
+		PackageVersion: "v1.2",
+	}, []string{requiredCRD}, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid package version "v1.2"`)
+}
+
+func TestRootPath(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{bucketsCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.TrimToRootPath("spec.forProvider"))
+
+	typeNames := make([]string, 0, len(pg.Types))
+	for typeName := range pg.Types {
+		typeNames = append(typeNames, typeName)
+	}
+	assert.ElementsMatch(t, []string{
+		"kubernetes:s3.aws.example.com/v1beta1:Bucket",
+		"kubernetes:s3.aws.example.com/v1beta1:BucketSpec",
+		"kubernetes:s3.aws.example.com/v1beta1:BucketSpecForProvider",
+		"kubernetes:s3.aws.example.com/v1beta1:BucketSpecForProviderVersioning",
+	}, typeNames)
+
+	// Properties along the path stay required only if they were required before
+	bucket := pg.Types["kubernetes:s3.aws.example.com/v1beta1:Bucket"]
+	assert.Equal(t, []string{"spec"}, bucket.Required)
+	assert.Contains(t, bucket.Properties, "metadata")
+	spec := pg.Types["kubernetes:s3.aws.example.com/v1beta1:BucketSpec"]
+	assert.Equal(t, []string{"forProvider"}, spec.Required)
+	assert.Equal(t, []string{"region"}, pg.Types["kubernetes:s3.aws.example.com/v1beta1:BucketSpecForProvider"].Required)
+
+	pg, err = gen.NewPackageGenerator([]string{bucketsCRD})
+	require.NoError(t, err)
+	err = pg.TrimToRootPath("spec.forProvider.missing")
+	assert.EqualError(t, err, `could not find root path "spec.forProvider.missing" in Bucket v1beta1: `+
+		`in property "spec": in property "forProvider": could not find property "missing"`)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, RootPath: "spec.forProvider"}, bucketsCRD)
+	inputs := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, inputs, "region: pulumi.Input<string>;")
+	assert.NotContains(t, inputs, "providerConfigRef")
+	assert.NotContains(t, inputs, "atProvider")
+}
+
+func TestExcludeStatus(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{bucketsCRD, widgetsCRD})
+	require.NoError(t, err)
+	require.Contains(t, pg.Types, "kubernetes:s3.aws.example.com/v1beta1:BucketStatus")
+	pg.ExcludeStatus()
+
+	for typeName, typeSpec := range pg.Types {
+		assert.NotContains(t, typeName, "Status")
+		assert.NotContains(t, typeSpec.Properties, "status", typeName)
+	}
+	assert.Contains(t, pg.Types, "kubernetes:s3.aws.example.com/v1beta1:BucketSpec")
+	assert.Contains(t, pg.Types["kubernetes:untyped.example.com/v1:Widget"].Properties, "spec")
+
+	// A required status is no longer required
+	schema := gen.RemoveStatus(map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"spec", "status"},
+		"properties": map[string]interface{}{
+			"spec":   map[string]interface{}{"type": "object"},
+			"status": map[string]interface{}{"type": "object"},
+		},
+	})
+	assert.Equal(t, []interface{}{"spec"}, schema["required"])
+	assert.Equal(t, map[string]interface{}{"spec": map[string]interface{}{"type": "object"}}, schema["properties"])
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, ExcludeStatus: true}, bucketsCRD)
+	assert.NotContains(t, readFile(t, nodejsDir, "types/output.ts"), "BucketStatus")
+	assert.NotContains(t, readFile(t, nodejsDir, "s3/v1beta1/bucket.ts"), "status")
+}
+
+func TestReplaceOnChanges(t *testing.T) {
+	replaceOnChanges := func(pg gen.PackageGenerator) []string {
+		resource, ok := pg.SchemaPackage().GetResource("kubernetes:s3.aws.example.com/v1beta1:Bucket")
+		require.True(t, ok)
+		changes, errs := resource.ReplaceOnChanges()
+		require.Empty(t, errs)
+		var paths []string
+		for _, change := range changes {
+			var names []string
+			for _, property := range change {
+				names = append(names, property.Name)
+			}
+			paths = append(paths, strings.Join(names, "."))
+		}
+		return paths
+	}
+
+	// `self == oldSelf` validation rules are only detected if enabled
+	pg, err := gen.NewPackageGenerator([]string{bucketsCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.AddReplaceOnChanges(nil, false))
+	assert.Empty(t, replaceOnChanges(pg))
+
+	pg, err = gen.NewPackageGenerator([]string{bucketsCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.AddReplaceOnChanges([]string{"spec.providerConfigRef.name"}, true))
+	assert.ElementsMatch(t, []string{"spec.forProvider.region", "spec.providerConfigRef.name"}, replaceOnChanges(pg))
+
+	pg, err = gen.NewPackageGenerator([]string{bucketsCRD})
+	require.NoError(t, err)
+	err = pg.AddReplaceOnChanges([]string{"spec.bucketName"}, false)
+	assert.EqualError(t, err, `could not find immutable path "spec.bucketName" in any resource`)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:      &nodejsDir,
+		NodeJSName:      gen.DefaultName,
+		DetectImmutable: true,
+	}, bucketsCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "s3/v1beta1/bucket.ts"),
+		`const replaceOnChanges = { replaceOnChanges: ["spec.forProvider.region"] };`)
+}
+
+func TestPrinterColumns(t *testing.T) {
+	const managedCertificatesCRD = "crds/GoogleCloudPlatform/gke-managed-certs/managedcertificates-crd.yaml"
+	pg, err := gen.NewPackageGenerator([]string{managedCertificatesCRD})
+	require.NoError(t, err)
+	require.Len(t, pg.CustomResourceGenerators, 1)
+	assert.Equal(t, map[string][]gen.PrinterColumn{
+		"v1": {
+			{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+			{Name: "Status", Type: "string", Description: "Status of the managed certificate", JSONPath: ".status.certificateStatus"},
+		},
+	}, pg.CustomResourceGenerators[0].PrinterColumns)
+
+	// apiextensions.k8s.io/v1beta1 CRDs may declare the columns once for every version
+	crds, err := gen.LoadCRDs(gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  versions:
+  - name: v1
+  - name: v2
+  scope: Namespaced
+  names:
+    plural: crontabs
+    kind: CronTab
+  validation:
+    openAPIV3Schema:
+      type: object
+  additionalPrinterColumns:
+  - name: Schedule
+    type: string
+    JSONPath: .spec.cronSpec
+  - name: Replicas
+    type: integer
+    priority: 1
+    JSONPath: .spec.replicas
+`)})
+	require.NoError(t, err)
+	crg, err := gen.NewCustomResourceGenerator(crds[0])
+	require.NoError(t, err)
+	columns := []gen.PrinterColumn{
+		{Name: "Schedule", Type: "string", JSONPath: ".spec.cronSpec"},
+		{Name: "Replicas", Type: "integer", JSONPath: ".spec.replicas", Priority: 1},
+	}
+	assert.Equal(t, map[string][]gen.PrinterColumn{"v1": columns, "v2": columns}, crg.PrinterColumns)
+
+	// The columns are only documented if enabled
+	token := "kubernetes:networking.gke.io/v1:ManagedCertificate"
+	assert.NotContains(t, pg.Types[token].Description, "kubectl get")
+	pg.DocumentPrinterColumns()
+	assert.Contains(t, pg.Types[token].Description, "Columns shown by `kubectl get`:\n"+
+		"- `Age` (`.metadata.creationTimestamp`)\n"+
+		"- `Status` (`.status.certificateStatus`): Status of the managed certificate")
+	assert.NotContains(t, pg.Types["kubernetes:networking.gke.io/v1beta1:ManagedCertificate"].Description, "kubectl get")
+}
+
+func TestResourceDocLinks(t *testing.T) {
+	const managedCertificatesCRD = "crds/GoogleCloudPlatform/gke-managed-certs/managedcertificates-crd.yaml"
+	pg, err := gen.NewPackageGenerator([]string{managedCertificatesCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.AddResourceDocLinks("https://docs.example.com/{group}/{version}/{kind}#{plural}"))
+	assert.Equal(t, "Documentation: https://docs.example.com/networking.gke.io/v1/ManagedCertificate#managedcertificates",
+		pg.Types["kubernetes:networking.gke.io/v1:ManagedCertificate"].Description)
+	assert.Equal(t, "Documentation: https://docs.example.com/networking.gke.io/v1beta1/ManagedCertificate#managedcertificates",
+		pg.Types["kubernetes:networking.gke.io/v1beta1:ManagedCertificate"].Description)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:       &nodejsDir,
+		NodeJSName:       gen.DefaultName,
+		ResourceDocLinks: "https://docs.example.com/{group}/{kind}",
+	}, managedCertificatesCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "networking/v1/managedCertificate.ts"),
+		"Documentation: https://docs.example.com/networking.gke.io/ManagedCertificate")
+
+	// Unknown placeholders are rejected before anything is generated
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: managedCertificatesCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{
+			NodeJSPath:       &nodejsDir,
+			NodeJSName:       gen.DefaultName,
+			ResourceDocLinks: "https://docs.example.com/{Kind}",
+		}),
+		gen.WithForce(true),
+	)
+	assert.EqualError(t, err, `unknown placeholder {Kind} in the doc link template "https://docs.example.com/{Kind}", expected {group}, {version}, {kind}, {plural}`)
+}
+
+func TestStripKubebuilderMarkers(t *testing.T) {
+	const jobToken = "kubernetes:markers.example.com/v1:Job"
+	const specToken = "kubernetes:markers.example.com/v1:JobSpec"
+	pg, err := gen.NewPackageGenerator([]string{markersCRD})
+	require.NoError(t, err)
+	assert.Contains(t, pg.Types[jobToken].Description, "+kubebuilder:object:root=true")
+
+	pg.StripKubebuilderMarkers()
+	assert.Equal(t, "Job runs a command once.", pg.Types[jobToken].Description)
+	assert.Equal(t, "JobSpec is the desired state of a Job.\n\nIt's immutable once the job starts.", pg.Types[specToken].Description)
+	properties := pg.Types[specToken].Properties
+	assert.Equal(t, "The command to run.", properties["command"].Description)
+	assert.Equal(t, "The number of times to retry the command, e.g. +1 for one retry.", properties["retries"].Description)
+	assert.Equal(t, "Markers like +optional are only stripped on their own lines.", properties["note"].Description)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:              &nodejsDir,
+		NodeJSName:              gen.DefaultName,
+		StripKubebuilderMarkers: true,
+	}, markersCRD)
+	code := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, code, "The command to run.\n")
+	assert.NotContains(t, code, "+kubebuilder")
+	assert.NotContains(t, code, "+required")
+}
+
+func TestUntypedRootSchema(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{widgetsCRD})
+	require.NoError(t, err)
+	require.Len(t, pg.CustomResourceGenerators, 1)
+	assert.Equal(t, []string{"v1"}, pg.CustomResourceGenerators[0].UntypedVersions())
+
+	resource, ok := pg.SchemaPackage().GetResource("kubernetes:untyped.example.com/v1:Widget")
+	require.True(t, ok)
+	assert.Equal(t, "Widget is an untyped widget.", resource.Comment)
+	var inputs []string
+	for _, property := range resource.InputProperties {
+		inputs = append(inputs, property.Name)
+	}
+	assert.ElementsMatch(t, []string{"apiVersion", "kind", "metadata", "spec", "status"}, inputs)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, widgetsCRD)
+	code := readFile(t, nodejsDir, "untyped/v1/widget.ts")
+	assert.Contains(t, code, `resourceInputs["apiVersion"] = "untyped.example.com/v1";`)
+	assert.Contains(t, code, "spec?: any;")
+
+	// The untyped resource is only a warning, unless in strict mode
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: widgetsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.Error(t, err)
+
+	// Root schemas with properties are typed
+	pg, err = gen.NewPackageGenerator([]string{defaultsCRD})
+	require.NoError(t, err)
+	assert.Empty(t, pg.CustomResourceGenerators[0].UntypedVersions())
+}
+
+func TestPreserveUnknownFieldsCRD(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{gizmosCRD})
+	require.NoError(t, err)
+	require.Len(t, pg.CustomResourceGenerators, 1)
+	crg := pg.CustomResourceGenerators[0]
+	assert.True(t, crg.PreserveUnknownFields)
+	assert.False(t, crg.IsStructural())
+	assert.Equal(t, []string{"v1", "v1alpha1"}, crg.Versions)
+	assert.Equal(t, []string{"v1", "v1alpha1"}, crg.SchemalessVersions)
+	assert.Equal(t, []string{"v1", "v1alpha1"}, crg.UntypedVersions())
+
+	// Each version is a resource with an untyped body
+	for _, version := range crg.Versions {
+		resource, ok := pg.SchemaPackage().GetResource("kubernetes:schemaless.example.com/" + version + ":Gizmo")
+		require.True(t, ok, version)
+		var inputs []string
+		for _, property := range resource.InputProperties {
+			inputs = append(inputs, property.Name)
+		}
+		assert.ElementsMatch(t, []string{"apiVersion", "kind", "metadata", "spec", "status"}, inputs)
+	}
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, gizmosCRD)
+	code := readFile(t, nodejsDir, "schemaless/v1/gizmo.ts")
+	assert.Contains(t, code, `resourceInputs["apiVersion"] = "schemaless.example.com/v1";`)
+	assert.Contains(t, code, "spec?: any;")
+
+	// A CRD that preserves unknown fields keeps the schemas it has
+	crds, err := gen.LoadCRDs(gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: gizmos.schemaless.example.com
+spec:
+  group: schemaless.example.com
+  preserveUnknownFields: true
+  version: v1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+  names:
+    plural: gizmos
+    kind: Gizmo
+`)})
+	require.NoError(t, err)
+	crg, err = gen.NewCustomResourceGenerator(crds[0])
+	require.NoError(t, err)
+	assert.Empty(t, crg.SchemalessVersions)
+	assert.Empty(t, crg.UntypedVersions())
+}
+
+func TestPreserveUnknownFieldsItems(t *testing.T) {
+	const specToken = "kubernetes:preserveunknown.example.com/v1:PipelineSpec"
+	const stepsToken = "kubernetes:preserveunknown.example.com/v1:PipelineSpecSteps"
+	pg, err := gen.NewPackageGenerator([]string{pipelinesCRD})
+	require.NoError(t, err)
+
+	// Items that preserve unknown fields keep their declared fields
+	properties := pg.Types[specToken].Properties
+	assert.Equal(t, pschema.TypeSpec{Type: "array", Items: &pschema.TypeSpec{Type: "object", Ref: "#/types/" + stepsToken}},
+		properties["steps"].TypeSpec)
+	steps := pg.Types[stepsToken]
+	assert.Equal(t, []string{"name"}, steps.Required)
+	assert.Equal(t, pschema.TypeSpec{Type: "string"}, steps.Properties["image"].TypeSpec)
+	assert.Contains(t, steps.Description, "Unknown fields are preserved")
+
+	// So do objects, while items without properties are still arbitrary JSON
+	assert.Equal(t, "#/types/kubernetes:preserveunknown.example.com/v1:PipelineSpecOptions", properties["options"].Ref)
+	assert.Equal(t, &pschema.TypeSpec{Type: "object", AdditionalProperties: &pschema.TypeSpec{Ref: "pulumi.json#/Any"}},
+		properties["params"].Items)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, pipelinesCRD)
+	code := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, code, "steps?: pulumi.Input<pulumi.Input<inputs.preserveunknown.v1.PipelineSpecStepsArgs>[]>;")
+}
+
+func TestCRDNames(t *testing.T) {
+	crds, err := gen.LoadCRDs(gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+  names:
+    plural: crontabs
+    singular: crontab
+    kind: CronTab
+    listKind: CronTabCollection
+    shortNames: [ct]
+    categories: [all, batch]
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gizmos.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+  names:
+    plural: gizmos
+    kind: Gizmo
+`)})
+	require.NoError(t, err)
+	require.Len(t, crds, 2)
+
+	crg, err := gen.NewCustomResourceGenerator(crds[0])
+	require.NoError(t, err)
+	assert.Equal(t, "CronTab", crg.Kind)
+	assert.Equal(t, "crontabs", crg.Plural)
+	assert.Equal(t, "crontab", crg.Singular)
+	assert.Equal(t, "CronTabCollection", crg.ListKind)
+	assert.Equal(t, []string{"ct"}, crg.ShortNames)
+	assert.Equal(t, []string{"all", "batch"}, crg.Categories)
+
+	// The singular name and the list kind default like in the API server
+	crg, err = gen.NewCustomResourceGenerator(crds[1])
+	require.NoError(t, err)
+	assert.Equal(t, "gizmo", crg.Singular)
+	assert.Equal(t, "GizmoList", crg.ListKind)
+	assert.Empty(t, crg.ShortNames)
+	assert.Empty(t, crg.Categories)
+}
+
+func TestMixedSchemaGroup(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{mixedSchemasCRDs})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"kubernetes:mixed.example.com/v1:Widget",
+		"kubernetes:mixed.example.com/v1beta1:Gadget",
+	}, pg.ResourceTokens)
+
+	// Only the versions that are generated have modules, in every language,
+	// so that the modules of the group only import the ones that exist
+	nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:       &nodejsDir,
+		PythonPath:       &pythonDir,
+		GoPath:           &goDir,
+		NodeJSName:       gen.DefaultName,
+		PythonName:       gen.DefaultName,
+		GoName:           gen.DefaultName,
+		VersionSelection: gen.AllVersions,
+	}, mixedSchemasCRDs)
+	for _, dir := range []string{
+		filepath.Join(nodejsDir, "mixed"),
+		filepath.Join(pythonDir, "pulumi_crds", "mixed"),
+		filepath.Join(goDir, "mixed"),
+	} {
+		entries, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		var modules []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				modules = append(modules, entry.Name())
+			}
+		}
+		assert.Equal(t, []string{"v1", "v1beta1"}, modules, dir)
+	}
+
+	code := readFile(t, nodejsDir, "mixed/index.ts")
+	assert.Contains(t, code, "import * as v1 from \"./v1\";\nimport * as v1beta1 from \"./v1beta1\";\n")
+	assert.NotContains(t, code, "v1alpha1")
+	assert.NotContains(t, code, "v2")
+	code = readFile(t, pythonDir, "pulumi_crds/mixed/__init__.py")
+	assert.Contains(t, code, "v1beta1 = _utilities.lazy_import('pulumi_crds.mixed.v1beta1')")
+	assert.NotContains(t, code, "v1alpha1")
+
+	// The module of the schemaless version only imports what it has
+	code = readFile(t, pythonDir, "pulumi_crds/mixed/v1beta1/__init__.py")
+	assert.Contains(t, code, "from .Gadget import *\n")
+	assert.NotContains(t, code, "_inputs")
+	assert.NoFileExists(t, filepath.Join(pythonDir, "pulumi_crds/mixed/v1beta1/_inputs.py"))
+}
+
+func TestIntOrStringEnums(t *testing.T) {
+	const specToken = "kubernetes:intorstring.example.com/v1:EndpointSpec"
+	enumValues := func(complexTypeSpec pschema.ComplexTypeSpec) []interface{} {
+		var values []interface{}
+		for _, enumValueSpec := range complexTypeSpec.Enum {
+			values = append(values, enumValueSpec.Value)
+		}
+		return values
+	}
+
+	pg, err := gen.NewPackageGenerator([]string{endpointsCRD})
+	require.NoError(t, err)
+	properties := pg.Types[specToken].Properties
+
+	// Mixed values are a union of an integer enum and a string enum
+	assert.Equal(t, []pschema.TypeSpec{
+		{Type: "integer", Ref: "#/types/" + specToken + "PortOneOf0"},
+		{Type: "string", Ref: "#/types/" + specToken + "PortOneOf1"},
+	}, properties["port"].OneOf)
+	integerEnum, stringEnum := pg.Types[specToken+"PortOneOf0"], pg.Types[specToken+"PortOneOf1"]
+	assert.Equal(t, []interface{}{float64(80), float64(443)}, enumValues(integerEnum))
+	assert.Equal(t, []interface{}{"http", "https"}, enumValues(stringEnum))
+	assert.Equal(t, "The HTTPS port.", integerEnum.Enum[1].Description)
+	assert.Equal(t, "The name of the HTTP port.", stringEnum.Enum[0].Description)
+
+	// Values of a single type are an enum of that type
+	assert.Equal(t, pschema.TypeSpec{Type: "integer", Ref: "#/types/" + specToken + "TargetPort"}, properties["targetPort"].TypeSpec)
+	assert.Equal(t, []interface{}{float64(8080), float64(8443)}, enumValues(pg.Types[specToken+"TargetPort"]))
+	assert.Equal(t, pschema.TypeSpec{Type: "string", Ref: "#/types/" + specToken + "Protocol"}, properties["protocol"].TypeSpec)
+
+	// Values that can't be enums are documented instead
+	assert.Equal(t, []pschema.TypeSpec{{Type: "integer"}, {Type: "string"}}, properties["weight"].OneOf)
+	assert.Contains(t, properties["weight"].Description, "one of `0.5`, `\"half\"`")
+
+	goDir := t.TempDir()
+	generate(t, gen.LanguageSettings{GoPath: &goDir, GoName: "crds"}, endpointsCRD)
+	code := readFile(t, goDir, "intorstring/v1/pulumiEnums.go")
+	assert.Contains(t, code, "EndpointSpecPortOneOf0Value80")
+	assert.Contains(t, code, "EndpointSpecPortOneOf1Http")
+}
+
+func TestPasswordFormatSecrets(t *testing.T) {
+	const specToken = "kubernetes:secrets.example.com/v1:DatabaseSpec"
+	pg, err := gen.NewPackageGenerator([]string{databasesCRD})
+	require.NoError(t, err)
+	properties := pg.Types[specToken].Properties
+	assert.True(t, properties["password"].Secret)
+	assert.True(t, pg.Types[specToken+"Credentials"].Properties["token"].Secret)
+	assert.False(t, properties["username"].Secret)
+	assert.False(t, properties["port"].Secret)
+
+	// The format is kept in the JSON Schema
+	schema, err := pg.JSONSchema("kubernetes:secrets.example.com/v1:Database")
+	require.NoError(t, err)
+	definitions := schema["definitions"].(map[string]interface{})
+	password, _, _ := unstruct.NestedMap(definitions, "DatabaseSpec", "properties", "password")
+	assert.Equal(t, "password", password["format"])
+
+	// The status has secret fields, so it's an additional secret output
+	const databaseToken = "kubernetes:secrets.example.com/v1:Database"
+	assert.True(t, pg.Types[databaseToken].Properties["status"].Secret)
+	assert.False(t, pg.Types[databaseToken].Properties["spec"].Secret)
+	nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		PythonPath: &pythonDir,
+		GoPath:     &goDir,
+		NodeJSName: gen.DefaultName,
+		PythonName: gen.DefaultName,
+		GoName:     gen.DefaultName,
+	}, databasesCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "secrets/v1/database.ts"), `const secretOpts = { additionalSecretOutputs: ["status"] };`)
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/secrets/v1/Database.py"), `additional_secret_outputs=["status"]`)
+	assert.Contains(t, readFile(t, goDir, "secrets/v1/database.go"), "pulumi.AdditionalSecretOutputs([]string{\n\t\t\"status\",\n\t})")
+}
+
+func TestReservedPropertyNames(t *testing.T) {
+	stubDotNetLogo(t)
+	languages := []string{gen.NodeJS, gen.Python, gen.Go, gen.DotNet}
+
+	// Keywords are renamed by the code generators
+	pg, err := gen.NewPackageGenerator([]string{keywordsCRD})
+	require.NoError(t, err)
+	assert.Empty(t, pg.ReservedPropertyWarnings(languages...))
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	dotnetDir := filepath.Join(outputDir, "dotnet")
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:      &nodejsDir,
+		PythonPath:      &pythonDir,
+		GoPath:          &goDir,
+		DotNetPath:      &dotnetDir,
+		NodeJSName:      gen.DefaultName,
+		PythonName:      gen.DefaultName,
+		GoName:          gen.DefaultName,
+		DotNetName:      gen.DefaultName,
+		GoDryRunCompile: !testing.Short(),
+	}, keywordsCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "types/input.ts"), "class?: pulumi.Input<string>;")
+	code := readFile(t, pythonDir, "pulumi_crds/reserved/v1/_inputs.py")
+	assert.Contains(t, code, "class_: Optional[pulumi.Input[str]] = None,")
+	assert.Contains(t, code, "lambda_: Optional[pulumi.Input[int]] = None,")
+	assert.Contains(t, readFile(t, goDir, "reserved/v1/pulumiTypes.go"), "Func      *string `pulumi:\"func\"`")
+	assert.Contains(t, readFile(t, dotnetDir, "Reserved/V1/Inputs/KeywordSpecArgs.cs"), "public Input<string>? Namespace { get; set; }")
+
+	// Names that clash with the generated code are reported per language
+	loader := gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clashes.reserved.example.com
+spec:
+  group: reserved.example.com
+  names:
+    plural: clashes
+    kind: Clash
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          urn:
+            type: string
+          spec:
+            type: object
+            properties:
+              property:
+                type: string
+              elementType:
+                type: string
+              clashSpec:
+                type: string
+`)}
+	pg, err = gen.NewPackageGeneratorFromLoader(loader)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"the clashSpec property of kubernetes:reserved.example.com/v1:ClashSpec has the same name as its class, so the .NET SDK may not compile; rename the property in the CRD",
+		"the elementType property of kubernetes:reserved.example.com/v1:ClashSpec has the same name as a generated method, so the Go SDK may not compile; rename the property in the CRD",
+		"the property property of kubernetes:reserved.example.com/v1:ClashSpec shadows a name that the generated class uses, so the Python SDK may not compile; rename the property in the CRD",
+		"the urn property of kubernetes:reserved.example.com/v1:Clash redeclares a property of every Pulumi resource, so the .NET SDK may not compile; rename the property in the CRD",
+		"the urn property of kubernetes:reserved.example.com/v1:Clash redeclares a property of every Pulumi resource, so the NodeJS SDK may not compile; rename the property in the CRD",
+		"the urn property of kubernetes:reserved.example.com/v1:Clash redeclares a property of every Pulumi resource, so the Python SDK may not compile; rename the property in the CRD",
+	}, pg.ReservedPropertyWarnings(gen.NodeJS, gen.Python, gen.Go, gen.DotNet))
+	assert.Empty(t, pg.ReservedPropertyWarnings())
+
+	// The clashes are only warnings, unless in strict mode
+	err = gen.GenerateWithOptions(loader,
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.Error(t, err)
+}
+
+func TestTypeTokens(t *testing.T) {
+	stubDotNetLogo(t)
+	const token = `"kubernetes:stable.example.com/v1:CronTab"`
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	dotnetDir := filepath.Join(outputDir, "dotnet")
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		PythonPath: &pythonDir,
+		GoPath:     &goDir,
+		DotNetPath: &dotnetDir,
+		NodeJSName: gen.DefaultName,
+		PythonName: gen.DefaultName,
+		GoName:     gen.DefaultName,
+		DotNetName: gen.DefaultName,
+	}, requiredCRD)
+
+	assert.Contains(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"), "export const CronTabTypeToken = "+token+";")
+
+	code := readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py")
+	assert.Contains(t, code, "__all__ = ['CronTabArgs', 'CronTab', 'CRON_TAB_TYPE_TOKEN']")
+	assert.Contains(t, code, "\nCRON_TAB_TYPE_TOKEN = "+token+"\n")
+
+	assert.Contains(t, readFile(t, goDir, "stable/v1/cronTab.go"), "const CronTabTypeToken = "+token+"\n")
+
+	code = readFile(t, dotnetDir, "Stable/V1/CronTab.cs")
+	assert.Contains(t, code, "namespace Pulumi.Crds.Stable.V1\n{\n    public partial class CronTab\n")
+	assert.Contains(t, code, "public const string TypeToken = "+token+";")
+}
+
+func TestAnnotateSource(t *testing.T) {
+	stubDotNetLogo(t)
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	dotnetDir := filepath.Join(outputDir, "dotnet")
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:     &nodejsDir,
+		PythonPath:     &pythonDir,
+		GoPath:         &goDir,
+		DotNetPath:     &dotnetDir,
+		NodeJSName:     gen.DefaultName,
+		PythonName:     gen.DefaultName,
+		GoName:         gen.DefaultName,
+		DotNetName:     gen.DefaultName,
+		AnnotateSource: true,
+	}, requiredCRD, gizmosCRD)
+
+	header := "// Generated by crd2pulumi " + gen.Version + " from:\n"
+	resource := "CronTab stable.example.com/v1 (" + requiredCRD + ")\n"
+	assert.Contains(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"), "***\n"+header+"//   "+resource+"\n")
+	assert.Contains(t, readFile(t, goDir, "stable/v1/cronTab.go"), "***\n"+header+"//   "+resource+"\n")
+	assert.Contains(t, readFile(t, dotnetDir, "Stable/V1/CronTab.cs"), header+"//   "+resource)
+
+	// The encoding declaration stays on the first line
+	code := readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py")
+	assert.True(t, strings.HasPrefix(code, "# coding=utf-8\n"))
+	assert.Contains(t, code, "# Generated by crd2pulumi "+gen.Version+" from:\n#   "+resource)
+
+	// Files that aren't of a single resource name every CRD
+	code = readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, code, header+"//   CronTab stable.example.com ("+requiredCRD+")\n//   Gizmo schemaless.example.com ("+gizmosCRD+")\n")
+
+	assert.NotContains(t, readFile(t, nodejsDir, "package.json"), "crd2pulumi "+gen.Version)
+}
+
+func TestSortProperties(t *testing.T) {
+	// The properties aren't declared in alphabetical order
+	loader := gen.YAMLLoader{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Namespaced
+  names:
+    kind: Gadget
+    plural: gadgets
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        required: [spec]
+        properties:
+          spec:
+            type: object
+            required: [zone, size, knobs]
+            properties:
+              zone:
+                type: string
+              size:
+                type: integer
+              knobs:
+                type: object
+                required: [volume, bass]
+                properties:
+                  volume:
+                    type: integer
+                  bass:
+                    type: integer
+`)}
+	crds, err := gen.LoadCRDs(loader)
+	require.NoError(t, err)
+	versions, _, _ := gen.NestedMapSlice(crds[0].Object, "spec", "versions")
+	properties, _, _ := unstruct.NestedMap(versions[0], "schema", "openAPIV3Schema", "properties", "spec")
+	assert.Equal(t, []interface{}{"zone", "size", "knobs"}, properties[gen.PropertyOrderKey])
+
+	manifest := func(schemaPropertyOrder bool) string {
+		manifestPath := filepath.Join(t.TempDir(), "example.yaml")
+		err := gen.GenerateFromLoader(gen.LanguageSettings{
+			ExampleManifestPath: &manifestPath,
+			SchemaPropertyOrder: schemaPropertyOrder,
+		}, loader, true)
+		require.NoError(t, err)
+		return readFile(t, filepath.Dir(manifestPath), "example.yaml")
+	}
+	assert.Contains(t, manifest(false), `spec:
+  knobs:
+    bass: 0 # TODO: integer
+    volume: 0 # TODO: integer
+  size: 0 # TODO: integer
+  zone: "" # TODO: string
+`)
+	assert.Contains(t, manifest(true), `spec:
+  zone: "" # TODO: string
+  size: 0 # TODO: integer
+  knobs:
+    volume: 0 # TODO: integer
+    bass: 0 # TODO: integer
+`)
+
+	// The test stubs set the required properties in the same order
+	stubs := func(schemaPropertyOrder bool) string {
+		nodejsPath := filepath.Join(t.TempDir(), "nodejs")
+		err := gen.GenerateFromLoader(gen.LanguageSettings{
+			NodeJSPath:          &nodejsPath,
+			NodeJSName:          gen.DefaultName,
+			SchemaPropertyOrder: schemaPropertyOrder,
+			TestStubs:           true,
+		}, loader, true)
+		require.NoError(t, err)
+		return readFile(t, filepath.Join(nodejsPath, "tests"), "resources.test.ts")
+	}
+	assert.Contains(t, stubs(false), `            spec: {
+                knobs: {
+                    bass: 0,
+                    volume: 0,
+                },
+                size: 0,
+                zone: "",
+            },
+`)
+	assert.Contains(t, stubs(true), `            spec: {
+                zone: "",
+                size: 0,
+                knobs: {
+                    volume: 0,
+                    bass: 0,
+                },
+            },
+`)
+}
+
+func TestJSONSchema(t *testing.T) {
+	const enumsCRD = "crds/crd2pulumi/enums/crontabs-crd.yaml"
+	const resourceToken = "kubernetes:stable.example.com/v1:CronTab"
+
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	schema, err := pg.JSONSchema(resourceToken)
+	require.NoError(t, err)
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+	assert.Equal(t, []interface{}{"apiVersion", "kind", "metadata", "spec"}, schema["required"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "const": "stable.example.com/v1"}, properties["apiVersion"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/CronTabSpec"}, properties["spec"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/ObjectMeta"}, properties["metadata"])
+	definitions := schema["definitions"].(map[string]interface{})
+	assert.Contains(t, definitions, "ObjectMeta")
+	assert.Equal(t, map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"cronSpec"},
+		"properties": map[string]interface{}{
+			"cronSpec": map[string]interface{}{"type": "string"},
+			"image":    map[string]interface{}{"type": "string"},
+		},
+	}, definitions["CronTabSpec"])
+
+	_, err = pg.JSONSchema("kubernetes:stable.example.com/v1:Missing")
+	assert.Error(t, err)
+
+	// Converting the JSON Schema again results in the same types
+	for _, yamlPath := range []string{requiredCRD, defaultsCRD, enumsCRD} {
+		pg, err := gen.NewPackageGenerator([]string{yamlPath})
+		require.NoError(t, err)
+		schema, err := pg.JSONSchema(resourceToken)
+		require.NoError(t, err)
+		data, err := json.Marshal(schema)
+		require.NoError(t, err)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		normalized, err := gen.NormalizeSchema(decoded)
+		require.NoError(t, err)
+
+		types := map[string]pschema.ComplexTypeSpec{}
+		gen.AddType(normalized, resourceToken, types)
+		assert.Equal(t, pg.Types[resourceToken].Required, types[resourceToken].Required, yamlPath)
+		for token, complexTypeSpec := range pg.Types {
+			if token != resourceToken {
+				assert.Equal(t, complexTypeSpec, types[token], "%s: %s", yamlPath, token)
+			}
+		}
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "schemas")
+	generate(t, gen.LanguageSettings{JSONSchemaPath: &outputDir}, requiredCRD)
+	var written map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, outputDir, "stable.example.com/v1/crontab.json")), &written))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", written["$schema"])
+}
+
+func TestProto(t *testing.T) {
+	const enumsCRD = "crds/crd2pulumi/enums/crontabs-crd.yaml"
+	const specToken = "kubernetes:stable.example.com/v1:CronTabSpec"
+
+	pg, err := gen.NewPackageGenerator([]string{enumsCRD})
+	require.NoError(t, err)
+	// Arrays of arrays and maps of arrays nest the messages of arbitrary JSON
+	spec := pg.Types[specToken]
+	spec.Properties["matrix"] = pschema.PropertySpec{TypeSpec: pschema.TypeSpec{Type: "array",
+		Items: &pschema.TypeSpec{Type: "array", Items: &pschema.TypeSpec{Type: "integer"}}}}
+	spec.Properties["groups"] = pschema.PropertySpec{TypeSpec: pschema.TypeSpec{Type: "object",
+		AdditionalProperties: &pschema.TypeSpec{Type: "array", Items: &pschema.TypeSpec{Type: "string"}}}}
+	files, err := pg.ProtoFiles()
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Contains(t, files, "meta/v1.proto")
+	code := files["stable.example.com/v1.proto"].String()
+	assert.Contains(t, code, "syntax = \"proto3\";\n\npackage kubernetes.stable.example.com.v1;\n\n"+
+		"import \"google/protobuf/struct.proto\";\nimport \"meta/v1.proto\";\n")
+	assert.Contains(t, code, "message CronTab {\n"+
+		"  string api_version = 1;\n"+
+		"  string kind = 2;\n"+
+		"  .kubernetes.meta.v1.ObjectMeta metadata = 3;\n"+
+		"  CronTabSpec spec = 4;\n"+
+		"}\n")
+	// Enums are their scalar types
+	assert.Contains(t, code, "  string cron_spec = 2;\n")
+	assert.Contains(t, code, "  map<string, google.protobuf.ListValue> groups = 3;\n")
+	assert.Contains(t, code, "  repeated google.protobuf.ListValue matrix = 4;\n")
+	assert.Contains(t, code, "  int64 priority = 5;\n")
+	assert.Contains(t, code, "  double ratio = 7;\n")
+
+	// Unions are oneofs, and fields whose JSON names protoc wouldn't derive
+	// set them
+	pg, err = gen.NewPackageGenerator([]string{endpointsCRD})
+	require.NoError(t, err)
+	files, err = pg.ProtoFiles()
+	require.NoError(t, err)
+	code = files["intorstring.example.com/v1.proto"].String()
+	assert.Contains(t, code, "  // The port number or name.\n"+
+		"  oneof port {\n"+
+		"    int64 port_int64 = 1;\n"+
+		"    string port_string = 2;\n"+
+		"  }\n")
+	pg, err = gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(anyTypesCRD)})
+	require.NoError(t, err)
+	files, err = pg.ProtoFiles()
+	require.NoError(t, err)
+	code = files["anytypes.example.com/v1.proto"].String()
+	assert.Contains(t, code, "  google.protobuf.Value config = 1;\n")
+	assert.Contains(t, code, "  repeated GadgetSpecContainers containers = 2;\n")
+	assert.Contains(t, code, "  google.protobuf.Value example_com_extra = 3 [json_name = \"example.com/extra\"];\n")
+	assert.Contains(t, code, "  map<string, google.protobuf.Value> labels = 4;\n")
+	assert.Contains(t, code, "  repeated google.protobuf.Value args = 1;\n")
+
+	// Every property of the object types is a field with its JSON name, or
+	// a oneof named after it
+	for _, yamlPath := range []string{requiredCRD, defaultsCRD, enumsCRD, endpointsCRD, routesCRD, keywordsCRD} {
+		pg, err := gen.NewPackageGenerator([]string{yamlPath})
+		require.NoError(t, err)
+		files, err := pg.ProtoFiles()
+		require.NoError(t, err)
+		messages := map[string][]string{}
+		for path, file := range files {
+			for name, jsonNames := range parseProtoMessages(t, file.String()) {
+				messages[strings.TrimSuffix(path, ".proto")+":"+name] = jsonNames
+			}
+		}
+		for token, complexTypeSpec := range pg.Types {
+			if len(complexTypeSpec.Enum) > 0 {
+				continue
+			}
+			var propertyNames []string
+			for propertyName := range complexTypeSpec.Properties {
+				propertyNames = append(propertyNames, propertyName)
+			}
+			assert.ElementsMatch(t, propertyNames, messages[strings.TrimPrefix(token, "kubernetes:")], "%s: %s", yamlPath, token)
+		}
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "proto")
+	generate(t, gen.LanguageSettings{ProtoPath: &outputDir}, requiredCRD)
+	assert.Contains(t, readFile(t, outputDir, "stable.example.com/v1.proto"), "message CronTabSpec {\n")
+	assert.FileExists(t, filepath.Join(outputDir, "meta", "v1.proto"))
+}
+
+// parseProtoMessages returns the JSON names of the fields of each message in
+// the generated `.proto` file, with the names of the oneofs in place of
+// their members.
+func parseProtoMessages(t *testing.T, code string) map[string][]string {
+	messages := map[string][]string{}
+	var message string
+	oneOf := false
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+		case strings.HasPrefix(line, "message "):
+			message = fields[1]
+		case strings.HasPrefix(line, "oneof "):
+			messages[message] = append(messages[message], protoJSONName(fields[1]))
+			oneOf = true
+		case line == "}":
+			if !oneOf {
+				message = ""
+			}
+			oneOf = false
+		case message != "" && !oneOf:
+			require.Contains(t, line, " = ", line)
+			if i := strings.Index(line, `[json_name = "`); i >= 0 {
+				messages[message] = append(messages[message], strings.TrimSuffix(line[i+len(`[json_name = "`):], `"];`))
+				continue
+			}
+			name := fields[len(fields)-3]
+			messages[message] = append(messages[message], protoJSONName(name))
+		}
+	}
+	return messages
+}
+
+// protoJSONName returns the JSON name that protoc derives from a field name
+func protoJSONName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func TestMergeSchema(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	schemaPath := filepath.Join(outputDir, "schema.json")
+	ls := gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, MergeSchemaPath: &schemaPath}
+
+	// The first run writes the schema
+	generate(t, ls, defaultsCRD)
+	spec, err := gen.ReadSchema(schemaPath)
+	require.NoError(t, err)
+	assert.Contains(t, spec.Resources, "kubernetes:stable.example.com/v1:CronTab")
+	assert.Contains(t, spec.Types, "kubernetes:stable.example.com/v1:CronTabSpec")
+	assert.NotContains(t, spec.Types, "kubernetes:stable.example.com/v1:CronTab")
+
+	// Later runs add their CRDs to it, and regenerate the CRDs of earlier runs
+	generate(t, ls, endpointsCRD)
+	assert.FileExists(t, filepath.Join(nodejsDir, "stable", "v1", "cronTab.ts"))
+	assert.FileExists(t, filepath.Join(nodejsDir, "intorstring", "v1", "endpoint.ts"))
+	spec, err = gen.ReadSchema(schemaPath)
+	require.NoError(t, err)
+	assert.Contains(t, spec.Resources, "kubernetes:stable.example.com/v1:CronTab")
+	assert.Contains(t, spec.Resources, "kubernetes:intorstring.example.com/v1:Endpoint")
+
+	// Regenerating the same CRD is fine, but changing it isn't
+	generate(t, ls, defaultsCRD)
+	err = gen.Generate(ls, []string{requiredCRD}, true)
+	assert.EqualError(t, err, "could not merge schema "+schemaPath+": conflicting definitions of type kubernetes:stable.example.com/v1:CronTabSpec")
+}
+
+func TestPackageNameOverrides(t *testing.T) {
+	stubDotNetLogo(t)
+	pythonDir, dotnetDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		PythonPath:             &pythonDir,
+		PythonName:             gen.DefaultName,
+		PythonDistributionName: "myorg-crds",
+		DotNetPath:             &dotnetDir,
+		DotNetName:             gen.DefaultName,
+		DotNetAssemblyName:     "MyOrg.Crds",
+		GoPath:                 &goDir,
+		GoName:                 gen.DefaultName,
+		GoPackageName:          "crds",
+	}, defaultsCRD)
+
+	// The distribution is still imported as the module of the package name
+	assert.Contains(t, readFile(t, pythonDir, "setup.py"), "setup(name='myorg-crds',")
+	assert.FileExists(t, filepath.Join(pythonDir, "pulumi_crds", "__init__.py"))
+
+	assert.NoFileExists(t, filepath.Join(dotnetDir, "Pulumi.Crds.csproj"))
+	project := readFile(t, dotnetDir, "MyOrg.Crds.csproj")
+	assert.Contains(t, project, "<AssemblyName>MyOrg.Crds</AssemblyName>")
+	assert.Contains(t, project, "<PackageId>MyOrg.Crds</PackageId>")
+	assert.Contains(t, project, "<RootNamespace>Pulumi.Crds</RootNamespace>")
+
+	assert.Contains(t, readFile(t, goDir, "pulumiUtilities.go"), "\npackage crds\n")
+	assert.Contains(t, readFile(t, goDir, "stable/v1/cronTab.go"), "\npackage v1\n")
+
+	outputDir := t.TempDir()
+	for ls, message := range map[*gen.LanguageSettings]string{
+		{NodeJSPath: &outputDir, NodeJSName: "My Crds"}:                                 `invalid NodeJS package name "My Crds"`,
+		{PythonPath: &outputDir, PythonName: "my-crds"}:                                 `invalid Python package name "my-crds"`,
+		{PythonPath: &outputDir, PythonName: "crds", PythonDistributionName: "-crds"}:   `invalid Python distribution name "-crds"`,
+		{DotNetPath: &outputDir, DotNetName: "crds", DotNetAssemblyName: "MyOrg..Crds"}: `invalid .NET assembly name "MyOrg..Crds"`,
+		{GoPath: &outputDir, GoName: "crds", GoPackageName: "type"}:                     `invalid Go package name "type"`,
+	} {
+		err := gen.Generate(*ls, []string{defaultsCRD}, true)
+		assert.EqualError(t, err, message)
+	}
+}
+
+func TestDotNetNullable(t *testing.T) {
+	stubDotNetLogo(t)
+	for _, nullable := range []bool{false, true} {
+		dotnetDir := t.TempDir()
+		generate(t, gen.LanguageSettings{DotNetPath: &dotnetDir, DotNetName: gen.DefaultName, DotNetNullable: nullable}, requiredCRD)
+		// The project always enables nullable reference types
+		assert.Contains(t, readFile(t, dotnetDir, "Pulumi.Crds.csproj"), "<Nullable>enable</Nullable>")
+		for path, code := range walkFiles(t, dotnetDir) {
+			if !strings.HasSuffix(path, ".cs") {
+				continue
+			}
+			assert.Equal(t, nullable, strings.Contains(code, "#nullable enable\n"), path)
+		}
+	}
+
+	// The directive follows the header comments
+	dotnetDir := t.TempDir()
+	generate(t, gen.LanguageSettings{DotNetPath: &dotnetDir, DotNetName: gen.DefaultName, DotNetNullable: true}, requiredCRD)
+	code := readFile(t, dotnetDir, "Stable/V1/CronTab.cs")
+	assert.True(t, strings.HasPrefix(code, "// *** WARNING: this file was generated by crd2pulumi. ***\n"+
+		"// *** Do not edit by hand unless you're certain you know what you are doing! ***\n\n"+
+		"#nullable enable\n\n"+
+		"using System;\n"), code)
+}
+
+func TestTestStubs(t *testing.T) {
+	nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:      &nodejsDir,
+		NodeJSName:      gen.DefaultName,
+		PythonPath:      &pythonDir,
+		PythonName:      gen.DefaultName,
+		GoPath:          &goDir,
+		GoName:          gen.DefaultName,
+		GoDryRunCompile: !testing.Short(),
+		TestStubs:       true,
+	}, requiredCRD, bucketsCRD)
+
+	// Only the required properties get placeholders
+	nodejs := readFile(t, nodejsDir, "tests/resources.test.ts")
+	assert.Contains(t, nodejs, `new pkg.stable.v1.CronTab("example", {`)
+	assert.Contains(t, nodejs, `cronSpec: "",`)
+	assert.NotContains(t, nodejs, "image")
+	assert.Contains(t, nodejs, `new pkg.s3.v1beta1.Bucket("example", {`)
+
+	python := readFile(t, pythonDir, "tests/test_resources.py")
+	assert.Contains(t, python, "from pulumi_crds.stable import v1 as stable_v1")
+	assert.Contains(t, python, "resource = stable_v1.CronTab('example',")
+	assert.Contains(t, python, "'cronSpec': '',")
+	assert.NotContains(t, python, "image")
+
+	// The Go tests are run by the dry-run compile
+	golang := readFile(t, goDir, "stable/v1/resources_test.go")
+	assert.Contains(t, golang, "func TestCronTab(t *testing.T) {")
+	assert.Contains(t, golang, `CronSpec: pulumi.String(""),`)
+	assert.FileExists(t, filepath.Join(goDir, "s3", "v1beta1", "resources_test.go"))
+}
+
+// awaitAnnotationsTest constructs a CronTab with the await annotations against
+// the Pulumi mocks, and checks that they're passed to the Kubernetes provider
+const awaitAnnotationsTest = `package v1
+
+import (
+	"testing"
+
+	metav1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type mocks struct{ t *testing.T }
+
+func (m mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	annotations := args.Inputs["metadata"].ObjectValue()["annotations"].ObjectValue()
+	if annotations["pulumi.com/skipAwait"].StringValue() != "true" || annotations["pulumi.com/timeoutSeconds"].StringValue() != "60" {
+		m.t.Errorf("unexpected annotations %v", annotations)
+	}
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestAwaitAnnotations(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		_, err := NewCronTab(ctx, "example", &CronTabArgs{
+			Metadata: &metav1.ObjectMetaArgs{
+				Annotations: pulumi.StringMap{
+					"pulumi.com/skipAwait":      pulumi.String("true"),
+					"pulumi.com/timeoutSeconds": pulumi.String("60"),
+				},
+			},
+		})
+		return err
+	}, pulumi.WithMocks("project", "stack", mocks{t}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestAwaitAnnotations(t *testing.T) {
+	nodejsDir, goDir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "crontabs-example.yaml")
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:          &nodejsDir,
+		NodeJSName:          gen.DefaultName,
+		GoPath:              &goDir,
+		GoName:              gen.DefaultName,
+		ExampleManifestPath: &manifestPath,
+		AwaitAnnotations:    true,
+	}, defaultsCRD)
+
+	cronTab := readFile(t, nodejsDir, "stable/v1/cronTab.ts")
+	assert.Contains(t, cronTab, "     * - `pulumi.com/skipAwait`: set to `\"true\"` to not wait for the resource to become ready")
+	assert.Contains(t, cronTab, "     * - `pulumi.com/timeoutSeconds`: the number of seconds to wait")
+	manifest := readFile(t, filepath.Dir(manifestPath), filepath.Base(manifestPath))
+	assert.Contains(t, manifest, "  namespace: default\n  # annotations:\n  #   pulumi.com/skipAwait: \"true\"")
+
+	// The annotations are set through the metadata input of the resources
+	if testing.Short() {
+		t.Skip("skipping downloading the dependencies of the generated Go code in short mode")
+	}
+	files := map[string]*bytes.Buffer{
+		"stable/v1/awaitAnnotations_test.go": bytes.NewBufferString(awaitAnnotationsTest),
+	}
+	for path, code := range walkFiles(t, goDir) {
+		if strings.HasPrefix(path, "stable/v1/") {
+			files[path] = bytes.NewBufferString(code)
+		}
+	}
+	assert.NoError(t, gen.CompileGoFiles(files))
+}
+
+// ownerReferenceTest constructs a ConfigMap that owns another against the
+// Pulumi mocks, which assign each resource a uid, and checks the owner
+// reference that the provider gets
+const ownerReferenceTest = `package kubernetes
+
+import (
+	"testing"
+
+	corev1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/core/v1"
+	metav1 "github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type mocks struct{ t *testing.T }
+
+func (m mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	metadata := args.Inputs["metadata"].ObjectValue()
+	if args.Name == "child" {
+		ownerReference := metadata["ownerReferences"].ArrayValue()[0].ObjectValue()
+		if ownerReference["uid"].StringValue() != "owner-uid" || ownerReference["kind"].StringValue() != "ConfigMap" || !ownerReference["controller"].BoolValue() {
+			m.t.Errorf("unexpected owner reference %v", ownerReference)
+		}
+	}
+	metadata["name"] = resource.NewStringProperty(args.Name)
+	metadata["uid"] = resource.NewStringProperty(args.Name + "-uid")
+	state := args.Inputs.Copy()
+	state["metadata"] = resource.NewObjectProperty(metadata)
+	return args.Name + "_id", state, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestNewOwnerReference(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		owner, err := corev1.NewConfigMap(ctx, "owner", &corev1.ConfigMapArgs{Metadata: &metav1.ObjectMetaArgs{}})
+		if err != nil {
+			return err
+		}
+		_, err = corev1.NewConfigMap(ctx, "child", &corev1.ConfigMapArgs{
+			Metadata: &metav1.ObjectMetaArgs{
+				OwnerReferences: metav1.OwnerReferenceArray{
+					NewOwnerReference(owner.ApiVersion, owner.Kind, owner.Metadata, true, false),
+				},
+			},
+		})
+		return err
+	}, pulumi.WithMocks("project", "stack", mocks{t}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestOwnerReferenceHelpers(t *testing.T) {
+	stubDotNetLogo(t)
+	nodejsDir, pythonDir, dotnetDir, goDir := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:            &nodejsDir,
+		NodeJSName:            gen.DefaultName,
+		PythonPath:            &pythonDir,
+		PythonName:            gen.DefaultName,
+		DotNetPath:            &dotnetDir,
+		DotNetName:            gen.DefaultName,
+		GoPath:                &goDir,
+		GoName:                gen.DefaultName,
+		OwnerReferenceHelpers: true,
+		ObjectMetaPackages:    map[string]gen.ObjectMetaPackage{gen.Python: {Name: "myorg-kubernetes", Version: "3.0.0"}},
+	}, defaultsCRD)
+
+	// The helpers are exported from the root of the package, and use the
+	// ObjectMeta types of the Kubernetes SDK
+	assert.Contains(t, readFile(t, nodejsDir, "index.ts"), "export * from \"./ownerReference\";")
+	nodejs := readFile(t, nodejsDir, "ownerReference.ts")
+	assert.Contains(t, nodejs, "import * as k8s from \"@pulumi/kubernetes\";")
+	assert.Contains(t, nodejs, "pulumi.Output<k8s.types.input.meta.v1.OwnerReference>")
+
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/__init__.py"), "from .owner_reference import *")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/owner_reference.py"), "from myorg_kubernetes.meta.v1 import OwnerReferenceArgs")
+
+	dotnet := readFile(t, dotnetDir, "OwnerReferences.cs")
+	assert.Contains(t, dotnet, "namespace Pulumi.Crds")
+	assert.Contains(t, dotnet, "using Pulumi.Kubernetes.Types.Outputs.Meta.V1;")
+	assert.Contains(t, dotnet, "public static Output<OwnerReferenceArgs> Create(Output<string> apiVersion, Output<string> kind, Output<ObjectMeta> metadata")
+
+	golang := readFile(t, goDir, "ownerReference.go")
+	assert.Contains(t, golang, "func NewOwnerReference(")
+
+	err := gen.Generate(gen.LanguageSettings{
+		NodeJSPath:            &nodejsDir,
+		NodeJSName:            gen.DefaultName,
+		KeepPlaceholderMeta:   true,
+		OwnerReferenceHelpers: true,
+	}, []string{defaultsCRD}, true)
+	assert.EqualError(t, err, "the owner reference helpers can't be generated with the placeholder ObjectMeta type")
+
+	if testing.Short() {
+		t.Skip("skipping downloading the dependencies of the generated Go code in short mode")
+	}
+	files := map[string]*bytes.Buffer{
+		"ownerReference_test.go": bytes.NewBufferString(ownerReferenceTest),
+	}
+	for path, code := range walkFiles(t, goDir) {
+		files[path] = bytes.NewBufferString(code)
+	}
+	assert.NoError(t, gen.CompileGoFiles(files))
+}
+
+const crdResourcesTest = `package kubernetes
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type crdMocks struct{ t *testing.T }
+
+func (m crdMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	if args.TypeToken != "kubernetes:apiextensions.k8s.io/v1:CustomResourceDefinition" || args.Name != "crontabs.stable.example.com" {
+		m.t.Errorf("unexpected resource %s %s", args.TypeToken, args.Name)
+	}
+	if group := args.Inputs["spec"].ObjectValue()["group"].StringValue(); group != "stable.example.com" {
+		m.t.Errorf("unexpected group %s", group)
+	}
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (crdMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestNewCustomResourceDefinitions(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		crds, err := NewCustomResourceDefinitions(ctx)
+		if err == nil && len(crds) != 1 {
+			t.Errorf("expected 1 CRD, got %d", len(crds))
+		}
+		return err
+	}, pulumi.WithMocks("project", "stack", crdMocks{t}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestCRDResources(t *testing.T) {
+	nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:         &nodejsDir,
+		NodeJSName:         gen.DefaultName,
+		PythonPath:         &pythonDir,
+		PythonName:         gen.DefaultName,
+		GoPath:             &goDir,
+		GoName:             gen.DefaultName,
+		CRDResources:       true,
+		ObjectMetaPackages: map[string]gen.ObjectMetaPackage{gen.Python: {Name: "myorg-kubernetes", Version: "3.0.0"}},
+	}, defaultsCRD)
+
+	// The helpers are exported from the root of the package, and register
+	// the manifests of the CRDs, without the annotations of crd2pulumi
+	assert.Contains(t, readFile(t, nodejsDir, "index.ts"), "export * from \"./customResourceDefinitions\";")
+	nodejs := readFile(t, nodejsDir, "customResourceDefinitions.ts")
+	assert.Contains(t, nodejs, "import * as k8s from \"@pulumi/kubernetes\";")
+	assert.Contains(t, nodejs, `    {"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"crontabs.stable.example.com"},"spec":{`)
+	assert.Contains(t, nodejs, "new k8s.apiextensions.v1.CustomResourceDefinition(manifest.metadata.name, manifest, opts)")
+	assert.NotContains(t, nodejs, gen.SourceAnnotation)
+	assert.NotContains(t, nodejs, gen.PropertyOrderKey)
+
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/__init__.py"), "from .custom_resource_definitions import *")
+	python := readFile(t, pythonDir, "pulumi_crds/custom_resource_definitions.py")
+	assert.Contains(t, python, "from myorg_kubernetes.apiextensions import v1, v1beta1")
+	assert.Contains(t, python, `    "{\"apiVersion\":\"apiextensions.k8s.io/v1\",`)
+
+	assert.Contains(t, readFile(t, goDir, "customResourceDefinitions.go"), "func NewCustomResourceDefinitions(")
+
+	err := gen.Generate(gen.LanguageSettings{
+		NodeJSPath:          &nodejsDir,
+		NodeJSName:          gen.DefaultName,
+		KeepPlaceholderMeta: true,
+		CRDResources:        true,
+	}, []string{defaultsCRD}, true)
+	assert.EqualError(t, err, "the CRD resources can't be generated with the placeholder ObjectMeta type")
+
+	if testing.Short() {
+		t.Skip("skipping downloading the dependencies of the generated Go code in short mode")
+	}
+	files := map[string]*bytes.Buffer{
+		"customResourceDefinitions_test.go": bytes.NewBufferString(crdResourcesTest),
+	}
+	for path, code := range walkFiles(t, goDir) {
+		files[path] = bytes.NewBufferString(code)
+	}
+	assert.NoError(t, gen.CompileGoFiles(files))
+}
+
+const goUtilityHelpersTest = `package v1
+
+import (
+	"testing"
+
+	kubernetes "crd2pulumi.dev/dryrun"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type utilityMocks struct{ t *testing.T }
+
+func (m utilityMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	spec := args.Inputs["spec"].ObjectValue()
+	if spec["cronSpec"].StringValue() != "* * * * */5" || spec["concurrencyPolicy"].StringValue() != "Forbid" || spec["priority"].NumberValue() != 3 {
+		m.t.Errorf("unexpected spec %v", spec)
+	}
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (utilityMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestUtilityHelpers(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		_, err := NewCronTab(ctx, "crontab", &CronTabArgs{
+			Spec: CronTabSpecFrom(CronTabSpec{
+				CronSpec:          kubernetes.StringRef("* * * * */5"),
+				ConcurrencyPolicy: CronTabSpecConcurrencyPolicyRef(CronTabSpecConcurrencyPolicyForbid),
+				Priority:          CronTabSpecPriorityRef(CronTabSpecPriorityHigh),
+			}),
+		})
+		return err
+	}, pulumi.WithMocks("project", "stack", utilityMocks{t}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestGoUtilityHelpers(t *testing.T) {
+	const enumsCRD = "crds/crd2pulumi/enums/crontabs-crd.yaml"
+	goDir := t.TempDir()
+	generate(t, gen.LanguageSettings{GoPath: &goDir, GoName: gen.DefaultName, GoUtilityHelpers: true}, enumsCRD, treesCRD)
+
+	// The pointer helpers are in the root package, and the helpers of the
+	// types and the enums in their own packages
+	assert.Contains(t, readFile(t, goDir, "utilityHelpers.go"), "func StringRef(v string) *string {")
+	helpers := readFile(t, goDir, "stable/v1/utilityHelpers.go")
+	assert.Contains(t, helpers, "func CronTabSpecFrom(v CronTabSpec) CronTabSpecOutput {\n\treturn pulumi.ToOutput(v).(CronTabSpecOutput)\n}\n")
+	assert.Contains(t, helpers, "func TreeSpecRootFrom(v TreeSpecRoot) TreeSpecRootOutput {")
+	assert.Contains(t, helpers, "func CronTabSpecPriorityRef(v CronTabSpecPriority) *CronTabSpecPriority {")
+
+	if testing.Short() {
+		t.Skip("skipping downloading the dependencies of the generated Go code in short mode")
+	}
+	files := map[string]*bytes.Buffer{
+		"stable/v1/utilityHelpers_test.go": bytes.NewBufferString(goUtilityHelpersTest),
+	}
+	for path, code := range walkFiles(t, goDir) {
+		files[path] = bytes.NewBufferString(code)
+	}
+	assert.NoError(t, gen.CompileGoFiles(files))
+}
+
+func TestUnknownTypes(t *testing.T) {
+	const specToken = "kubernetes:unknowntypes.example.com/v1:SprocketSpec"
+
+	// Unknown types are converted to the any type by default
+	pg, err := gen.NewPackageGenerator([]string{sprocketsCRD})
+	require.NoError(t, err)
+	assert.Equal(t, "pulumi.json#/Any", pg.Types[specToken].Properties["color"].Ref)
+	assert.Equal(t, "integer", pg.Types[specToken].Properties["size"].Type)
+
+	require.NoError(t, pg.SetUnknownTypePolicy(gen.UnknownTypeObject))
+	color := pg.Types[specToken].Properties["color"]
+	assert.Equal(t, "object", color.Type)
+	assert.Empty(t, color.Ref)
+
+	err = pg.SetUnknownTypePolicy(gen.UnknownTypeError)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `SprocketSpecColor has an unknown type "strnig"`)
+
+	_, err = gen.ParseUnknownTypePolicy("strict")
+	assert.Error(t, err)
+
+	nodejsDir := t.TempDir()
+	err = gen.Generate(gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, UnknownTypes: gen.UnknownTypeError}, []string{sprocketsCRD}, true)
+	assert.Error(t, err)
+}
+
+func TestAnyTypeRef(t *testing.T) {
+	const widgetToken = "kubernetes:untyped.example.com/v1:Widget"
+	const jsonValueToken = "kubernetes:untyped.example.com/v1:JSONValue"
+
+	// The untyped `spec` and `status` fall back to the overridden type
+	pg, err := gen.NewPackageGenerator([]string{widgetsCRD})
+	require.NoError(t, err)
+	require.Equal(t, "pulumi.json#/Any", pg.Types[widgetToken].Properties["spec"].Ref)
+	require.NoError(t, pg.SetAnyTypeRef("pulumi.json#/Json"))
+	assert.Equal(t, "pulumi.json#/Json", pg.Types[widgetToken].Properties["spec"].Ref)
+	assert.Equal(t, "pulumi.json#/Json", pg.Types[widgetToken].Properties["status"].Ref)
+	assert.Equal(t, 2, pg.Stats().AnyTypes)
+
+	// The fallbacks can point at a type of the package, including the
+	// values of maps of arbitrary JSON
+	pg, err = gen.NewPackageGenerator([]string{widgetsCRD, gizmosCRD})
+	require.NoError(t, err)
+	pg.Types[jsonValueToken] = pschema.ComplexTypeSpec{
+		ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "object", Properties: map[string]pschema.PropertySpec{}},
+	}
+	require.NoError(t, pg.SetAnyTypeRef("#/types/"+jsonValueToken))
+	assert.Equal(t, "#/types/"+jsonValueToken, pg.Types[widgetToken].Properties["spec"].Ref)
+	for token, complexTypeSpec := range pg.Types {
+		for propertyName, propertySpec := range complexTypeSpec.Properties {
+			assert.NotContains(t, typeSpecJSON(t, propertySpec.TypeSpec), "pulumi.json#/Any", token+"."+propertyName)
+		}
+	}
+	_, ok := pg.SchemaPackage().GetResource(widgetToken)
+	assert.True(t, ok)
+
+	err = pg.SetAnyTypeRef("#/types/kubernetes:untyped.example.com/v1:Missing")
+	assert.Error(t, err)
+	err = pg.SetAnyTypeRef("JSONValue")
+	assert.Error(t, err)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, AnyTypeRef: "pulumi.json#/Json"}, widgetsCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "untyped/v1/widget.ts"), "spec?: any")
+}
+
+// typeSpecJSON returns the given type as JSON, to search it for refs.
+func typeSpecJSON(t *testing.T, typeSpec pschema.TypeSpec) string {
+	data, err := json.Marshal(typeSpec)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPrettyJSON(t *testing.T) {
+	writeJSON := func(compact bool) (string, string) {
+		outputDir := t.TempDir()
+		jsonSchemaDir := filepath.Join(outputDir, "schemas")
+		schemaPath := filepath.Join(outputDir, "schema.json")
+		generate(t, gen.LanguageSettings{JSONSchemaPath: &jsonSchemaDir, MergeSchemaPath: &schemaPath, CompactJSON: compact}, bucketsCRD, endpointsCRD)
+		return readFile(t, jsonSchemaDir, "s3.aws.example.com/v1beta1/bucket.json"), readFile(t, outputDir, "schema.json")
+	}
+
+	// The JSON is indented by default, and compact otherwise
+	prettyJSONSchema, prettySchema := writeJSON(false)
+	compactJSONSchema, compactSchema := writeJSON(true)
+	for _, pretty := range []string{prettyJSONSchema, prettySchema} {
+		assert.Contains(t, pretty, "{\n    \"")
+	}
+	for _, compact := range []string{compactJSONSchema, compactSchema} {
+		assert.Equal(t, 1, strings.Count(compact, "\n"))
+		assert.True(t, strings.HasSuffix(compact, "}\n"))
+	}
+	var pretty, compact interface{}
+	require.NoError(t, json.Unmarshal([]byte(prettySchema), &pretty))
+	require.NoError(t, json.Unmarshal([]byte(compactSchema), &compact))
+	assert.Equal(t, pretty, compact)
+
+	// The output is the same across runs
+	for i := 0; i < 3; i++ {
+		jsonSchema, schema := writeJSON(false)
+		assert.Equal(t, prettyJSONSchema, jsonSchema)
+		assert.Equal(t, prettySchema, schema)
+		jsonSchema, schema = writeJSON(true)
+		assert.Equal(t, compactJSONSchema, jsonSchema)
+		assert.Equal(t, compactSchema, schema)
+	}
+}
+
+func TestSDKVersionFile(t *testing.T) {
+	stubDotNetLogo(t)
+	nodejsDir, pythonDir, dotnetDir, goDir := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:      &nodejsDir,
+		NodeJSName:      gen.DefaultName,
+		PythonPath:      &pythonDir,
+		PythonName:      gen.DefaultName,
+		DotNetPath:      &dotnetDir,
+		DotNetName:      gen.DefaultName,
+		GoPath:          &goDir,
+		GoName:          gen.DefaultName,
+		GoPackageName:   "crds",
+		GoDryRunCompile: !testing.Short(),
+		PackageVersion:  "2.0.0-alpha.1",
+		SDKVersionFile:  true,
+	}, requiredCRD)
+
+	// Each version file has the version that the package is stamped with
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"version": "2.0.0-alpha.1",`)
+	assert.Contains(t, readFile(t, nodejsDir, "version.ts"), "export const version = \"2.0.0-alpha.1\";\n")
+	assert.Contains(t, readFile(t, nodejsDir, "index.ts"), "export * from \"./version\";\n")
+	assert.Contains(t, readFile(t, pythonDir, "setup.py"), `VERSION = "2.0.0-alpha.1"`)
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/_version.py"), "__version__ = \"2.0.0-alpha.1\"\n")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/__init__.py"), "from ._version import __version__\n")
+	assert.Contains(t, readFile(t, goDir, "version.go"), "\npackage crds\n")
+	assert.Contains(t, readFile(t, goDir, "version.go"), "const Version = \"2.0.0-alpha.1\"\n")
+	assert.Equal(t, "2.0.0-alpha.1", readFile(t, dotnetDir, "version.txt"))
+	assert.Contains(t, readFile(t, dotnetDir, "Pulumi.Crds.csproj"), `<EmbeddedResource Include="version.txt" />`)
+
+	// They aren't generated by default
+	nodejsDir = t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, requiredCRD)
+	assert.NoFileExists(t, filepath.Join(nodejsDir, "version.ts"))
+}
+
+func TestBooleanSchemas(t *testing.T) {
+	const specToken = "kubernetes:booleanschemas.example.com/v1:FlagSpec"
+	pg, err := gen.NewPackageGenerator([]string{flagsCRD})
+	require.NoError(t, err)
+	properties := pg.Types[specToken].Properties
+
+	// `true` accepts any value, and `false` none
+	assert.Equal(t, pschema.PropertySpec{TypeSpec: pschema.TypeSpec{Ref: "pulumi.json#/Any"}}, properties["payload"])
+	assert.Equal(t, "pulumi.json#/Any", properties["legacy"].Ref)
+	assert.Contains(t, properties["legacy"].Description, "Forbidden")
+	assert.Equal(t, "string", properties["name"].Type)
+
+	// Forbidden properties convert back to the `false` schema
+	schema, err := pg.JSONSchema("kubernetes:booleanschemas.example.com/v1:Flag")
+	require.NoError(t, err)
+	spec := schema["definitions"].(map[string]interface{})["FlagSpec"].(map[string]interface{})
+	assert.Equal(t, false, spec["properties"].(map[string]interface{})["legacy"])
+	assert.Equal(t, map[string]interface{}{}, spec["properties"].(map[string]interface{})["payload"])
+
+	// Properties are forbidden by their schema, not by their description
+	name := properties["name"]
+	name.Description = properties["legacy"].Description
+	properties["name"] = name
+	schema, err = pg.JSONSchema("kubernetes:booleanschemas.example.com/v1:Flag")
+	require.NoError(t, err)
+	spec = schema["definitions"].(map[string]interface{})["FlagSpec"].(map[string]interface{})
+	assert.NotEqual(t, false, spec["properties"].(map[string]interface{})["name"])
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, flagsCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "types/input.ts"), "Forbidden: the schema of this property is `false`, so it can't be set.")
+}
+
+func TestImportIDs(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD, bucketsCRD})
+	require.NoError(t, err)
+	pg.DocumentImportIDs()
+
+	// The ID depends on the scope of the CRD
+	assert.Contains(t, pg.Types["kubernetes:stable.example.com/v1:CronTab"].Description,
+		"## Import\n\nAn existing CronTab can be imported with `<namespace>/<name>` as the ID, e.g.:\n\n"+
+			"```sh\n$ pulumi import kubernetes:stable.example.com/v1:CronTab my-crontab default/my-crontab\n```")
+	assert.Contains(t, pg.Types["kubernetes:s3.aws.example.com/v1beta1:Bucket"].Description,
+		"```sh\n$ pulumi import kubernetes:s3.aws.example.com/v1beta1:Bucket my-bucket my-bucket\n```")
+	assert.NotContains(t, pg.Types["kubernetes:s3.aws.example.com/v1beta1:BucketSpec"].Description, "## Import")
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, requiredCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"), " * $ pulumi import kubernetes:stable.example.com/v1:CronTab my-crontab default/my-crontab\n")
+}
+
+func TestRefValuedMaps(t *testing.T) {
+	const specToken = "kubernetes:networking.example.com/v1:RouteSpec"
+	const backendToken = "kubernetes:networking.example.com/v1:RouteSpecBackends"
+	pg, err := gen.NewPackageGenerator([]string{routesCRD})
+	require.NoError(t, err)
+	properties := pg.Types[specToken].Properties
+
+	// The `$ref` of a map's values resolves to the type of the referenced schema
+	backends := properties["backends"]
+	assert.Equal(t, "object", backends.Type)
+	if assert.NotNil(t, backends.AdditionalProperties) {
+		assert.Equal(t, "#/types/"+backendToken, backends.AdditionalProperties.Ref)
+	}
+	backend := pg.Types[backendToken]
+	assert.Equal(t, "A backend that traffic is routed to.", backend.Description)
+	assert.Equal(t, "string", backend.Properties["host"].Type)
+	assert.Equal(t, "integer", backend.Properties["port"].Type)
+	assert.Equal(t, []string{"host"}, backend.Required)
+
+	// A `$ref` to a scalar schema is a map of scalars
+	weights := properties["weights"]
+	if assert.NotNil(t, weights.AdditionalProperties) {
+		assert.Equal(t, pschema.TypeSpec{Type: "integer"}, *weights.AdditionalProperties)
+	}
+
+	// The keywords next to a `$ref` override the ones of the referenced schema
+	defaultBackend := properties["defaultBackend"]
+	assert.Equal(t, "The backend that unmatched traffic is routed to.", defaultBackend.Description)
+	assert.Equal(t, "A backend that traffic is routed to.", pg.Types[backendToken].Description)
+	defaultBackendToken := strings.TrimPrefix(defaultBackend.Ref, "#/types/")
+	if assert.Contains(t, pg.Types, defaultBackendToken) {
+		assert.Equal(t, []string{"host"}, pg.Types[defaultBackendToken].Required)
+	}
+	defaultWeight := properties["defaultWeight"]
+	assert.Equal(t, "integer", defaultWeight.Type)
+	assert.EqualValues(t, 1, defaultWeight.Default)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, routesCRD)
+	input := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, input, "backends?: pulumi.Input<{[key: string]: pulumi.Input<inputs.networking.v1.RouteSpecBackendsArgs>}>;")
+	assert.Contains(t, input, "weights?: pulumi.Input<{[key: string]: pulumi.Input<number>}>;")
+}
+
+func TestPartiallyOpenObjects(t *testing.T) {
+	const specToken = "kubernetes:networking.example.com/v1:IngressSpec"
+	const headersToken = "kubernetes:networking.example.com/v1:IngressSpecHeaders"
+	const annotationsToken = "kubernetes:networking.example.com/v1:IngressSpecAnnotations"
+	pg, err := gen.NewPackageGenerator([]string{partialMapsCRD})
+	require.NoError(t, err)
+	properties := pg.Types[specToken].Properties
+
+	// An object with both `properties` and a typed `additionalProperties`
+	// keeps its declared keys, or is a map of the additional properties
+	headers := properties["headers"]
+	assert.Equal(t, "The headers to set, with typed well-known headers.", headers.Description)
+	assert.Equal(t, []pschema.TypeSpec{
+		{Type: "object", Ref: "#/types/" + headersToken},
+		{Type: "object", AdditionalProperties: &pschema.TypeSpec{Type: "string"}},
+	}, headers.OneOf)
+	if assert.Contains(t, pg.Types, headersToken) {
+		assert.Equal(t, "string", pg.Types[headersToken].Properties["host"].Type)
+		assert.Equal(t, "integer", pg.Types[headersToken].Properties["maxAge"].Type)
+		assert.Equal(t, []string{"host"}, pg.Types[headersToken].Required)
+	}
+
+	// `additionalProperties: true` makes the extra keys untyped
+	annotations := properties["annotations"]
+	if assert.Len(t, annotations.OneOf, 2) {
+		assert.Equal(t, "#/types/"+annotationsToken, annotations.OneOf[0].Ref)
+		assert.NotNil(t, annotations.OneOf[1].AdditionalProperties)
+	}
+	assert.Contains(t, pg.Types[annotationsToken].Properties, "owner")
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, partialMapsCRD)
+	input := readFile(t, nodejsDir, "types/input.ts")
+	assert.Contains(t, input, "headers?: pulumi.Input<inputs.networking.v1.IngressSpecHeadersArgs | {[key: string]: pulumi.Input<string>}>;")
+	assert.Contains(t, input, "export interface IngressSpecHeadersArgs {")
+}
+
+func TestRecursiveArrays(t *testing.T) {
+	const rootToken = "kubernetes:stable.example.com/v1:TreeSpecRoot"
+	pg, err := gen.NewPackageGenerator([]string{treesCRD})
+	require.NoError(t, err)
+
+	// The items of the recursive array have the type that's being converted
+	root := pg.Types[rootToken]
+	assert.Equal(t, "A node of the tree.", root.Description)
+	children := root.Properties["children"]
+	assert.Equal(t, "array", children.Type)
+	if assert.NotNil(t, children.Items) {
+		assert.Equal(t, "#/types/"+rootToken, children.Items.Ref)
+	}
+	assert.NotContains(t, pg.Types, rootToken+"Children")
+
+	nodejsDir, pythonDir, goDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		PythonPath: &pythonDir,
+		GoPath:     &goDir,
+		NodeJSName: gen.DefaultName,
+		PythonName: gen.DefaultName,
+		GoName:     gen.DefaultName,
+		TestStubs:  true,
+	}, treesCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "types/input.ts"), "children?: pulumi.Input<pulumi.Input<inputs.stable.v1.TreeSpecRootArgs>[]>;")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/stable/v1/_inputs.py"), "children: Optional[pulumi.Input[Sequence[pulumi.Input['TreeSpecRootArgs']]]] = None")
+	assert.Contains(t, readFile(t, goDir, "stable/v1/pulumiTypes.go"), "Children TreeSpecRootArrayInput `pulumi:\"children\"`")
+}
+
+func TestTopLevelModule(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	dotNetDir := filepath.Join(outputDir, "dotnet")
+	stubDotNetLogo(t)
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:     &nodejsDir,
+		NodeJSName:     gen.DefaultName,
+		PythonPath:     &pythonDir,
+		PythonName:     gen.DefaultName,
+		GoPath:         &goDir,
+		GoName:         gen.DefaultName,
+		DotNetPath:     &dotNetDir,
+		DotNetName:     gen.DefaultName,
+		TopLevelModule: "crds",
+		TestStubs:      true,
+	}, requiredCRD)
+
+	// Every group's modules are nested under the top-level module
+	assert.Contains(t, readFile(t, nodejsDir, "index.ts"), "import * as crds from \"./crds\";\n")
+	assert.Contains(t, readFile(t, nodejsDir, "crds/index.ts"), "import * as stable from \"./stable\";\n")
+	assert.FileExists(t, filepath.Join(nodejsDir, "crds/stable/v1/cronTab.ts"))
+	assert.NoDirExists(t, filepath.Join(nodejsDir, "stable"))
+	assert.Contains(t, readFile(t, nodejsDir, "tests/resources.test.ts"), "new pkg.crds.stable.v1.CronTab(\"example\"")
+
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/__init__.py"), "crds = _utilities.lazy_import('pulumi_crds.crds')\n")
+	assert.FileExists(t, filepath.Join(pythonDir, "pulumi_crds/crds/stable/v1/CronTab.py"))
+	assert.Contains(t, readFile(t, pythonDir, "tests/test_resources.py"), "from pulumi_crds.crds.stable import v1 as crds_stable_v1  # noqa: E402\n")
+
+	assert.Contains(t, readFile(t, goDir, "crds/stable/v1/cronTab.go"), "package v1\n")
+	assert.FileExists(t, filepath.Join(goDir, "crds/stable/v1/resources_test.go"))
+
+	assert.Contains(t, readFile(t, dotNetDir, "Crds/Stable/V1/CronTab.cs"), "namespace Pulumi.Crds.Crds.Stable.V1\n")
+
+	nodejsDir = t.TempDir()
+	err := gen.Generate(gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, TopLevelModule: "my-crds"}, []string{requiredCRD}, true)
+	assert.EqualError(t, err, `invalid top-level module "my-crds"`)
+}
+
+func TestGroupPrefixStrip(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	dotNetDir := filepath.Join(outputDir, "dotnet")
+	stubDotNetLogo(t)
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:       &nodejsDir,
+		NodeJSName:       gen.DefaultName,
+		PythonPath:       &pythonDir,
+		PythonName:       gen.DefaultName,
+		GoPath:           &goDir,
+		GoName:           gen.DefaultName,
+		DotNetPath:       &dotNetDir,
+		DotNetName:       gen.DefaultName,
+		GroupPrefixStrip: "stable.",
+	}, requiredCRD)
+
+	// Only the modules change, not the tokens or the apiVersions
+	code := readFile(t, nodejsDir, "example/v1/cronTab.ts")
+	assert.Contains(t, code, "public static readonly __pulumiType = 'kubernetes:stable.example.com/v1:CronTab';")
+	assert.Contains(t, code, "\"stable.example.com/v1\"")
+	assert.NoDirExists(t, filepath.Join(nodejsDir, "stable"))
+	assert.FileExists(t, filepath.Join(pythonDir, "pulumi_crds/example/v1/CronTab.py"))
+	assert.Contains(t, readFile(t, goDir, "example/v1/cronTab.go"), "\"kubernetes:stable.example.com/v1:CronTab\"")
+	assert.Contains(t, readFile(t, dotNetDir, "Example/V1/CronTab.cs"), "namespace Pulumi.Crds.Example.V1\n")
+
+	// A suffix is stripped too, and the group is kept whole if nothing would
+	// be left of it
+	for _, strip := range []string{"example.com", "stable.example.com"} {
+		nodejsDir := t.TempDir()
+		generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName, GroupPrefixStrip: strip}, requiredCRD)
+		assert.FileExists(t, filepath.Join(nodejsDir, "stable/v1/cronTab.ts"), strip)
+	}
+}
+
+func TestSelectVersions(t *testing.T) {
+	const gadgetV1 = "kubernetes:conversion.example.com/v1:Gadget"
+	const gadgetV1Alpha1 = "kubernetes:conversion.example.com/v1alpha1:Gadget"
+	const gizmoV1 = "kubernetes:conversion.example.com/v1:Gizmo"
+	const gizmoV1Beta1 = "kubernetes:conversion.example.com/v1beta1:Gizmo"
+
+	for selection, resourceTokens := range map[gen.VersionSelection][]string{
+		// Only the CRD without a conversion webhook is reduced to its storage version
+		gen.ConversionVersions: {gadgetV1, gizmoV1, gizmoV1Beta1},
+		gen.AllVersions:        {gadgetV1, gadgetV1Alpha1, gizmoV1, gizmoV1Beta1},
+		gen.StorageVersionOnly: {gadgetV1, gizmoV1},
+	} {
+		pg, err := gen.NewPackageGenerator([]string{conversionCRDs})
+		require.NoError(t, err)
+		assert.Equal(t, "None", pg.CustomResourceGenerators[0].ConversionStrategy)
+		assert.Equal(t, "Webhook", pg.CustomResourceGenerators[1].ConversionStrategy)
+
+		require.NoError(t, pg.SelectVersions(selection))
+		assert.ElementsMatch(t, resourceTokens, pg.ResourceTokens, selection)
+		// The types of the other versions aren't generated
+		for _, token := range []string{gadgetV1, gadgetV1Alpha1, gizmoV1, gizmoV1Beta1} {
+			_, generated := pg.Types[token]
+			selected := false
+			for _, resourceToken := range resourceTokens {
+				selected = selected || resourceToken == token
+			}
+			assert.Equal(t, selected, generated, "%s %s", selection, token)
+		}
+	}
+
+	// The conversion-aware selection is the default
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, conversionCRDs)
+	assert.FileExists(t, filepath.Join(nodejsDir, "conversion/v1/gadget.ts"))
+	assert.NoFileExists(t, filepath.Join(nodejsDir, "conversion/v1alpha1/gadget.ts"))
+	assert.FileExists(t, filepath.Join(nodejsDir, "conversion/v1beta1/gizmo.ts"))
+
+	pg, err := gen.NewPackageGenerator([]string{conversionCRDs})
+	require.NoError(t, err)
+	assert.EqualError(t, pg.SelectVersions("latest"), `invalid version selection "latest", expected conversion, all or storage`)
+}
+
+func TestRenameResources(t *testing.T) {
+	const scheduledJobToken = "kubernetes:stable.example.com/v1:ScheduledJob"
+
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.RenameResources(map[string]string{"stable.example.com/CronTab": "ScheduledJob"}))
+	assert.Equal(t, []string{scheduledJobToken}, pg.ResourceTokens)
+	assert.Equal(t, "CronTab", pg.CustomResourceGenerators[0].Kind)
+	assert.Equal(t, "ScheduledJob", pg.CustomResourceGenerators[0].TypeName)
+	// The types are named after the new name, but the kind is the CRD's
+	resourceType, ok := pg.Types[scheduledJobToken]
+	require.True(t, ok)
+	assert.Equal(t, "CronTab", resourceType.Properties["kind"].Const)
+	assert.Equal(t, "stable.example.com/v1", resourceType.Properties["apiVersion"].Const)
+	assert.Contains(t, pg.Types, "kubernetes:stable.example.com/v1:ScheduledJobSpec")
+	assert.NotContains(t, pg.Types, "kubernetes:stable.example.com/v1:CronTab")
+
+	err = pg.RenameResources(map[string]string{"stable.example.com/Missing": "Other"})
+	assert.EqualError(t, err, "could not find the CRD of rename stable.example.com/Missing=Other")
+	// The new names can't be taken by another resource of the group
+	pg, err = gen.NewPackageGenerator([]string{conversionCRDs})
+	require.NoError(t, err)
+	err = pg.RenameResources(map[string]string{"conversion.example.com/Gadget": "Gizmo"})
+	assert.EqualError(t, err, "conversion.example.com/Gadget and conversion.example.com/Gizmo would both generate the resource type conversion.example.com/Gizmo")
+	require.NoError(t, pg.RenameResources(map[string]string{"conversion.example.com/Gadget": "Gizmo", "conversion.example.com/Gizmo": "Gadget"}))
+
+	groupKind, name, err := gen.ParseRename("stable.example.com/CronTab=ScheduledJob")
+	require.NoError(t, err)
+	assert.Equal(t, "stable.example.com/CronTab", groupKind)
+	assert.Equal(t, "ScheduledJob", name)
+	_, _, err = gen.ParseRename("stable.example.com/CronTab=Scheduled-Job")
+	assert.Error(t, err)
+	_, _, err = gen.ParseRename("CronTab=ScheduledJob")
+	assert.Error(t, err)
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	goDir := filepath.Join(outputDir, "go")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: requiredCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, GoPath: &goDir, GoClientHelpers: true}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithRenames("stable.example.com/CronTab=ScheduledJob"),
+	)
+	require.NoError(t, err)
+	code := readFile(t, nodejsDir, "stable/v1/scheduledJob.ts")
+	assert.Contains(t, code, "export class ScheduledJob extends pulumi.CustomResource")
+	assert.Contains(t, code, `resourceInputs["kind"] = "CronTab";`)
+	client := readFile(t, goDir, "stable/v1/scheduledJobClient.go")
+	assert.Contains(t, client, "func NewScheduledJobClient(")
+	assert.Contains(t, client, `Kind:    "CronTab",`)
+
+	_, err = gen.NewGenerateOptions(gen.WithRenames("stable.example.com/CronTab=A", "stable.example.com/CronTab=B"))
+	assert.EqualError(t, err, "stable.example.com/CronTab is renamed more than once")
+}
+
+func TestMetadataConstraints(t *testing.T) {
+	const certificateToken = "kubernetes:metadata.example.com/v1:Certificate"
+
+	pg, err := gen.NewPackageGenerator([]string{metadataCRD})
+	require.NoError(t, err)
+	constraints := []string{
+		"`metadata.labels` is required.",
+		"`metadata.labels.app` is required.",
+		"`metadata.labels.app` must be one of `\"web\"`, `\"api\"`.",
+		"`metadata.name` must match `^[a-z0-9-]+$`.",
+		"`metadata.name` must be at most 63 characters long.",
+	}
+	assert.Equal(t, constraints, pg.CustomResourceGenerators[0].MetadataConstraints("v1"))
+
+	// The metadata is still the ObjectMeta type, with the constraints in its
+	// description, and no types are generated for its schema
+	metadata := pg.Types[certificateToken].Properties["metadata"]
+	assert.Equal(t, "#/types/kubernetes:meta/v1:ObjectMeta", metadata.Ref)
+	assert.Equal(t, "The CRD constrains the metadata further than the ObjectMeta type, which doesn't enforce it:\n\n- "+
+		strings.Join(constraints, "\n- "), metadata.Description)
+	for token := range pg.Types {
+		assert.NotContains(t, token, "CertificateMetadata")
+	}
+
+	// Resources without a metadata schema aren't affected
+	pg, err = gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	assert.Empty(t, pg.CustomResourceGenerators[0].MetadataConstraints("v1"))
+	assert.Empty(t, pg.Types["kubernetes:stable.example.com/v1:CronTab"].Properties["metadata"].Description)
+
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, metadataCRD)
+	assert.Contains(t, readFile(t, nodejsDir, "metadata/v1/certificate.ts"), "`metadata.labels.app` is required.")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: metadataCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the schema of Certificate v1 constrains its metadata")
+}
+
+func TestResourceMethods(t *testing.T) {
+	const cronTabToken = "kubernetes:stable.example.com/v1:CronTab"
+
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	require.NoError(t, pg.AddMethods(map[string][]string{"stable.example.com/CronTab": {"trigger"}}))
+	resource, ok := pg.SchemaPackage().GetResource(cronTabToken)
+	require.True(t, ok)
+	if assert.Len(t, resource.Methods, 1) {
+		method := resource.Methods[0]
+		assert.Equal(t, "trigger", method.Name)
+		assert.Equal(t, cronTabToken+"/trigger", method.Function.Token)
+		assert.True(t, method.Function.IsMethod)
+		assert.Contains(t, method.Function.Comment, "The Kubernetes provider doesn't implement it")
+	}
+
+	err = pg.AddMethods(map[string][]string{"stable.example.com/Missing": {"trigger"}})
+	assert.EqualError(t, err, "could not find the CRD of the methods of stable.example.com/Missing")
+	err = pg.AddMethods(map[string][]string{"stable.example.com/CronTab": {"spec"}})
+	assert.EqualError(t, err, "the method spec of "+cronTabToken+" has the name of one of its properties")
+
+	groupKind, name, err := gen.ParseMethod("stable.example.com/CronTab=trigger")
+	require.NoError(t, err)
+	assert.Equal(t, "stable.example.com/CronTab", groupKind)
+	assert.Equal(t, "trigger", name)
+	_, _, err = gen.ParseMethod("stable.example.com/CronTab=Trigger")
+	assert.Error(t, err)
+	_, _, err = gen.ParseMethod("CronTab=trigger")
+	assert.Error(t, err)
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	goDir := filepath.Join(outputDir, "go")
+	schemaPath := filepath.Join(outputDir, "schema.json")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: requiredCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{
+			NodeJSPath:      &nodejsDir,
+			PythonPath:      &pythonDir,
+			GoPath:          &goDir,
+			MergeSchemaPath: &schemaPath,
+		}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithMethods("stable.example.com/CronTab=trigger"),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"), "trigger(")
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crds/stable/v1/CronTab.py"), "def trigger(")
+	assert.Contains(t, readFile(t, goDir, "stable/v1/cronTab.go"), ") Trigger(")
+
+	// The methods are kept when the schema is merged into a run of other CRDs
+	spec, err := gen.ReadSchema(schemaPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"trigger": cronTabToken + "/trigger"}, spec.Resources[cronTabToken].Methods)
+	pg, err = gen.NewPackageGenerator([]string{conversionCRDs})
+	require.NoError(t, err)
+	require.NoError(t, pg.MergeSchema(spec))
+	resource, ok = pg.SchemaPackage().GetResource(cronTabToken)
+	require.True(t, ok)
+	assert.Len(t, resource.Methods, 1)
+}
+
+func TestObjectMetaRequired(t *testing.T) {
+	const cronTabToken = "kubernetes:stable.example.com/v1:CronTab"
+
+	pg, err := gen.NewPackageGenerator([]string{defaultsCRD})
+	require.NoError(t, err)
+	assert.NotContains(t, pg.Types[cronTabToken].Required, "metadata")
+	pg.RequireObjectMeta()
+	assert.Contains(t, pg.Types[cronTabToken].Required, "metadata")
+	// The types that are generated again still require it
+	pg.ExcludeStatus()
+	assert.Contains(t, pg.Types[cronTabToken].Required, "metadata")
+	resource, ok := pg.SchemaPackage().GetResource(cronTabToken)
+	require.True(t, ok)
+	for _, property := range resource.InputProperties {
+		if property.Name == "metadata" {
+			assert.True(t, property.IsRequired())
+		}
+	}
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	goDir := filepath.Join(outputDir, "go")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, GoPath: &goDir, ObjectMetaRequired: true, TestStubs: true}),
+		gen.WithPackageName(gen.DefaultName),
+	)
+	require.NoError(t, err)
+	code := readFile(t, nodejsDir, "stable/v1/cronTab.ts")
+	assert.Contains(t, code, "metadata: pulumi.Input<ObjectMeta>;")
+	assert.Contains(t, code, `if ((!args || args.metadata === undefined) && !opts.urn) {`)
+	// The test stubs set a placeholder metadata
+	stubs := readFile(t, goDir, "stable/v1/resources_test.go")
+	assert.Contains(t, stubs, "\tmetav1 \"github.com/pulumi/pulumi-kubernetes/sdk/v3/go/kubernetes/meta/v1\"\n")
+	assert.Contains(t, stubs, "\t\t\tMetadata: &metav1.ObjectMetaArgs{},\n")
+}
+
+func TestOnlyLanguagesChanged(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir, pythonDir := filepath.Join(outputDir, "nodejs"), filepath.Join(outputDir, "python")
+	python := gen.LanguageSettings{
+		PythonPath:           &pythonDir,
+		PythonName:           gen.DefaultName,
+		OnlyLanguagesChanged: true,
+	}
+	run := func(ls gen.LanguageSettings, force bool) error {
+		return gen.GenerateWithOptions(gen.FileLoader{Path: requiredCRD}, gen.WithLanguageSettings(ls), gen.WithForce(force))
+	}
+	require.NoError(t, run(python, false))
+	assert.Contains(t, readFile(t, pythonDir, gen.LanguageManifestFile), `"language": "python"`)
+
+	// A current language is skipped, so its edits are kept, while a new
+	// language is generated
+	const edited = "# edited\n"
+	setupPath := filepath.Join(pythonDir, "setup.py")
+	require.NoError(t, ioutil.WriteFile(setupPath, []byte(edited), 0644))
+	both := python
+	both.NodeJSPath = &nodejsDir
+	both.NodeJSName = gen.DefaultName
+	require.NoError(t, run(both, false))
+	assert.Equal(t, edited, readFile(t, pythonDir, "setup.py"))
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"name": "@pulumi/crds"`)
+	assert.Contains(t, readFile(t, nodejsDir, gen.LanguageManifestFile), `"language": "nodejs"`)
+
+	// A language whose settings changed is stale, so it's regenerated
+	versioned := both
+	versioned.PackageVersion = "1.2.3"
+	require.NoError(t, run(versioned, false))
+	assert.NotEqual(t, edited, readFile(t, pythonDir, "setup.py"))
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"version": "1.2.3"`)
+
+	// Forcing regenerates every language
+	require.NoError(t, ioutil.WriteFile(setupPath, []byte(edited), 0644))
+	require.NoError(t, run(versioned, true))
+	assert.NotEqual(t, edited, readFile(t, pythonDir, "setup.py"))
+
+	// An existing output without a manifest is only overwritten when forced
+	goDir := filepath.Join(outputDir, "go")
+	require.NoError(t, os.MkdirAll(goDir, 0755))
+	withGo := versioned
+	withGo.GoPath = &goDir
+	withGo.GoName = gen.DefaultName
+	assert.EqualError(t, run(withGo, false), "path(s) ["+goDir+"] already exists; use --force to overwrite")
+}
+
+func TestPartialSchemas(t *testing.T) {
+	token, path, err := gen.ParsePartialSchema("stable.example.com/v1:CronTabSpec=" + cronTabSpecSchema)
+	require.NoError(t, err)
+	assert.Equal(t, "kubernetes:stable.example.com/v1:CronTabSpec", token)
+	assert.Equal(t, cronTabSpecSchema, path)
+	_, _, err = gen.ParsePartialSchema("CronTabSpec=" + cronTabSpecSchema)
+	assert.EqualError(t, err, `invalid partial schema "CronTabSpec=`+cronTabSpecSchema+`", expected <group>/<version>:<Name>=<path>`)
+	_, _, err = gen.ParsePartialSchema("stable.example.com/v1:CronTab-Spec=" + cronTabSpecSchema)
+	assert.Error(t, err)
+
+	// The types are generated under the given name, without a resource
+	outputDir := t.TempDir()
+	declarationsPath := filepath.Join(outputDir, "types.d.ts")
+	jsonSchemaDir := filepath.Join(outputDir, "jsonschema")
+	err = gen.GenerateWithOptions(gen.MultiLoader{},
+		gen.WithLanguageSettings(gen.LanguageSettings{TypeDeclarationsPath: &declarationsPath, JSONSchemaPath: &jsonSchemaDir}),
+		gen.WithPartialSchemas("stable.example.com/v1:CronTabSpec="+cronTabSpecSchema),
+		gen.WithStrict(true),
+	)
+	require.NoError(t, err)
+	declarations := readFile(t, outputDir, "types.d.ts")
+	assert.Contains(t, declarations, "    /**\n"+
+		"     * CronTabSpec is the desired state of a CronTab.\n"+
+		"     */\n"+
+		"    export interface CronTabSpec {\n"+
+		"        /**\n"+
+		"         * The schedule, in Cron format.\n"+
+		"         */\n"+
+		"        cronSpec: string;\n"+
+		"        policy?: CronTabSpecPolicy;\n"+
+		"        replicas?: number;\n"+
+		"    }\n")
+	assert.Contains(t, declarations, "    export type CronTabSpecPolicyConcurrency = \"Allow\" | \"Forbid\";\n")
+	assert.NotContains(t, declarations, "apiVersion")
+	assert.Contains(t, readFile(t, jsonSchemaDir, "stable.example.com/v1/crontabspec.json"), `"cronSpec"`)
+
+	// The partial schemas can be added to the types of CRDs, but not replace them
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD})
+	require.NoError(t, err)
+	schema, err := gen.LoadPartialSchema(cronTabSpecSchema)
+	require.NoError(t, err)
+	assert.EqualError(t, pg.AddPartialSchema("kubernetes:stable.example.com/v1:CronTabSpec", schema),
+		"the type of the partial schema kubernetes:stable.example.com/v1:CronTabSpec already exists")
+	require.NoError(t, pg.AddPartialSchema("kubernetes:stable.example.com/v1:Schedule", schema))
+	assert.Equal(t, []string{"cronSpec"}, pg.Types["kubernetes:stable.example.com/v1:Schedule"].Required)
+	assert.Contains(t, pg.Types, "kubernetes:stable.example.com/v1:SchedulePolicy")
+	assert.EqualError(t, pg.AddPartialSchema("kubernetes:stable.example.com/v1:Replicas", map[string]interface{}{"type": "integer"}),
+		"the partial schema kubernetes:stable.example.com/v1:Replicas isn't an object with properties")
+
+	// The language SDKs only generate the types of resources
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	err = gen.GenerateWithOptions(gen.MultiLoader{},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}),
+		gen.WithPartialSchemas("stable.example.com/v1:CronTabSpec="+cronTabSpecSchema),
+		gen.WithStrict(true),
+	)
+	assert.EqualError(t, err, "the language SDKs only generate the types of resources, so the types of the partial schemas "+
+		"kubernetes:stable.example.com/v1:CronTabSpec are only in the type declarations, the JSON schemas, the Protobuf files and the YAML reference")
+}
+
+func TestImportFile(t *testing.T) {
+	manifests, err := gen.LoadManifests(existingResources)
+	require.NoError(t, err)
+	require.Len(t, manifests, 5)
+
+	// The IDs depend on the scope, and the names of a type are unique
+	pg, err := gen.NewPackageGenerator([]string{requiredCRD, bucketsCRD})
+	require.NoError(t, err)
+	importFile, warnings := pg.ImportFile(manifests)
+	assert.Equal(t, gen.ImportFile{Resources: []gen.ImportResource{
+		{Type: "kubernetes:stable.example.com/v1:CronTab", Name: "default-nightly", ID: "default/nightly"},
+		{Type: "kubernetes:stable.example.com/v1:CronTab", Name: "staging-nightly", ID: "staging/nightly"},
+		{Type: "kubernetes:stable.example.com/v1:CronTab", Name: "hourly", ID: "staging/hourly"},
+		{Type: "kubernetes:s3.aws.example.com/v1beta1:Bucket", Name: "assets", ID: "assets"},
+	}}, importFile)
+	assert.Equal(t, []string{"the existing ConfigMap settings isn't a resource of the package, so it's not imported"}, warnings)
+
+	// The file is in the format of `pulumi import --file`
+	importPath := filepath.Join(t.TempDir(), "import.json")
+	generate(t, gen.LanguageSettings{ImportFilePath: &importPath, ImportManifests: []string{existingResources}}, requiredCRD)
+	assert.JSONEq(t, `{"resources": [
+		{"type": "kubernetes:stable.example.com/v1:CronTab", "name": "default-nightly", "id": "default/nightly"},
+		{"type": "kubernetes:stable.example.com/v1:CronTab", "name": "staging-nightly", "id": "staging/nightly"},
+		{"type": "kubernetes:stable.example.com/v1:CronTab", "name": "hourly", "id": "staging/hourly"}
+	]}`, readFile(t, filepath.Dir(importPath), "import.json"))
+
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: requiredCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{ImportFilePath: &importPath}),
+		gen.WithForce(true),
+	)
+	assert.EqualError(t, err, "the import file needs the manifests of the existing resources to import")
+}
+
+func TestTypeDeclarations(t *testing.T) {
+	declarationsPath := filepath.Join(t.TempDir(), "types.d.ts")
+	generate(t, gen.LanguageSettings{TypeDeclarationsPath: &declarationsPath}, requiredCRD, endpointsCRD, flagsCRD, treesCRD)
+	declarations := readFile(t, filepath.Dir(declarationsPath), "types.d.ts")
+
+	// The types are plain interfaces, in namespaces of their modules
+	assert.Contains(t, declarations, "export namespace stable.v1 {\n")
+	assert.Contains(t, declarations, "    export interface CronTab {\n"+
+		"        apiVersion: \"stable.example.com/v1\";\n"+
+		"        kind: \"CronTab\";\n"+
+		"        metadata: meta.v1.ObjectMeta;\n"+
+		"        spec: CronTabSpec;\n"+
+		"        status?: CronTabStatus;\n"+
+		"    }\n")
+	assert.NotContains(t, declarations, "pulumi.Input")
+	assert.NotRegexp(t, "(?m)^import ", declarations)
+	assert.Contains(t, declarations, "export namespace meta.v1 {\n    /**\n")
+
+	// Enums are unions of their values, and oneOfs of their types
+	assert.Contains(t, declarations, "    export type EndpointSpecProtocol = \"tcp\" | \"udp\";\n")
+	assert.Contains(t, declarations, "        port?: EndpointSpecPortOneOf0 | EndpointSpecPortOneOf1;\n")
+	assert.Contains(t, declarations, "        weight?: number | string;\n")
+
+	// Forbidden and free-form properties
+	assert.Contains(t, declarations, "        legacy?: never;\n")
+	assert.Contains(t, declarations, "        payload?: any;\n")
+
+	// Recursive types refer to themselves
+	assert.Contains(t, declarations, "        children?: TreeSpecRoot[];\n")
+}
+
+func TestChangelog(t *testing.T) {
+	// The base is either the previous CRDs, or a schema of a previous run
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	generate(t, gen.LanguageSettings{MergeSchemaPath: &schemaPath}, defaultsCRD)
+	for _, base := range []string{defaultsCRD, schemaPath} {
+		changelogPath := filepath.Join(dir, "CHANGELOG.md")
+		generate(t, gen.LanguageSettings{ChangelogPath: &changelogPath, ChangelogBase: base}, requiredCRD)
+		assert.Equal(t, "# Changelog\n\n"+
+			"## Breaking changes\n\n"+
+			"- Changed the property `spec` of `kubernetes:stable.example.com/v1:CronTab`: it's now required\n"+
+			"- Added the property `cronSpec` of `kubernetes:stable.example.com/v1:CronTabSpec`: it's required\n"+
+			"- Removed the property `jobTemplate` of `kubernetes:stable.example.com/v1:CronTabSpec`\n"+
+			"- Removed the property `port` of `kubernetes:stable.example.com/v1:CronTabSpec`\n"+
+			"- Removed the property `ratio` of `kubernetes:stable.example.com/v1:CronTabSpec`\n"+
+			"- Removed the property `replicas` of `kubernetes:stable.example.com/v1:CronTabSpec`\n"+
+			"- Removed the property `suspend` of `kubernetes:stable.example.com/v1:CronTabSpec`\n"+
+			"- Removed the type `kubernetes:stable.example.com/v1:CronTabSpecJobTemplate`\n\n"+
+			"## Other changes\n\n"+
+			"- Added the property `status` of `kubernetes:stable.example.com/v1:CronTab`\n"+
+			"- Added the type `kubernetes:stable.example.com/v1:CronTabStatus`\n",
+			readFile(t, dir, "CHANGELOG.md"), base)
+	}
+
+	// The same CRDs have no changes
+	changelogPath := filepath.Join(dir, "changelog.json")
+	generate(t, gen.LanguageSettings{ChangelogPath: &changelogPath, ChangelogBase: defaultsCRD}, defaultsCRD)
+	assert.JSONEq(t, "[]", readFile(t, dir, "changelog.json"))
+
+	// Changed types and enum values
+	const token = "kubernetes:stable.example.com/v1:CronTabSpec"
+	const enumToken = "kubernetes:stable.example.com/v1:CronTabSpecPolicy"
+	old := pschema.PackageSpec{Types: map[string]pschema.ComplexTypeSpec{
+		token: {ObjectTypeSpec: pschema.ObjectTypeSpec{
+			Properties: map[string]pschema.PropertySpec{
+				"image":    {TypeSpec: pschema.TypeSpec{Type: "string"}},
+				"replicas": {TypeSpec: pschema.TypeSpec{Type: "integer"}},
+			},
+			Required: []string{"image"},
+		}},
+		enumToken: {Enum: []pschema.EnumValueSpec{{Value: "Allow"}, {Value: "Forbid"}}},
+	}}
+	new := pschema.PackageSpec{Types: map[string]pschema.ComplexTypeSpec{
+		token: {ObjectTypeSpec: pschema.ObjectTypeSpec{
+			Properties: map[string]pschema.PropertySpec{
+				"image":    {TypeSpec: pschema.TypeSpec{Type: "string"}, Description: "The image."},
+				"replicas": {TypeSpec: pschema.TypeSpec{Type: "array", Items: &pschema.TypeSpec{Type: "integer"}}},
+			},
+		}},
+		enumToken: {Enum: []pschema.EnumValueSpec{{Value: "Allow"}, {Value: "Replace"}}},
+	}}
+	assert.Equal(t, []gen.ChangelogEntry{
+		{Change: "changed", Kind: "property", Token: token, Name: "image", Detail: "it's now optional"},
+		{Change: "changed", Kind: "property", Token: token, Name: "replicas",
+			Detail: "type changed from `integer` to `array of integer`", Breaking: true},
+		{Change: "removed", Kind: "value", Token: enumToken, Name: `"Forbid"`, Breaking: true},
+		{Change: "added", Kind: "value", Token: enumToken, Name: `"Replace"`},
+	}, gen.Changelog(old, new))
+
+	err := gen.Generate(gen.LanguageSettings{ChangelogPath: &changelogPath}, []string{requiredCRD}, true)
+	assert.EqualError(t, err, "the changelog needs a base to diff the generated package against")
+}
+
+// nestedCRD returns a CRD whose spec nests objects in the property `child` the
+// given number of times.
+func nestedCRD(t *testing.T, depth int) []byte {
+	schema := map[string]interface{}{"type": "string"}
+	for i := 0; i < depth; i++ {
+		schema = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"child": schema},
+		}
+	}
+	crd := map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "nests.stable.example.com"},
+		"spec": map[string]interface{}{
+			"group": "stable.example.com",
+			"names": map[string]interface{}{"kind": "Nest", "plural": "nests", "singular": "nest"},
+			"scope": "Namespaced",
+			"versions": []interface{}{map[string]interface{}{
+				"name":    "v1",
+				"served":  true,
+				"storage": true,
+				"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"spec": schema},
+				}},
+			}},
+		},
+	}
+	data, err := json.Marshal(crd)
+	require.NoError(t, err)
+	return data
+}
+
+func TestMaxDepth(t *testing.T) {
+	// Schemas nested deeper than the default are typed as any
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: nestedCRD(t, 150)})
+	require.NoError(t, err)
+	truncated := pg.TruncatedTypes()
+	require.Len(t, truncated, 1)
+	assert.True(t, strings.HasPrefix(truncated[0], "kubernetes:stable.example.com/v1:NestSpecChildChild"), truncated[0])
+	assert.NotContains(t, pg.Types, truncated[0])
+	assert.Contains(t, pg.Types, "kubernetes:stable.example.com/v1:NestSpecChild")
+
+	// Schemas nested less deeply than the limit are typed
+	pg, err = gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: nestedCRD(t, 5)})
+	require.NoError(t, err)
+	assert.Empty(t, pg.TruncatedTypes())
+	assert.Contains(t, pg.Types, "kubernetes:stable.example.com/v1:NestSpecChildChildChildChild")
+
+	require.NoError(t, pg.SetMaxDepth(4))
+	assert.NotEmpty(t, pg.TruncatedTypes())
+	assert.NotContains(t, pg.Types, "kubernetes:stable.example.com/v1:NestSpecChildChildChildChild")
+	assert.EqualError(t, pg.SetMaxDepth(0), "invalid max depth 0, expected a positive number")
+
+	// Truncated types fail strict generations
+	nodejsDir := t.TempDir()
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: nestedCRD(t, 5)},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, MaxDepth: 4}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is nested deeper than 4 levels, so it's typed as any")
+}
+
+func TestSchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	ls := gen.LanguageSettings{
+		MergeSchemaPath: &schemaPath,
+		Methods:         map[string][]string{"stable.example.com/CronTab": {"trigger"}},
+		ImmutablePaths:  []string{"spec.cronSpec"},
+	}
+
+	// The schema targets the latest version by default
+	generate(t, ls, requiredCRD)
+	schema := readFile(t, dir, "schema.json")
+	assert.Contains(t, schema, `"methods"`)
+	assert.Contains(t, schema, `"replaceOnChanges": true`)
+	assert.Contains(t, schema, `"allowedPackageNames"`)
+
+	// Older versions omit the features they don't support
+	require.NoError(t, os.Remove(schemaPath))
+	ls.SchemaVersion = "3.5.0"
+	generate(t, ls, requiredCRD)
+	schema = readFile(t, dir, "schema.json")
+	assert.NotContains(t, schema, `"methods"`)
+	assert.NotContains(t, schema, `"replaceOnChanges"`)
+	assert.NotContains(t, schema, `"allowedPackageNames"`)
+	assert.Contains(t, schema, `"kubernetes:stable.example.com/v1:CronTab"`)
+
+	// Rerunning merges the schema, since the generated types are compared
+	// to it without the omitted features
+	generate(t, ls, requiredCRD)
+	assert.Equal(t, schema, readFile(t, dir, "schema.json"))
+
+	// The omitted features fail strict generations
+	require.NoError(t, os.Remove(schemaPath))
+	err := gen.GenerateWithOptions(gen.FileLoader{Path: requiredCRD},
+		gen.WithLanguageSettings(ls),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithStrict(true),
+	)
+	assert.EqualError(t, err, "the schema omits its resource methods, which need Pulumi 3.6.0 or later")
+
+	ls.SchemaVersion = "2.25.0"
+	err = gen.Generate(ls, []string{requiredCRD}, true)
+	assert.EqualError(t, err, "unsupported schema version 2.25.0, expected a Pulumi version from 3.0.0 to 3.21.0")
+	ls.SchemaVersion = "latest"
+	err = gen.Generate(ls, []string{requiredCRD}, true)
+	assert.Error(t, err)
+}
+
+func TestExcludeDescriptions(t *testing.T) {
+	size := func(files map[string]string) int {
+		total := 0
+		for _, code := range files {
+			total += len(code)
+		}
+		return total
+	}
+
+	withDescriptions := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &withDescriptions, NodeJSName: gen.DefaultName}, markersCRD, flagsCRD)
+	assert.Contains(t, readFile(t, withDescriptions, "types/input.ts"), "JobSpec is the desired state of a Job.")
+
+	withoutDescriptions := t.TempDir()
+	generate(t, gen.LanguageSettings{
+		NodeJSPath:          &withoutDescriptions,
+		NodeJSName:          gen.DefaultName,
+		ExcludeDescriptions: true,
+	}, markersCRD, flagsCRD)
+	input := readFile(t, withoutDescriptions, "types/input.ts")
+	assert.NotContains(t, input, "JobSpec is the desired state of a Job.")
+	assert.NotContains(t, input, "The command to run.")
+	assert.Less(t, size(walkFiles(t, withoutDescriptions)), size(walkFiles(t, withDescriptions)))
+
+	// Forbidden properties keep their marker
+	assert.Contains(t, input, "Forbidden: the schema of this property is `false`, so it can't be set.")
+}
+
+func TestMalformedSchemas(t *testing.T) {
+	pg, err := gen.NewPackageGenerator([]string{malformedCRD})
+	require.NoError(t, err)
+	require.Len(t, pg.CustomResourceGenerators, 1)
+	assert.Equal(t, []string{
+		"`spec.name` is a string with `properties` or `items`, which are ignored; fix its `type`.",
+		"`spec.ports` is an array with `properties` but no `items`, so its items are typed as any; move the properties under `items`.",
+		"`spec.selector` is an object with its `properties` under `items`, so it's typed as any; set `type: array` or move the properties out of `items`.",
+	}, pg.CustomResourceGenerators[0].MalformedSchemas("v1"))
+
+	// Well-formed schemas have no mistakes
+	pg, err = gen.NewPackageGenerator([]string{routesCRD})
+	require.NoError(t, err)
+	assert.Empty(t, pg.CustomResourceGenerators[0].MalformedSchemas("v1"))
+
+	// The mistakes are warnings, and fail strict generations
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, malformedCRD)
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: malformedCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.EqualError(t, err, "the schema of Appliance v1 is malformed: `spec.name` is a string with `properties` or `items`, which are ignored; fix its `type`.")
+}
+
+func TestYAMLReference(t *testing.T) {
+	dir := t.TempDir()
+	referencePath := filepath.Join(dir, "reference.yaml")
+	generate(t, gen.LanguageSettings{YAMLReferencePath: &referencePath}, requiredCRD)
+	reference := readFile(t, dir, "reference.yaml")
+	assert.Contains(t, reference, "# The inputs of the resources, for Pulumi YAML programs.")
+	assert.Contains(t, reference, `resources:
+  kubernetes:stable.example.com/v1:CronTab:
+    required:
+      spec: kubernetes:stable.example.com/v1:CronTabSpec
+    optional:
+      metadata: kubernetes:meta/v1:ObjectMeta
+types:
+  kubernetes:stable.example.com/v1:CronTabSpec:
+    required:
+      cronSpec: string
+    optional:
+      image: string
+  kubernetes:stable.example.com/v1:CronTabStatus:
+    optional:
+      lastScheduleTime: string
+`)
+
+	// Enum types list their values
+	const enumsCRD = "crds/crd2pulumi/enums/crontabs-crd.yaml"
+	require.NoError(t, os.Remove(referencePath))
+	generate(t, gen.LanguageSettings{YAMLReferencePath: &referencePath}, enumsCRD)
+	var document map[string]map[string]map[string]interface{}
+	require.NoError(t, yaml.NewYAMLOrJSONDecoder(strings.NewReader(readFile(t, dir, "reference.yaml")), 128).Decode(&document))
+	assert.Equal(t, map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"if-not-present", "always", "never"},
+	}, document["types"]["kubernetes:stable.example.com/v1:CronTabSpecPullPolicy"])
+	assert.Equal(t, "kubernetes:stable.example.com/v1:CronTabSpecPriority", document["types"]["kubernetes:stable.example.com/v1:CronTabSpec"]["optional"].(map[string]interface{})["priority"])
+}
+
+func TestEnumCollisions(t *testing.T) {
+	const monitorsCRD = "crds/crd2pulumi/enums/monitors-crd.yaml"
+	const specToken = "kubernetes:stable.example.com/v1:MonitorSpec"
+	enumMemberNames := func(complexTypeSpec pschema.ComplexTypeSpec) []string {
+		names := make([]string, 0, len(complexTypeSpec.Enum))
+		for _, enumValueSpec := range complexTypeSpec.Enum {
+			names = append(names, enumValueSpec.Name)
+		}
+		return names
+	}
+
+	// The later values are suffixed by default, without colliding with other
+	// values
+	pg, err := gen.NewPackageGenerator([]string{monitorsCRD})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Active", "Active3", "ACTIVE4", "Active2"}, enumMemberNames(pg.Types[specToken+"State"]))
+	assert.Equal(t, []string{"FastPath", "FastPath2"}, enumMemberNames(pg.Types[specToken+"Mode"]))
+	// A repeated value is listed once, and only the different value is suffixed
+	assert.Equal(t, []string{"A", "A2"}, enumMemberNames(pg.Types[specToken+"Level"]))
+	assert.Equal(t, []string{"Http", "HTTP2"}, enumMemberNames(pg.Types[specToken+"PortOneOf1"]))
+	assert.Equal(t, []string{
+		`the member names of the enum values "Active" and "ACTIVE" of kubernetes:stable.example.com/v1:MonitorSpecState collide, so the second is named ACTIVE4`,
+		`the member names of the enum values "Active" and "active" of kubernetes:stable.example.com/v1:MonitorSpecState collide, so the second is named Active3`,
+		`the member names of the enum values "a" and "A" of kubernetes:stable.example.com/v1:MonitorSpecLevel collide, so the second is named A2`,
+		`the member names of the enum values "fast-path" and "fast_path" of kubernetes:stable.example.com/v1:MonitorSpecMode collide, so the second is named FastPath2`,
+		`the member names of the enum values "http" and "HTTP" of kubernetes:stable.example.com/v1:MonitorSpecPort collide, so the second is named HTTP2`,
+	}, pg.RenamedEnumValues())
+
+	// The generated SDK has distinct members
+	nodejsDir := t.TempDir()
+	generate(t, gen.LanguageSettings{NodeJSPath: &nodejsDir, NodeJSName: gen.DefaultName}, monitorsCRD)
+	enums := readFile(t, nodejsDir, "types/enums/stable/v1/index.ts")
+	assert.Contains(t, enums, `FastPath: "fast-path",`)
+	assert.Contains(t, enums, `FastPath2: "fast_path",`)
+
+	err = pg.SetEnumCollisionPolicy(gen.EnumCollisionError)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collide")
+	_, err = gen.ParseEnumCollisionPolicy("lowercase")
+	assert.Error(t, err)
+
+	// The renamed members fail strict generations
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: monitorsCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,666 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pulumi/crd2pulumi/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	unstruct "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// staticLoader is a custom SchemaLoader, like one a library consumer would supply
+type staticLoader []unstruct.Unstructured
+
+func (l staticLoader) Load() ([]unstruct.Unstructured, error) {
+	return l, nil
+}
+
+func TestNewSchemaLoader(t *testing.T) {
+	loader, err := gen.NewSchemaLoader("crds/crd.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, gen.FileLoader{Path: "crds/crd.yaml"}, loader)
+
+	loader, err = gen.NewSchemaLoader("-")
+	require.NoError(t, err)
+	assert.Equal(t, gen.FileLoader{Path: "-"}, loader)
+
+	loader, err = gen.NewSchemaLoader("https://example.com/crd.yaml")
+	require.NoError(t, err)
+	if assert.IsType(t, gen.URLLoader{}, loader) {
+		assert.Equal(t, "example.com", loader.(gen.URLLoader).URL.Host)
+	}
+
+	loader, err = gen.NewSchemaLoader("oci://ghcr.io/myorg/crds:v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, gen.OCILoader{Reference: gen.OCIReference{
+		Registry:   "ghcr.io",
+		Repository: "myorg/crds",
+		Reference:  "v1.0.0",
+	}}, loader)
+
+	loader, err = gen.NewSchemaLoader("manifests/**/*.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, gen.GlobLoader{Pattern: "manifests/**/*.yaml"}, loader)
+
+	_, err = gen.NewSchemaLoader("ftp://example.com/crd.yaml")
+	assert.EqualError(t, err, `scheme "ftp" is not supported`)
+}
+
+func TestSchemaLoaders(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("crds/crd2pulumi")))
+	defer server.Close()
+
+	loader, err := gen.NewSchemaLoaders([]string{defaultsCRD, server.URL + "/enums/crontabs-crd.yaml"})
+	require.NoError(t, err)
+	crds, err := gen.LoadCRDs(loader)
+	require.NoError(t, err)
+	assert.Len(t, crds, 2)
+
+	loader, err = gen.NewSchemaLoaders([]string{server.URL + "/missing.yaml"})
+	require.NoError(t, err)
+	_, err = gen.LoadCRDs(loader)
+	assert.Error(t, err)
+
+	_, err = gen.LoadCRDs(gen.YAMLLoader{Data: []byte("apiVersion: v1\nkind: ConfigMap\n")})
+	assert.EqualError(t, err, "could not find any CRD YAML files")
+}
+
+func TestCustomSchemaLoader(t *testing.T) {
+	crds, err := gen.ReadCRDs([]string{defaultsCRD})
+	require.NoError(t, err)
+
+	pg, err := gen.NewPackageGeneratorFromLoader(staticLoader(crds))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes:stable.example.com/v1:CronTab"}, pg.ResourceTokens)
+
+	nodejsDir := t.TempDir()
+	err = gen.GenerateFromLoader(gen.LanguageSettings{
+		NodeJSPath: &nodejsDir,
+		NodeJSName: gen.DefaultName,
+	}, staticLoader(crds), true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, readFile(t, nodejsDir, "stable/v1/cronTab.ts"))
+}
+
+func TestParseOCIReference(t *testing.T) {
+	for ref, expected := range map[string]gen.OCIReference{
+		"oci://ghcr.io/myorg/crds:v1.0.0":  {Registry: "ghcr.io", Repository: "myorg/crds", Reference: "v1.0.0"},
+		"oci://localhost:5000/crds":        {Registry: "localhost:5000", Repository: "crds", Reference: "latest"},
+		"docker.io/myorg/crds@sha256:abc1": {Registry: "docker.io", Repository: "myorg/crds", Reference: "sha256:abc1"},
+	} {
+		reference, err := gen.ParseOCIReference(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, expected, reference, ref)
+	}
+	reference, _ := gen.ParseOCIReference("oci://localhost:5000/crds")
+	assert.Equal(t, "oci://localhost:5000/crds:latest", reference.String())
+
+	for ref, message := range map[string]string{
+		"oci://ghcr.io":              `invalid OCI reference "oci://ghcr.io", expected oci://<registry>/<repository>[:<tag>|@<digest>]`,
+		"oci://ghcr.io/MyOrg/crds":   `invalid OCI reference "oci://ghcr.io/MyOrg/crds", expected oci://<registry>/<repository>[:<tag>|@<digest>]`,
+		"oci://ghcr.io/crds:v1/2":    `invalid tag "v1/2" in OCI reference "oci://ghcr.io/crds:v1/2"`,
+		"oci://ghcr.io/crds@sha256:": `invalid digest "sha256:" in OCI reference "oci://ghcr.io/crds@sha256:"`,
+	} {
+		_, err := gen.ParseOCIReference(ref)
+		assert.EqualError(t, err, message, ref)
+	}
+}
+
+func TestOCILoader(t *testing.T) {
+	crd, err := ioutil.ReadFile(defaultsCRD)
+	require.NoError(t, err)
+	sum := sha256.Sum256(crd)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	manifests := map[string]interface{}{
+		"v1": map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"layers": []interface{}{
+				map[string]interface{}{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": digest,
+					"annotations": map[string]interface{}{"org.opencontainers.image.title": "crontabs-crd.yaml"}},
+				map[string]interface{}{"mediaType": "application/octet-stream", "digest": "sha256:0"},
+			},
+		},
+		"binary": map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"layers":    []interface{}{map[string]interface{}{"mediaType": "application/octet-stream", "digest": "sha256:0"}},
+		},
+		"index": map[string]interface{}{"mediaType": "application/vnd.oci.image.index.v1+json"},
+	}
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	var realm string
+	var requests int
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "repository:myorg/crds:pull", r.URL.Query().Get("scope"))
+		_, _ = w.Write([]byte(`{"token": "secret"}`))
+	})
+	mux.HandleFunc("/v2/myorg/crds/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`",service="registry",scope="repository:myorg/crds:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v2/myorg/crds/")
+		if manifest, ok := manifests[strings.TrimPrefix(path, "manifests/")]; ok {
+			_ = json.NewEncoder(w).Encode(manifest)
+		} else if path == "blobs/"+digest {
+			_, _ = w.Write(crd)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewTLSServer(mux)
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	realm = server.URL + "/token"
+
+	// The credentials come from the Docker config
+	setDockerConfig := func(auths string) {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {`+auths+`}}`), 0600))
+		dockerConfig, set := os.LookupEnv("DOCKER_CONFIG")
+		require.NoError(t, os.Setenv("DOCKER_CONFIG", dir))
+		t.Cleanup(func() {
+			if set {
+				_ = os.Setenv("DOCKER_CONFIG", dockerConfig)
+			} else {
+				_ = os.Unsetenv("DOCKER_CONFIG")
+			}
+		})
+	}
+	load := func(tag string, cache ...*gen.Cache) ([]string, error) {
+		loader := gen.OCILoader{
+			Reference: gen.OCIReference{Registry: registry, Repository: "myorg/crds", Reference: tag},
+			Client:    server.Client(),
+		}
+		if len(cache) > 0 {
+			loader.Cache = cache[0]
+		}
+		crds, err := loader.Load()
+		var names []string
+		for _, crd := range crds {
+			names = append(names, crd.GetName())
+		}
+		return names, err
+	}
+
+	setDockerConfig(`"` + registry + `": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("user:pass")) + `"}`)
+	names, err := load("v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"crontabs.stable.example.com"}, names)
+
+	_, err = load("missing")
+	assert.EqualError(t, err, "could not find oci://"+registry+"/myorg/crds:missing in the registry")
+	_, err = load("index")
+	assert.EqualError(t, err, "oci://"+registry+"/myorg/crds:index is an image index, not a single artifact; reference one of its manifests by digest")
+	_, err = load("binary")
+	assert.EqualError(t, err, "oci://"+registry+"/myorg/crds:binary has no YAML layers, only layers of media type(s) application/octet-stream")
+
+	// The manifest and layers are pulled once with a cache
+	cache := &gen.Cache{Dir: t.TempDir(), TTL: time.Hour}
+	_, err = load("v1", cache)
+	require.NoError(t, err)
+	requests = 0
+	names, err = load("v1", cache)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"crontabs.stable.example.com"}, names)
+	assert.Zero(t, requests)
+
+	// The credentials aren't sent to a realm without TLS
+	realm = "http://" + registry + "/token"
+	_, err = load("v1")
+	assert.EqualError(t, err, "registry "+registry+` asks for a token from "http://`+registry+`/token", which isn't an https URL`)
+	realm = server.URL + "/token"
+
+	setDockerConfig("")
+	_, err = load("v1")
+	assert.EqualError(t, err, "could not authenticate to registry "+registry+" to pull oci://"+registry+
+		"/myorg/crds:v1 (401 Unauthorized); log in with `docker login "+registry+"`")
+}
+
+func TestExternalRefs(t *testing.T) {
+	crds, err := gen.FileLoader{Path: "crds/crd2pulumi/externalrefs/gadgets-crd.yaml"}.Load()
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+	versions, _, _ := unstruct.NestedSlice(crds[0].Object, "spec", "versions")
+	schema, _, _ := unstruct.NestedMap(versions[0].(map[string]interface{}), "schema", "openAPIV3Schema", "properties")
+
+	// The keywords next to the `$ref` are merged into the referenced schema,
+	// and the `$ref`s of the referenced file are resolved against it
+	spec := schema["spec"].(map[string]interface{})
+	assert.Equal(t, "The desired state of the gadget", spec["description"])
+	assert.Equal(t, []interface{}{"size"}, spec["required"])
+	assert.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string"},
+			"port": map[string]interface{}{"type": "integer"},
+		},
+	}, spec["properties"].(map[string]interface{})["ports"].(map[string]interface{})["items"])
+	assert.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}, spec["properties"].(map[string]interface{})["labels"])
+
+	// Recursive references become arbitrary JSON, and local `$ref`s of the
+	// CRD are left to NormalizeSchema
+	parts := spec["properties"].(map[string]interface{})["parts"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"type":                                 "object",
+		"x-kubernetes-preserve-unknown-fields": true,
+	}, parts["properties"].(map[string]interface{})["parts"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/GadgetStatus"}, schema["status"])
+
+	dir := t.TempDir()
+	crdPath := filepath.Join(dir, "gizmos-crd.yaml")
+	crd := strings.ReplaceAll(readFile(t, "crds/crd2pulumi/externalrefs", "gadgets-crd.yaml"), "schemas/gadget.json", "schemas/missing.json")
+	require.NoError(t, ioutil.WriteFile(crdPath, []byte(crd), 0600))
+	_, err = gen.FileLoader{Path: crdPath}.Load()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `could not resolve $ref "schemas/missing.json#/definitions/GadgetSpec": could not read file `+filepath.Join(dir, "schemas", "missing.json"))
+	}
+}
+
+func TestHelmTemplateOutput(t *testing.T) {
+	data, err := ioutil.ReadFile("crds/crd2pulumi/helm/template-output.yaml")
+	require.NoError(t, err)
+	crds, err := gen.YAMLLoader{Data: data}.Load()
+	require.NoError(t, err)
+
+	// Only the CRDs are kept, including the ones that are hooks, but not the
+	// ones that are test hooks or of another API group
+	names := make([]string, 0, len(crds))
+	for _, crd := range crds {
+		names = append(names, crd.GetName())
+	}
+	assert.Equal(t, []string{"gadgets.helm.example.com", "gizmos.helm.example.com"}, names)
+
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: data})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kubernetes:helm.example.com/v1:Gadget", "kubernetes:helm.example.com/v1:Gizmo"}, pg.ResourceTokens)
+}
+
+func TestGlobLoader(t *testing.T) {
+	// `**` matches any number of directories, including none
+	paths, err := gen.Glob("crds/crd2pulumi/**/*-crd.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, paths, filepath.FromSlash(defaultsCRD))
+	assert.Contains(t, paths, filepath.FromSlash(widgetsCRD))
+	assert.NotContains(t, paths, filepath.FromSlash("crds/crd2pulumi/helm/template-output.yaml"))
+	paths, err = gen.Glob("crds/crd2pulumi/rootpath/**/*-crd.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.FromSlash(bucketsCRD)}, paths)
+	paths, err = gen.Glob("crds/crd2pulumi/*-crd.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	// The documents of the matched files that aren't CRDs are ignored
+	crds, err := gen.GlobLoader{Pattern: "crds/crd2pulumi/h*/*.yaml"}.Load()
+	require.NoError(t, err)
+	names := make([]string, 0, len(crds))
+	for _, crd := range crds {
+		names = append(names, crd.GetName())
+	}
+	assert.Equal(t, []string{"gadgets.helm.example.com", "gizmos.helm.example.com"}, names)
+
+	_, err = gen.GlobLoader{Pattern: "crds/**/*.yml"}.Load()
+	assert.EqualError(t, err, "no files match crds/**/*.yml")
+	_, err = gen.GlobLoader{Pattern: "missing/**/*.yaml"}.Load()
+	assert.EqualError(t, err, "no files match missing/**/*.yaml")
+	_, err = gen.Glob("crds/[/*.yaml")
+	assert.Error(t, err)
+}
+
+func TestInterleavedInstances(t *testing.T) {
+	// The instances of a CRD in the same file aren't loaded, wherever they are
+	crds, err := gen.FileLoader{Path: "crds/crd2pulumi/instances/crontabs-bundle.yaml"}.Load()
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+	assert.Equal(t, "crontabs.stable.example.com", crds[0].GetName())
+
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.FileLoader{Path: "crds/crd2pulumi/instances/crontabs-bundle.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes:stable.example.com/v1:CronTab"}, pg.ResourceTokens)
+}
+
+func TestCache(t *testing.T) {
+	crd, err := ioutil.ReadFile(defaultsCRD)
+	require.NoError(t, err)
+	var downloads, revalidations int
+	var cacheControl string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		_, _ = w.Write(crd)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL + "/crontabs-crd.yaml")
+	require.NoError(t, err)
+	load := func(cache *gen.Cache) {
+		crds, err := gen.URLLoader{URL: u, Cache: cache}.Load()
+		require.NoError(t, err)
+		require.Len(t, crds, 1)
+		assert.Equal(t, "crontabs.stable.example.com", crds[0].GetName())
+	}
+	reset := func(header string) {
+		downloads, revalidations, cacheControl = 0, 0, header
+	}
+
+	// Fresh entries are used without a request
+	cache := &gen.Cache{Dir: t.TempDir(), TTL: time.Hour}
+	load(cache)
+	load(cache)
+	assert.Equal(t, 1, downloads)
+	assert.Equal(t, 0, revalidations)
+
+	// Stale entries are revalidated with their ETag
+	reset("")
+	cache = &gen.Cache{Dir: t.TempDir()}
+	load(cache)
+	load(cache)
+	assert.Equal(t, 1, downloads)
+	assert.Equal(t, 1, revalidations)
+
+	// The caching headers override the TTL
+	reset("max-age=0")
+	cache = &gen.Cache{Dir: t.TempDir(), TTL: time.Hour}
+	load(cache)
+	load(cache)
+	assert.Equal(t, 1, downloads)
+	assert.Equal(t, 1, revalidations)
+	reset("no-store")
+	cache = &gen.Cache{Dir: t.TempDir(), TTL: time.Hour}
+	load(cache)
+	load(cache)
+	assert.Equal(t, 2, downloads)
+	assert.Equal(t, 0, revalidations)
+
+	// Without a cache, the file is always downloaded
+	reset("")
+	load(nil)
+	load(nil)
+	assert.Equal(t, 2, downloads)
+
+	// The entries can be of authenticated requests, so only the user can
+	// read them
+	cache = &gen.Cache{Dir: filepath.Join(t.TempDir(), "crd2pulumi"), TTL: time.Hour}
+	load(cache)
+	info, err := os.Stat(cache.Dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	entries, err := ioutil.ReadDir(cache.Dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, os.FileMode(0600), entries[0].Mode().Perm())
+
+	// Only the loaders that fetch files get the cache
+	loaders := gen.MultiLoader{gen.FileLoader{Path: defaultsCRD}, gen.URLLoader{URL: u}}.WithCache(cache)
+	assert.Equal(t, gen.FileLoader{Path: defaultsCRD}, loaders[0])
+	assert.Equal(t, cache, loaders[1].(gen.URLLoader).Cache)
+}
+
+func TestURLAuth(t *testing.T) {
+	crd, err := ioutil.ReadFile(defaultsCRD)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, bytes []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0600))
+		return path
+	}
+
+	// A self-signed client certificate, that the server trusts
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "crd2pulumi"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	clientCertPath := writePEM("client.crt", "CERTIFICATE", certificate)
+	clientKeyPath := writePEM("client.key", "EC PRIVATE KEY", keyBytes)
+	clientCAs := x509.NewCertPool()
+	parsed, err := x509.ParseCertificate(certificate)
+	require.NoError(t, err)
+	clientCAs.AddCert(parsed)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(crd)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caCertPath := writePEM("ca.crt", "CERTIFICATE", server.Certificate().Raw)
+
+	load := func(auth gen.URLAuth) error {
+		loader, err := gen.NewSchemaLoaders([]string{defaultsCRD, server.URL + "/crontabs-crd.yaml"})
+		require.NoError(t, err)
+		if loader, err = loader.WithURLAuth(auth); err != nil {
+			return err
+		}
+		assert.Equal(t, gen.FileLoader{Path: defaultsCRD}, loader[0])
+		crds, err := gen.LoadCRDs(loader)
+		if err == nil {
+			assert.Len(t, crds, 2)
+		}
+		return err
+	}
+	auth := gen.URLAuth{CACertPath: caCertPath, ClientCertPath: clientCertPath, ClientKeyPath: clientKeyPath, BearerToken: "secret"}
+	assert.NoError(t, load(auth))
+
+	// The server requires the CA, the client certificate and the token
+	withoutCA := auth
+	withoutCA.CACertPath = ""
+	assert.Error(t, load(withoutCA))
+	withoutClientCert := auth
+	withoutClientCert.ClientCertPath, withoutClientCert.ClientKeyPath = "", ""
+	assert.Error(t, load(withoutClientCert))
+	withoutToken := auth
+	withoutToken.BearerToken = ""
+	assert.EqualError(t, load(withoutToken), "could not read file "+server.URL+"/crontabs-crd.yaml: error getting CRD. Status=401")
+
+	// Misconfigurations fail before fetching
+	withoutKey := auth
+	withoutKey.ClientKeyPath = ""
+	assert.EqualError(t, load(withoutKey), "the client certificate and key must be set together")
+	invalidCA := auth
+	invalidCA.CACertPath = defaultsCRD
+	assert.EqualError(t, load(invalidCA), "could not parse the CA certificate "+defaultsCRD+": it has no PEM certificates")
+	mismatchedKey := auth
+	mismatchedKey.ClientKeyPath = caCertPath
+	assert.Error(t, load(mismatchedKey))
+
+	// The token can be read from a file instead, to keep it off the command
+	// line
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenPath, []byte("secret\n"), 0600))
+	withTokenFile := auth
+	withTokenFile.BearerToken, withTokenFile.BearerTokenPath = "", tokenPath
+	assert.NoError(t, load(withTokenFile))
+	withBothTokens := withTokenFile
+	withBothTokens.BearerToken = "secret"
+	assert.EqualError(t, load(withBothTokens), "the bearer token and the bearer token file can't both be set")
+
+	// The token is only sent over https, including after redirects
+	var leaked bool
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = leaked || r.Header.Get("Authorization") != ""
+		_, _ = w.Write(crd)
+	}))
+	defer plainServer.Close()
+	plainURL, err := url.Parse(plainServer.URL + "/crontabs-crd.yaml")
+	require.NoError(t, err)
+	_, err = gen.URLLoader{URL: plainURL, BearerToken: "secret"}.Load()
+	assert.EqualError(t, err, "could not read file "+plainURL.String()+": the bearer token is only sent over https, not to "+plainURL.String())
+	redirectServer := httptest.NewTLSServer(http.RedirectHandler(plainURL.String(), http.StatusFound))
+	defer redirectServer.Close()
+	redirectURL, err := url.Parse(redirectServer.URL + "/crontabs-crd.yaml")
+	require.NoError(t, err)
+	_, err = gen.URLLoader{URL: redirectURL, Client: redirectServer.Client(), BearerToken: "secret"}.Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the bearer token is only sent over https, not to "+plainURL.String())
+	assert.False(t, leaked)
+}
+
+func TestEmbeddedCRDs(t *testing.T) {
+	const operatorManifest = "testdata/embedded/operator.yaml"
+
+	// The embedded CRDs are only loaded if enabled
+	crds, err := gen.FileLoader{Path: operatorManifest}.Load()
+	require.NoError(t, err)
+	assert.Empty(t, crds)
+
+	crds, err = gen.FileLoader{Path: operatorManifest, Embedded: true}.Load()
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+	assert.Equal(t, "crontabs.embedded.example.com", crds[0].GetName())
+	assert.Equal(t, "ConfigMap operators/crontab-operator-crds, key crontabs.yaml", crds[0].GetAnnotations()[gen.EmbeddedInAnnotation])
+	assert.Equal(t, operatorManifest, crds[0].GetAnnotations()[gen.SourceAnnotation])
+	// The order of the properties is recorded from the embedded YAML
+	versions, _, _ := gen.NestedMapSlice(crds[0].Object, "spec", "versions")
+	spec, _, _ := unstruct.NestedMap(versions[0], "schema", "openAPIV3Schema", "properties", "spec")
+	assert.Equal(t, []interface{}{"schedule", "image"}, spec[gen.PropertyOrderKey])
+
+	loaders, err := gen.NewSchemaLoaders([]string{operatorManifest})
+	require.NoError(t, err)
+	pg, err := gen.NewPackageGeneratorFromLoader(loaders.WithEmbeddedCRDs())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes:embedded.example.com/v1:CronTab"}, pg.ResourceTokens)
+}
+
+func TestParseGitReference(t *testing.T) {
+	for ref, expected := range map[string]gen.GitReference{
+		"https://github.com/myorg/operator@v1.2.3": {Repository: "https://github.com/myorg/operator", Ref: "v1.2.3"},
+		"https://github.com/myorg/operator":        {Repository: "https://github.com/myorg/operator"},
+		"git@github.com:myorg/operator.git@main":   {Repository: "git@github.com:myorg/operator.git", Ref: "main"},
+		"git@github.com:myorg/operator.git":        {Repository: "git@github.com:myorg/operator.git"},
+	} {
+		reference, err := gen.ParseGitReference(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, expected, reference, ref)
+		assert.Equal(t, ref, reference.String())
+	}
+
+	_, err := gen.ParseGitReference("https://github.com/myorg/operator@")
+	assert.EqualError(t, err, `invalid ref "" in Git reference "https://github.com/myorg/operator@"`)
+	_, err = gen.ParseGitReference("--upload-pack=touch")
+	assert.Error(t, err)
+}
+
+func TestGitLoader(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	repository := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repository, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	writeFile := func(path, data string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repository, path)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repository, path), []byte(data), 0644))
+	}
+	crd, err := ioutil.ReadFile(defaultsCRD)
+	require.NoError(t, err)
+
+	// The tag has a CRD in the path, which the default branch no longer has
+	git("init", "--quiet")
+	writeFile("config/crd/bases/crontabs.yaml", string(crd))
+	writeFile("config/samples/crontab.yaml", "apiVersion: stable.example.com/v1\nkind: CronTab\n")
+	writeFile("README.md", "# operator\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "Add the CronTab CRD")
+	git("tag", "v1.0.0")
+	git("rm", "--quiet", "config/crd/bases/crontabs.yaml")
+	writeFile("config/crd/bases/.keep", "")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "Remove the CronTab CRD")
+	url := "file://" + filepath.ToSlash(repository)
+
+	loader := gen.GitLoader{Reference: gen.GitReference{Repository: url, Ref: "v1.0.0"}, Path: "config/crd/bases"}
+	crds, err := loader.Load()
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+	assert.Equal(t, "crontabs.stable.example.com", crds[0].GetName())
+	assert.Equal(t, url+"@v1.0.0/config/crd/bases/crontabs.yaml", crds[0].GetAnnotations()[gen.SourceAnnotation])
+
+	// The whole repository is loaded by default, ignoring the documents that
+	// aren't CRDs
+	loader.Path = ""
+	crds, err = loader.Load()
+	require.NoError(t, err)
+	assert.Len(t, crds, 1)
+
+	loader.Path = "config/crd/bases"
+	loader.Reference.Ref = ""
+	_, err = loader.Load()
+	assert.EqualError(t, err, "the path config/crd/bases in "+url+" has no YAML or JSON files")
+	loader.Path = "deploy/crds"
+	_, err = loader.Load()
+	assert.EqualError(t, err, "could not find the path deploy/crds in "+url)
+	loader.Reference.Ref = "v9.9.9"
+	_, err = loader.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not clone "+url+"@v9.9.9: git fetch:")
+}
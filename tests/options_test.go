@@ -0,0 +1,600 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/crd2pulumi/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nonStructuralCRD has a property without a type
+const nonStructuralCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Namespaced
+  names:
+    plural: crontabs
+    kind: CronTab
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            description: The untyped spec.
+`
+
+func TestNewGenerateOptions(t *testing.T) {
+	options, err := gen.NewGenerateOptions()
+	require.NoError(t, err)
+	assert.False(t, options.GeneratesAtLeastOneLanguage())
+	assert.Equal(t, gen.DefaultName, options.GoName)
+
+	options, err = gen.NewGenerateOptions(
+		gen.WithLanguages(map[string]string{gen.NodeJS: "out/nodejs", gen.Go: "out/go"}),
+		gen.WithPackageName("crontabs"),
+		gen.WithObjectMeta(gen.PlaceholderObjectMeta),
+		gen.WithStrict(true),
+	)
+	require.NoError(t, err)
+	if assert.NotNil(t, options.NodeJSPath) && assert.NotNil(t, options.GoPath) {
+		assert.Equal(t, "out/nodejs", *options.NodeJSPath)
+		assert.Equal(t, "out/go", *options.GoPath)
+	}
+	assert.Nil(t, options.PythonPath)
+	assert.Nil(t, options.DotNetPath)
+	assert.Equal(t, "crontabs", options.NodeJSName)
+	assert.Equal(t, "crontabs", options.PythonName)
+	assert.True(t, options.KeepPlaceholderMeta)
+	assert.True(t, options.Strict)
+	assert.False(t, options.Force)
+	assert.True(t, options.FailOnEmpty)
+
+	// Later options override earlier ones
+	options, err = gen.NewGenerateOptions(
+		gen.WithPackageName("crontabs"),
+		gen.WithLanguageSettings(gen.LanguageSettings{GoName: "ls"}),
+		gen.WithObjectMeta(gen.KubernetesObjectMeta),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "ls", options.GoName)
+	assert.Empty(t, options.NodeJSName)
+	assert.False(t, options.KeepPlaceholderMeta)
+
+	_, err = gen.NewGenerateOptions(gen.WithLanguages(map[string]string{"java": "out/java"}))
+	assert.EqualError(t, err, `unsupported language "java"`)
+	_, err = gen.NewGenerateOptions(gen.WithObjectMeta(gen.ObjectMetaSource(42)))
+	assert.EqualError(t, err, "unsupported ObjectMeta source 42")
+}
+
+func TestGenerateWithOptions(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	err := gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(nonStructuralCRD)},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithPackageName("crontabs"),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"name": "@pulumi/crontabs"`)
+
+	// The output directory exists now
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(nonStructuralCRD)},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+	)
+	assert.Error(t, err)
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(nonStructuralCRD)},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.EqualError(t, err, "crontabs.stable.example.com does not have a structural schema")
+
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(nonStructuralCRD)},
+		gen.WithLanguages(map[string]string{gen.Go: filepath.Join(outputDir, "go")}),
+		gen.WithObjectMeta(gen.PlaceholderObjectMeta),
+	)
+	assert.EqualError(t, err, "the placeholder ObjectMeta type can only be kept for NodeJS and Python")
+}
+
+func TestFailOnEmpty(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	noCRDs := gen.YAMLLoader{Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n")}
+	err := gen.GenerateWithOptions(noCRDs,
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+	)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the inputs contain no CRDs, so the SDK would be empty")
+		assert.Contains(t, err.Error(), "use --fail-on-empty=false")
+	}
+	assert.NoDirExists(t, nodejsDir)
+
+	err = gen.GenerateWithOptions(noCRDs,
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithFailOnEmpty(false),
+	)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(nodejsDir, "package.json"))
+}
+
+func TestGenerationMetrics(t *testing.T) {
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(nonStructuralCRD)})
+	require.NoError(t, err)
+	stats := pg.Stats()
+	assert.Equal(t, 1, stats.CRDs)
+	assert.Equal(t, 1, stats.Resources)
+	assert.Equal(t, 0, stats.Types)
+	// The spec has no type
+	assert.Equal(t, 1, stats.AnyTypes)
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	metricsPath := filepath.Join(outputDir, "metrics", "crd2pulumi.prom")
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, MetricsPath: &metricsPath}),
+		gen.WithPackageName(gen.DefaultName),
+	)
+	require.NoError(t, err)
+	metrics := readFile(t, filepath.Dir(metricsPath), filepath.Base(metricsPath))
+	assert.Contains(t, metrics, "# TYPE crd2pulumi_crds_processed gauge\ncrd2pulumi_crds_processed 1\n")
+	assert.Contains(t, metrics, "\ncrd2pulumi_resources_generated 1\n")
+	assert.Contains(t, metrics, "\ncrd2pulumi_types_generated 2\n")
+	assert.Contains(t, metrics, "\ncrd2pulumi_any_type_fallbacks 0\n")
+	assert.Contains(t, metrics, "\ncrd2pulumi_generation_success 1\n")
+	assert.Contains(t, metrics, "\ncrd2pulumi_generation_duration_seconds ")
+
+	// Failed runs are recorded too, and the metrics file doesn't need Force
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(nonStructuralCRD)},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, MetricsPath: &metricsPath}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.Error(t, err)
+	metrics = readFile(t, filepath.Dir(metricsPath), filepath.Base(metricsPath))
+	assert.Contains(t, metrics, "\ncrd2pulumi_generation_success 0\n")
+}
+
+func TestParseObjectMetaPackage(t *testing.T) {
+	language, pkg, err := gen.ParseObjectMetaPackage("nodejs=@myorg/kubernetes@^3.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, gen.NodeJS, language)
+	assert.Equal(t, gen.ObjectMetaPackage{Name: "@myorg/kubernetes", Version: "^3.0.0"}, pkg)
+
+	language, pkg, err = gen.ParseObjectMetaPackage("python=myorg-kubernetes@>=3.0.0,<4.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, gen.Python, language)
+	assert.Equal(t, gen.ObjectMetaPackage{Name: "myorg-kubernetes", Version: ">=3.0.0,<4.0.0"}, pkg)
+
+	for spec, message := range map[string]string{
+		"nodejs=@myorg/kubernetes":     `invalid ObjectMeta package "nodejs=@myorg/kubernetes", expected <language>=<name>@<version>`,
+		"nodejs=kubernetes@":           `invalid ObjectMeta package "nodejs=kubernetes@", expected <language>=<name>@<version>`,
+		"@myorg/kubernetes@^3.0.0":     `invalid ObjectMeta package "@myorg/kubernetes@^3.0.0", expected <language>=<name>@<version>`,
+		"nodejs=My Kubernetes@^3.0.0":  `invalid npm package name "My Kubernetes"`,
+		"python=myorg.kubernetes@3.0":  `invalid Python package name "myorg.kubernetes"`,
+		"go=github.com/myorg/k8s@v3.0": `the ObjectMeta type can only be imported from another package for NodeJS and Python, not "go"`,
+	} {
+		_, _, err := gen.ParseObjectMetaPackage(spec)
+		assert.EqualError(t, err, message, spec)
+	}
+
+	_, err = gen.NewGenerateOptions(gen.WithObjectMetaFrom("nodejs=a@1", "nodejs=b@2"))
+	assert.EqualError(t, err, "the ObjectMeta package for nodejs is set more than once")
+}
+
+func TestParseLanguageOption(t *testing.T) {
+	language, key, value, err := gen.ParseLanguageOption("nodejs:typescriptVersion=4.9")
+	require.NoError(t, err)
+	assert.Equal(t, gen.NodeJS, language)
+	assert.Equal(t, "typescriptVersion", key)
+	assert.Equal(t, "4.9", value)
+
+	_, _, value, err = gen.ParseLanguageOption(`python:requires={"foo": ">=1.0,<2.0"}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": ">=1.0,<2.0"}, value)
+	_, _, value, err = gen.ParseLanguageOption("dotnet:respectSchemaVersion=true")
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+
+	for spec, message := range map[string]string{
+		"typescriptVersion=4.9":     `invalid language option "typescriptVersion=4.9", expected <language>:<key>=<value>`,
+		"nodejs:typescriptVersion":  `invalid language option "nodejs:typescriptVersion", expected <language>:<key>=<value>`,
+		"java:packageName=crds":     `unsupported language "java" of language option "java:packageName=crds"`,
+		"go:=crds":                  `invalid key "" of language option "go:=crds"`,
+		"python:requires={foo}":     `invalid JSON value of language option "python:requires={foo}": invalid character 'f' looking for beginning of object key string`,
+		"nodejs:a:b=c":              `invalid key "a:b" of language option "nodejs:a:b=c"`,
+		"nodejs:dependencies=[1, 2": `invalid JSON value of language option "nodejs:dependencies=[1, 2": unexpected end of JSON input`,
+	} {
+		_, _, _, err := gen.ParseLanguageOption(spec)
+		assert.EqualError(t, err, message, spec)
+	}
+}
+
+func TestLanguageOptions(t *testing.T) {
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	err := gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir, gen.Python: pythonDir}),
+		gen.WithLanguageOptions(
+			"nodejs:typescriptVersion=4.9",
+			"python:pythonRequires=>=3.8",
+			`python:requires={"foo": ">=1.0"}`,
+		),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, readFile(t, nodejsDir, "package.json"), `"typescript": "4.9"`)
+	setup := readFile(t, pythonDir, "setup.py")
+	assert.Contains(t, setup, "python_requires='>=3.8',")
+	// Objects are merged into the settings of crd2pulumi
+	assert.Contains(t, setup, "'foo>=1.0',")
+	assert.Contains(t, setup, "'pulumi>=3.0.0,<4.0.0',")
+
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithLanguageOptions("go:generateResourceContainerTypes=true"),
+		gen.WithForce(true),
+	)
+	assert.EqualError(t, err, "language options are set for go, which isn't generated")
+}
+
+func TestParseOverlayTemplate(t *testing.T) {
+	language, overlay, path, err := gen.ParseOverlayTemplate("python:utilities=templates/utilities.py.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, gen.Python, language)
+	assert.Equal(t, gen.UtilitiesOverlay, overlay)
+	assert.Equal(t, "templates/utilities.py.tmpl", path)
+
+	for spec, message := range map[string]string{
+		"python:utilities":           `invalid overlay template "python:utilities", expected <language>:<overlay>=<path>`,
+		"python:utilities=":          `invalid overlay template "python:utilities=", expected <language>:<overlay>=<path>`,
+		"java:meta=meta.tmpl":        `unsupported language "java" of overlay template "java:meta=meta.tmpl"`,
+		"nodejs:utilities=util.tmpl": `unknown nodejs overlay "utilities" of overlay template "nodejs:utilities=util.tmpl"`,
+	} {
+		_, _, _, err := gen.ParseOverlayTemplate(spec)
+		assert.EqualError(t, err, message, spec)
+	}
+}
+
+func TestOverlayTemplates(t *testing.T) {
+	outputDir := t.TempDir()
+	templatePath := filepath.Join(outputDir, "meta.ts.tmpl")
+	require.NoError(t, ioutil.WriteFile(templatePath, []byte(`export * from "{{.KubernetesPackage}}/meta";
+// {{.PackageName}}@{{.PackageVersion}}
+{{range .Resources}}// {{.Group}} {{.Version}} {{.Kind}} {{.Token}}
+{{end}}`), 0600))
+
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	pythonDir := filepath.Join(outputDir, "python")
+	err := gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{PackageVersion: "1.2.3"}),
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir, gen.Python: pythonDir}),
+		gen.WithPackageName("crontabs"),
+		gen.WithOverlayTemplates("nodejs:meta="+templatePath),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "export * from \"@pulumi/kubernetes/meta\";\n"+
+		"// crontabs@1.2.3\n"+
+		"// stable.example.com v1 CronTab kubernetes:stable.example.com/v1:CronTab\n",
+		readFile(t, nodejsDir, "meta/v1.ts"))
+	// The other overlays keep their default templates
+	assert.Contains(t, readFile(t, pythonDir, "pulumi_crontabs/_utilities.py"), "from pulumi_kubernetes import _utilities\n")
+
+	require.NoError(t, ioutil.WriteFile(templatePath, []byte("{{.Unknown}}"), 0600))
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithOverlayTemplates("nodejs:meta="+templatePath),
+		gen.WithForce(true),
+	)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "could not render the nodejs meta overlay")
+	}
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguages(map[string]string{gen.NodeJS: nodejsDir}),
+		gen.WithOverlayTemplates("python:utilities="+templatePath),
+		gen.WithForce(true),
+	)
+	assert.EqualError(t, err, "overlay templates are set for python, which isn't generated")
+}
+
+// anyTypesCRD has untyped properties in a nested object, in the items of an
+// array, in the values of a map, and under a key with dots
+const anyTypesCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.anytypes.example.com
+spec:
+  group: anytypes.example.com
+  scope: Namespaced
+  names:
+    plural: gadgets
+    kind: Gadget
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              name:
+                type: string
+              config:
+                description: The untyped config.
+              containers:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    image:
+                      type: string
+                    args:
+                      type: array
+                      items:
+                        description: An untyped argument.
+              labels:
+                type: object
+                additionalProperties:
+                  description: An untyped label.
+              example.com/extra:
+                description: The untyped extra.
+`
+
+func TestAnyTypesReport(t *testing.T) {
+	const gadgetToken = "kubernetes:anytypes.example.com/v1:Gadget"
+
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(anyTypesCRD)})
+	require.NoError(t, err)
+	assert.Equal(t, []gen.AnyTypePath{
+		{Resource: gadgetToken, Path: "spec.config"},
+		{Resource: gadgetToken, Path: "spec.containers[*].args[*]"},
+		{Resource: gadgetToken, Path: "spec.labels.*"},
+		{Resource: gadgetToken, Path: `spec["example.com/extra"]`},
+	}, pg.AnyTypePaths())
+
+	outputDir := t.TempDir()
+	nodejsDir := filepath.Join(outputDir, "nodejs")
+	reportPath := filepath.Join(outputDir, "reports", "any-types.json")
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(anyTypesCRD)},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, AnyTypesReportPath: &reportPath, CompactJSON: true}),
+		gen.WithPackageName(gen.DefaultName),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"resource":"`+gadgetToken+`","path":"spec.config"},`+
+		`{"resource":"`+gadgetToken+`","path":"spec.containers[*].args[*]"},`+
+		`{"resource":"`+gadgetToken+`","path":"spec.labels.*"},`+
+		`{"resource":"`+gadgetToken+`","path":"spec[\"example.com/extra\"]"}]`+"\n",
+		readFile(t, filepath.Dir(reportPath), filepath.Base(reportPath)))
+
+	// Fully typed resources have an empty report
+	err = gen.GenerateWithOptions(gen.FileLoader{Path: defaultsCRD},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir, AnyTypesReportPath: &reportPath, CompactJSON: true}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", readFile(t, filepath.Dir(reportPath), filepath.Base(reportPath)))
+}
+
+// skewedCRDs has the same CRD version twice, with different schemas
+const skewedCRDs = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Namespaced
+  names:
+    plural: crontabs
+    kind: CronTab
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              cronSpec:
+                type: string
+              image:
+                type: string
+              replicas:
+                type: integer
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  scope: Namespaced
+  names:
+    plural: crontabs
+    kind: CronTab
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              image:
+                type: string
+              replicas:
+                type: string
+              schedule:
+                type: array
+                items:
+                  type: string
+`
+
+func TestSchemaSkews(t *testing.T) {
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(skewedCRDs)})
+	require.NoError(t, err)
+	skews := gen.FindSchemaSkews(pg.CustomResourceGenerators)
+	require.Len(t, skews, 1)
+	assert.Equal(t, gen.SchemaSkew{
+		Group:   "stable.example.com",
+		Kind:    "CronTab",
+		Version: "v1",
+		Sources: []string{"crd 0", "crd 1"},
+		Differences: []string{
+			"spec.cronSpec: removed in crd 1",
+			`spec.replicas: type is "string" in crd 1, instead of "integer"`,
+			"spec.schedule: added in crd 1",
+		},
+	}, skews[0])
+	assert.Equal(t, `the schemas of CronTab stable.example.com/v1 differ across crd 0, crd 1: `+
+		`spec.cronSpec: removed in crd 1; spec.replicas: type is "string" in crd 1, instead of "integer"; `+
+		`spec.schedule: added in crd 1`, skews[0].String())
+
+	// The same schema in several inputs isn't a skew
+	pg, err = gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(nonStructuralCRD + "---" + nonStructuralCRD)})
+	require.NoError(t, err)
+	assert.Empty(t, gen.FindSchemaSkews(pg.CustomResourceGenerators))
+
+	// Skews are warnings, unless generation is strict
+	nodejsDir := filepath.Join(t.TempDir(), "nodejs")
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(skewedCRDs)},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir}),
+		gen.WithPackageName(gen.DefaultName),
+	)
+	require.NoError(t, err)
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(skewedCRDs)},
+		gen.WithLanguageSettings(gen.LanguageSettings{NodeJSPath: &nodejsDir}),
+		gen.WithPackageName(gen.DefaultName),
+		gen.WithForce(true),
+		gen.WithStrict(true),
+	)
+	assert.EqualError(t, err, skews[0].String())
+}
+
+// deepCRD has types nested deeply enough for their names to be compacted
+const deepCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: pipelines.deep.example.com
+spec:
+  group: deep.example.com
+  scope: Namespaced
+  names:
+    plural: pipelines
+    kind: Pipeline
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              templateConfiguration:
+                type: object
+                properties:
+                  containerSpecification:
+                    type: object
+                    properties:
+                      volumeMountDefinitions:
+                        type: array
+                        items:
+                          type: object
+                          properties:
+                            mountPath:
+                              type: string
+                            propagationOptions:
+                              type: object
+                              properties:
+                                mode:
+                                  type: string
+`
+
+func TestCompactNames(t *testing.T) {
+	const (
+		pipelineToken    = "kubernetes:deep.example.com/v1:Pipeline"
+		mountsToken      = "kubernetes:deep.example.com/v1:T_394d74cd1d14"
+		propagationToken = "kubernetes:deep.example.com/v1:T_08b74871ac7f"
+		mountsPath       = "spec.templateConfiguration.containerSpecification.volumeMountDefinitions[*]"
+	)
+
+	pg, err := gen.NewPackageGeneratorFromLoader(gen.YAMLLoader{Data: []byte(deepCRD)})
+	require.NoError(t, err)
+	assert.Equal(t, []gen.CompactTypeName{
+		{
+			Token:    propagationToken,
+			Original: pipelineToken + "SpecTemplateConfigurationContainerSpecificationVolumeMountDefinitionsPropagationOptions",
+			Resource: pipelineToken,
+			Path:     mountsPath + ".propagationOptions",
+		},
+		{
+			Token:    mountsToken,
+			Original: pipelineToken + "SpecTemplateConfigurationContainerSpecificationVolumeMountDefinitions",
+			Resource: pipelineToken,
+			Path:     mountsPath,
+		},
+	}, pg.CompactTypeNames())
+
+	// The refs point to the compact names, which document the original ones
+	containerSpecification := pg.Types[pipelineToken+"SpecTemplateConfigurationContainerSpecification"]
+	assert.Equal(t, "#/types/"+mountsToken, containerSpecification.Properties["volumeMountDefinitions"].Items.Ref)
+	mounts := pg.Types[mountsToken]
+	assert.Equal(t, "#/types/"+propagationToken, mounts.Properties["propagationOptions"].Ref)
+	assert.Equal(t, "Compacted from the type `PipelineSpecTemplateConfigurationContainerSpecificationVolumeMountDefinitions`.", mounts.Description)
+
+	outputDir := t.TempDir()
+	goDir := filepath.Join(outputDir, "go")
+	compactNamesPath := filepath.Join(outputDir, "compact-names.json")
+	err = gen.GenerateWithOptions(gen.YAMLLoader{Data: []byte(deepCRD)},
+		gen.WithLanguageSettings(gen.LanguageSettings{GoPath: &goDir, CompactNamesPath: &compactNamesPath, CompactJSON: true}),
+		gen.WithPackageName(gen.DefaultName),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"token":"`+propagationToken+`","original":"`+pipelineToken+`SpecTemplateConfigurationContainerSpecificationVolumeMountDefinitionsPropagationOptions",`+
+		`"resource":"`+pipelineToken+`","path":"`+mountsPath+`.propagationOptions"},`+
+		`{"token":"`+mountsToken+`","original":"`+pipelineToken+`SpecTemplateConfigurationContainerSpecificationVolumeMountDefinitions",`+
+		`"resource":"`+pipelineToken+`","path":"`+mountsPath+`"}]`+"\n",
+		readFile(t, outputDir, "compact-names.json"))
+	types := readFile(t, filepath.Join(goDir, "deep", "v1"), "pulumiTypes.go")
+	assert.Contains(t, types, "type T_394d74cd1d14 struct")
+	assert.NotContains(t, types, "type PipelineSpecTemplateConfigurationContainerSpecificationVolumeMountDefinitions")
+}
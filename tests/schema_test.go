@@ -17,6 +17,8 @@ package tests
 import (
 	"encoding/json"
 	"io/ioutil"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -28,6 +30,7 @@ import (
 const TestCombineSchemasYAML = "test-combineschemas.yaml"
 const TestGetTypeSpecYAML = "test-gettypespec.yaml"
 const TestGetTypeSpecJSON = "test-gettypespec.json"
+const TestNormalizeSchemaYAML = "test-normalizeschema.yaml"
 
 func UnmarshalSchemas(yamlPath string) (map[string]interface{}, error) {
 	yamlFile, err := ioutil.ReadFile(yamlPath)
@@ -78,6 +81,26 @@ func TestCombineSchemas(t *testing.T) {
 	personAndEmployeeWithoutRequiredExpected := schemas["personAndEmployeeWithoutRequired"].(map[string]interface{})
 	personAndEmployeeWithoutRequiredActual := gen.CombineSchemas(false, person, employee)
 	assert.EqualValues(t, personAndEmployeeWithoutRequiredExpected, personAndEmployeeWithoutRequiredActual)
+
+	// Test that nested combinators are combined too, and that the
+	// `required` fields of nested `anyOf` sub-schemas are dropped
+	nestedAllOf, _, _ := gen.NestedMapSlice(schemas["nestedAllOfAndAnyOf"].(map[string]interface{}), "allOf")
+	assert.EqualValues(t, schemas["nestedAllOfAndAnyOfCombined"], gen.CombineSchemas(true, nestedAllOf...))
+
+	// Test that a nested `oneOf` is kept, with each alternative combined with
+	// the other sub-schemas
+	allOfWithOneOf := schemas["allOfWithOneOf"].(map[string]interface{})
+	allOf, _, _ := gen.NestedMapSlice(allOfWithOneOf, "allOf")
+	assert.EqualValues(t, schemas["allOfWithOneOfCombined"], gen.CombineSchemas(true, allOf...))
+
+	types := map[string]pschema.ComplexTypeSpec{}
+	typeSpec := gen.GetTypeSpec(allOfWithOneOf, "Storage", types)
+	assert.Equal(t, []pschema.TypeSpec{
+		{Type: "object", Ref: "#/types/StorageOneOf0"},
+		{Type: "object", Ref: "#/types/StorageOneOf1"},
+	}, typeSpec.OneOf)
+	assert.Equal(t, []string{"region", "path"}, types["StorageOneOf1"].Required)
+	assert.Contains(t, types["StorageOneOf1"].Properties, "region")
 }
 
 func TestGetTypeSpec(t *testing.T) {
@@ -85,11 +108,11 @@ func TestGetTypeSpec(t *testing.T) {
 	// (map[string]pschema.ObjectTypeSpec{}) to add object refs when we see
 	// them. However we only want the returned pschema.TypeSpec, so this
 	// wrapper function creates a placeholder types map and just returns
-	// the pschema.TypeSpec. Since our initial name arg is "", this causes all
-	// objects to have the ref "#/types/"
-	getOnlyTypeSpec := func(schema map[string]interface{}) pschema.TypeSpec {
+	// the pschema.TypeSpec. Each schema is named after its key, so the
+	// objects and enums have the ref "#/types/<key>"
+	getOnlyTypeSpec := func(schema map[string]interface{}, name string) pschema.TypeSpec {
 		placeholderTypes := map[string]pschema.ComplexTypeSpec{}
-		return gen.GetTypeSpec(schema, "", placeholderTypes)
+		return gen.GetTypeSpec(schema, name, placeholderTypes)
 	}
 
 	// Load YAML schemas
@@ -105,8 +128,320 @@ func TestGetTypeSpec(t *testing.T) {
 		assert.True(t, ok)
 
 		schema := schemas[name].(map[string]interface{})
-		actual := getOnlyTypeSpec(schema)
+		actual := getOnlyTypeSpec(schema, name)
 
 		assert.EqualValues(t, expected, actual)
 	}
 }
+
+func TestGetTypeSpecWithoutType(t *testing.T) {
+	// A schema with properties but no type, like the spec of older CRDs, is
+	// an object
+	types := map[string]pschema.ComplexTypeSpec{}
+	spec := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": "integer"},
+		},
+	}
+	assert.Equal(t, pschema.TypeSpec{Type: "object", Ref: "#/types/Spec"}, gen.GetTypeSpec(spec, "Spec", types))
+	assert.Equal(t, pschema.TypeSpec{Type: "integer"}, types["Spec"].Properties["replicas"].TypeSpec)
+
+	// Without properties, there's nothing to type it by
+	assert.Equal(t, pschema.TypeSpec{Ref: "pulumi.json#/Any"}, gen.GetTypeSpec(map[string]interface{}{}, "Status", types))
+	assert.NotContains(t, types, "Status")
+}
+
+func TestGetEnumTypeSpec(t *testing.T) {
+	enumMemberNames := func(enumTypeSpec pschema.ComplexTypeSpec) []string {
+		names := make([]string, 0, len(enumTypeSpec.Enum))
+		for _, enumValueSpec := range enumTypeSpec.Enum {
+			names = append(names, enumValueSpec.Name)
+		}
+		return names
+	}
+
+	// Without any vendor extensions, member names fall back to the sanitized values
+	enumTypeSpec, ok := gen.GetEnumTypeSpec(map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"if-not-present", "Always", "", "3", "v1.5", nil},
+	}, "string")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"IfNotPresent", "Always", "Empty", "Value3", "V1_5"}, enumMemberNames(enumTypeSpec))
+
+	// Annotations that don't line up with the enum values are ignored
+	enumTypeSpec, ok = gen.GetEnumTypeSpec(map[string]interface{}{
+		"type":                "integer",
+		"enum":                []interface{}{float64(1), float64(2)},
+		"x-enum-varnames":     []interface{}{"low"},
+		"x-enum-descriptions": []interface{}{"Low priority", "High priority"},
+	}, "integer")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Value1", "Value2"}, enumMemberNames(enumTypeSpec))
+	assert.Equal(t, "High priority", enumTypeSpec.Enum[1].Description)
+
+	// Annotated names are used when present
+	enumTypeSpec, ok = gen.GetEnumTypeSpec(map[string]interface{}{
+		"type":            "integer",
+		"enum":            []interface{}{float64(1), float64(2)},
+		"x-enum-varnames": []interface{}{"low", "high"},
+	}, "integer")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Low", "High"}, enumMemberNames(enumTypeSpec))
+
+	// Values that don't match the schema type can't be represented as an enum
+	_, ok = gen.GetEnumTypeSpec(map[string]interface{}{
+		"type": "integer",
+		"enum": []interface{}{float64(1), "two"},
+	}, "integer")
+	assert.False(t, ok)
+
+	// Values whose sanitized names collide, even only by case, are suffixed
+	enumTypeSpec, ok = gen.GetEnumTypeSpec(map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"foo-bar", "foo_bar", "FOO-BAR"},
+	}, "string")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"FooBar", "FooBar2", "FOOBAR3"}, enumMemberNames(enumTypeSpec))
+}
+
+func TestNormalizeSchema(t *testing.T) {
+	schemas, err := UnmarshalSchemas(TestNormalizeSchemaYAML)
+	assert.NoError(t, err)
+
+	for name := range schemas {
+		testCase := schemas[name].(map[string]interface{})
+		input := testCase["input"].(map[string]interface{})
+		expected := testCase["expected"].(map[string]interface{})
+
+		actual, err := gen.NormalizeSchema(input)
+		assert.NoError(t, err, name)
+		assert.EqualValues(t, expected, actual, name)
+	}
+
+	// Local references that can't be resolved are an error
+	_, err = gen.NormalizeSchema(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"address": map[string]interface{}{"$ref": "#/definitions/missing"},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestDetectSchemaDraft(t *testing.T) {
+	detect := func(uri string) string {
+		return gen.DetectSchemaDraft(map[string]interface{}{"$schema": uri})
+	}
+	assert.Equal(t, gen.Draft4, detect("http://json-schema.org/draft-04/schema#"))
+	assert.Equal(t, gen.Draft7, detect("http://json-schema.org/draft-07/schema#"))
+	assert.Equal(t, gen.Draft202012, detect("https://json-schema.org/draft/2020-12/schema"))
+	assert.Equal(t, gen.DraftOpenAPIV3, gen.DetectSchemaDraft(map[string]interface{}{"type": "object"}))
+}
+
+func TestArrayConstraints(t *testing.T) {
+	array := func(constraints map[string]interface{}) map[string]interface{} {
+		schema := map[string]interface{}{
+			"type":        "array",
+			"description": "The hosts.",
+			"items":       map[string]interface{}{"type": "string", "maxLength": int64(253)},
+		}
+		for key, value := range constraints {
+			schema[key] = value
+		}
+		return schema
+	}
+	cases := map[string]struct {
+		schema      map[string]interface{}
+		description string
+	}{
+		"none":      {array(nil), "The hosts."},
+		"range":     {array(map[string]interface{}{"minItems": int64(1), "maxItems": int64(10), "uniqueItems": true}), "The hosts.\n\nConstraints: 1-10 items, unique."},
+		"exact":     {array(map[string]interface{}{"minItems": int64(2), "maxItems": int64(2)}), "The hosts.\n\nConstraints: 2 items."},
+		"min":       {array(map[string]interface{}{"minItems": int64(1)}), "The hosts.\n\nConstraints: at least 1 item."},
+		"max":       {array(map[string]interface{}{"maxItems": float64(5)}), "The hosts.\n\nConstraints: at most 5 items."},
+		"unique":    {array(map[string]interface{}{"uniqueItems": true}), "The hosts.\n\nConstraints: unique items."},
+		"notUnique": {array(map[string]interface{}{"uniqueItems": false}), "The hosts."},
+		"noDescription": {
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "minItems": int64(0)},
+			"Constraints: at least 0 items.",
+		},
+		// Only the array's own keywords are used, not those of its items
+		"itemsConstraints": {
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "array", "minItems": int64(1)}},
+			"",
+		},
+	}
+	for name, tc := range cases {
+		types := map[string]pschema.ComplexTypeSpec{}
+		gen.AddType(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"hosts": tc.schema},
+		}, "test", types)
+		assert.Equal(t, tc.description, types["test"].Properties["hosts"].Description, name)
+	}
+}
+
+func TestNumericBoundConstraints(t *testing.T) {
+	cases := map[string]struct {
+		schema      map[string]interface{}
+		description string
+	}{
+		"inclusive": {
+			map[string]interface{}{"type": "integer", "minimum": int64(0), "maximum": int64(10)},
+			"Constraints: minimum 0, maximum 10.",
+		},
+		// Draft 4 and OpenAPI v3
+		"booleanExclusive": {
+			map[string]interface{}{"type": "number", "minimum": float64(0), "exclusiveMinimum": true, "maximum": 1.5, "exclusiveMaximum": false},
+			"Constraints: exclusive minimum 0, maximum 1.5.",
+		},
+		"booleanExclusiveWithoutBound": {
+			map[string]interface{}{"type": "number", "exclusiveMinimum": true},
+			"",
+		},
+		// Draft 6+
+		"numericExclusive": {
+			map[string]interface{}{"type": "number", "exclusiveMinimum": float64(0), "exclusiveMaximum": int64(100)},
+			"Constraints: exclusive minimum 0, exclusive maximum 100.",
+		},
+		"numericExclusiveDominated": {
+			map[string]interface{}{"type": "integer", "minimum": int64(5), "exclusiveMinimum": int64(0)},
+			"Constraints: minimum 5.",
+		},
+		"numericExclusiveStricter": {
+			map[string]interface{}{"type": "integer", "minimum": int64(0), "exclusiveMinimum": int64(0)},
+			"Constraints: exclusive minimum 0.",
+		},
+		"notNumeric": {
+			map[string]interface{}{"type": "string", "minimum": int64(0)},
+			"",
+		},
+	}
+	for name, tc := range cases {
+		types := map[string]pschema.ComplexTypeSpec{}
+		gen.AddType(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"value": tc.schema},
+		}, "test", types)
+		assert.Equal(t, tc.description, types["test"].Properties["value"].Description, name)
+	}
+}
+
+func TestImmutablePaths(t *testing.T) {
+	immutable := map[string]interface{}{
+		"type": "string",
+		"x-kubernetes-validations": []interface{}{
+			map[string]interface{}{"rule": "self == oldSelf", "message": "is immutable"},
+		},
+	}
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": immutable,
+					"size": map[string]interface{}{
+						"type": "integer",
+						"x-kubernetes-validations": []interface{}{
+							map[string]interface{}{"rule": "self >= oldSelf"},
+						},
+					},
+				},
+			},
+			"region": immutable,
+		},
+	}
+	assert.Equal(t, []string{"region", "spec.name"}, gen.ImmutablePaths(schema))
+}
+
+func TestDependencies(t *testing.T) {
+	object := func(dependencies map[string]interface{}) map[string]interface{} {
+		schema := map[string]interface{}{
+			"type":        "object",
+			"description": "The ingress.",
+			"properties": map[string]interface{}{
+				"tls":  map[string]interface{}{"type": "boolean"},
+				"host": map[string]interface{}{"type": "string"},
+				"port": map[string]interface{}{"type": "integer"},
+				"path": map[string]interface{}{"type": "string"},
+			},
+		}
+		for key, value := range dependencies {
+			schema[key] = value
+		}
+		return schema
+	}
+	cases := map[string]struct {
+		schema      map[string]interface{}
+		description string
+	}{
+		"none": {object(nil), "The ingress."},
+		"dependentRequired": {
+			object(map[string]interface{}{"dependentRequired": map[string]interface{}{"tls": []interface{}{"host"}}}),
+			"The ingress.\n\nIf `tls` is set, `host` is required.",
+		},
+		"propertyDependencies": {
+			object(map[string]interface{}{"dependencies": map[string]interface{}{"tls": []interface{}{"host", "port"}}}),
+			"The ingress.\n\nIf `tls` is set, `host` and `port` are required.",
+		},
+		"schemaDependencies": {
+			object(map[string]interface{}{"dependencies": map[string]interface{}{
+				"tls":  map[string]interface{}{"required": []interface{}{"host", "port", "path"}},
+				"path": map[string]interface{}{"properties": map[string]interface{}{"host": map[string]interface{}{"minLength": int64(1)}}},
+			}}),
+			"The ingress.\n\nIf `tls` is set, `host`, `port`, and `path` are required.",
+		},
+		"merged": {
+			object(map[string]interface{}{
+				"dependentRequired": map[string]interface{}{"tls": []interface{}{"host"}, "port": []interface{}{"host"}},
+				"dependentSchemas":  map[string]interface{}{"tls": map[string]interface{}{"required": []interface{}{"host", "port"}}},
+			}),
+			"The ingress.\n\nIf `port` is set, `host` is required. If `tls` is set, `host` and `port` are required.",
+		},
+	}
+	for name, tc := range cases {
+		types := map[string]pschema.ComplexTypeSpec{}
+		gen.AddType(tc.schema, "test", types)
+		assert.Equal(t, tc.description, types["test"].Description, name)
+	}
+
+	// Nested object types are described too
+	types := map[string]pschema.ComplexTypeSpec{}
+	gen.AddType(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"ingress": object(map[string]interface{}{"dependentRequired": map[string]interface{}{"tls": []interface{}{"host"}}}),
+		},
+	}, "test", types)
+	assert.Equal(t, "The ingress.\n\nIf `tls` is set, `host` is required.", types["testIngress"].Description)
+}
+
+func TestTitleCase(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":                 "",
+		"spec":             "Spec",
+		"forProvider":      "ForProvider",
+		"foo-bar":          "Foo-Bar",
+		"foo_bar":          "Foo_bar",
+		"foo.bar/baz":      "Foo.Bar/Baz",
+		"x-kubernetes-map": "X-Kubernetes-Map",
+		"1st":              "1st",
+		"$ref":             "$Ref",
+		"hello world":      "Hello World",
+		"élan":             "Élan",
+	} {
+		assert.Equal(t, expected, gen.TitleCase(input), input)
+		// It matches the strings.Title that generated names used to be built with
+		assert.Equal(t, strings.Title(input), gen.TitleCase(input), input)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "MemoizedName", gen.TitleCase("memoizedName"))
+		}()
+	}
+	wg.Wait()
+}